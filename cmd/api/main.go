@@ -4,20 +4,43 @@ import (
 	"fmt"
 	"github.com/Loviiin/ponto-api-go/internal/domain/bancohoras"
 	"github.com/Loviiin/ponto-api-go/pkg/scheduler"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/Loviiin/ponto-api-go/internal/config"
 	"github.com/Loviiin/ponto-api-go/internal/model"
 
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
 	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/autorizacao"
 	"github.com/Loviiin/ponto-api-go/internal/domain/cargo"
+	"github.com/Loviiin/ponto-api-go/internal/domain/centrocusto"
+	"github.com/Loviiin/ponto-api-go/internal/domain/chaveapi"
+	"github.com/Loviiin/ponto-api-go/internal/domain/convite"
+	"github.com/Loviiin/ponto-api-go/internal/domain/departamento"
+	"github.com/Loviiin/ponto-api-go/internal/domain/dispositivo"
 	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/lgpd"
+	"github.com/Loviiin/ponto-api-go/internal/domain/localtrabalho"
 	"github.com/Loviiin/ponto-api-go/internal/domain/permissao"
+	"github.com/Loviiin/ponto-api-go/internal/domain/plataforma"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
 	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
+	"github.com/Loviiin/ponto-api-go/internal/domain/quiosque"
+	"github.com/Loviiin/ponto-api-go/internal/domain/retencao"
+	"github.com/Loviiin/ponto-api-go/internal/domain/revisao"
+	"github.com/Loviiin/ponto-api-go/internal/domain/risco"
+	"github.com/Loviiin/ponto-api-go/internal/domain/sincronizacao"
+	"github.com/Loviiin/ponto-api-go/internal/domain/sso"
 	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
 
+	"github.com/Loviiin/ponto-api-go/pkg/armazenamento"
 	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
 	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"github.com/Loviiin/ponto-api-go/pkg/mailer"
+	"github.com/Loviiin/ponto-api-go/pkg/oidc"
 	// Vamos usar este pacote para as nossas constantes de permissão
 	"github.com/Loviiin/ponto-api-go/pkg/permissions"
 
@@ -32,24 +55,54 @@ func main() {
 		log.Fatal("Não foi possível carregar as configurações: ", err)
 	}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=America/Sao_Paulo",
-		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
+	db := abrirBanco(cfg, cfg.DBUser, cfg.DBPassword)
+	log.Println("Conexão com o banco de dados estabelecida com sucesso.")
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatal("Falha ao conectar ao banco de dados: ", err)
+	// A migração cria e altera tabelas, o que só o dono delas pode fazer. Com DB_MIGRACAO_USER ela
+	// roda com esse papel, e a API atende com DB_USER, que não é dono de nada e não escapa do RLS.
+	migracao := db
+	if cfg.DBMigracaoUser != "" {
+		migracao = abrirBanco(cfg, cfg.DBMigracaoUser, cfg.DBMigracaoPassword)
 	}
-	log.Println("Conexão com o banco de dados estabelecida com sucesso.")
 
 	// Adicionámos o &model.Permissao{} para a migração automática
-	err = db.AutoMigrate(&model.Usuario{}, &model.RegistroPonto{}, &model.Empresa{}, &model.Cargo{}, &model.Permissao{})
+	modelos := []interface{}{&model.Departamento{}, &model.Usuario{}, &model.RegistroPonto{}, &model.Empresa{}, &model.Cargo{}, &model.Permissao{}, &model.TentativaLogin{},
+		&model.ConfiguracaoOIDC{}, &model.MapeamentoCargoOIDC{}, &model.SessaoOIDC{}, &model.IdentidadeExterna{},
+		&model.ChaveAPI{}, &model.OperadorPlataforma{}, &model.Impersonacao{},
+		&model.Convite{}, &model.SolicitacaoCadastro{}, &model.RegistroAuditoria{},
+		&model.CentroCusto{}, &model.Politica{}, &model.LocalTrabalho{}, &model.LocalTrabalhoAtribuicao{},
+		&model.ConfiguracaoRisco{}, &model.Quiosque{}, &model.Dispositivo{}, &model.CodigoDispositivo{}, &model.FechamentoDia{}}
+	err = migracao.AutoMigrate(modelos...)
 	if err != nil {
 		log.Fatal("Falha ao rodar a migração: ", err)
 	}
+	if err := auditoria.ProtegerTabela(migracao); err != nil {
+		log.Fatal("Falha ao proteger o log de auditoria: ", err)
+	}
+	// Segunda barreira: o próprio Postgres recusa linhas de outra empresa, mesmo em SQL sem filtro.
+	if err := tenant.AtivarRLS(migracao, modelos...); err != nil {
+		log.Fatal("Falha ao ativar o Row-Level Security: ", err)
+	}
+	if migracao != db {
+		if sqlDB, err := migracao.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
 	log.Println("Migração do banco de dados executada com sucesso.")
-	log.Println("Migração do banco de dados executada com sucesso.")
+	// Um superusuário ou papel com BYPASSRLS ignora as políticas: a API só sobe assim se isso for
+	// pedido explicitamente.
+	if efetivo, err := tenant.RLSEfetivo(db); err != nil {
+		log.Fatal("Falha ao verificar o papel do banco de dados: ", err)
+	} else if !efetivo {
+		if !cfg.DBPermitirSemRLS {
+			log.Fatal("O usuário do banco (DB_USER) é superusuário ou tem BYPASSRLS, e as políticas de Row-Level Security não se aplicariam a ele. " +
+				"Conecte com um papel comum (veja docker/postgres) ou defina DB_PERMITIR_SEM_RLS=true.")
+		}
+		log.Println("AVISO: DB_PERMITIR_SEM_RLS ativo; o usuário do banco ignora as políticas de Row-Level Security.")
+	}
 	config.SeedPermissions(db)
 	config.SeedSuperAdmin(db)
+	config.SeedOperadorPlataforma(db, cfg.PlataformaAdminEmail, cfg.PlataformaAdminSenha)
 
 	// --- Inicialização de Serviços e Repositórios ---
 	jwtService := jwt.NewJWTService(cfg.JWTSecretKey, "ponto-api-go")
@@ -60,25 +113,102 @@ func main() {
 	empresaRepo := empresa.NewEmpresaRepository(db)
 	cargoRepo := cargo.NewCargoRepository(db)
 	permissaoRepo := permissao.NewRepository(db)
+	departamentoRepo := departamento.NewDepartamentoRepository(db)
+	centroCustoRepo := centrocusto.NewCentroCustoRepository(db)
 
-	usuarioService := usuario.NewUsuarioService(usuarioRepo)
-	authService := auth.NewAuthService(usuarioRepo, jwtService)
-	pontoService := ponto.NewPontoService(pontoRepo, usuarioRepo, empresaRepo)
+	permissoesCache := autorizacao.NovoCache(time.Duration(cfg.PermissoesCacheSegundos) * time.Second)
+	usuarioService := usuario.NewUsuarioService(usuarioRepo, permissoesCache)
+	auditoriaService := auditoria.NewAuditoriaService(auditoria.NewAuditoriaRepository(db))
+	tentativaLoginRepo := auth.NewTentativaLoginRepository(db)
+	eventosSeguranca := auth.NewAuditoriaRegistradorEventos(auditoriaService)
+	politicaBloqueio := auth.PoliticaBloqueio{
+		MaxTentativasConta: cfg.LoginMaxTentativas,
+		MaxTentativasIP:    cfg.LoginMaxTentativasIP,
+		DuracaoBloqueio:    time.Duration(cfg.LoginBloqueioMinutos) * time.Minute,
+		AtrasoBase:         time.Duration(cfg.LoginAtrasoBaseSegundos) * time.Second,
+	}
+	authService := auth.NewAuthService(usuarioRepo, jwtService, tentativaLoginRepo, eventosSeguranca, politicaBloqueio)
+	politicaService := politica.NewPoliticaService(politica.NewPoliticaRepository(db))
+	localTrabalhoService := localtrabalho.NewLocalTrabalhoService(localtrabalho.NewLocalTrabalhoRepository(db))
+	riscoService := risco.NewRiscoService(risco.NewRiscoRepository(db), usuarioService)
+	var arquivos armazenamento.Armazenamento
+	switch cfg.ArmazenamentoTipo {
+	case "s3":
+		arquivos, err = armazenamento.NewS3(armazenamento.ConfigS3{
+			Endpoint:      cfg.S3Endpoint,
+			Regiao:        cfg.S3Regiao,
+			Bucket:        cfg.S3Bucket,
+			ChaveAcesso:   cfg.S3ChaveAcesso,
+			ChaveSecreta:  cfg.S3ChaveSecreta,
+			EstiloCaminho: cfg.S3EstiloCaminho,
+		})
+	case "local":
+		arquivos, err = armazenamento.NewLocal(cfg.ArmazenamentoDiretorio)
+	default:
+		log.Fatalf("ARMAZENAMENTO_TIPO inválido: %q (use \"local\" ou \"s3\")", cfg.ArmazenamentoTipo)
+	}
+	if err != nil {
+		log.Fatal("Falha ao configurar o armazenamento de arquivos: ", err)
+	}
 	empresaService := empresa.NewEmpresaService(empresaRepo)
-	cargoService := cargo.NewCargoService(cargoRepo)
+	dispositivoService := dispositivo.NewDispositivoService(dispositivo.NewDispositivoRepository(db), usuarioService, empresaService)
+	pontoService := ponto.NewPontoService(pontoRepo, usuarioRepo, empresaRepo, politicaService, localTrabalhoService, riscoService, arquivos, dispositivoService)
+	quiosqueService := quiosque.NewQuiosqueService(quiosque.NewQuiosqueRepository(db), localTrabalhoService, usuarioRepo, pontoService, tentativaLoginRepo, politicaBloqueio)
+	cargoService := cargo.NewCargoService(cargoRepo, permissoesCache)
 	permissaoService := permissao.NewService(permissaoRepo)
-	bancoHorasService := bancohoras.NewBancoHorasService(pontoRepo, usuarioRepo)
+	bancoHorasService := bancohoras.NewBancoHorasService(pontoRepo, usuarioRepo, empresaRepo, politicaService, bancohoras.NewFechamentoRepository(db))
+	revisaoService := revisao.NewRevisaoService(revisao.NewRevisaoRepository(db), usuarioService, bancoHorasService, politicaService)
+	sincronizacaoService := sincronizacao.NewSincronizacaoService(sincronizacao.NewSincronizacaoRepository(db), pontoService, bancoHorasService)
+	departamentoService := departamento.NewDepartamentoService(departamentoRepo)
+	centroCustoService := centrocusto.NewCentroCustoService(centroCustoRepo)
+	plataformaService := plataforma.NewPlataformaService(plataforma.NewPlataformaRepository(db), usuarioRepo, jwtService, tentativaLoginRepo, eventosSeguranca, politicaBloqueio)
+	chaveAPIService := chaveapi.NewChaveAPIService(chaveapi.NewChaveAPIRepository(db), usuarioRepo)
+	var mailerService mailer.Mailer = mailer.NewLogMailer()
+	if cfg.SMTPHost != "" {
+		mailerService = mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPRemetente)
+	}
+	conviteService := convite.NewConviteService(convite.NewConviteRepository(db), usuarioRepo, usuarioService, cargoRepo, empresaRepo, jwtService, mailerService, cfg.ConviteURLBase, politicaService,
+		tentativaLoginRepo, politicaBloqueio, cfg.CadastroMaxPedidosIP)
+	lgpdService := lgpd.NewLGPDService(lgpd.NewLGPDRepository(db), permissoesCache, arquivos)
+	ssoService := sso.NewSSOService(sso.NewSSORepository(db), usuarioRepo, cargoRepo, jwtService, oidc.NewClient(nil), cfg.OIDCRedirectURL)
 
-	usuarioHandler := usuario.NewUsuarioHandler(usuarioService, empresaService, cargoService, funcoesService)
-	authHandler := auth.NewAuthHandler(authService)
-	pontoHandler := ponto.NewPontoHandler(pontoService)
+	usuarioHandler := usuario.NewUsuarioHandler(usuarioService, funcoesService)
+	authHandler := auth.NewAuthHandler(authService, funcoesService)
+	pontoHandler := ponto.NewPontoHandler(pontoService, ponto.NewFotoService(pontoRepo, usuarioService, arquivos), ponto.NewConsultaService(pontoRepo, usuarioService), usuarioService, empresaService, funcoesService)
 	empresaHandler := empresa.NewEmpresaHandler(empresaService, funcoesService, db)
 	cargoHandler := cargo.NewCargoHandler(cargoService, funcoesService)
 	permissaoHandler := permissao.NewHandler(permissaoService)
-	bancoHorasHandler := bancohoras.NewBancoHorasHandler(bancoHorasService, usuarioService, funcoesService)
+	bancoHorasHandler := bancohoras.NewBancoHorasHandler(bancoHorasService, usuarioService, empresaService, funcoesService)
+	ssoHandler := sso.NewHandler(ssoService, funcoesService)
+	chaveAPIHandler := chaveapi.NewHandler(chaveAPIService, funcoesService)
+	plataformaHandler := plataforma.NewHandler(plataformaService, funcoesService)
+	conviteHandler := convite.NewHandler(conviteService, funcoesService)
+	auditoriaHandler := auditoria.NewHandler(auditoriaService, funcoesService)
+	departamentoHandler := departamento.NewHandler(departamentoService, funcoesService)
+	centroCustoHandler := centrocusto.NewHandler(centroCustoService, funcoesService)
+	localTrabalhoHandler := localtrabalho.NewHandler(localTrabalhoService, funcoesService)
+	politicaHandler := politica.NewHandler(politicaService, usuarioService, funcoesService)
+	lgpdHandler := lgpd.NewHandler(lgpdService, usuarioService, funcoesService)
+	revisaoHandler := revisao.NewHandler(revisaoService, usuarioService, funcoesService)
+	riscoHandler := risco.NewHandler(riscoService, usuarioService, empresaService, funcoesService)
+	sincronizacaoHandler := sincronizacao.NewHandler(sincronizacaoService, funcoesService)
+	quiosqueHandler := quiosque.NewHandler(quiosqueService, funcoesService)
+	dispositivoHandler := dispositivo.NewHandler(dispositivoService, usuarioService, funcoesService)
 
 	// --- Middlewares ---
-	authMiddleware := auth.AuthMiddleware(jwtService)
+	// Chaves de API só entram nas rotas liberadas abaixo, e só com o escopo indicado; nas demais,
+	// inclusive nas que não exigem permissão, recebem 403.
+	rotasChaveAPI := auth.NewRotasChaveAPI()
+	rotasChaveAPI.Permitir(http.MethodGet, "/api/v1/pontos", permissions.VER_SALDO_FUNCIONARIOS)
+	rotasChaveAPI.Permitir(http.MethodGet, "/api/v1/bancohoras/saldos", permissions.VER_SALDO_FUNCIONARIOS)
+	rotasChaveAPI.Permitir(http.MethodGet, "/api/v1/bancohoras/saldos/exportar", permissions.VER_SALDO_FUNCIONARIOS)
+	rotasChaveAPI.Permitir(http.MethodGet, "/api/v1/bancohoras/saldo/usuario/:id", permissions.VER_SALDO_FUNCIONARIOS)
+	rotasChaveAPI.Permitir(http.MethodPost, "/api/v1/bancohoras/fechamento/usuario/:id", permissions.EDITAR_SALDO_FUNCIONARIOS)
+	rotasChaveAPI.Permitir(http.MethodGet, "/api/v1/auditoria", permissions.VER_AUDITORIA)
+	rotasChaveAPI.Permitir(http.MethodGet, "/api/v1/auditoria/exportar", permissions.VER_AUDITORIA)
+	authMiddleware := auth.AuthMiddleware(jwtService, chaveAPIService, rotasChaveAPI, plataformaService)
+	superAdminMiddleware := auth.SuperAdminMiddleware(jwtService, plataformaService, funcoesService)
+	auditoriaMiddleware := auditoria.Middleware(auditoriaService, funcoesService)
 
 	// Criamos os nossos middlewares de permissão aqui.
 	// Cada um verifica uma permissão específica.
@@ -87,26 +217,67 @@ func main() {
 	canDeleteUsuario := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.DELETAR_USUARIO)
 	canManageCargos := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.GERENCIAR_CARGOS)
 	canEditSaldo := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.EDITAR_SALDO_FUNCIONARIOS)
+	canUnlockUsuario := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.DESBLOQUEAR_USUARIO)
+	canManageSSO := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.GERENCIAR_SSO)
+	canManageChavesAPI := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.GERENCIAR_CHAVES_API)
+	canInviteUsuario := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.CONVIDAR_USUARIO)
+	canViewAuditoria := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.VER_AUDITORIA)
+	canManageEstrutura := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.GERENCIAR_ESTRUTURA)
+	canManagePoliticas := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.GERENCIAR_POLITICAS)
+	canManageDadosPessoais := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.GERENCIAR_DADOS_PESSOAIS)
+	canReviewPontos := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.REVISAR_PONTOS)
+	canViewFotosPonto := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.VER_FOTOS_PONTO)
+	canManageDispositivos := auth.PermissionMiddleware(usuarioService, funcoesService, permissions.GERENCIAR_DISPOSITIVOS)
 
-	scheduler := scheduler.NewScheduler(bancoHorasService, usuarioService)
+	retencaoService := retencao.NewRetencaoService(retencao.NewRetencaoRepository(db), cfg.RetencaoAnos, arquivos)
+	scheduler := scheduler.NewScheduler(bancoHorasService, usuarioService, retencaoService, empresaService, authService)
 	scheduler.Start()
 
 	// --- Rotas da API ---
 	router := gin.Default()
+	// Sem isto o gin confia no X-Forwarded-For de qualquer origem, e um cliente trocaria de IP a cada
+	// tentativa de login para escapar do bloqueio.
+	if err := router.SetTrustedProxies(cfg.ProxiesConfiaveis); err != nil {
+		log.Fatal("PROXIES_CONFIAVEIS inválido: ", err)
+	}
 	apiV1 := router.Group("/api/v1")
+	// Toda requisição que altera dados entra no log de auditoria. O ator é lido do contexto
+	// depois que a rota executa, então o middleware enxerga a autenticação feita mais adiante.
+	apiV1.Use(auditoriaMiddleware)
 	{
 		// Rotas Públicas
 		apiV1.POST("/auth/login", authHandler.Login)
-		apiV1.POST("/usuarios", usuarioHandler.CriarUsuarioHandler)
+		// Não há cadastro aberto: novos usuários entram por convite ou pela fila de aprovação.
+		apiV1.GET("/convites/aceitar", conviteHandler.Consultar)
+		apiV1.POST("/convites/aceitar", conviteHandler.Aceitar)
+		apiV1.POST("/empresas/:id/cadastro", conviteHandler.SolicitarCadastro)
+		apiV1.GET("/sso/:empresaId/login", ssoHandler.Login)
+		apiV1.GET("/sso/callback", ssoHandler.Callback)
 
-		// Rotas para Super-Admin (no futuro, proteger com um middleware de "SuperAdmin")
-		apiV1.POST("/permissoes", permissaoHandler.Create)
-		apiV1.GET("/permissoes", permissaoHandler.FindAll)
+		// Rotas da plataforma: só operadores (super-admin), nunca usuários de uma empresa.
+		apiV1.POST("/plataforma/auth/login", plataformaHandler.Login)
+		rotasPlataforma := apiV1.Group("/plataforma")
+		rotasPlataforma.Use(superAdminMiddleware)
+		{
+			rotasPlataforma.GET("/permissoes", permissaoHandler.FindAll)
 
-		apiV1.POST("/empresas", empresaHandler.CriarEmpresaHandler)
-		apiV1.GET("/empresas/:id", empresaHandler.GetEmpresaByIDHandler)
-		apiV1.POST("/cargos", cargoHandler.CreateCargo)
-		apiV1.POST("/cargos/:id/permissoes/:permissaoId", cargoHandler.AddPermissionToCargo)
+			rotasPlataforma.POST("/empresas", empresaHandler.CriarEmpresaHandler)
+			rotasPlataforma.GET("/empresas", empresaHandler.GetAllEmpresasHandler)
+			rotasPlataforma.GET("/empresas/:id", empresaHandler.GetEmpresaByIDHandler)
+			rotasPlataforma.POST("/empresas/:id/restaurar", empresaHandler.RestaurarEmpresaHandler)
+			rotasPlataforma.POST("/empresas/:id/impersonar", plataformaHandler.Impersonar)
+			rotasPlataforma.GET("/impersonacoes", plataformaHandler.GetImpersonacoes)
+			rotasPlataforma.GET("/auditoria", auditoriaHandler.GetAllPlataforma)
+			rotasPlataforma.GET("/auditoria/exportar", auditoriaHandler.ExportarPlataforma)
+		}
+
+		// Rotas do terminal de quiosque: autenticadas pela credencial do próprio quiosque.
+		rotasQuiosque := apiV1.Group("/quiosque")
+		rotasQuiosque.Use(quiosque.Middleware(quiosqueService))
+		{
+			rotasQuiosque.GET("/codigo", quiosqueHandler.GetCodigo)
+			rotasQuiosque.POST("/pontos", quiosqueHandler.BaterComPin)
+		}
 
 		// Rotas Protegidas (requerem login básico)
 		rotasProtegidas := apiV1.Group("")
@@ -117,26 +288,112 @@ func main() {
 			rotasProtegidas.GET("/usuarios/:id", usuarioHandler.GetByIdHandler)
 			rotasProtegidas.PUT("/usuarios/:id", usuarioHandler.UpdateUsuarioHandler) // Utilizador só pode alterar a si mesmo
 			rotasProtegidas.GET("/usuarios/me", usuarioHandler.GetMeuPerfil)
+			rotasProtegidas.GET("/usuarios/:id/permissoes", usuarioHandler.GetPermissoesEfetivas)
 
 			// Agora, para apagar um utilizador, é preciso a permissão DELETAR_USUARIO
 			rotasProtegidas.DELETE("/usuarios/:id", canDeleteUsuario, usuarioHandler.DeleteHandler)
+			rotasProtegidas.POST("/usuarios/:id/restaurar", canDeleteUsuario, usuarioHandler.RestaurarHandler)
+			rotasProtegidas.PUT("/usuarios/:id/pin", usuarioHandler.DefinirPinHandler) // Próprio usuário ou EDITAR_USUARIO no escopo
+			// Exportar os próprios dados não exige permissão; o handler confere o escopo para os demais.
+			rotasProtegidas.GET("/usuarios/:id/dados-pessoais", lgpdHandler.Exportar)
+			rotasProtegidas.POST("/usuarios/:id/anonimizar", canManageDadosPessoais, lgpdHandler.Anonimizar)
+			rotasProtegidas.POST("/usuarios/:id/desbloqueio", canUnlockUsuario, authHandler.Desbloquear)
+
+			rotasProtegidas.POST("/convites", canInviteUsuario, conviteHandler.Create)
+			rotasProtegidas.GET("/convites", canInviteUsuario, conviteHandler.GetAll)
+			rotasProtegidas.DELETE("/convites/:id", canInviteUsuario, conviteHandler.Cancel)
+			rotasProtegidas.GET("/solicitacoes-cadastro", canInviteUsuario, conviteHandler.GetSolicitacoes)
+			rotasProtegidas.POST("/solicitacoes-cadastro/:id/aprovar", canInviteUsuario, conviteHandler.AprovarSolicitacao)
+			rotasProtegidas.POST("/solicitacoes-cadastro/:id/rejeitar", canInviteUsuario, conviteHandler.RejeitarSolicitacao)
 
 			// Rota de Ponto
 			rotasProtegidas.POST("/pontos", pontoHandler.BaterPonto)
+			// Consulta por período: os próprios pontos e os da equipe no escopo de VER_SALDO_FUNCIONARIOS.
+			rotasProtegidas.GET("/pontos", pontoHandler.GetPontos)
 			rotasProtegidas.GET("/pontos/meus-registros", pontoHandler.GetMeusRegistos)
+			rotasProtegidas.POST("/pontos/quiosque", quiosqueHandler.BaterComCodigo)
+			// Batidas feitas sem conexão, enviadas em lote pelo aplicativo quando ele volta a ficar online.
+			rotasProtegidas.POST("/pontos/sincronizar", sincronizacaoHandler.Sincronizar)
+			// Caixa de entrada dos gestores: pontos remotos aguardando revisão, no escopo de REVISAR_PONTOS.
+			rotasProtegidas.GET("/pontos/revisoes", canReviewPontos, revisaoHandler.GetPendentes)
+			rotasProtegidas.POST("/pontos/:id/aprovar", canReviewPontos, revisaoHandler.Aprovar)
+			rotasProtegidas.POST("/pontos/:id/rejeitar", canReviewPontos, revisaoHandler.Rejeitar)
+			// A selfie da batida, no escopo de VER_FOTOS_PONTO.
+			rotasProtegidas.GET("/pontos/:id/foto", canViewFotosPonto, pontoHandler.GetFoto)
+			// Relatório de batidas com localização suspeita e os limites de risco da empresa.
+			rotasProtegidas.GET("/pontos/risco", canReviewPontos, riscoHandler.GetRelatorio)
+			rotasProtegidas.GET("/pontos/risco/configuracao", canEditEmpresa, riscoHandler.GetConfiguracao)
+			rotasProtegidas.PUT("/pontos/risco/configuracao", canEditEmpresa, riscoHandler.SalvarConfiguracao)
 
-			// Rotas de Empresa (Ações gerais)
-			rotasProtegidas.GET("/empresas", empresaHandler.GetAllEmpresasHandler)
+			// Aparelhos de ponto: cada usuário vê os seus e ativa um novo com o código recebido; a
+			// aprovação e a revogação ficam no escopo de GERENCIAR_DISPOSITIVOS.
+			rotasProtegidas.GET("/dispositivos/meus", dispositivoHandler.GetMeus)
+			rotasProtegidas.POST("/dispositivos/ativar", dispositivoHandler.Ativar)
+			rotasProtegidas.GET("/dispositivos", canManageDispositivos, dispositivoHandler.GetAll)
+			rotasProtegidas.GET("/dispositivos/compartilhados", canManageDispositivos, dispositivoHandler.GetCompartilhados)
+			rotasProtegidas.POST("/dispositivos/:id/aprovar", canManageDispositivos, dispositivoHandler.Aprovar)
+			rotasProtegidas.POST("/dispositivos/:id/rejeitar", canManageDispositivos, dispositivoHandler.Rejeitar)
+			rotasProtegidas.POST("/dispositivos/:id/revogar", canManageDispositivos, dispositivoHandler.Revogar)
+			rotasProtegidas.POST("/usuarios/:id/dispositivos/codigo", canManageDispositivos, dispositivoHandler.GerarCodigo)
+
+			// Rotas de Empresa (Ações gerais): cada usuário só enxerga a própria empresa.
+			rotasProtegidas.GET("/empresas", empresaHandler.GetMinhaEmpresaHandler)
 
 			// Rotas de Empresa (Ações Administrativas, protegidas por permissão)
 			rotasProtegidas.PUT("/empresas/:id", canEditEmpresa, empresaHandler.UpdateEmpresaHandler)
 			rotasProtegidas.DELETE("/empresas/:id", canDeleteEmpresa, empresaHandler.DeleteEmpresaHandler)
+			rotasProtegidas.GET("/sso/configuracao", canManageSSO, ssoHandler.GetConfiguracao)
+			rotasProtegidas.PUT("/sso/configuracao", canManageSSO, ssoHandler.SalvarConfiguracao)
+
+			rotasProtegidas.POST("/chaves-api", canManageChavesAPI, chaveAPIHandler.Create)
+			rotasProtegidas.GET("/chaves-api", canManageChavesAPI, chaveAPIHandler.GetAll)
+			rotasProtegidas.DELETE("/chaves-api/:id", canManageChavesAPI, chaveAPIHandler.Revoke)
+
+			rotasProtegidas.GET("/auditoria", canViewAuditoria, auditoriaHandler.GetAll)
+			rotasProtegidas.GET("/auditoria/exportar", canViewAuditoria, auditoriaHandler.Exportar)
 
 			// A gestão de cargos (apagar, atualizar, adicionar permissões) continua protegida.
+			rotasProtegidas.POST("/cargos", canManageCargos, cargoHandler.CreateCargo)
 			rotasProtegidas.GET("/cargos", cargoHandler.GetAllCargos)
+			rotasProtegidas.POST("/cargos/:id/permissoes/:permissaoId", canManageCargos, cargoHandler.AddPermissionToCargo)
+			rotasProtegidas.DELETE("/cargos/:id/permissoes/:permissaoId", canManageCargos, cargoHandler.RemovePermissionFromCargo)
+			rotasProtegidas.GET("/permissoes", canManageCargos, permissaoHandler.FindAll)
 			rotasProtegidas.PUT("/cargos/:id", canManageCargos, cargoHandler.UpdateCargo)
 			rotasProtegidas.DELETE("/cargos/:id", canManageCargos, cargoHandler.DeleteCargo)
+			rotasProtegidas.POST("/cargos/:id/restaurar", canManageCargos, cargoHandler.RestaurarCargo)
+
+			// Estrutura da empresa: qualquer usuário consulta, só quem tem GERENCIAR_ESTRUTURA altera.
+			rotasProtegidas.GET("/departamentos", departamentoHandler.GetAll)
+			rotasProtegidas.GET("/departamentos/:id", departamentoHandler.GetByID)
+			rotasProtegidas.POST("/departamentos", canManageEstrutura, departamentoHandler.Create)
+			rotasProtegidas.PUT("/departamentos/:id", canManageEstrutura, departamentoHandler.Update)
+			rotasProtegidas.DELETE("/departamentos/:id", canManageEstrutura, departamentoHandler.Delete)
+			rotasProtegidas.GET("/centros-custo", centroCustoHandler.GetAll)
+			rotasProtegidas.GET("/centros-custo/:id", centroCustoHandler.GetByID)
+			rotasProtegidas.POST("/centros-custo", canManageEstrutura, centroCustoHandler.Create)
+			rotasProtegidas.PUT("/centros-custo/:id", canManageEstrutura, centroCustoHandler.Update)
+			rotasProtegidas.DELETE("/centros-custo/:id", canManageEstrutura, centroCustoHandler.Delete)
+			rotasProtegidas.GET("/locais-trabalho", localTrabalhoHandler.GetAll)
+			rotasProtegidas.GET("/locais-trabalho/:id", localTrabalhoHandler.GetByID)
+			rotasProtegidas.POST("/locais-trabalho", canManageEstrutura, localTrabalhoHandler.Create)
+			rotasProtegidas.PUT("/locais-trabalho/:id", canManageEstrutura, localTrabalhoHandler.Update)
+			rotasProtegidas.DELETE("/locais-trabalho/:id", canManageEstrutura, localTrabalhoHandler.Delete)
+			rotasProtegidas.GET("/locais-trabalho/:id/atribuicoes", canManageEstrutura, localTrabalhoHandler.GetAtribuicoes)
+			rotasProtegidas.PUT("/locais-trabalho/:id/atribuicoes", canManageEstrutura, localTrabalhoHandler.SetAtribuicoes)
+			rotasProtegidas.GET("/quiosques", canManageEstrutura, quiosqueHandler.GetAll)
+			rotasProtegidas.POST("/quiosques", canManageEstrutura, quiosqueHandler.Create)
+			rotasProtegidas.DELETE("/quiosques/:id", canManageEstrutura, quiosqueHandler.Revogar)
+
+			// Políticas ABAC da empresa; /simular avalia uma requisição sem executá-la.
+			rotasProtegidas.GET("/politicas", canManagePoliticas, politicaHandler.GetAll)
+			rotasProtegidas.POST("/politicas", canManagePoliticas, politicaHandler.Create)
+			rotasProtegidas.POST("/politicas/simular", canManagePoliticas, politicaHandler.Simular)
+			rotasProtegidas.GET("/politicas/:id", canManagePoliticas, politicaHandler.GetByID)
+			rotasProtegidas.PUT("/politicas/:id", canManagePoliticas, politicaHandler.Update)
+			rotasProtegidas.DELETE("/politicas/:id", canManagePoliticas, politicaHandler.Delete)
 
+			rotasProtegidas.GET("/bancohoras/saldos", bancoHorasHandler.GetSaldos)
+			rotasProtegidas.GET("/bancohoras/saldos/exportar", bancoHorasHandler.ExportarSaldos)
 			rotasProtegidas.GET("/bancohoras/saldo/usuario/:id", bancoHorasHandler.GetSaldoDoDia)
 			rotasProtegidas.POST("/bancohoras/fechamento/usuario/:id", canEditSaldo, bancoHorasHandler.FecharDia)
 		}
@@ -148,3 +405,31 @@ func main() {
 		log.Fatal("Falha ao iniciar o servidor: ", err)
 	}
 }
+
+// abrirBanco conecta ao Postgres com o papel informado, já com o isolamento entre empresas instalado.
+func abrirBanco(cfg config.Config, usuario string, senha string) *gorm.DB {
+	// Os horários são gravados em UTC; o fuso de cada empresa só entra na leitura e nos limites do dia.
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+		cfg.DBHost, usuario, senha, cfg.DBName, cfg.DBPort)
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		log.Fatal("Falha ao conectar ao banco de dados: ", err)
+	}
+
+	// Toda tabela com empresa_id passa a ser filtrada pela empresa do contexto; sem empresa, o acesso falha.
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		log.Fatal("Falha ao instalar o isolamento entre empresas: ", err)
+	}
+	if err := db.Use(tenant.SessaoPostgres{}); err != nil {
+		log.Fatal("Falha ao instalar as variáveis de sessão do isolamento: ", err)
+	}
+
+	// A junção cargo-permissão guarda também o escopo da concessão.
+	if err := db.SetupJoinTable(&model.Cargo{}, "Permissoes", &model.CargoPermissao{}); err != nil {
+		log.Fatal("Falha ao configurar a tabela de permissões dos cargos: ", err)
+	}
+	return db
+}
@@ -0,0 +1,18 @@
+#!/bin/sh
+# Cria o papel com que a API atende as requisições. Roda uma única vez, quando o volume do banco é
+# inicializado (docker-entrypoint-initdb.d).
+#
+# POSTGRES_USER é superusuário e continua dono das tabelas: a API o usa só para a migração
+# (DB_MIGRACAO_USER). O papel da aplicação não é superusuário, não tem BYPASSRLS e não é dono de
+# nenhuma tabela, então as políticas de Row-Level Security valem para ele.
+set -e
+
+psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" \
+	-v papel="$PONTO_APP_USER" -v senha="$PONTO_APP_PASSWORD" -v dono="$POSTGRES_USER" -v banco="$POSTGRES_DB" <<'EOSQL'
+CREATE ROLE :"papel" LOGIN PASSWORD :'senha' NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE;
+GRANT CONNECT ON DATABASE :"banco" TO :"papel";
+GRANT USAGE ON SCHEMA public TO :"papel";
+-- As tabelas são criadas depois, pela migração, com o dono abaixo: os privilégios padrão as liberam.
+ALTER DEFAULT PRIVILEGES FOR ROLE :"dono" IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO :"papel";
+ALTER DEFAULT PRIVILEGES FOR ROLE :"dono" IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO :"papel";
+EOSQL
@@ -18,9 +18,71 @@ type Config struct {
 	DBUser     string `mapstructure:"DB_USER"`
 	DBPassword string `mapstructure:"DB_PASSWORD"`
 	DBName     string `mapstructure:"DB_NAME"`
+	// Papel dono das tabelas, usado só para a migração. Vazio, a migração usa DB_USER.
+	DBMigracaoUser     string `mapstructure:"DB_MIGRACAO_USER"`
+	DBMigracaoPassword string `mapstructure:"DB_MIGRACAO_PASSWORD"`
+	// Deixa a API subir mesmo quando DB_USER ignora o Row-Level Security (superusuário ou
+	// BYPASSRLS). Só para desenvolvimento.
+	DBPermitirSemRLS bool `mapstructure:"DB_PERMITIR_SEM_RLS"`
 
 	// Chave secreta para assinar os tokens JWT (usaremos mais tarde)
 	JWTSecretKey string `mapstructure:"JWT_SECRET_KEY"`
+
+	// Proteção contra força bruta no login.
+	// Após LOGIN_MAX_TENTATIVAS falhas seguidas a conta fica bloqueada por LOGIN_BLOQUEIO_MINUTOS;
+	// o mesmo vale para o IP de origem com LOGIN_MAX_TENTATIVAS_IP.
+	LoginMaxTentativas   int `mapstructure:"LOGIN_MAX_TENTATIVAS"`
+	LoginMaxTentativasIP int `mapstructure:"LOGIN_MAX_TENTATIVAS_IP"`
+	LoginBloqueioMinutos int `mapstructure:"LOGIN_BLOQUEIO_MINUTOS"`
+	// Atraso base (em segundos) entre tentativas; dobra a cada nova falha.
+	LoginAtrasoBaseSegundos int `mapstructure:"LOGIN_ATRASO_BASE_SEGUNDOS"`
+	// Pedidos de cadastro público aceitos de um mesmo IP antes de ele ficar bloqueado por
+	// LOGIN_BLOQUEIO_MINUTOS.
+	CadastroMaxPedidosIP int `mapstructure:"CADASTRO_MAX_PEDIDOS_IP"`
+
+	// Endereços (IPs ou CIDRs, separados por vírgula) dos proxies reversos na frente da API. Só deles
+	// o X-Forwarded-For é aceito como IP do cliente, que conta no bloqueio de login por IP. Vazio,
+	// vale o IP da conexão.
+	ProxiesConfiaveis []string `mapstructure:"PROXIES_CONFIAVEIS"`
+
+	// URL pública do callback de login único (ex: https://api.exemplo.com/api/v1/sso/callback),
+	// que deve estar cadastrada como redirect URI no provedor de cada empresa.
+	OIDCRedirectURL string `mapstructure:"OIDC_REDIRECT_URL"`
+
+	// Credenciais do primeiro operador da plataforma (super-admin), criado se ainda não houver nenhum.
+	PlataformaAdminEmail string `mapstructure:"PLATAFORMA_ADMIN_EMAIL"`
+	PlataformaAdminSenha string `mapstructure:"PLATAFORMA_ADMIN_SENHA"`
+
+	// Envio de e-mails (convites). Sem SMTP_HOST, os e-mails são apenas escritos no log, com os
+	// tokens dos links omitidos.
+	SMTPHost      string `mapstructure:"SMTP_HOST"`
+	SMTPPort      string `mapstructure:"SMTP_PORT"`
+	SMTPUser      string `mapstructure:"SMTP_USER"`
+	SMTPPassword  string `mapstructure:"SMTP_PASSWORD"`
+	SMTPRemetente string `mapstructure:"SMTP_REMETENTE"`
+
+	// Endereço da tela de aceite de convite; o token vai no parâmetro "token".
+	ConviteURLBase string `mapstructure:"CONVITE_URL_BASE"`
+
+	// Tempo máximo que as permissões efetivas de um usuário ficam em cache. Mudanças feitas por
+	// esta instância valem na hora; as de outras instâncias, em até este prazo. Zero desliga o cache.
+	PermissoesCacheSegundos int `mapstructure:"PERMISSOES_CACHE_SEGUNDOS"`
+
+	// Anos que os dados de funcionários excluídos são guardados antes de serem anonimizados.
+	// Zero desliga o expurgo.
+	RetencaoAnos int `mapstructure:"RETENCAO_ANOS"`
+
+	// Onde ficam os arquivos, como as selfies das batidas: "local" (o diretório
+	// ARMAZENAMENTO_DIRETORIO) ou "s3" (um bucket compatível com S3, como o MinIO).
+	ArmazenamentoTipo      string `mapstructure:"ARMAZENAMENTO_TIPO"`
+	ArmazenamentoDiretorio string `mapstructure:"ARMAZENAMENTO_DIRETORIO"`
+	S3Endpoint             string `mapstructure:"S3_ENDPOINT"`
+	S3Regiao               string `mapstructure:"S3_REGIAO"`
+	S3Bucket               string `mapstructure:"S3_BUCKET"`
+	S3ChaveAcesso          string `mapstructure:"S3_CHAVE_ACESSO"`
+	S3ChaveSecreta         string `mapstructure:"S3_CHAVE_SECRETA"`
+	// Endereça o bucket no caminho da URL em vez de no nome do host; necessário no MinIO.
+	S3EstiloCaminho bool `mapstructure:"S3_ESTILO_CAMINHO"`
 }
 
 // LoadConfig é a função que lê as configurações do arquivo .env no caminho especificado.
@@ -39,6 +101,25 @@ func LoadConfig(path string) (config Config, err error) {
 	// do ambiente do sistema, que podem sobrescrever as do arquivo .env.
 	viper.AutomaticEnv()
 
+	// Valores padrão para as configurações opcionais.
+	viper.SetDefault("LOGIN_MAX_TENTATIVAS", 5)
+	viper.SetDefault("LOGIN_MAX_TENTATIVAS_IP", 20)
+	viper.SetDefault("LOGIN_BLOQUEIO_MINUTOS", 15)
+	viper.SetDefault("LOGIN_ATRASO_BASE_SEGUNDOS", 1)
+	viper.SetDefault("CADASTRO_MAX_PEDIDOS_IP", 5)
+	viper.SetDefault("DB_MIGRACAO_USER", "")
+	viper.SetDefault("DB_MIGRACAO_PASSWORD", "")
+	viper.SetDefault("DB_PERMITIR_SEM_RLS", false)
+	viper.SetDefault("PROXIES_CONFIAVEIS", "")
+	viper.SetDefault("OIDC_REDIRECT_URL", "http://localhost:8083/api/v1/sso/callback")
+	viper.SetDefault("SMTP_PORT", "587")
+	viper.SetDefault("CONVITE_URL_BASE", "http://localhost:8083/api/v1/convites/aceitar")
+	viper.SetDefault("PERMISSOES_CACHE_SEGUNDOS", 60)
+	viper.SetDefault("RETENCAO_ANOS", 5)
+	viper.SetDefault("ARMAZENAMENTO_TIPO", "local")
+	viper.SetDefault("ARMAZENAMENTO_DIRETORIO", "./dados/arquivos")
+	viper.SetDefault("S3_REGIAO", "us-east-1")
+
 	// Tenta ler o arquivo de configuração.
 	err = viper.ReadInConfig()
 	if err != nil {
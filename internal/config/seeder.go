@@ -6,25 +6,21 @@ import (
 
 	"github.com/Loviiin/ponto-api-go/internal/model"
 	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// SeedPermissions cria as permissões padrão no sistema se elas não existirem.
+// SeedPermissions grava no banco as permissões do catálogo (permissions.Catalogo).
 func SeedPermissions(db *gorm.DB) map[string]model.Permissao {
-	permissoes := []model.Permissao{
-		{Nome: permissions.EDITAR_EMPRESA, Descricao: "Permite editar os dados da própria empresa."},
-		{Nome: permissions.DELETAR_EMPRESA, Descricao: "Permite deletar a própria empresa."},
-		{Nome: permissions.GERENCIAR_CARGOS, Descricao: "Permite criar, editar, apagar e gerenciar permissões de cargos."},
-		{Nome: permissions.DELETAR_USUARIO, Descricao: "Permite deletar outros usuários da empresa."},
-		{Nome: permissions.EDITAR_USUARIO, Descricao: "Permite editar os dados de outros usuários da empresa."},
-		{Nome: permissions.DELETAR_PROPRIA_CONTA, Descricao: "Permite que um usuário delete a sua própria conta."},
-		{Nome: permissions.EDITAR_PROPRIA_CONTA, Descricao: "Permite que um usuário edite seus próprios dados."},
-		{Nome: permissions.VER_SALDO_FUNCIONARIOS, Descricao: "Permite ver saldo de horas de um funcionário"},
-		{Nome: permissions.EDITAR_SALDO_FUNCIONARIOS, Descricao: "Pemite a edição de pontos de um funcionário caso necessário"},
+	permissoes := make([]model.Permissao, 0, len(permissions.Catalogo))
+	for _, definicao := range permissions.Catalogo {
+		permissoes = append(permissoes, model.Permissao{Nome: definicao.Nome, Descricao: definicao.Descricao})
 	}
 
 	for i := range permissoes {
-		db.FirstOrCreate(&permissoes[i], model.Permissao{Nome: permissoes[i].Nome})
+		// Assign mantém a descrição do banco igual à do código.
+		db.Where(model.Permissao{Nome: permissoes[i].Nome}).Assign(model.Permissao{Descricao: permissoes[i].Descricao}).FirstOrCreate(&permissoes[i])
 	}
 	log.Println("Permissões padrão verificadas/criadas.")
 
@@ -36,6 +32,7 @@ func SeedPermissions(db *gorm.DB) map[string]model.Permissao {
 }
 
 func SetupDefaultRolesAndPermissions(db *gorm.DB, empresaID uint, mapaPermissoes map[string]model.Permissao) {
+	db = tenant.Escopo(db, empresaID)
 	adminRole := model.Cargo{Nome: "Admin", EmpresaID: empresaID}
 	db.Where(model.Cargo{Nome: adminRole.Nome, EmpresaID: empresaID}).FirstOrCreate(&adminRole)
 
@@ -52,6 +49,17 @@ func SetupDefaultRolesAndPermissions(db *gorm.DB, empresaID uint, mapaPermissoes
 		mapaPermissoes[permissions.EDITAR_PROPRIA_CONTA],
 		mapaPermissoes[permissions.EDITAR_SALDO_FUNCIONARIOS],
 		mapaPermissoes[permissions.VER_SALDO_FUNCIONARIOS],
+		mapaPermissoes[permissions.DESBLOQUEAR_USUARIO],
+		mapaPermissoes[permissions.GERENCIAR_SSO],
+		mapaPermissoes[permissions.GERENCIAR_CHAVES_API],
+		mapaPermissoes[permissions.CONVIDAR_USUARIO],
+		mapaPermissoes[permissions.VER_AUDITORIA],
+		mapaPermissoes[permissions.GERENCIAR_ESTRUTURA],
+		mapaPermissoes[permissions.GERENCIAR_POLITICAS],
+		mapaPermissoes[permissions.GERENCIAR_DADOS_PESSOAIS],
+		mapaPermissoes[permissions.REVISAR_PONTOS],
+		mapaPermissoes[permissions.VER_FOTOS_PONTO],
+		mapaPermissoes[permissions.GERENCIAR_DISPOSITIVOS],
 	}
 
 	funcPermissions := []model.Permissao{
@@ -68,10 +76,27 @@ func SetupDefaultRolesAndPermissions(db *gorm.DB, empresaID uint, mapaPermissoes
 		return
 	}
 
+	// Gestor é um modelo que herda tudo de Funcionário e acrescenta o cuidado com a própria equipe.
+	gestorRole := model.Cargo{Nome: "Gestor", EmpresaID: empresaID, HerdaDeID: &funcRole.ID}
+	db.Where(model.Cargo{Nome: gestorRole.Nome, EmpresaID: empresaID}).FirstOrCreate(&gestorRole)
+	gestorPermissions := []model.CargoPermissao{
+		{CargoID: gestorRole.ID, PermissaoID: mapaPermissoes[permissions.VER_SALDO_FUNCIONARIOS].ID, Escopo: model.EscopoEquipe},
+		{CargoID: gestorRole.ID, PermissaoID: mapaPermissoes[permissions.EDITAR_SALDO_FUNCIONARIOS].ID, Escopo: model.EscopoEquipe},
+		{CargoID: gestorRole.ID, PermissaoID: mapaPermissoes[permissions.REVISAR_PONTOS].ID, Escopo: model.EscopoEquipe},
+		{CargoID: gestorRole.ID, PermissaoID: mapaPermissoes[permissions.VER_FOTOS_PONTO].ID, Escopo: model.EscopoEquipe},
+		{CargoID: gestorRole.ID, PermissaoID: mapaPermissoes[permissions.GERENCIAR_DISPOSITIVOS].ID, Escopo: model.EscopoEquipe},
+	}
+	err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&gestorPermissions).Error
+	if err != nil {
+		return
+	}
+
 	log.Printf("Cargos e permissões padrão configurados para a empresa %d.", empresaID)
 }
 
 func SeedSuperAdmin(db *gorm.DB) {
+	// O seed roda antes de existir qualquer requisição, e cria a própria empresa padrão.
+	db = tenant.Plataforma(db)
 	var usuarioExistente model.Usuario
 	err := db.Where("email = ?", "superadmin@ponto.com").First(&usuarioExistente).Error
 	if err == nil {
@@ -108,3 +133,31 @@ func SeedSuperAdmin(db *gorm.DB) {
 	db.Create(&superAdmin)
 	log.Println("Usuário Super Admin criado com sucesso.")
 }
+
+// SeedOperadorPlataforma cria o primeiro operador da plataforma quando a tabela está vazia.
+func SeedOperadorPlataforma(db *gorm.DB, email string, senha string) {
+	var total int64
+	db.Model(&model.OperadorPlataforma{}).Count(&total)
+	if total > 0 {
+		log.Println("Operador da plataforma já existe.")
+		return
+	}
+	if email == "" || senha == "" {
+		log.Println("PLATAFORMA_ADMIN_EMAIL/PLATAFORMA_ADMIN_SENHA não definidos; nenhum operador da plataforma foi criado.")
+		return
+	}
+
+	senhaCripto, err := password.CriptografaSenha(senha)
+	if err != nil {
+		log.Println("Falha ao criptografar a senha do operador da plataforma.")
+		return
+	}
+	operador := model.OperadorPlataforma{
+		Nome:  "Operador da Plataforma",
+		Email: email,
+		Senha: senhaCripto,
+		Ativo: true,
+	}
+	db.Create(&operador)
+	log.Println("Operador da plataforma criado com sucesso.")
+}
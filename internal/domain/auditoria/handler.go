@@ -0,0 +1,166 @@
+package auditoria
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service   AuditoriaService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s AuditoriaService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+// GetAll consulta o log da empresa do requisitante.
+func (h *Handler) GetAll(c *gin.Context) {
+	filtro, ok := h.filtroDaEmpresa(c)
+	if !ok {
+		return
+	}
+	h.buscar(c, filtro)
+}
+
+// Exportar devolve o log da empresa em JSON lines, para ingestão por um SIEM.
+func (h *Handler) Exportar(c *gin.Context) {
+	filtro, ok := h.filtroDaEmpresa(c)
+	if !ok {
+		return
+	}
+	h.exportar(c, filtro)
+}
+
+// GetAllPlataforma consulta o log de todas as empresas (filtro opcional "empresa_id").
+func (h *Handler) GetAllPlataforma(c *gin.Context) {
+	filtro, ok := h.filtroDaPlataforma(c)
+	if !ok {
+		return
+	}
+	h.buscar(c, filtro)
+}
+
+func (h *Handler) ExportarPlataforma(c *gin.Context) {
+	filtro, ok := h.filtroDaPlataforma(c)
+	if !ok {
+		return
+	}
+	h.exportar(c, filtro)
+}
+
+func (h *Handler) buscar(c *gin.Context, filtro FiltroAuditoria) {
+	registros, err := h.service.Buscar(filtro)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao consultar o log de auditoria."})
+		return
+	}
+	c.JSON(http.StatusOK, registros)
+}
+
+func (h *Handler) exportar(c *gin.Context, filtro FiltroAuditoria) {
+	nomeArquivo := fmt.Sprintf("auditoria-%s.jsonl", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", "attachment; filename=\""+nomeArquivo+"\"")
+	c.Status(http.StatusOK)
+
+	// Depois que as primeiras linhas foram enviadas já não é possível mudar o status da resposta.
+	if err := h.service.Exportar(filtro, c.Writer); err != nil {
+		log.Printf("AUDITORIA: exportação interrompida: %v", err)
+	}
+}
+
+func (h *Handler) filtroDaEmpresa(c *gin.Context) (FiltroAuditoria, bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return FiltroAuditoria{}, false
+	}
+	filtro, err := h.lerFiltro(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return FiltroAuditoria{}, false
+	}
+	filtro.EmpresaID = &empresaID
+	return filtro, true
+}
+
+func (h *Handler) filtroDaPlataforma(c *gin.Context) (FiltroAuditoria, bool) {
+	filtro, err := h.lerFiltro(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return FiltroAuditoria{}, false
+	}
+	if valor := c.Query("empresa_id"); valor != "" {
+		empresaID, err := h.converter.StrParaUint(valor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "O parâmetro 'empresa_id' deve ser um número."})
+			return FiltroAuditoria{}, false
+		}
+		filtro.EmpresaID = &empresaID
+	}
+	return filtro, true
+}
+
+// lerFiltro interpreta os parâmetros comuns: ator_id, entidade, entidade_id, acao,
+// de e ate (AAAA-MM-DD ou RFC 3339, com "ate" exclusivo), limite e pagina.
+func (h *Handler) lerFiltro(c *gin.Context) (FiltroAuditoria, error) {
+	filtro := FiltroAuditoria{
+		Entidade:   c.Query("entidade"),
+		EntidadeID: c.Query("entidade_id"),
+		Acao:       c.Query("acao"),
+	}
+	if valor := c.Query("ator_id"); valor != "" {
+		atorID, err := h.converter.StrParaUint(valor)
+		if err != nil {
+			return filtro, fmt.Errorf("o parâmetro 'ator_id' deve ser um número")
+		}
+		filtro.AtorID = &atorID
+	}
+	var err error
+	if filtro.De, err = lerData(c, "de"); err != nil {
+		return filtro, err
+	}
+	if filtro.Ate, err = lerData(c, "ate"); err != nil {
+		return filtro, err
+	}
+	if valor := c.Query("limite"); valor != "" {
+		limite, err := strconv.Atoi(valor)
+		if err != nil {
+			return filtro, fmt.Errorf("o parâmetro 'limite' deve ser um número")
+		}
+		filtro.Limite = limite
+	}
+	if valor := c.Query("pagina"); valor != "" {
+		pagina, err := strconv.Atoi(valor)
+		if err != nil {
+			return filtro, fmt.Errorf("o parâmetro 'pagina' deve ser um número")
+		}
+		filtro.Pagina = pagina
+	}
+	return filtro, nil
+}
+
+func lerData(c *gin.Context, parametro string) (*time.Time, error) {
+	valor := c.Query(parametro)
+	if valor == "" {
+		return nil, nil
+	}
+	momento, err := time.Parse(time.RFC3339, valor)
+	if err != nil {
+		momento, err = time.Parse("2006-01-02", valor)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("formato de data inválido em '%s'. Use AAAA-MM-DD ou RFC 3339", parametro)
+	}
+	return &momento, nil
+}
@@ -0,0 +1,143 @@
+package auditoria
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+)
+
+const contextoAlvo = "auditoriaAlvo"
+
+// Ações detalhadas pelos handlers. As demais requisições usam "MÉTODO /rota" como ação.
+const (
+	AcaoUsuarioAtualizado        = "USUARIO_ATUALIZADO"
+	AcaoCargoCriado              = "CARGO_CRIADO"
+	AcaoCargoAtualizado          = "CARGO_ATUALIZADO"
+	AcaoCargoExcluido            = "CARGO_EXCLUIDO"
+	AcaoCargoRestaurado          = "CARGO_RESTAURADO"
+	AcaoCargoPermissaoAdicionada = "CARGO_PERMISSAO_ADICIONADA"
+	AcaoCargoPermissaoRemovida   = "CARGO_PERMISSAO_REMOVIDA"
+	AcaoDepartamentoCriado       = "DEPARTAMENTO_CRIADO"
+	AcaoDepartamentoAtualizado   = "DEPARTAMENTO_ATUALIZADO"
+	AcaoDepartamentoExcluido     = "DEPARTAMENTO_EXCLUIDO"
+	AcaoCentroCustoCriado        = "CENTRO_CUSTO_CRIADO"
+	AcaoCentroCustoAtualizado    = "CENTRO_CUSTO_ATUALIZADO"
+	AcaoCentroCustoExcluido      = "CENTRO_CUSTO_EXCLUIDO"
+	AcaoPoliticaCriada           = "POLITICA_CRIADA"
+	AcaoPoliticaAtualizada       = "POLITICA_ATUALIZADA"
+	AcaoPoliticaExcluida         = "POLITICA_EXCLUIDA"
+	AcaoLocalTrabalhoCriado      = "LOCAL_TRABALHO_CRIADO"
+	AcaoLocalTrabalhoAtualizado  = "LOCAL_TRABALHO_ATUALIZADO"
+	AcaoLocalTrabalhoExcluido    = "LOCAL_TRABALHO_EXCLUIDO"
+	AcaoLocalTrabalhoAtribuicoes = "LOCAL_TRABALHO_ATRIBUICOES"
+	AcaoChaveAPICriada           = "CHAVE_API_CRIADA"
+	AcaoChaveAPIRevogada         = "CHAVE_API_REVOGADA"
+	AcaoQuiosqueCriado           = "QUIOSQUE_CRIADO"
+	AcaoQuiosqueRevogado         = "QUIOSQUE_REVOGADO"
+	AcaoEmpresaAtualizada        = "EMPRESA_ATUALIZADA"
+	AcaoDiaFechado               = "BANCO_HORAS_DIA_FECHADO"
+	AcaoRiscoConfigurado         = "RISCO_CONFIGURACAO_ATUALIZADA"
+	AcaoPontoRevisado            = "PONTO_REVISADO"
+	AcaoDispositivoDecidido      = "DISPOSITIVO_DECIDIDO"
+)
+
+// Alvo descreve a entidade alterada por uma requisição. Antes e Depois são os estados
+// completos; o registro guarda apenas a diferença entre eles.
+type Alvo struct {
+	Acao       string
+	Entidade   string
+	EntidadeID uint
+	Antes      interface{}
+	Depois     interface{}
+}
+
+// Anotar permite ao handler detalhar o registro de auditoria da requisição atual.
+// Sem o middleware de auditoria na rota, a anotação é simplesmente ignorada.
+func Anotar(c *gin.Context, alvo Alvo) {
+	c.Set(contextoAlvo, alvo)
+}
+
+// Middleware registra toda requisição que altera dados (POST, PUT, PATCH e DELETE),
+// inclusive as recusadas. O ator é lido do contexto só depois da execução da rota,
+// então o middleware pode ficar antes da autenticação.
+func Middleware(service AuditoriaService, f funcoes.FuncoesInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			return
+		}
+
+		registro := &model.RegistroAuditoria{
+			Metodo:    c.Request.Method,
+			Rota:      c.FullPath(),
+			Status:    c.Writer.Status(),
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+		if registro.Rota == "" {
+			registro.Rota = c.Request.URL.Path
+		}
+		preencherAtor(c, f, registro)
+
+		if valor, existe := c.Get(contextoAlvo); existe {
+			alvo := valor.(Alvo)
+			registro.Acao = alvo.Acao
+			registro.Entidade = alvo.Entidade
+			registro.EntidadeID = strconv.FormatUint(uint64(alvo.EntidadeID), 10)
+			alteracoes, err := DiferencasDaEntidade(alvo.Entidade, alvo.Antes, alvo.Depois)
+			if err != nil {
+				log.Printf("AUDITORIA: falha ao calcular alterações de %s %s: %v", alvo.Entidade, registro.EntidadeID, err)
+			}
+			registro.Alteracoes = alteracoes
+		}
+		if registro.Acao == "" {
+			registro.Acao = registro.Metodo + " " + registro.Rota
+		}
+		if registro.Entidade == "" {
+			registro.Entidade = entidadeDaRota(registro.Rota)
+			registro.EntidadeID = c.Param("id")
+		}
+
+		if err := service.Registrar(registro); err != nil {
+			log.Printf("AUDITORIA: falha ao registrar %s por %s %v: %v", registro.Acao, registro.AtorTipo, registro.AtorID, err)
+		}
+	}
+}
+
+func preencherAtor(c *gin.Context, f funcoes.FuncoesInterface, registro *model.RegistroAuditoria) {
+	if empresaID, err := f.GetUintIDFromContext(c, "empresaID"); err == nil {
+		registro.EmpresaID = &empresaID
+	}
+	if impersonadoPor, err := f.GetUintIDFromContext(c, "impersonadoPor"); err == nil {
+		registro.ImpersonadoPor = &impersonadoPor
+	}
+
+	if userID, err := f.GetUintIDFromContext(c, "userID"); err == nil {
+		registro.AtorTipo = model.AtorUsuario
+		registro.AtorID = &userID
+	} else if chaveID, err := f.GetUintIDFromContext(c, "chaveAPIID"); err == nil {
+		registro.AtorTipo = model.AtorChaveAPI
+		registro.AtorID = &chaveID
+	} else if operadorID, err := f.GetUintIDFromContext(c, "operadorID"); err == nil {
+		registro.AtorTipo = model.AtorOperador
+		registro.AtorID = &operadorID
+	} else if quiosqueID, err := f.GetUintIDFromContext(c, "quiosqueID"); err == nil {
+		registro.AtorTipo = model.AtorQuiosque
+		registro.AtorID = &quiosqueID
+	} else {
+		registro.AtorTipo = model.AtorAnonimo
+	}
+}
+
+// entidadeDaRota usa o primeiro segmento do recurso como nome da entidade,
+// ex: "/api/v1/cargos/:id" vira "cargos".
+func entidadeDaRota(rota string) string {
+	rota = strings.TrimPrefix(rota, "/api/v1/")
+	rota = strings.TrimPrefix(rota, "plataforma/")
+	return strings.SplitN(rota, "/", 2)[0]
+}
@@ -0,0 +1,136 @@
+package auditoria
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+)
+
+func novoRouterAuditado(repo *memoriaAuditoriaRepository, autenticar gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api/v1")
+	api.Use(Middleware(NewAuditoriaService(repo), funcoes.NewFuncoes()))
+	api.GET("/cargos", autenticar, func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.DELETE("/cargos/:id", autenticar, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	api.PUT("/cargos/:id", autenticar, func(c *gin.Context) {
+		Anotar(c, Alvo{
+			Acao:       AcaoCargoAtualizado,
+			Entidade:   "cargos",
+			EntidadeID: 7,
+			Antes:      &model.Cargo{ID: 7, Nome: "Antigo"},
+			Depois:     &model.Cargo{ID: 7, Nome: "Novo"},
+		})
+		c.Status(http.StatusNoContent)
+	})
+	api.DELETE("/chaves-api/:id", autenticar, func(c *gin.Context) {
+		revogadaEm := time.Now()
+		Anotar(c, Alvo{
+			Acao:       AcaoChaveAPIRevogada,
+			Entidade:   "chaves-api",
+			EntidadeID: 4,
+			Antes:      &model.ChaveAPI{ID: 4, Hash: "hash-antigo"},
+			Depois:     &model.ChaveAPI{ID: 4, Hash: "hash-novo", RevogadaEm: &revogadaEm},
+		})
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func autenticarComo(userID, empresaID, impersonadoPor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Set("empresaID", empresaID)
+		if impersonadoPor != "" {
+			c.Set("impersonadoPor", impersonadoPor)
+		}
+	}
+}
+
+func TestMiddleware_RegistraAtorEAlteracoes(t *testing.T) {
+	repo := &memoriaAuditoriaRepository{}
+	router := novoRouterAuditado(repo, autenticarComo("3", "1", "9"))
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/cargos/7", nil)
+	req.Header.Set("User-Agent", "teste/1.0")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(repo.registros) != 1 {
+		t.Fatalf("Esperava 1 registro, recebeu %d", len(repo.registros))
+	}
+	r := repo.registros[0]
+	if r.AtorTipo != model.AtorUsuario || r.AtorID == nil || *r.AtorID != 3 {
+		t.Errorf("Ator incorreto: %s %v", r.AtorTipo, r.AtorID)
+	}
+	if r.EmpresaID == nil || *r.EmpresaID != 1 || r.ImpersonadoPor == nil || *r.ImpersonadoPor != 9 {
+		t.Errorf("Empresa ou impersonação incorretas: %+v", r)
+	}
+	if r.Acao != AcaoCargoAtualizado || r.Entidade != "cargos" || r.EntidadeID != "7" || r.Status != http.StatusNoContent {
+		t.Errorf("Dados da ação incorretos: %+v", r)
+	}
+	if r.UserAgent != "teste/1.0" || r.Rota != "/api/v1/cargos/:id" {
+		t.Errorf("Dados da requisição incorretos: %+v", r)
+	}
+	var alteracoes map[string]map[string]interface{}
+	if err := json.Unmarshal(r.Alteracoes, &alteracoes); err != nil || alteracoes["nome"]["depois"] != "Novo" {
+		t.Errorf("Alterações incorretas: %s", r.Alteracoes)
+	}
+}
+
+func TestMiddleware_SemAnotacaoUsaRotaELeiturasSaoIgnoradas(t *testing.T) {
+	repo := &memoriaAuditoriaRepository{}
+	router := novoRouterAuditado(repo, autenticarComo("3", "1", ""))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/cargos", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/cargos/5", nil))
+
+	if len(repo.registros) != 1 {
+		t.Fatalf("Só a requisição de alteração deveria ser registrada, recebeu %d", len(repo.registros))
+	}
+	r := repo.registros[0]
+	if r.Acao != "DELETE /api/v1/cargos/:id" || r.Entidade != "cargos" || r.EntidadeID != "5" {
+		t.Errorf("Registro padrão incorreto: %+v", r)
+	}
+}
+
+func TestMiddleware_RequisicaoRecusadaSemAtor(t *testing.T) {
+	repo := &memoriaAuditoriaRepository{}
+	recusar := func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) }
+	router := novoRouterAuditado(repo, recusar)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/cargos/5", nil))
+
+	if len(repo.registros) != 1 {
+		t.Fatalf("Esperava 1 registro, recebeu %d", len(repo.registros))
+	}
+	if r := repo.registros[0]; r.AtorTipo != model.AtorAnonimo || r.Status != http.StatusUnauthorized {
+		t.Errorf("Tentativa recusada deveria ficar registrada como anônima: %+v", r)
+	}
+}
+
+func TestMiddleware_RevogacaoNaoExpoeSegredos(t *testing.T) {
+	repo := &memoriaAuditoriaRepository{}
+	router := novoRouterAuditado(repo, autenticarComo("3", "1", ""))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/chaves-api/4", nil))
+
+	if len(repo.registros) != 1 {
+		t.Fatalf("Esperava 1 registro, recebeu %d", len(repo.registros))
+	}
+	var alteracoes map[string]map[string]interface{}
+	if err := json.Unmarshal(repo.registros[0].Alteracoes, &alteracoes); err != nil {
+		t.Fatalf("Alterações inválidas: %v", err)
+	}
+	if _, existe := alteracoes["revogada_em"]; !existe {
+		t.Errorf("A revogação deveria aparecer nas alterações: %v", alteracoes)
+	}
+	if _, existe := alteracoes["hash"]; existe || len(alteracoes) != 1 {
+		t.Errorf("Só a revogação deveria aparecer, sem o hash: %v", alteracoes)
+	}
+}
@@ -0,0 +1,122 @@
+package auditoria
+
+import (
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+// FiltroAuditoria restringe a consulta do log. EmpresaID nulo só é usado pela plataforma.
+type FiltroAuditoria struct {
+	EmpresaID  *uint
+	AtorID     *uint
+	Entidade   string
+	EntidadeID string
+	Acao       string
+	De         *time.Time
+	Ate        *time.Time
+	Limite     int
+	Pagina     int
+}
+
+// AuditoriaRepository só insere e consulta: não existe atualização nem remoção de registros.
+type AuditoriaRepository interface {
+	Create(registro *model.RegistroAuditoria) error
+	Buscar(filtro FiltroAuditoria) ([]model.RegistroAuditoria, error)
+	Percorrer(filtro FiltroAuditoria, fn func(registro *model.RegistroAuditoria) error) error
+}
+
+type auditoriaRepository struct {
+	Db *gorm.DB
+}
+
+func NewAuditoriaRepository(db *gorm.DB) AuditoriaRepository {
+	return &auditoriaRepository{Db: db}
+}
+
+// ProtegerTabela instala o gatilho que impede UPDATE e DELETE no log de auditoria,
+// garantindo que nem a própria aplicação consiga reescrever o histórico.
+func ProtegerTabela(db *gorm.DB) error {
+	return db.Exec(`
+CREATE OR REPLACE FUNCTION auditoria_somente_insercao() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'o log de auditoria não pode ser alterado';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS registro_auditoria_somente_insercao ON registro_auditoria;
+CREATE TRIGGER registro_auditoria_somente_insercao
+	BEFORE UPDATE OR DELETE ON registro_auditoria
+	FOR EACH ROW EXECUTE FUNCTION auditoria_somente_insercao();
+`).Error
+}
+
+// escopo restringe o acesso à empresa. Sem empresa, o acesso é da plataforma, que registra e
+// consulta eventos de todas as empresas.
+func (r *auditoriaRepository) escopo(empresaID *uint) *gorm.DB {
+	if empresaID == nil {
+		return tenant.Plataforma(r.Db)
+	}
+	return tenant.Escopo(r.Db, *empresaID)
+}
+
+func (r *auditoriaRepository) Create(registro *model.RegistroAuditoria) error {
+	return r.escopo(registro.EmpresaID).Create(registro).Error
+}
+
+func (r *auditoriaRepository) Buscar(filtro FiltroAuditoria) ([]model.RegistroAuditoria, error) {
+	var registros []model.RegistroAuditoria
+	consulta := aplicarFiltro(r.escopo(filtro.EmpresaID).Model(&model.RegistroAuditoria{}), filtro).Order("id desc")
+	if filtro.Limite > 0 {
+		consulta = consulta.Limit(filtro.Limite).Offset(filtro.Pagina * filtro.Limite)
+	}
+	err := consulta.Find(&registros).Error
+	return registros, err
+}
+
+// Percorrer lê os registros em ordem cronológica sem carregar tudo em memória, para exportação.
+func (r *auditoriaRepository) Percorrer(filtro FiltroAuditoria, fn func(registro *model.RegistroAuditoria) error) error {
+	rows, err := aplicarFiltro(r.escopo(filtro.EmpresaID).Model(&model.RegistroAuditoria{}), filtro).Order("id asc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var registro model.RegistroAuditoria
+		if err := r.Db.ScanRows(rows, &registro); err != nil {
+			return err
+		}
+		if err := fn(&registro); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func aplicarFiltro(consulta *gorm.DB, filtro FiltroAuditoria) *gorm.DB {
+	if filtro.EmpresaID != nil {
+		consulta = consulta.Where("empresa_id = ?", *filtro.EmpresaID)
+	}
+	if filtro.AtorID != nil {
+		consulta = consulta.Where("ator_id = ?", *filtro.AtorID)
+	}
+	if filtro.Entidade != "" {
+		consulta = consulta.Where("entidade = ?", filtro.Entidade)
+	}
+	if filtro.EntidadeID != "" {
+		consulta = consulta.Where("entidade_id = ?", filtro.EntidadeID)
+	}
+	if filtro.Acao != "" {
+		consulta = consulta.Where("acao = ?", filtro.Acao)
+	}
+	if filtro.De != nil {
+		consulta = consulta.Where("data_criacao >= ?", *filtro.De)
+	}
+	if filtro.Ate != nil {
+		consulta = consulta.Where("data_criacao < ?", *filtro.Ate)
+	}
+	return consulta
+}
@@ -0,0 +1,128 @@
+package auditoria
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+)
+
+const (
+	limitePadrao = 50
+	limiteMaximo = 500
+)
+
+type AuditoriaService interface {
+	Registrar(registro *model.RegistroAuditoria) error
+	Buscar(filtro FiltroAuditoria) ([]model.RegistroAuditoria, error)
+	// Exportar escreve os registros no formato JSON lines (um objeto por linha).
+	Exportar(filtro FiltroAuditoria, w io.Writer) error
+}
+
+type auditoriaService struct {
+	repo AuditoriaRepository
+}
+
+func NewAuditoriaService(repo AuditoriaRepository) AuditoriaService {
+	return &auditoriaService{repo: repo}
+}
+
+func (s *auditoriaService) Registrar(registro *model.RegistroAuditoria) error {
+	if registro.AtorTipo == "" {
+		registro.AtorTipo = model.AtorSistema
+	}
+	return s.repo.Create(registro)
+}
+
+func (s *auditoriaService) Buscar(filtro FiltroAuditoria) ([]model.RegistroAuditoria, error) {
+	if filtro.Limite <= 0 {
+		filtro.Limite = limitePadrao
+	}
+	if filtro.Limite > limiteMaximo {
+		filtro.Limite = limiteMaximo
+	}
+	if filtro.Pagina < 0 {
+		filtro.Pagina = 0
+	}
+	return s.repo.Buscar(filtro)
+}
+
+func (s *auditoriaService) Exportar(filtro FiltroAuditoria, w io.Writer) error {
+	filtro.Limite = 0
+	encoder := json.NewEncoder(w)
+	return s.repo.Percorrer(filtro, func(registro *model.RegistroAuditoria) error {
+		return encoder.Encode(registro)
+	})
+}
+
+type alteracaoCampo struct {
+	Antes  interface{} `json:"antes"`
+	Depois interface{} `json:"depois"`
+}
+
+// ValorOmitido toma o lugar, no log de auditoria, do valor de um campo com dado pessoal.
+const ValorOmitido = "[dado pessoal omitido]"
+
+// camposPessoais são, por entidade, os campos que a anonimização (LGPD) apaga. O log é somente de
+// inserção e não pode ser anonimizado depois, então guarda apenas que esses campos mudaram.
+var camposPessoais = map[string][]string{
+	"usuarios":        {"nome", "email", "matricula"},
+	"registro_pontos": {"latitude", "longitude", "justificativa"},
+	"dispositivos":    {"nome", "identificador"},
+}
+
+// Diferencas compara a forma JSON de dois estados de uma entidade e devolve só os campos
+// alterados. Campos com json:"-" (como senhas) nunca aparecem no resultado.
+func Diferencas(antes interface{}, depois interface{}) (model.JSONB, error) {
+	return diferencas(antes, depois, nil)
+}
+
+// DiferencasDaEntidade é Diferencas com os dados pessoais da entidade trocados por ValorOmitido.
+func DiferencasDaEntidade(entidade string, antes interface{}, depois interface{}) (model.JSONB, error) {
+	return diferencas(antes, depois, camposPessoais[entidade])
+}
+
+func diferencas(antes interface{}, depois interface{}, omitidos []string) (model.JSONB, error) {
+	mapaAntes, err := paraMapa(antes)
+	if err != nil {
+		return nil, err
+	}
+	mapaDepois, err := paraMapa(depois)
+	if err != nil {
+		return nil, err
+	}
+
+	alteracoes := make(map[string]alteracaoCampo)
+	for _, mapa := range []map[string]interface{}{mapaAntes, mapaDepois} {
+		for campo := range mapa {
+			if !reflect.DeepEqual(mapaAntes[campo], mapaDepois[campo]) {
+				alteracoes[campo] = alteracaoCampo{Antes: mapaAntes[campo], Depois: mapaDepois[campo]}
+			}
+		}
+	}
+	if len(alteracoes) == 0 {
+		return nil, nil
+	}
+	for _, campo := range omitidos {
+		if _, alterado := alteracoes[campo]; alterado {
+			alteracoes[campo] = alteracaoCampo{Antes: ValorOmitido, Depois: ValorOmitido}
+		}
+	}
+	return json.Marshal(alteracoes)
+}
+
+func paraMapa(valor interface{}) (map[string]interface{}, error) {
+	mapa := make(map[string]interface{})
+	if valor == nil || (reflect.ValueOf(valor).Kind() == reflect.Ptr && reflect.ValueOf(valor).IsNil()) {
+		return mapa, nil
+	}
+	dados, err := json.Marshal(valor)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(dados, &mapa); err != nil {
+		return nil, err
+	}
+	return mapa, nil
+}
@@ -0,0 +1,166 @@
+package auditoria
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+)
+
+type memoriaAuditoriaRepository struct {
+	registros    []model.RegistroAuditoria
+	ultimoFiltro FiltroAuditoria
+}
+
+func (m *memoriaAuditoriaRepository) Create(registro *model.RegistroAuditoria) error {
+	registro.ID = uint(len(m.registros) + 1)
+	m.registros = append(m.registros, *registro)
+	return nil
+}
+
+func (m *memoriaAuditoriaRepository) Buscar(filtro FiltroAuditoria) ([]model.RegistroAuditoria, error) {
+	m.ultimoFiltro = filtro
+	return m.registros, nil
+}
+
+func (m *memoriaAuditoriaRepository) Percorrer(filtro FiltroAuditoria, fn func(registro *model.RegistroAuditoria) error) error {
+	m.ultimoFiltro = filtro
+	for i := range m.registros {
+		if filtro.EmpresaID != nil && (m.registros[i].EmpresaID == nil || *m.registros[i].EmpresaID != *filtro.EmpresaID) {
+			continue
+		}
+		if err := fn(&m.registros[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDiferencas_SoCamposAlterados(t *testing.T) {
+	antes := &model.Usuario{ID: 1, Nome: "Ana", Email: "ana@exemplo.com", Senha: "hash-antigo", CargoID: 2}
+	depois := &model.Usuario{ID: 1, Nome: "Ana Souza", Email: "ana@exemplo.com", Senha: "hash-novo", CargoID: 3}
+
+	alteracoes, err := Diferencas(antes, depois)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	var mapa map[string]map[string]interface{}
+	if err := json.Unmarshal(alteracoes, &mapa); err != nil {
+		t.Fatalf("Alterações não são JSON válido: %v", err)
+	}
+
+	if len(mapa) != 2 {
+		t.Errorf("Esperava 2 campos alterados, recebeu %v", mapa)
+	}
+	if mapa["nome"]["antes"] != "Ana" || mapa["nome"]["depois"] != "Ana Souza" {
+		t.Errorf("Diferença de nome incorreta: %v", mapa["nome"])
+	}
+	if mapa["cargo_id"]["antes"] != float64(2) || mapa["cargo_id"]["depois"] != float64(3) {
+		t.Errorf("Diferença de cargo incorreta: %v", mapa["cargo_id"])
+	}
+	if _, ok := mapa["senha"]; ok {
+		t.Error("A senha nunca deveria aparecer no log de auditoria")
+	}
+}
+
+func TestDiferencasDaEntidade_OmiteDadosPessoais(t *testing.T) {
+	matricula := "A-17"
+	antes := &model.Usuario{ID: 1, Nome: "Ana", Email: "ana@exemplo.com", CargoID: 2}
+	depois := &model.Usuario{ID: 1, Nome: "Ana Souza", Email: "ana.souza@exemplo.com", Matricula: &matricula, CargoID: 3}
+
+	alteracoes, err := DiferencasDaEntidade("usuarios", antes, depois)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	for _, dado := range []string{"Ana", "exemplo.com", "A-17"} {
+		if bytes.Contains(alteracoes, []byte(dado)) {
+			t.Errorf("O dado pessoal %q não deveria ir para o log de auditoria: %s", dado, alteracoes)
+		}
+	}
+	var mapa map[string]map[string]interface{}
+	if err := json.Unmarshal(alteracoes, &mapa); err != nil {
+		t.Fatalf("Alterações não são JSON válido: %v", err)
+	}
+	for _, campo := range []string{"nome", "email", "matricula"} {
+		if mapa[campo]["depois"] != ValorOmitido {
+			t.Errorf("Esperava o campo %s registrado como alterado e omitido, recebeu %v", campo, mapa[campo])
+		}
+	}
+	if mapa["cargo_id"]["depois"] != float64(3) {
+		t.Errorf("Campos sem dado pessoal deveriam manter o valor: %v", mapa["cargo_id"])
+	}
+
+	pontoAntes := &model.RegistroPonto{ID: 9, Latitude: -23.5, Longitude: -46.6, Justificativa: "consulta médica", StatusRevisao: model.RevisaoPendente}
+	pontoDepois := &model.RegistroPonto{ID: 9, StatusRevisao: model.RevisaoAprovada}
+	alteracoes, err = DiferencasDaEntidade("registro_pontos", pontoAntes, pontoDepois)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if bytes.Contains(alteracoes, []byte("-23.5")) || bytes.Contains(alteracoes, []byte("médica")) {
+		t.Errorf("Coordenadas e justificativa não deveriam ir para o log de auditoria: %s", alteracoes)
+	}
+}
+
+func TestDiferencas_SemAlteracaoOuEstadoNulo(t *testing.T) {
+	cargo := &model.Cargo{ID: 1, Nome: "Admin"}
+	alteracoes, err := Diferencas(cargo, cargo)
+	if err != nil || alteracoes != nil {
+		t.Errorf("Sem alteração, esperava nil, recebeu %s (%v)", alteracoes, err)
+	}
+
+	var nulo *model.Cargo
+	alteracoes, err = Diferencas(nulo, cargo)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if !bytes.Contains(alteracoes, []byte(`"nome":{"antes":null,"depois":"Admin"}`)) {
+		t.Errorf("Criação deveria ter antes nulo, recebeu %s", alteracoes)
+	}
+}
+
+func TestBuscar_LimitaTamanhoDaPagina(t *testing.T) {
+	repo := &memoriaAuditoriaRepository{}
+	service := NewAuditoriaService(repo)
+
+	if _, err := service.Buscar(FiltroAuditoria{Limite: 100000, Pagina: -1}); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if repo.ultimoFiltro.Limite != limiteMaximo || repo.ultimoFiltro.Pagina != 0 {
+		t.Errorf("Filtro não foi normalizado: %+v", repo.ultimoFiltro)
+	}
+}
+
+func TestExportar_JSONLines(t *testing.T) {
+	repo := &memoriaAuditoriaRepository{}
+	service := NewAuditoriaService(repo)
+	empresa1, empresa2 := uint(1), uint(2)
+	_ = service.Registrar(&model.RegistroAuditoria{EmpresaID: &empresa1, Acao: "A"})
+	_ = service.Registrar(&model.RegistroAuditoria{EmpresaID: &empresa2, Acao: "B"})
+	_ = service.Registrar(&model.RegistroAuditoria{EmpresaID: &empresa1, Acao: "C"})
+
+	var saida bytes.Buffer
+	if err := service.Exportar(FiltroAuditoria{EmpresaID: &empresa1, Limite: 1}, &saida); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if repo.ultimoFiltro.Limite != 0 {
+		t.Error("A exportação não deveria ser paginada")
+	}
+
+	var acoes []string
+	scanner := bufio.NewScanner(&saida)
+	for scanner.Scan() {
+		var registro model.RegistroAuditoria
+		if err := json.Unmarshal(scanner.Bytes(), &registro); err != nil {
+			t.Fatalf("Linha não é um objeto JSON: %q", scanner.Text())
+		}
+		if registro.AtorTipo != model.AtorSistema {
+			t.Errorf("Registro sem ator deveria ser do sistema, recebeu %q", registro.AtorTipo)
+		}
+		acoes = append(acoes, registro.Acao)
+	}
+	if len(acoes) != 2 || acoes[0] != "A" || acoes[1] != "C" {
+		t.Errorf("Esperava as ações [A C], recebeu %v", acoes)
+	}
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+)
+
+const (
+	EventoBloqueioConta = "LOGIN_BLOQUEIO_CONTA"
+	EventoBloqueioIP    = "LOGIN_BLOQUEIO_IP"
+	EventoDesbloqueio   = "LOGIN_DESBLOQUEIO"
+	// EventoBloqueioOperador é o bloqueio do login de um operador da plataforma.
+	EventoBloqueioOperador = "LOGIN_BLOQUEIO_OPERADOR"
+	// EventoImpersonacao registra que um operador passou a agir como um usuário de uma empresa.
+	EventoImpersonacao = "IMPERSONACAO"
+)
+
+// EventoSeguranca descreve um acontecimento de segurança que deve ficar registrado para auditoria.
+type EventoSeguranca struct {
+	Tipo      string
+	Chave     string
+	EmpresaID uint
+	UsuarioID uint
+	AtorID    uint
+	// OperadorID é o operador da plataforma envolvido: o dono do login bloqueado ou quem impersonou.
+	OperadorID uint
+	// Motivo é a justificativa informada pelo operador na impersonação.
+	Motivo  string
+	IP      string
+	Ate     *time.Time
+	Momento time.Time
+}
+
+// RegistradorEventos recebe os eventos de segurança gerados pela autenticação.
+type RegistradorEventos interface {
+	Registrar(evento EventoSeguranca)
+}
+
+type logRegistradorEventos struct{}
+
+// NewLogRegistradorEventos escreve os eventos no log da aplicação.
+func NewLogRegistradorEventos() RegistradorEventos {
+	return &logRegistradorEventos{}
+}
+
+func (r *logRegistradorEventos) Registrar(evento EventoSeguranca) {
+	log.Printf("AUDITORIA: %s chave=%s empresa=%d usuario=%d ator=%d operador=%d ip=%s ate=%v motivo=%q",
+		evento.Tipo, evento.Chave, evento.EmpresaID, evento.UsuarioID, evento.AtorID, evento.OperadorID, evento.IP, evento.Ate, evento.Motivo)
+}
+
+type auditoriaRegistradorEventos struct {
+	auditoria auditoria.AuditoriaService
+}
+
+// NewAuditoriaRegistradorEventos grava os eventos de segurança no log de auditoria.
+func NewAuditoriaRegistradorEventos(a auditoria.AuditoriaService) RegistradorEventos {
+	return &auditoriaRegistradorEventos{auditoria: a}
+}
+
+func (r *auditoriaRegistradorEventos) Registrar(evento EventoSeguranca) {
+	registro := &model.RegistroAuditoria{
+		CreatedAt: evento.Momento,
+		AtorTipo:  model.AtorSistema,
+		Acao:      evento.Tipo,
+		Entidade:  "usuarios",
+		IP:        evento.IP,
+	}
+	if evento.EmpresaID != 0 {
+		registro.EmpresaID = &evento.EmpresaID
+	}
+	if evento.AtorID != 0 {
+		registro.AtorTipo = model.AtorUsuario
+		registro.AtorID = &evento.AtorID
+	}
+	if evento.Tipo == EventoImpersonacao {
+		registro.AtorTipo = model.AtorOperador
+		registro.AtorID = &evento.OperadorID
+	}
+	switch {
+	case evento.Tipo == EventoBloqueioIP:
+		registro.Entidade = "ip"
+		registro.EntidadeID = evento.IP
+	case evento.Tipo == EventoBloqueioOperador:
+		registro.Entidade = "operadores"
+		if evento.OperadorID != 0 {
+			registro.EntidadeID = strconv.FormatUint(uint64(evento.OperadorID), 10)
+		}
+	case evento.UsuarioID != 0:
+		registro.EntidadeID = strconv.FormatUint(uint64(evento.UsuarioID), 10)
+	}
+
+	alteracoes := map[string]interface{}{}
+	if evento.Ate != nil {
+		// Na impersonação, Ate é o fim da validade do token emitido.
+		campo := "bloqueado_ate"
+		if evento.Tipo == EventoImpersonacao {
+			campo = "impersonacao_expira_em"
+		}
+		alteracoes[campo] = map[string]interface{}{"antes": nil, "depois": evento.Ate}
+	}
+	if evento.Motivo != "" {
+		alteracoes["motivo"] = map[string]interface{}{"antes": nil, "depois": evento.Motivo}
+	}
+	if evento.Tipo == EventoDesbloqueio && evento.IP != "" {
+		// No desbloqueio, IP é o endereço liberado junto com a conta, não a origem da requisição.
+		registro.IP = ""
+		alteracoes["ip_liberado"] = map[string]interface{}{"antes": nil, "depois": evento.IP}
+	}
+	if len(alteracoes) > 0 {
+		registro.Alteracoes, _ = json.Marshal(alteracoes)
+	}
+
+	if err := r.auditoria.Registrar(registro); err != nil {
+		log.Printf("AUDITORIA: falha ao registrar evento %s: %v", evento.Tipo, err)
+	}
+}
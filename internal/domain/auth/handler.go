@@ -1,16 +1,26 @@
 package auth
 
 import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
 	authService AuthService
+	converter   funcoes.FuncoesInterface
 }
 
-func NewAuthHandler(service AuthService) *AuthHandler {
+func NewAuthHandler(service AuthService, f funcoes.FuncoesInterface) *AuthHandler {
 	return &AuthHandler{
 		authService: service,
+		converter:   f,
 	}
 }
 
@@ -26,10 +36,51 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		c.JSON(400, gin.H{"erro": err.Error()})
 		return
 	}
-	authenticate, err := h.authService.Authenticate(request.Email, request.Password)
+	authenticate, err := h.authService.Authenticate(request.Email, request.Password, c.ClientIP())
 	if err != nil {
-		c.JSON(401, gin.H{"erro": err.Error()})
+		var bloqueio *BloqueioError
+		if errors.As(err, &bloqueio) {
+			segundos := math.Ceil(time.Until(bloqueio.Ate).Seconds())
+			c.Header("Retry-After", strconv.Itoa(int(math.Max(segundos, 1))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"erro": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrCredenciaisInvalidas) {
+			c.JSON(401, gin.H{"erro": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Falha ao autenticar."})
 		return
 	}
 	c.JSON(200, gin.H{"token": authenticate})
 }
+
+// Desbloquear remove o bloqueio de login de um usuário da empresa do administrador.
+func (h *AuthHandler) Desbloquear(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	atorID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem desbloquear logins."})
+		return
+	}
+	usuarioID, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O ID do usuário deve ser um número"})
+		return
+	}
+
+	if err := h.authService.Desbloquear(usuarioID, empresaID, atorID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao desbloquear o usuário."})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,104 @@
+package auth
+
+import "time"
+
+// Limitador conta as falhas seguidas de cada chave e bloqueia a chave quando elas chegam ao limite.
+// É o mecanismo do login de usuários, usado também no login de operadores e nos quiosques, para
+// que todos expirem falhas, esperem e bloqueiem do mesmo jeito.
+type Limitador struct {
+	tentativas TentativaLoginStore
+	politica   PoliticaBloqueio
+}
+
+// NewLimitador aplica a política sobre os contadores do store. Com AtrasoBase zero não há espera
+// entre tentativas, só o bloqueio ao atingir o limite.
+func NewLimitador(tentativas TentativaLoginStore, politica PoliticaBloqueio) *Limitador {
+	return &Limitador{tentativas: tentativas, politica: politica}
+}
+
+// Verificar devolve um *BloqueioError se a chave estiver bloqueada ou aguardando a próxima tentativa.
+func (l *Limitador) Verificar(chave string, momento time.Time) error {
+	tentativa, err := l.tentativas.Buscar(chave)
+	if err != nil {
+		return err
+	}
+	if tentativa.BloqueadoAte != nil && tentativa.BloqueadoAte.After(momento) {
+		return &BloqueioError{Ate: *tentativa.BloqueadoAte, Bloqueio: true}
+	}
+	if tentativa.ProximaTentativaEm != nil && tentativa.ProximaTentativaEm.After(momento) {
+		return &BloqueioError{Ate: *tentativa.ProximaTentativaEm}
+	}
+	return nil
+}
+
+// Falhou registra uma falha da chave, vinda de ip (vazio se não se sabe). Ao chegar a maximo falhas
+// seguidas a chave fica bloqueada por DuracaoBloqueio, e Falhou devolve o fim do bloqueio para quem
+// precisa registrar o evento; antes disso impõe a espera progressiva. Com maximo zero a chave nunca
+// é bloqueada.
+func (l *Limitador) Falhou(chave string, ip string, maximo int, momento time.Time) (*time.Time, error) {
+	anterior, err := l.tentativas.Buscar(chave)
+	if err != nil {
+		return nil, err
+	}
+	// Falhas antigas expiram depois de um período sem novas tentativas.
+	if anterior.Falhas > 0 && momento.Sub(anterior.UltimaFalha) > l.politica.DuracaoBloqueio {
+		if err := l.tentativas.Limpar(chave); err != nil {
+			return nil, err
+		}
+	}
+
+	tentativa, err := l.tentativas.RegistrarFalha(chave, ip, momento)
+	if err != nil {
+		return nil, err
+	}
+
+	var bloqueadoAte *time.Time
+	if maximo > 0 && tentativa.Falhas >= maximo {
+		ate := momento.Add(l.politica.DuracaoBloqueio)
+		tentativa.BloqueadoAte = &ate
+		tentativa.ProximaTentativaEm = nil
+		bloqueadoAte = &ate
+	} else if l.politica.AtrasoBase > 0 {
+		proxima := momento.Add(l.atraso(tentativa.Falhas))
+		tentativa.ProximaTentativaEm = &proxima
+	} else {
+		return nil, nil
+	}
+
+	if err := l.tentativas.Atualizar(tentativa); err != nil {
+		return nil, err
+	}
+	return bloqueadoAte, nil
+}
+
+// Limpar zera as falhas da chave, depois de um acerto ou de um desbloqueio manual.
+func (l *Limitador) Limpar(chave string) error {
+	return l.tentativas.Limpar(chave)
+}
+
+// UltimoIP devolve o IP da falha mais recente da chave, ou vazio.
+func (l *Limitador) UltimoIP(chave string) (string, error) {
+	tentativa, err := l.tentativas.Buscar(chave)
+	if err != nil {
+		return "", err
+	}
+	return tentativa.UltimoIP, nil
+}
+
+// Expurgar apaga os contadores que já não contam: sem falha há mais de DuracaoBloqueio, Falhou os
+// recomeçaria do zero, e qualquer bloqueio deles já venceu.
+func (l *Limitador) Expurgar(momento time.Time) (int64, error) {
+	return l.tentativas.Expurgar(momento.Add(-l.politica.DuracaoBloqueio), momento)
+}
+
+// atraso dobra a cada falha consecutiva: base, 2*base, 4*base... até atrasoMaximo.
+func (l *Limitador) atraso(falhas int) time.Duration {
+	espera := l.politica.AtrasoBase
+	for i := 1; i < falhas && espera < atrasoMaximo; i++ {
+		espera *= 2
+	}
+	if espera > atrasoMaximo {
+		espera = atrasoMaximo
+	}
+	return espera
+}
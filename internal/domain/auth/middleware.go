@@ -5,12 +5,63 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Loviiin/ponto-api-go/internal/model"
 	"github.com/Loviiin/ponto-api-go/pkg/jwt" // Importa o nosso serviço de JWT
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(jwtService *jwt.JWTService) gin.HandlerFunc {
+// Chaves do contexto preenchidas quando a requisição vem de uma chave de API em vez de um usuário.
+const (
+	ContextoChaveAPIID = "chaveAPIID"
+	ContextoEscopos    = "escopos"
+	// ContextoImpersonadoPor guarda o ID do operador quando o token é de impersonação.
+	ContextoImpersonadoPor = "impersonadoPor"
+)
+
+// ValidadorChaveAPI confere uma chave de API recebida no cabeçalho e devolve o seu registro.
+type ValidadorChaveAPI interface {
+	Validar(chave string) (*model.ChaveAPI, error)
+}
+
+// RotasChaveAPI lista as rotas que aceitam chaves de API e o escopo que a chave precisa ter em
+// cada uma. Numa rota fora da lista a chave é recusada, mesmo que a rota não exija permissão:
+// essas rotas foram escritas para usuários.
+type RotasChaveAPI struct {
+	escopos map[string]string
+}
+
+func NewRotasChaveAPI() *RotasChaveAPI {
+	return &RotasChaveAPI{escopos: map[string]string{}}
+}
+
+// Permitir libera a rota (o caminho completo, como registrado no gin) para chaves com o escopo
+// indicado. Uma permissão fora do catálogo derruba a inicialização, como em PermissionMiddleware.
+func (r *RotasChaveAPI) Permitir(metodo, caminho, escopo string) {
+	if !permissions.Existe(escopo) {
+		panic(fmt.Sprintf("permissão %q usada numa rota não está em permissions.Catalogo", escopo))
+	}
+	r.escopos[metodo+" "+caminho] = escopo
+}
+
+func (r *RotasChaveAPI) escopo(c *gin.Context) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	escopo, existe := r.escopos[c.Request.Method+" "+c.FullPath()]
+	return escopo, existe
+}
+
+// AuthMiddleware aceita um token JWT ("Authorization: Bearer ...") ou uma chave de API
+// ("Authorization: ApiKey ..." ou "X-API-Key: ..."), esta só nas rotas de rotasChave. Tokens de
+// impersonação só valem enquanto o operador que os emitiu continuar ativo.
+func AuthMiddleware(jwtService *jwt.JWTService, chaves ValidadorChaveAPI, rotasChave *RotasChaveAPI, operadores VerificadorOperador) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if chave := c.GetHeader("X-API-Key"); chave != "" {
+			autenticarChaveAPI(c, chaves, rotasChave, chave)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token de autorização não fornecido"})
@@ -18,6 +69,10 @@ func AuthMiddleware(jwtService *jwt.JWTService) gin.HandlerFunc {
 		}
 
 		splitToken := strings.Split(authHeader, " ")
+		if len(splitToken) == 2 && splitToken[0] == "ApiKey" {
+			autenticarChaveAPI(c, chaves, rotasChave, splitToken[1])
+			return
+		}
 		if len(splitToken) != 2 || splitToken[0] != "Bearer" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Formato do token de autorização inválido"})
 			return
@@ -36,13 +91,88 @@ func AuthMiddleware(jwtService *jwt.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		// Tokens de operador só valem nas rotas da plataforma; para agir dentro de uma empresa
+		// o operador precisa impersonar um usuário, o que fica registrado.
+		if claims.Operador {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Tokens de operador da plataforma não acessam rotas de empresa"})
+			return
+		}
+
 		userID := claims.Subject
 
 		empresaID := fmt.Sprintf("%d", claims.EmpresaID)
 
+		if claims.ImpersonadoPor != 0 {
+			ativo, err := operadores.OperadorAtivo(claims.ImpersonadoPor)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar o operador."})
+				return
+			}
+			if !ativo {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Operador desativado ou inexistente"})
+				return
+			}
+			c.Set(ContextoImpersonadoPor, fmt.Sprintf("%d", claims.ImpersonadoPor))
+		}
+
 		c.Set("userID", userID)
 		c.Set("empresaID", empresaID)
 
 		c.Next()
 	}
 }
+
+func autenticarChaveAPI(c *gin.Context, chaves ValidadorChaveAPI, rotasChave *RotasChaveAPI, textoChave string) {
+	chave, err := chaves.Validar(textoChave)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Chave de API inválida, expirada ou revogada"})
+		return
+	}
+	escopoRota, liberada := rotasChave.escopo(c)
+	if !liberada {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Acesso negado. Esta rota não aceita chaves de API."})
+		return
+	}
+
+	escopos := make([]string, 0, len(chave.Escopos))
+	for _, p := range chave.Escopos {
+		escopos = append(escopos, p.Nome)
+	}
+
+	// Não há "userID": a chave age em nome da empresa, limitada aos seus escopos.
+	c.Set("empresaID", fmt.Sprintf("%d", chave.EmpresaID))
+	c.Set(ContextoChaveAPIID, fmt.Sprintf("%d", chave.ID))
+	c.Set(ContextoEscopos, escopos)
+
+	if !ChaveAPITemEscopo(c, escopoRota) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Acesso negado. A chave de API não tem o escopo necessário para esta ação."})
+		return
+	}
+
+	c.Next()
+}
+
+// EscoposChaveAPI devolve os escopos da chave de API que autenticou a requisição.
+// O segundo retorno é falso quando a requisição foi feita por um usuário.
+func EscoposChaveAPI(c *gin.Context) ([]string, bool) {
+	valor, existe := c.Get(ContextoEscopos)
+	if !existe {
+		return nil, false
+	}
+	escopos, ok := valor.([]string)
+	return escopos, ok
+}
+
+// ChaveAPITemEscopo informa se a requisição veio de uma chave de API com o escopo indicado.
+func ChaveAPITemEscopo(c *gin.Context, escopo string) bool {
+	escopos, ok := EscoposChaveAPI(c)
+	if !ok {
+		return false
+	}
+	for _, e := range escopos {
+		if e == escopo {
+			return true
+		}
+	}
+	return false
+}
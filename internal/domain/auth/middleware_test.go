@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+	"github.com/gin-gonic/gin"
+)
+
+type mockValidadorChaveAPI struct{}
+
+func (m *mockValidadorChaveAPI) Validar(chave string) (*model.ChaveAPI, error) {
+	if chave != "pk_valida" {
+		return nil, errors.New("chave inválida")
+	}
+	return &model.ChaveAPI{ID: 3, EmpresaID: 5, Escopos: []model.Permissao{{Nome: permissions.VER_SALDO_FUNCIONARIOS}}}, nil
+}
+
+func TestAuthMiddleware_ChaveAPISoNasRotasLiberadas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rotas := NewRotasChaveAPI()
+	rotas.Permitir(http.MethodGet, "/saldos/:id", permissions.VER_SALDO_FUNCIONARIOS)
+	rotas.Permitir(http.MethodGet, "/auditoria", permissions.VER_AUDITORIA)
+
+	router := gin.New()
+	router.Use(AuthMiddleware(nil, &mockValidadorChaveAPI{}, rotas, nil))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/saldos/:id", ok)
+	router.GET("/auditoria", ok)
+	router.GET("/usuarios", ok)
+	router.POST("/saldos/:id", ok)
+
+	casos := []struct {
+		metodo, caminho, chave string
+		esperado               int
+	}{
+		{http.MethodGet, "/saldos/7", "pk_valida", http.StatusOK},
+		// A rota está liberada, mas a chave não tem o escopo.
+		{http.MethodGet, "/auditoria", "pk_valida", http.StatusForbidden},
+		// Rotas fora da lista recusam a chave, mesmo sem exigir permissão.
+		{http.MethodGet, "/usuarios", "pk_valida", http.StatusForbidden},
+		{http.MethodPost, "/saldos/7", "pk_valida", http.StatusForbidden},
+		{http.MethodGet, "/saldos/7", "pk_errada", http.StatusUnauthorized},
+	}
+	for _, caso := range casos {
+		req := httptest.NewRequest(caso.metodo, caso.caminho, nil)
+		req.Header.Set("X-API-Key", caso.chave)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != caso.esperado {
+			t.Errorf("%s %s com %s: esperava %d, recebeu %d", caso.metodo, caso.caminho, caso.chave, caso.esperado, resp.Code)
+		}
+	}
+}
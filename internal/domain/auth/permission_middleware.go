@@ -2,16 +2,33 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"github.com/Loviiin/ponto-api-go/internal/domain/autorizacao"
 	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
 	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
 )
 
 // PermissionMiddleware verifica se o cargo de um utilizador tem uma permissão específica.
+// Uma permissão fora do catálogo é erro de programação e derruba a inicialização do servidor.
 func PermissionMiddleware(usuarioService usuario.UsuarioService, funcoesService funcoes.FuncoesInterface, requiredPermission string) gin.HandlerFunc {
+	if !permissions.Existe(requiredPermission) {
+		panic(fmt.Sprintf("permissão %q usada numa rota não está em permissions.Catalogo", requiredPermission))
+	}
 	return func(c *gin.Context) {
+		// Chaves de API não têm cargo: a permissão vem dos escopos concedidos à chave.
+		if _, ehChaveAPI := EscoposChaveAPI(c); ehChaveAPI {
+			if !ChaveAPITemEscopo(c, requiredPermission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Acesso negado. A chave de API não tem o escopo necessário para esta ação."})
+				return
+			}
+			c.Next()
+			return
+		}
+
 		// 1. Obter os IDs do token (quem está a fazer o pedido?)
 		empresaID, err := funcoesService.GetUintIDFromContext(c, "empresaID")
 		if err != nil {
@@ -25,8 +42,8 @@ func PermissionMiddleware(usuarioService usuario.UsuarioService, funcoesService
 		}
 
 		// 2. Obter o utilizador, o seu cargo e as suas permissões, tudo de uma vez.
-		// Graças ao nosso pré-carregamento aninhado, esta única chamada traz tudo.
-		user, err := usuarioService.FindByID(userID, empresaID)
+		// O resultado vem do cache de permissões efetivas sempre que possível.
+		user, err := usuarioService.Resolver(userID, empresaID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Acesso negado: utilizador não encontrado."})
@@ -36,14 +53,8 @@ func PermissionMiddleware(usuarioService usuario.UsuarioService, funcoesService
 			return
 		}
 
-		// 3. Verificar se a permissão necessária está na lista de permissões do cargo.
-		hasPermission := false
-		for _, p := range user.Cargo.Permissoes {
-			if p.Nome == requiredPermission {
-				hasPermission = true
-				break // Encontrámos a permissão, não precisamos de procurar mais.
-			}
-		}
+		// 3. Verificar se a permissão necessária está no cargo ou nos cargos de que ele herda.
+		_, hasPermission := user.Cargo.EscopoPermissao(requiredPermission)
 
 		// 4. Tomar a decisão final.
 		if !hasPermission {
@@ -51,7 +62,9 @@ func PermissionMiddleware(usuarioService usuario.UsuarioService, funcoesService
 			return
 		}
 
-		// Se o utilizador tem a permissão, deixamo-lo continuar para o handler final.
+		// Se o utilizador tem a permissão, deixamo-lo continuar para o handler final,
+		// que pode reaproveitar o utilizador já resolvido (usuario.Requisitante).
+		autorizacao.DefinirPrincipal(c, user)
 		c.Next()
 	}
 }
@@ -2,40 +2,124 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
 	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
 	"github.com/Loviiin/ponto-api-go/pkg/jwt"
 	"github.com/Loviiin/ponto-api-go/pkg/password"
 	"gorm.io/gorm"
 )
 
+var ErrCredenciaisInvalidas = errors.New("credenciais inválidas")
+
+// BloqueioError indica que a conta ou o IP não pode tentar login antes de Ate.
+type BloqueioError struct {
+	Ate      time.Time
+	Bloqueio bool
+}
+
+func (e *BloqueioError) Error() string {
+	if e.Bloqueio {
+		return fmt.Sprintf("muitas tentativas de login falharam; acesso bloqueado até %s", e.Ate.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("aguarde até %s para tentar novamente", e.Ate.Format(time.RFC3339))
+}
+
+// PoliticaBloqueio define os limites da proteção contra força bruta.
+type PoliticaBloqueio struct {
+	MaxTentativasConta int
+	MaxTentativasIP    int
+	DuracaoBloqueio    time.Duration
+	AtrasoBase         time.Duration
+}
+
+// atrasoMaximo limita a espera progressiva entre tentativas antes do bloqueio.
+const atrasoMaximo = 30 * time.Second
+
+// gruposEmailDesconhecido é em quantas chaves se dividem as falhas de e-mails sem conta. Um
+// contador por e-mail inventado deixaria qualquer um encher a tabela; nenhum contador deixaria
+// descobrir quais contas existem, já que só elas seriam bloqueadas.
+const gruposEmailDesconhecido = 1024
+
 type AuthService interface {
-	Authenticate(email string, password string) (string, error)
+	Authenticate(email string, password string, ip string) (string, error)
+	// Desbloquear libera a conta e o IP da falha que a bloqueou.
+	Desbloquear(usuarioID uint, empresaID uint, atorID uint) error
+	// ExpurgarTentativas apaga os contadores de falhas vencidos e devolve quantos.
+	ExpurgarTentativas(momento time.Time) (int64, error)
 }
 
+var agora = time.Now
+
 type authService struct {
 	usuarioRepo usuario.UsuarioRepository
 	jwtService  *jwt.JWTService
+	limitador   *Limitador
+	eventos     RegistradorEventos
+	politica    PoliticaBloqueio
 }
 
-func NewAuthService(usuarioRepo usuario.UsuarioRepository, jwtService *jwt.JWTService) AuthService {
+func NewAuthService(
+	usuarioRepo usuario.UsuarioRepository,
+	jwtService *jwt.JWTService,
+	tentativas TentativaLoginStore,
+	eventos RegistradorEventos,
+	politica PoliticaBloqueio,
+) AuthService {
 	return &authService{
 		usuarioRepo: usuarioRepo,
 		jwtService:  jwtService,
+		limitador:   NewLimitador(tentativas, politica),
+		eventos:     eventos,
+		politica:    politica,
 	}
 }
 
-func (s *authService) Authenticate(email string, passwordStr string) (string, error) {
+func chaveConta(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// chaveEmailDesconhecido põe o e-mail sem conta num dos gruposEmailDesconhecido contadores, que
+// bloqueiam e esperam como o de uma conta.
+func chaveEmailDesconhecido(email string) string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return fmt.Sprintf("email-desconhecido:%d", h.Sum32()%gruposEmailDesconhecido)
+}
+
+func chaveIP(ip string) string {
+	return "ip:" + ip
+}
+
+func (s *authService) Authenticate(email string, passwordStr string, ip string) (string, error) {
+	momento := agora()
+	if err := s.limitador.Verificar(chaveIP(ip), momento); err != nil {
+		return "", err
+	}
 
 	usuari, err := s.usuarioRepo.FindByEmail(email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	conta := chaveConta(email)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", errors.New("credenciais inválidas")
-		}
+		usuari = nil
+		conta = chaveEmailDesconhecido(email)
+	}
+	if err := s.limitador.Verificar(conta, momento); err != nil {
 		return "", err
 	}
 
-	if !password.VerificaHashSenha(passwordStr, usuari.Senha) {
-		return "", errors.New("credenciais inválidas")
+	if usuari == nil || !password.VerificaHashSenha(passwordStr, usuari.Senha) {
+		return "", s.registrarFalha(conta, ip, usuari, momento)
+	}
+
+	if err := s.limitador.Limpar(conta); err != nil {
+		return "", err
 	}
 
 	token, err := s.jwtService.GenerateToken(usuari.ID, usuari.EmpresaID)
@@ -44,3 +128,70 @@ func (s *authService) Authenticate(email string, passwordStr string) (string, er
 	}
 	return token, nil
 }
+
+// registrarFalha contabiliza a falha na conta e no IP e devolve o erro a ser mostrado ao cliente.
+// A mensagem é a mesma para e-mail inexistente e senha errada, para não revelar quais contas existem.
+// O bloqueio de um grupo de e-mails sem conta não gera evento: não há conta a auditar.
+func (s *authService) registrarFalha(conta string, ip string, usuari *model.Usuario, momento time.Time) error {
+	limites := []struct {
+		chave  string
+		maximo int
+		evento string
+	}{
+		{conta, s.politica.MaxTentativasConta, EventoBloqueioConta},
+		{chaveIP(ip), s.politica.MaxTentativasIP, EventoBloqueioIP},
+	}
+
+	for _, limite := range limites {
+		ate, err := s.limitador.Falhou(limite.chave, ip, limite.maximo, momento)
+		if err != nil {
+			return err
+		}
+		if ate != nil && (usuari != nil || limite.evento == EventoBloqueioIP) {
+			evento := EventoSeguranca{Tipo: limite.evento, Chave: limite.chave, IP: ip, Ate: ate, Momento: momento}
+			if usuari != nil {
+				evento.UsuarioID = usuari.ID
+				evento.EmpresaID = usuari.EmpresaID
+			}
+			s.eventos.Registrar(evento)
+		}
+	}
+	return ErrCredenciaisInvalidas
+}
+
+// Desbloquear libera, além da conta, o IP da última falha dela: quem errou a senha até bloquear a
+// conta costuma ter levado junto o IP, às vezes o de um escritório inteiro. Os outros IPs que
+// falharam contra a conta expiram sozinhos.
+func (s *authService) Desbloquear(usuarioID uint, empresaID uint, atorID uint) error {
+	usuari, err := s.usuarioRepo.FindByID(usuarioID, empresaID)
+	if err != nil {
+		return err
+	}
+	conta := chaveConta(usuari.Email)
+	ip, err := s.limitador.UltimoIP(conta)
+	if err != nil {
+		return err
+	}
+	if err := s.limitador.Limpar(conta); err != nil {
+		return err
+	}
+	if ip != "" {
+		if err := s.limitador.Limpar(chaveIP(ip)); err != nil {
+			return err
+		}
+	}
+	s.eventos.Registrar(EventoSeguranca{
+		Tipo:      EventoDesbloqueio,
+		Chave:     conta,
+		EmpresaID: empresaID,
+		UsuarioID: usuarioID,
+		AtorID:    atorID,
+		IP:        ip,
+		Momento:   agora(),
+	})
+	return nil
+}
+
+func (s *authService) ExpurgarTentativas(momento time.Time) (int64, error) {
+	return s.limitador.Expurgar(momento)
+}
@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type mockUsuarioRepository struct {
+	usuario.UsuarioRepository
+	usuarios map[string]*model.Usuario
+}
+
+func (m *mockUsuarioRepository) FindByEmail(email string) (*model.Usuario, error) {
+	if u, ok := m.usuarios[email]; ok {
+		return u, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *mockUsuarioRepository) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
+	for _, u := range m.usuarios {
+		if u.ID == id && u.EmpresaID == empresaID {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type mockRegistradorEventos struct {
+	eventos []EventoSeguranca
+}
+
+func (m *mockRegistradorEventos) Registrar(evento EventoSeguranca) {
+	m.eventos = append(m.eventos, evento)
+}
+
+func novoServicoDeTeste(t *testing.T, momento *time.Time) (AuthService, *mockRegistradorEventos) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("senha-certa"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("falha ao gerar hash: %v", err)
+	}
+	repo := &mockUsuarioRepository{usuarios: map[string]*model.Usuario{
+		"ana@email.com": {ID: 1, EmpresaID: 10, Email: "ana@email.com", Senha: string(hash)},
+	}}
+
+	originalAgora := agora
+	t.Cleanup(func() { agora = originalAgora })
+	agora = func() time.Time { return *momento }
+
+	eventos := &mockRegistradorEventos{}
+	politica := PoliticaBloqueio{
+		MaxTentativasConta: 3,
+		MaxTentativasIP:    5,
+		DuracaoBloqueio:    15 * time.Minute,
+		AtrasoBase:         time.Second,
+	}
+	service := NewAuthService(repo, jwt.NewJWTService("segredo", "teste"), NewMemoriaTentativaLoginStore(), eventos, politica)
+	return service, eventos
+}
+
+func TestAuthenticate_AtrasoProgressivo(t *testing.T) {
+	momento := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	service, _ := novoServicoDeTeste(t, &momento)
+
+	_, err := service.Authenticate("ana@email.com", "errada", "10.0.0.1")
+	if !errors.Is(err, ErrCredenciaisInvalidas) {
+		t.Fatalf("Esperava credenciais inválidas, recebeu: %v", err)
+	}
+
+	// Uma nova tentativa imediata deve ser recusada, mesmo com a senha correta.
+	_, err = service.Authenticate("ana@email.com", "senha-certa", "10.0.0.1")
+	var bloqueio *BloqueioError
+	if !errors.As(err, &bloqueio) || bloqueio.Bloqueio {
+		t.Fatalf("Esperava um pedido de espera, recebeu: %v", err)
+	}
+
+	momento = momento.Add(2 * time.Second)
+	if _, err := service.Authenticate("ana@email.com", "senha-certa", "10.0.0.1"); err != nil {
+		t.Fatalf("Esperava login com sucesso após a espera, recebeu: %v", err)
+	}
+}
+
+func TestAuthenticate_BloqueiaContaAposLimite(t *testing.T) {
+	momento := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	service, eventos := novoServicoDeTeste(t, &momento)
+
+	for i := 0; i < 3; i++ {
+		momento = momento.Add(time.Minute)
+		_, _ = service.Authenticate("ana@email.com", "errada", "10.0.0.1")
+	}
+
+	momento = momento.Add(time.Minute)
+	_, err := service.Authenticate("ana@email.com", "senha-certa", "10.0.0.2")
+	var bloqueio *BloqueioError
+	if !errors.As(err, &bloqueio) || !bloqueio.Bloqueio {
+		t.Fatalf("Esperava conta bloqueada, recebeu: %v", err)
+	}
+
+	if len(eventos.eventos) != 1 || eventos.eventos[0].Tipo != EventoBloqueioConta || eventos.eventos[0].UsuarioID != 1 {
+		t.Fatalf("Esperava um evento de bloqueio da conta, recebeu: %+v", eventos.eventos)
+	}
+
+	momento = momento.Add(16 * time.Minute)
+	if _, err := service.Authenticate("ana@email.com", "senha-certa", "10.0.0.2"); err != nil {
+		t.Fatalf("Esperava login liberado após o bloqueio expirar, recebeu: %v", err)
+	}
+}
+
+func TestAuthenticate_BloqueiaIP(t *testing.T) {
+	momento := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	service, eventos := novoServicoDeTeste(t, &momento)
+
+	emails := []string{"a@x.com", "b@x.com", "c@x.com", "d@x.com", "e@x.com"}
+	for _, email := range emails {
+		momento = momento.Add(time.Minute)
+		_, _ = service.Authenticate(email, "qualquer", "10.0.0.9")
+	}
+
+	momento = momento.Add(time.Minute)
+	_, err := service.Authenticate("ana@email.com", "senha-certa", "10.0.0.9")
+	var bloqueio *BloqueioError
+	if !errors.As(err, &bloqueio) || !bloqueio.Bloqueio {
+		t.Fatalf("Esperava IP bloqueado, recebeu: %v", err)
+	}
+	if eventos.eventos[len(eventos.eventos)-1].Tipo != EventoBloqueioIP {
+		t.Errorf("Esperava evento de bloqueio de IP, recebeu: %+v", eventos.eventos)
+	}
+}
+
+func TestDesbloquear_LiberaConta(t *testing.T) {
+	momento := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	service, eventos := novoServicoDeTeste(t, &momento)
+
+	for i := 0; i < 3; i++ {
+		momento = momento.Add(time.Minute)
+		_, _ = service.Authenticate("ana@email.com", "errada", "10.0.0.1")
+	}
+
+	if err := service.Desbloquear(1, 10, 99); err != nil {
+		t.Fatalf("Erro inesperado ao desbloquear: %v", err)
+	}
+	if _, err := service.Authenticate("ana@email.com", "senha-certa", "10.0.0.3"); err != nil {
+		t.Fatalf("Esperava login com sucesso após desbloqueio, recebeu: %v", err)
+	}
+	ultimo := eventos.eventos[len(eventos.eventos)-1]
+	if ultimo.Tipo != EventoDesbloqueio || ultimo.AtorID != 99 {
+		t.Errorf("Esperava evento de desbloqueio pelo ator 99, recebeu: %+v", ultimo)
+	}
+
+	if err := service.Desbloquear(1, 11, 99); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Esperava não encontrar usuário de outra empresa, recebeu: %v", err)
+	}
+}
+
+func TestDesbloquear_LiberaOIPDaUltimaFalha(t *testing.T) {
+	momento := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	service, eventos := novoServicoDeTeste(t, &momento)
+
+	// Três falhas bloqueiam a conta e mais duas, com outros e-mails, o IP.
+	for _, email := range []string{"ana@email.com", "ana@email.com", "ana@email.com", "x@x.com", "y@x.com"} {
+		momento = momento.Add(time.Minute)
+		_, _ = service.Authenticate(email, "errada", "10.0.0.1")
+	}
+	var bloqueio *BloqueioError
+	if _, err := service.Authenticate("ana@email.com", "senha-certa", "10.0.0.1"); !errors.As(err, &bloqueio) {
+		t.Fatalf("Esperava conta e IP bloqueados, recebeu: %v", err)
+	}
+
+	if err := service.Desbloquear(1, 10, 99); err != nil {
+		t.Fatalf("Erro inesperado ao desbloquear: %v", err)
+	}
+	if _, err := service.Authenticate("ana@email.com", "senha-certa", "10.0.0.1"); err != nil {
+		t.Fatalf("Esperava login do mesmo IP após o desbloqueio, recebeu: %v", err)
+	}
+	ultimo := eventos.eventos[len(eventos.eventos)-1]
+	if ultimo.Tipo != EventoDesbloqueio || ultimo.IP != "10.0.0.1" {
+		t.Errorf("Esperava o desbloqueio registrando o IP liberado, recebeu: %+v", ultimo)
+	}
+}
+
+func TestAuthenticate_EmailsSemContaDividemContadores(t *testing.T) {
+	momento := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	originalAgora := agora
+	t.Cleanup(func() { agora = originalAgora })
+	agora = func() time.Time { return momento }
+
+	tentativas := NewMemoriaTentativaLoginStore().(*memoriaTentativaLoginStore)
+	eventos := &mockRegistradorEventos{}
+	politica := PoliticaBloqueio{MaxTentativasConta: 3, MaxTentativasIP: 5, DuracaoBloqueio: 15 * time.Minute}
+	service := NewAuthService(&mockUsuarioRepository{}, jwt.NewJWTService("segredo", "teste"), tentativas, eventos, politica)
+
+	for i := 0; i < 3*gruposEmailDesconhecido; i++ {
+		_, _ = service.Authenticate(fmt.Sprintf("inventado%d@x.com", i), "qualquer", fmt.Sprintf("10.%d.%d.1", i/256, i%256))
+	}
+	if contas := len(tentativas.registros) - 3*gruposEmailDesconhecido; contas > gruposEmailDesconhecido {
+		t.Errorf("E-mails sem conta deveriam ocupar no máximo %d contadores, ocuparam %d", gruposEmailDesconhecido, contas)
+	}
+	if len(eventos.eventos) != 0 {
+		t.Errorf("O bloqueio de e-mails sem conta não deveria gerar eventos, recebeu %d", len(eventos.eventos))
+	}
+
+	// Como uma conta, um e-mail sem conta fica bloqueado depois de três falhas seguidas.
+	for i := 0; i < 3; i++ {
+		_, _ = service.Authenticate("fantasma@x.com", "qualquer", fmt.Sprintf("192.168.0.%d", i))
+	}
+	var bloqueio *BloqueioError
+	if _, err := service.Authenticate("fantasma@x.com", "qualquer", "192.168.1.1"); !errors.As(err, &bloqueio) || !bloqueio.Bloqueio {
+		t.Errorf("Esperava o e-mail sem conta bloqueado, recebeu: %v", err)
+	}
+}
+
+func TestExpurgarTentativas_ApagaContadoresVencidos(t *testing.T) {
+	momento := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	service, _ := novoServicoDeTeste(t, &momento)
+
+	_, _ = service.Authenticate("ana@email.com", "errada", "10.0.0.1")
+	if apagados, err := service.ExpurgarTentativas(momento.Add(time.Minute)); err != nil || apagados != 0 {
+		t.Fatalf("Contadores recentes não deveriam ser apagados: apagados=%d err=%v", apagados, err)
+	}
+	if apagados, err := service.ExpurgarTentativas(momento.Add(16 * time.Minute)); err != nil || apagados != 2 {
+		t.Fatalf("Esperava apagar os contadores da conta e do IP: apagados=%d err=%v", apagados, err)
+	}
+}
+
+func TestLimitador_SemAtrasoSoBloqueiaNoLimite(t *testing.T) {
+	momento := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	limitador := NewLimitador(NewMemoriaTentativaLoginStore(), PoliticaBloqueio{DuracaoBloqueio: 15 * time.Minute})
+
+	for i := 1; i <= 2; i++ {
+		ate, err := limitador.Falhou("quiosque:1", "", 3, momento)
+		if err != nil || ate != nil {
+			t.Fatalf("Falha %d não deveria bloquear: ate=%v err=%v", i, ate, err)
+		}
+		if err := limitador.Verificar("quiosque:1", momento); err != nil {
+			t.Fatalf("Sem AtrasoBase não deveria haver espera entre tentativas, recebeu: %v", err)
+		}
+	}
+
+	ate, err := limitador.Falhou("quiosque:1", "", 3, momento)
+	if err != nil || ate == nil || !ate.Equal(momento.Add(15*time.Minute)) {
+		t.Fatalf("A terceira falha deveria bloquear por 15 minutos: ate=%v err=%v", ate, err)
+	}
+	var bloqueio *BloqueioError
+	if err := limitador.Verificar("quiosque:1", momento); !errors.As(err, &bloqueio) || !bloqueio.Bloqueio {
+		t.Fatalf("Esperava a chave bloqueada, recebeu: %v", err)
+	}
+
+	// Falhas antigas expiram: depois do bloqueio a contagem recomeça.
+	momento = momento.Add(16 * time.Minute)
+	if ate, _ := limitador.Falhou("quiosque:1", "", 3, momento); ate != nil {
+		t.Error("Uma falha depois do bloqueio expirado deveria recomeçar a contagem")
+	}
+}
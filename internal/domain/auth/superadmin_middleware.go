@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"github.com/gin-gonic/gin"
+)
+
+// VerificadorOperador confirma que o operador do token ainda existe e está ativo.
+type VerificadorOperador interface {
+	OperadorAtivo(id uint) (bool, error)
+}
+
+// SuperAdminMiddleware protege as rotas da plataforma: só aceita tokens de operador
+// e coloca o "operadorID" no contexto.
+func SuperAdminMiddleware(jwtService *jwt.JWTService, operadores VerificadorOperador, funcoesService funcoes.FuncoesInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		splitToken := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(splitToken) != 2 || splitToken[0] != "Bearer" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token de operador não fornecido"})
+			return
+		}
+
+		token, err := jwtService.ValidateToken(splitToken[1])
+		if err != nil || !token.Valid {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token de autorização inválido ou expirado"})
+			return
+		}
+		claims, ok := token.Claims.(*jwt.PontoClaims)
+		if !ok || !claims.Operador {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Acesso restrito a operadores da plataforma"})
+			return
+		}
+
+		operadorID, err := funcoesService.StrParaUint(claims.Subject)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token de operador inválido"})
+			return
+		}
+		ativo, err := operadores.OperadorAtivo(operadorID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar o operador."})
+			return
+		}
+		if !ativo {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Operador desativado ou inexistente"})
+			return
+		}
+
+		c.Set("operadorID", claims.Subject)
+		c.Next()
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"github.com/gin-gonic/gin"
+)
+
+type mockVerificadorOperador struct {
+	ativos map[uint]bool
+}
+
+func (m *mockVerificadorOperador) OperadorAtivo(id uint) (bool, error) {
+	return m.ativos[id], nil
+}
+
+func executar(t *testing.T, middleware gin.HandlerFunc, token string) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/rota", middleware, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/rota", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp.Code
+}
+
+func TestSuperAdminMiddleware_SeparaOperadoresDeUsuarios(t *testing.T) {
+	jwtService := jwt.NewJWTService("segredo", "teste")
+	operadores := &mockVerificadorOperador{ativos: map[uint]bool{1: true}}
+	superAdmin := SuperAdminMiddleware(jwtService, operadores, funcoes.NewFuncoes())
+	tenant := AuthMiddleware(jwtService, nil, nil, operadores)
+
+	tokenOperador, _ := jwtService.GenerateOperadorToken(1)
+	tokenUsuario, _ := jwtService.GenerateToken(1, 5)
+	tokenOperadorInativo, _ := jwtService.GenerateOperadorToken(2)
+
+	if code := executar(t, superAdmin, tokenOperador); code != http.StatusOK {
+		t.Errorf("Operador ativo deveria acessar a plataforma, recebeu %d", code)
+	}
+	if code := executar(t, superAdmin, tokenUsuario); code != http.StatusForbidden {
+		t.Errorf("Usuário de empresa não deveria acessar a plataforma, recebeu %d", code)
+	}
+	if code := executar(t, superAdmin, tokenOperadorInativo); code != http.StatusForbidden {
+		t.Errorf("Operador inativo não deveria acessar a plataforma, recebeu %d", code)
+	}
+	if code := executar(t, tenant, tokenOperador); code != http.StatusForbidden {
+		t.Errorf("Token de operador não deveria acessar rotas de empresa, recebeu %d", code)
+	}
+	if code := executar(t, tenant, tokenUsuario); code != http.StatusOK {
+		t.Errorf("Usuário deveria acessar rotas de empresa, recebeu %d", code)
+	}
+}
+
+func TestAuthMiddleware_ImpersonacaoExigeOperadorAtivo(t *testing.T) {
+	jwtService := jwt.NewJWTService("segredo", "teste")
+	tenant := AuthMiddleware(jwtService, nil, nil, &mockVerificadorOperador{ativos: map[uint]bool{1: true}})
+
+	tokenAtivo, _ := jwtService.GenerateImpersonationToken(9, 5, 1, time.Hour)
+	tokenDesativado, _ := jwtService.GenerateImpersonationToken(9, 5, 2, time.Hour)
+
+	if code := executar(t, tenant, tokenAtivo); code != http.StatusOK {
+		t.Errorf("A impersonação de um operador ativo deveria valer, recebeu %d", code)
+	}
+	if code := executar(t, tenant, tokenDesativado); code != http.StatusForbidden {
+		t.Errorf("O token de um operador desativado deveria deixar de valer, recebeu %d", code)
+	}
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TentativaLoginStore guarda os contadores de falhas de login.
+// Existe uma implementação em Postgres para produção e uma em memória para testes.
+type TentativaLoginStore interface {
+	// Buscar retorna o registro da chave, ou um registro zerado se ela nunca falhou.
+	Buscar(chave string) (*model.TentativaLogin, error)
+	// RegistrarFalha incrementa atomicamente o contador e retorna o estado atualizado. ip pode ser vazio.
+	RegistrarFalha(chave string, ip string, agora time.Time) (*model.TentativaLogin, error)
+	// Atualizar grava os prazos de espera e de bloqueio calculados pelo serviço.
+	Atualizar(tentativa *model.TentativaLogin) error
+	Limpar(chave string) error
+	// Expurgar apaga os registros sem falha desde limite e sem bloqueio em vigor em agora, e
+	// devolve quantos foram apagados.
+	Expurgar(limite time.Time, agora time.Time) (int64, error)
+}
+
+type tentativaLoginRepository struct {
+	Db *gorm.DB
+}
+
+func NewTentativaLoginRepository(db *gorm.DB) TentativaLoginStore {
+	return &tentativaLoginRepository{Db: db}
+}
+
+func (r *tentativaLoginRepository) Buscar(chave string) (*model.TentativaLogin, error) {
+	var tentativa model.TentativaLogin
+	err := r.Db.Where("chave = ?", chave).First(&tentativa).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.TentativaLogin{Chave: chave}, nil
+	}
+	return &tentativa, err
+}
+
+func (r *tentativaLoginRepository) RegistrarFalha(chave string, ip string, agora time.Time) (*model.TentativaLogin, error) {
+	tentativa := model.TentativaLogin{Chave: chave, Falhas: 1, UltimaFalha: agora, UltimoIP: ip}
+	err := r.Db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chave"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"falhas":       gorm.Expr("tentativa_logins.falhas + 1"),
+			"ultima_falha": agora,
+			"ultimo_ip":    ip,
+		}),
+	}).Create(&tentativa).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.Buscar(chave)
+}
+
+func (r *tentativaLoginRepository) Atualizar(tentativa *model.TentativaLogin) error {
+	return r.Db.Model(&model.TentativaLogin{}).Where("chave = ?", tentativa.Chave).Updates(map[string]interface{}{
+		"proxima_tentativa_em": tentativa.ProximaTentativaEm,
+		"bloqueado_ate":        tentativa.BloqueadoAte,
+	}).Error
+}
+
+func (r *tentativaLoginRepository) Limpar(chave string) error {
+	return r.Db.Where("chave = ?", chave).Delete(&model.TentativaLogin{}).Error
+}
+
+func (r *tentativaLoginRepository) Expurgar(limite time.Time, agora time.Time) (int64, error) {
+	resultado := r.Db.Where("ultima_falha < ? AND (bloqueado_ate IS NULL OR bloqueado_ate <= ?)", limite, agora).
+		Delete(&model.TentativaLogin{})
+	return resultado.RowsAffected, resultado.Error
+}
+
+type memoriaTentativaLoginStore struct {
+	mu        sync.Mutex
+	registros map[string]model.TentativaLogin
+}
+
+// NewMemoriaTentativaLoginStore cria um store em memória, usado nos testes.
+func NewMemoriaTentativaLoginStore() TentativaLoginStore {
+	return &memoriaTentativaLoginStore{registros: make(map[string]model.TentativaLogin)}
+}
+
+func (m *memoriaTentativaLoginStore) Buscar(chave string) (*model.TentativaLogin, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tentativa, ok := m.registros[chave]
+	if !ok {
+		tentativa = model.TentativaLogin{Chave: chave}
+	}
+	return &tentativa, nil
+}
+
+func (m *memoriaTentativaLoginStore) RegistrarFalha(chave string, ip string, agora time.Time) (*model.TentativaLogin, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tentativa := m.registros[chave]
+	tentativa.Chave = chave
+	tentativa.Falhas++
+	tentativa.UltimaFalha = agora
+	tentativa.UltimoIP = ip
+	m.registros[chave] = tentativa
+	return &tentativa, nil
+}
+
+func (m *memoriaTentativaLoginStore) Atualizar(tentativa *model.TentativaLogin) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	atual := m.registros[tentativa.Chave]
+	atual.Chave = tentativa.Chave
+	atual.ProximaTentativaEm = tentativa.ProximaTentativaEm
+	atual.BloqueadoAte = tentativa.BloqueadoAte
+	m.registros[tentativa.Chave] = atual
+	return nil
+}
+
+func (m *memoriaTentativaLoginStore) Limpar(chave string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.registros, chave)
+	return nil
+}
+
+func (m *memoriaTentativaLoginStore) Expurgar(limite time.Time, agora time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var apagados int64
+	for chave, tentativa := range m.registros {
+		if tentativa.UltimaFalha.Before(limite) && (tentativa.BloqueadoAte == nil || !tentativa.BloqueadoAte.After(agora)) {
+			delete(m.registros, chave)
+			apagados++
+		}
+	}
+	return apagados, nil
+}
@@ -0,0 +1,114 @@
+package autorizacao
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+)
+
+// maxEntradas limita a memória do cache; ao passar do limite ele é esvaziado e repovoado sob demanda.
+const maxEntradas = 10000
+
+var agora = time.Now
+
+type entrada struct {
+	usuario  *model.Usuario
+	versao   uint64
+	expiraEm time.Time
+}
+
+type chave struct {
+	usuarioID uint
+	empresaID uint
+}
+
+// Versao é o estado do cache lido por Buscar antes de carregar o usuário do banco. Guardar só
+// aceita o usuário se nada foi invalidado desde então; sem isso, uma invalidação feita entre a
+// leitura do banco e a gravação no cache deixaria permissões antigas guardadas até o TTL vencer.
+type Versao struct {
+	empresa  uint64
+	usuarios uint64
+}
+
+// Cache guarda, em memória do processo, o usuário já carregado com cargo, escopos e cargos herdados.
+// Cada entrada é válida enquanto a versão dos cargos da empresa não mudar e o TTL não vencer; o TTL
+// cobre alterações feitas por outras instâncias da API, que este processo não enxerga.
+// Um *Cache nil é válido e não guarda nada.
+type Cache struct {
+	mu       sync.RWMutex
+	ttl      time.Duration
+	entradas map[chave]entrada
+	versoes  map[uint]uint64
+	// invalidacoes conta as chamadas a InvalidarUsuario, de qualquer empresa.
+	invalidacoes uint64
+}
+
+func NovoCache(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:      ttl,
+		entradas: make(map[chave]entrada),
+		versoes:  make(map[uint]uint64),
+	}
+}
+
+// Buscar devolve o usuário em cache. O valor é compartilhado e deve ser tratado como somente leitura.
+// Quando o usuário não está no cache, a Versao devolvida deve ser passada a Guardar depois de
+// carregá-lo do banco.
+func (c *Cache) Buscar(usuarioID uint, empresaID uint) (*model.Usuario, Versao, bool) {
+	if c == nil {
+		return nil, Versao{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	versao := Versao{empresa: c.versoes[empresaID], usuarios: c.invalidacoes}
+	e, ok := c.entradas[chave{usuarioID, empresaID}]
+	if !ok || e.versao != versao.empresa || agora().After(e.expiraEm) {
+		return nil, versao, false
+	}
+	return e.usuario, versao, true
+}
+
+// Guardar registra o usuário carregado depois de Buscar devolver versao. Se desde então os cargos
+// da empresa mudaram ou algum usuário foi invalidado, o usuário pode estar desatualizado e não é
+// guardado.
+func (c *Cache) Guardar(usuario *model.Usuario, versao Versao) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if versao.empresa != c.versoes[usuario.EmpresaID] || versao.usuarios != c.invalidacoes {
+		return
+	}
+	if len(c.entradas) >= maxEntradas {
+		c.entradas = make(map[chave]entrada)
+	}
+	c.entradas[chave{usuario.ID, usuario.EmpresaID}] = entrada{
+		usuario:  usuario,
+		versao:   versao.empresa,
+		expiraEm: agora().Add(c.ttl),
+	}
+}
+
+// InvalidarUsuario descarta o usuário, por exemplo ao trocar de cargo, gestor ou departamento.
+func (c *Cache) InvalidarUsuario(usuarioID uint, empresaID uint) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entradas, chave{usuarioID, empresaID})
+	c.invalidacoes++
+}
+
+// InvalidarEmpresa avança a versão dos cargos da empresa. Como um cargo pode ser herdado por
+// outros, qualquer mudança em cargos ou concessões invalida todos os usuários da empresa.
+func (c *Cache) InvalidarEmpresa(empresaID uint) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versoes[empresaID]++
+}
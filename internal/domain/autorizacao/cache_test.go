@@ -0,0 +1,97 @@
+package autorizacao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+)
+
+// guardar faz o caminho de usuarioService.Resolver: Buscar, carregar e Guardar.
+func guardar(cache *Cache, usuario *model.Usuario) {
+	_, versao, _ := cache.Buscar(usuario.ID, usuario.EmpresaID)
+	cache.Guardar(usuario, versao)
+}
+
+func TestCache_InvalidacaoPorVersaoEUsuario(t *testing.T) {
+	cache := NovoCache(time.Minute)
+	guardar(cache, &model.Usuario{ID: 1, EmpresaID: 7})
+	guardar(cache, &model.Usuario{ID: 2, EmpresaID: 7})
+	guardar(cache, &model.Usuario{ID: 3, EmpresaID: 8})
+
+	if _, _, ok := cache.Buscar(1, 7); !ok {
+		t.Fatal("Esperava encontrar o usuário recém-guardado")
+	}
+	if _, _, ok := cache.Buscar(1, 8); ok {
+		t.Error("O mesmo ID em outra empresa não pode vir do cache")
+	}
+
+	cache.InvalidarUsuario(1, 7)
+	if _, _, ok := cache.Buscar(1, 7); ok {
+		t.Error("Usuário invalidado não deveria vir do cache")
+	}
+
+	// Mudar um cargo da empresa 7 invalida todos os usuários dela, mas não os da empresa 8.
+	cache.InvalidarEmpresa(7)
+	if _, _, ok := cache.Buscar(2, 7); ok {
+		t.Error("Usuário com versão antiga dos cargos não deveria vir do cache")
+	}
+	if _, _, ok := cache.Buscar(3, 8); !ok {
+		t.Error("Usuários de outra empresa não deveriam ser afetados")
+	}
+
+	guardar(cache, &model.Usuario{ID: 2, EmpresaID: 7})
+	if _, _, ok := cache.Buscar(2, 7); !ok {
+		t.Error("Usuário guardado com a versão nova deveria vir do cache")
+	}
+}
+
+func TestCache_Expira(t *testing.T) {
+	original := agora
+	t.Cleanup(func() { agora = original })
+	momento := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	agora = func() time.Time { return momento }
+
+	cache := NovoCache(time.Minute)
+	guardar(cache, &model.Usuario{ID: 1, EmpresaID: 7})
+
+	momento = momento.Add(2 * time.Minute)
+	if _, _, ok := cache.Buscar(1, 7); ok {
+		t.Error("Entrada vencida não deveria vir do cache")
+	}
+}
+
+func TestCache_NilOuDesligado(t *testing.T) {
+	var nulo *Cache
+	guardar(nulo, &model.Usuario{ID: 1, EmpresaID: 7})
+	nulo.InvalidarEmpresa(7)
+	if _, _, ok := nulo.Buscar(1, 7); ok {
+		t.Error("Cache nil não guarda nada")
+	}
+
+	desligado := NovoCache(0)
+	guardar(desligado, &model.Usuario{ID: 1, EmpresaID: 7})
+	if _, _, ok := desligado.Buscar(1, 7); ok {
+		t.Error("Cache com TTL zero não guarda nada")
+	}
+}
+
+func TestCache_InvalidacaoDuranteACarga(t *testing.T) {
+	cache := NovoCache(time.Minute)
+
+	// O cargo muda entre a leitura do banco e a gravação no cache.
+	_, versao, _ := cache.Buscar(1, 7)
+	cache.InvalidarEmpresa(7)
+	cache.Guardar(&model.Usuario{ID: 1, EmpresaID: 7}, versao)
+	if _, _, ok := cache.Buscar(1, 7); ok {
+		t.Error("Um usuário carregado antes da mudança de cargo não deveria ser guardado")
+	}
+
+	// O mesmo com o próprio usuário alterado no meio da carga.
+	_, versao, _ = cache.Buscar(2, 7)
+	cache.InvalidarUsuario(2, 7)
+	cache.Guardar(&model.Usuario{ID: 2, EmpresaID: 7}, versao)
+	if _, _, ok := cache.Buscar(2, 7); ok {
+		t.Error("Um usuário carregado antes de ser alterado não deveria ser guardado")
+	}
+}
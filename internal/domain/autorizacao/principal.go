@@ -0,0 +1,24 @@
+package autorizacao
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// chavePrincipal guarda no contexto do gin o usuário autenticado, já com as permissões resolvidas.
+const chavePrincipal = "principal"
+
+// DefinirPrincipal é chamado pelo middleware de permissões depois de resolver o usuário.
+func DefinirPrincipal(c *gin.Context, usuario *model.Usuario) {
+	c.Set(chavePrincipal, usuario)
+}
+
+// Principal devolve o usuário resolvido pelo middleware, se a rota passou por ele.
+func Principal(c *gin.Context) (*model.Usuario, bool) {
+	valor, ok := c.Get(chavePrincipal)
+	if !ok {
+		return nil, false
+	}
+	usuario, ok := valor.(*model.Usuario)
+	return usuario, ok && usuario != nil
+}
@@ -1,28 +1,53 @@
 package bancohoras
 
 import (
+	"encoding/csv"
+	"errors"
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
 	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
 	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
 	"github.com/Loviiin/ponto-api-go/pkg/permissions"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 type Handler struct {
 	service        BancoHorasService
 	usuarioService usuario.UsuarioService
+	empresas       empresa.EmpresaService
 	converter      funcoes.FuncoesInterface
 }
 
-func NewBancoHorasHandler(s BancoHorasService, u usuario.UsuarioService, f funcoes.FuncoesInterface) *Handler {
+func NewBancoHorasHandler(s BancoHorasService, u usuario.UsuarioService, e empresa.EmpresaService, f funcoes.FuncoesInterface) *Handler {
 	return &Handler{
 		service:        s,
 		usuarioService: u,
+		empresas:       e,
 		converter:      f,
 	}
 }
 
+// lerDia interpreta 'dia' (AAAA-MM-DD) como um dia de calendário no fuso da empresa.
+func (h *Handler) lerDia(c *gin.Context, empresaID uint, valor string) (time.Time, bool) {
+	fusoEmpresa, err := h.empresas.Fuso(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar o fuso horário da empresa."})
+		return time.Time{}, false
+	}
+	dia, err := fuso.ParseDia(valor, fusoEmpresa)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data inválido. Use AAAA-MM-DD."})
+		return time.Time{}, false
+	}
+	return dia, true
+}
+
 // GetSaldoDoDia é a função que vai lidar com a requisição da API.
 func (h *Handler) GetSaldoDoDia(c *gin.Context) {
 	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
@@ -36,37 +61,42 @@ func (h *Handler) GetSaldoDoDia(c *gin.Context) {
 		return
 	}
 
-	diaString := c.Query("dia")
-	diaTime, err := time.Parse("2006-01-02", diaString)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data inválido. Use AAAA-MM-DD."})
+	diaTime, ok := h.lerDia(c, empresaID, c.Query("dia"))
+	if !ok {
 		return
 	}
 
-	idDoRequisitante, err := h.converter.GetUintIDFromContext(c, "userID")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Chaves de API não representam um funcionário: só enxergam saldos com o escopo adequado.
+	_, ehChaveAPI := auth.EscoposChaveAPI(c)
+	if ehChaveAPI && !auth.ChaveAPITemEscopo(c, permissions.VER_SALDO_FUNCIONARIOS) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "A chave de API não tem o escopo para ver saldos."})
 		return
 	}
 
-	if idDoRequisitante != id {
-		requisitante, err := h.usuarioService.FindByID(idDoRequisitante, empresaID)
+	if !ehChaveAPI {
+		idDoRequisitante, err := h.converter.GetUintIDFromContext(c, "userID")
 		if err != nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		temPermissao := false
-		for _, permissao := range requisitante.Cargo.Permissoes {
-			if permissao.Nome == permissions.VER_SALDO_FUNCIONARIOS {
-				temPermissao = true
-				break
+		if idDoRequisitante != id {
+			requisitante, err := usuario.Requisitante(c, h.usuarioService, idDoRequisitante, empresaID)
+			if err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+				return
 			}
-		}
 
-		if !temPermissao {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Você não tem permissão para ver o saldo de outros funcionários."})
-			return
+			temPermissao, err := h.usuarioService.AlvoNoEscopo(requisitante, permissions.VER_SALDO_FUNCIONARIOS, id)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao verificar permissões."})
+				return
+			}
+
+			if !temPermissao {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Você não tem permissão para ver o saldo deste funcionário."})
+				return
+			}
 		}
 	}
 
@@ -96,17 +126,169 @@ func (h *Handler) FecharDia(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "O parâmetro 'dia' é obrigatório. Use o formato AAAA-MM-DD."})
 		return
 	}
-	diaTime, err := time.Parse("2006-01-02", diaString)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data inválido. Use AAAA-MM-DD."})
+	diaTime, ok := h.lerDia(c, empresaID, diaString)
+	if !ok {
 		return
 	}
 
-	usuarioAtualizado, err := h.service.FecharDiaParaUsuario(idUsuarioAlvo, empresaID, diaTime)
+	// A permissão da rota já foi verificada; aqui confere se o alvo está no escopo concedido.
+	// Chaves de API agem em nome da empresa inteira.
+	var sujeito politica.Atributos
+	if _, ehChaveAPI := auth.EscoposChaveAPI(c); ehChaveAPI {
+		chaveID, err := h.converter.GetUintIDFromContext(c, "chaveAPIID")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		sujeito = politica.AtributosChaveAPI(chaveID)
+	} else {
+		idDoRequisitante, err := h.converter.GetUintIDFromContext(c, "userID")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		requisitante, err := usuario.Requisitante(c, h.usuarioService, idDoRequisitante, empresaID)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+			return
+		}
+		noEscopo, err := h.usuarioService.AlvoNoEscopo(requisitante, permissions.EDITAR_SALDO_FUNCIONARIOS, idUsuarioAlvo)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao verificar permissões."})
+			return
+		}
+		if !noEscopo {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Você não tem permissão para fechar o dia deste funcionário."})
+			return
+		}
+		sujeito = politica.AtributosUsuario(requisitante)
+	}
+
+	antes, _ := h.usuarioService.FindByID(idUsuarioAlvo, empresaID)
+	usuarioAtualizado, err := h.service.FecharDiaSolicitado(sujeito, idUsuarioAlvo, empresaID, diaTime)
 	if err != nil {
+		if errors.Is(err, politica.ErrNegadoPorPolitica) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrDiaJaFechado) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao processar o fechamento do dia: " + err.Error()})
 		return
 	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoDiaFechado, Entidade: "usuarios", EntidadeID: idUsuarioAlvo, Antes: antes, Depois: usuarioAtualizado})
 
 	c.JSON(http.StatusOK, usuarioAtualizado)
 }
+
+// saldoFuncionario é a linha devolvida pela listagem e pela exportação de saldos.
+type saldoFuncionario struct {
+	UsuarioID      uint   `json:"usuario_id"`
+	Nome           string `json:"nome"`
+	Email          string `json:"email"`
+	DepartamentoID *uint  `json:"departamento_id"`
+	CentroCustoID  *uint  `json:"centro_custo_id"`
+	SaldoMinutos   int    `json:"saldo_minutos"`
+}
+
+// saldosVisiveis aplica os filtros da query e o escopo de VER_SALDO_FUNCIONARIOS do requisitante.
+// Em caso de erro a resposta já foi escrita e o retorno é false.
+func (h *Handler) saldosVisiveis(c *gin.Context) ([]saldoFuncionario, bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	filtro, err := usuario.FiltroDaQuery(c, h.converter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	usuarios, err := h.usuarioService.Buscar(empresaID, filtro)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar os saldos."})
+		return nil, false
+	}
+
+	if _, ehChaveAPI := auth.EscoposChaveAPI(c); ehChaveAPI {
+		if !auth.ChaveAPITemEscopo(c, permissions.VER_SALDO_FUNCIONARIOS) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "A chave de API não tem o escopo para ver saldos."})
+			return nil, false
+		}
+	} else {
+		idDoRequisitante, err := h.converter.GetUintIDFromContext(c, "userID")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		requisitante, err := usuario.Requisitante(c, h.usuarioService, idDoRequisitante, empresaID)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+			return nil, false
+		}
+		usuarios, err = h.usuarioService.FiltrarPorEscopo(requisitante, permissions.VER_SALDO_FUNCIONARIOS, usuarios)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao verificar permissões."})
+			return nil, false
+		}
+	}
+
+	saldos := make([]saldoFuncionario, 0, len(usuarios))
+	for _, u := range usuarios {
+		saldos = append(saldos, saldoFuncionario{
+			UsuarioID:      u.ID,
+			Nome:           u.Nome,
+			Email:          u.Email,
+			DepartamentoID: u.DepartamentoID,
+			CentroCustoID:  u.CentroCustoID,
+			SaldoMinutos:   u.SaldoBancoHorasMinutos,
+		})
+	}
+	return saldos, true
+}
+
+// GetSaldos lista o saldo acumulado dos funcionários que o requisitante pode ver,
+// com filtros opcionais por departamento_id, centro_custo_id e gestor_id.
+func (h *Handler) GetSaldos(c *gin.Context) {
+	saldos, ok := h.saldosVisiveis(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, saldos)
+}
+
+// ExportarSaldos devolve a mesma listagem de GetSaldos em CSV, para a folha de pagamento.
+func (h *Handler) ExportarSaldos(c *gin.Context) {
+	saldos, ok := h.saldosVisiveis(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="saldos.csv"`)
+	c.Status(http.StatusOK)
+
+	escritor := csv.NewWriter(c.Writer)
+	escritor.Write([]string{"usuario_id", "nome", "email", "departamento_id", "centro_custo_id", "saldo_minutos"})
+	for _, s := range saldos {
+		escritor.Write([]string{
+			strconv.FormatUint(uint64(s.UsuarioID), 10),
+			s.Nome,
+			s.Email,
+			idOuVazio(s.DepartamentoID),
+			idOuVazio(s.CentroCustoID),
+			strconv.Itoa(s.SaldoMinutos),
+		})
+	}
+	escritor.Flush()
+}
+
+func idOuVazio(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
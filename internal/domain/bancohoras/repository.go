@@ -0,0 +1,75 @@
+package bancohoras
+
+import (
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FechamentoRepository interface {
+	// Registrar grava o fechamento e soma o saldo do dia ao banco de horas, tudo ou nada. Devolve
+	// false, sem alterar nada, se o dia já estava fechado para o funcionário.
+	Registrar(fechamento *model.FechamentoDia) (bool, error)
+	// Ajustar soma diferenca ao saldo de um dia já fechado e ao banco de horas. Devolve false, sem
+	// alterar nada, se o dia ainda não foi fechado.
+	Ajustar(usuarioID uint, empresaID uint, dia time.Time, diferenca int) (bool, error)
+	// UltimoDia devolve o dia fechado mais recente do funcionário, ou nil se nenhum foi fechado.
+	UltimoDia(usuarioID uint, empresaID uint) (*time.Time, error)
+}
+
+type fechamentoRepository struct {
+	Db *gorm.DB
+}
+
+func NewFechamentoRepository(db *gorm.DB) FechamentoRepository {
+	return &fechamentoRepository{Db: db}
+}
+
+func (r *fechamentoRepository) Registrar(fechamento *model.FechamentoDia) (bool, error) {
+	registrado := false
+	err := tenant.Escopo(r.Db, fechamento.EmpresaID).Transaction(func(tx *gorm.DB) error {
+		// Dois fechamentos simultâneos do mesmo dia: só o que inserir a linha soma o saldo.
+		resultado := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(fechamento)
+		if resultado.Error != nil || resultado.RowsAffected == 0 {
+			return resultado.Error
+		}
+		registrado = true
+		return somarAoBanco(tx, fechamento.UsuarioID, fechamento.EmpresaID, fechamento.SaldoMinutos)
+	})
+	return registrado, err
+}
+
+func (r *fechamentoRepository) Ajustar(usuarioID uint, empresaID uint, dia time.Time, diferenca int) (bool, error) {
+	ajustado := false
+	err := tenant.Escopo(r.Db, empresaID).Transaction(func(tx *gorm.DB) error {
+		resultado := tx.Model(&model.FechamentoDia{}).
+			Where("usuario_id = ? AND dia = ?", usuarioID, dia).
+			Update("saldo_minutos", gorm.Expr("saldo_minutos + ?", diferenca))
+		if resultado.Error != nil || resultado.RowsAffected == 0 {
+			return resultado.Error
+		}
+		ajustado = true
+		return somarAoBanco(tx, usuarioID, empresaID, diferenca)
+	})
+	return ajustado, err
+}
+
+func (r *fechamentoRepository) UltimoDia(usuarioID uint, empresaID uint) (*time.Time, error) {
+	var fechamentos []model.FechamentoDia
+	err := tenant.Escopo(r.Db, empresaID).Where("usuario_id = ?", usuarioID).
+		Order("dia desc").Limit(1).Find(&fechamentos).Error
+	if err != nil || len(fechamentos) == 0 {
+		return nil, err
+	}
+	return &fechamentos[0].Dia, nil
+}
+
+// somarAoBanco soma no próprio banco de dados, sem ler o saldo antes, para não perder uma soma
+// feita ao mesmo tempo por outro fechamento ou ajuste.
+func somarAoBanco(tx *gorm.DB, usuarioID uint, empresaID uint, minutos int) error {
+	return tx.Model(&model.Usuario{}).Where("id = ?", usuarioID).
+		Update("saldo_banco_horas_minutos", gorm.Expr("saldo_banco_horas_minutos + ?", minutos)).Error
+}
@@ -1,49 +1,146 @@
 package bancohoras
 
 import (
+	"errors"
 	"sort"
 	"time"
 
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
 	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
 	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
 	"github.com/Loviiin/ponto-api-go/internal/model"
 )
 
+// HoraFechamentoDiario é a hora em que o agendador fecha o dia anterior de todos os usuários.
+const HoraFechamentoDiario = 1
+
+var ErrDiaJaFechado = errors.New("este dia já foi fechado no banco de horas do funcionário")
+
+// UltimoDiaAFechar devolve o dia mais recente cujo fechamento já é devido, como data de calendário
+// (meia-noite UTC). agora deve estar no fuso da empresa. A comparação é "a partir da hora do
+// fechamento", e não "na hora": nas mudanças de horário de verão essa hora pode se repetir ou
+// não existir.
+func UltimoDiaAFechar(agora time.Time) time.Time {
+	ano, mes, d := agora.Date()
+	hoje := time.Date(ano, mes, d, 0, 0, 0, 0, time.UTC)
+	if agora.Hour() >= HoraFechamentoDiario {
+		return hoje.AddDate(0, 0, -1)
+	}
+	return hoje.AddDate(0, 0, -2)
+}
+
+// diaDeCalendario devolve a data de dia no fuso, como meia-noite UTC.
+func diaDeCalendario(dia time.Time, fuso *time.Location) time.Time {
+	ano, mes, d := dia.In(fuso).Date()
+	return time.Date(ano, mes, d, 0, 0, 0, 0, time.UTC)
+}
+
+// DiaFechado informa se o fechamento automático já somou o dia ao banco de horas. agora deve
+// estar no fuso da empresa: é nele que o dia termina e o fechamento acontece.
+func DiaFechado(dia time.Time, agora time.Time) bool {
+	ano, mes, d := agora.Date()
+	hoje := time.Date(ano, mes, d, 0, 0, 0, 0, agora.Location())
+	ano, mes, d = dia.In(agora.Location()).Date()
+	inicioDoDia := time.Date(ano, mes, d, 0, 0, 0, 0, agora.Location())
+	if !inicioDoDia.Before(hoje) {
+		return false
+	}
+	return inicioDoDia.Before(hoje.AddDate(0, 0, -1)) || agora.Hour() >= HoraFechamentoDiario
+}
+
+// Os dias recebidos são instantes quaisquer dentro do dia: o dia de calendário é o do fuso da empresa.
 type BancoHorasService interface {
 	CalcularSaldoParaUsuario(usuarioID uint, empresaID uint, dia time.Time) (int, error)
 	FecharDiaParaUsuario(usuarioID uint, empresaID uint, dia time.Time) (*model.Usuario, error)
+	// FecharDiasPendentes fecha, um a um, os dias do funcionário posteriores ao último fechado até o
+	// último já devido em agora (UltimoDiaAFechar). Sem nenhum dia fechado, fecha só o último devido.
+	// Devolve quantos dias fechou; chamado de novo, não fecha nada.
+	FecharDiasPendentes(usuarioID uint, empresaID uint, agora time.Time) (int, error)
+	// FecharDiaSolicitado é o fechamento pedido pela API: antes de gravar, consulta as políticas da
+	// empresa com o sujeito que fez o pedido. O agendador continua usando FecharDiaParaUsuario.
+	FecharDiaSolicitado(sujeito politica.Atributos, usuarioID uint, empresaID uint, dia time.Time) (*model.Usuario, error)
+	// AjustarDiaFechado corrige o banco de horas quando os pontos de um dia já fechado mudam (ex: um
+	// ponto remoto aprovado depois do fechamento): soma a diferença entre o saldo atual do dia e
+	// saldoAnterior. Para dias que o funcionário ainda não fechou não faz nada, já que o fechamento
+	// lerá os pontos novos.
+	AjustarDiaFechado(usuarioID uint, empresaID uint, dia time.Time, saldoAnterior int) error
+	// SaldoComDecisao calcula, sem gravar nada, o saldo do dia do ponto como ficaria se ele tivesse
+	// o status de revisão informado. Mede o efeito de uma revisão antes de ela ser decidida.
+	SaldoComDecisao(ponto *model.RegistroPonto, status string) (int, error)
+	// DiaEstaFechado aplica DiaFechado no fuso da empresa.
+	DiaEstaFechado(empresaID uint, dia time.Time) (bool, error)
 }
 
+var agora = time.Now
+
 type bancoHorasService struct {
 	pontoRepo   ponto.RegistroPontoRepository
 	usuarioRepo usuario.UsuarioRepository
+	empresaRepo empresa.EmpresaRepository
+	politicas   politica.Verificador
+	fechamentos FechamentoRepository
 }
 
-func NewBancoHorasService(pontoRepo ponto.RegistroPontoRepository, userRepo usuario.UsuarioRepository) BancoHorasService {
+func NewBancoHorasService(pontoRepo ponto.RegistroPontoRepository, userRepo usuario.UsuarioRepository, empresaRepo empresa.EmpresaRepository, politicas politica.Verificador, fechamentos FechamentoRepository) BancoHorasService {
 	return &bancoHorasService{
 		pontoRepo:   pontoRepo,
 		usuarioRepo: userRepo,
+		empresaRepo: empresaRepo,
+		politicas:   politicas,
+		fechamentos: fechamentos,
 	}
 }
 
 func (s *bancoHorasService) CalcularSaldoParaUsuario(usuarioID uint, empresaID uint, dia time.Time) (int, error) {
-	user, err := s.usuarioRepo.FindByID(usuarioID, empresaID)
+	pontos, cargo, err := s.pontosDoDia(usuarioID, empresaID, dia)
 	if err != nil {
 		return 0, err
 	}
-	pontos, err := s.pontoRepo.FindPontosByUserIDAndDate(user.ID, dia)
+	return CalcularSaldoDoDia(pontos, cargo)
+}
+
+func (s *bancoHorasService) SaldoComDecisao(ponto *model.RegistroPonto, status string) (int, error) {
+	pontos, cargo, err := s.pontosDoDia(ponto.UsuarioID, ponto.EmpresaID, ponto.Timestamp)
 	if err != nil {
 		return 0, err
 	}
-	doDia, err := CalcularSaldoDoDia(pontos, user.Cargo)
-	if err != nil {
-		return 0, err
+	for i := range pontos {
+		if pontos[i].ID == ponto.ID {
+			pontos[i].StatusRevisao = status
+		}
 	}
+	return CalcularSaldoDoDia(pontos, cargo)
+}
 
-	return doDia, err
+// pontosDoDia carrega os pontos do funcionário no dia, no fuso da empresa, e o cargo que define a
+// carga horária.
+func (s *bancoHorasService) pontosDoDia(usuarioID uint, empresaID uint, dia time.Time) ([]model.RegistroPonto, model.Cargo, error) {
+	user, err := s.usuarioRepo.FindByID(usuarioID, empresaID)
+	if err != nil {
+		return nil, model.Cargo{}, err
+	}
+	fusoEmpresa, err := empresa.FusoDaEmpresa(s.empresaRepo, empresaID)
+	if err != nil {
+		return nil, model.Cargo{}, err
+	}
+	pontos, err := s.pontoRepo.FindPontosByUserIDAndDate(user.ID, empresaID, dia.In(fusoEmpresa))
+	if err != nil {
+		return nil, model.Cargo{}, err
+	}
+	return pontos, user.Cargo, nil
 }
 
 func CalcularSaldoDoDia(pontosDoDia []model.RegistroPonto, cargoDoUsuario model.Cargo) (saldoEmMinutos int, err error) {
+	// Pontos remotos aguardando revisão ou rejeitados não formam pares de entrada e saída.
+	validos := make([]model.RegistroPonto, 0, len(pontosDoDia))
+	for _, p := range pontosDoDia {
+		if p.ContaNoSaldo() {
+			validos = append(validos, p)
+		}
+	}
+	pontosDoDia = validos
+
 	sort.Slice(pontosDoDia, func(i, j int) bool {
 		return pontosDoDia[i].Timestamp.Before(pontosDoDia[j].Timestamp)
 	})
@@ -63,27 +160,104 @@ func CalcularSaldoDoDia(pontosDoDia []model.RegistroPonto, cargoDoUsuario model.
 }
 
 func (s *bancoHorasService) FecharDiaParaUsuario(usuarioID uint, empresaID uint, dia time.Time) (*model.Usuario, error) {
-	saldoDoDia, err := s.CalcularSaldoParaUsuario(usuarioID, empresaID, dia)
+	return s.fecharDia(nil, usuarioID, empresaID, dia)
+}
+
+func (s *bancoHorasService) FecharDiaSolicitado(sujeito politica.Atributos, usuarioID uint, empresaID uint, dia time.Time) (*model.Usuario, error) {
+	return s.fecharDia(sujeito, usuarioID, empresaID, dia)
+}
+
+func (s *bancoHorasService) FecharDiasPendentes(usuarioID uint, empresaID uint, agora time.Time) (int, error) {
+	fusoEmpresa, err := empresa.FusoDaEmpresa(s.empresaRepo, empresaID)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-
-	usuarioAtual, err := s.usuarioRepo.FindByID(usuarioID, empresaID)
+	ultimoDevido := UltimoDiaAFechar(agora.In(fusoEmpresa))
+	inicio := ultimoDevido
+	ultimoFechado, err := s.fechamentos.UltimoDia(usuarioID, empresaID)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
+	if ultimoFechado != nil {
+		inicio = ultimoFechado.AddDate(0, 0, 1)
+	}
+
+	fechados := 0
+	for dia := inicio; !dia.After(ultimoDevido); dia = dia.AddDate(0, 0, 1) {
+		// Meio-dia no fuso da empresa cai sempre dentro do dia, com ou sem horário de verão.
+		meioDia := time.Date(dia.Year(), dia.Month(), dia.Day(), 12, 0, 0, 0, fusoEmpresa)
+		_, err := s.fecharDia(nil, usuarioID, empresaID, meioDia)
+		if errors.Is(err, ErrDiaJaFechado) {
+			continue
+		}
+		if err != nil {
+			return fechados, err
+		}
+		fechados++
+	}
+	return fechados, nil
+}
 
-	novoSaldoTotal := usuarioAtual.SaldoBancoHorasMinutos + saldoDoDia
+func (s *bancoHorasService) DiaEstaFechado(empresaID uint, dia time.Time) (bool, error) {
+	fusoEmpresa, err := empresa.FusoDaEmpresa(s.empresaRepo, empresaID)
+	if err != nil {
+		return false, err
+	}
+	return DiaFechado(dia, agora().In(fusoEmpresa)), nil
+}
 
-	dadosParaAtualizar := map[string]interface{}{
-		"saldo_banco_horas_minutos": novoSaldoTotal,
+func (s *bancoHorasService) AjustarDiaFechado(usuarioID uint, empresaID uint, dia time.Time, saldoAnterior int) error {
+	fusoEmpresa, err := empresa.FusoDaEmpresa(s.empresaRepo, empresaID)
+	if err != nil {
+		return err
+	}
+	saldoAtual, err := s.CalcularSaldoParaUsuario(usuarioID, empresaID, dia)
+	if err != nil || saldoAtual == saldoAnterior {
+		return err
 	}
+	_, err = s.fechamentos.Ajustar(usuarioID, empresaID, diaDeCalendario(dia, fusoEmpresa), saldoAtual-saldoAnterior)
+	return err
+}
 
-	err = s.usuarioRepo.Update(usuarioID, empresaID, dadosParaAtualizar)
+// fecharDia soma o saldo do dia ao banco de horas, uma única vez por dia: um dia já fechado
+// devolve ErrDiaJaFechado. Sem sujeito, as políticas não são consultadas.
+func (s *bancoHorasService) fecharDia(sujeito politica.Atributos, usuarioID uint, empresaID uint, dia time.Time) (*model.Usuario, error) {
+	fusoEmpresa, err := empresa.FusoDaEmpresa(s.empresaRepo, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	saldoDoDia, err := s.CalcularSaldoParaUsuario(usuarioID, empresaID, dia)
 	if err != nil {
 		return nil, err
 	}
 
-	usuarioAtual.SaldoBancoHorasMinutos = novoSaldoTotal
-	return usuarioAtual, nil
+	if sujeito != nil {
+		absoluto := saldoDoDia
+		if absoluto < 0 {
+			absoluto = -absoluto
+		}
+		err = s.politicas.Exigir(empresaID, politica.Requisicao{
+			Acao:    politica.AcaoBancoHorasFecharDia,
+			Sujeito: sujeito,
+			Recurso: politica.Atributos{"usuario_id": usuarioID, "saldo_minutos": saldoDoDia, "saldo_minutos_absoluto": absoluto},
+			Momento: time.Now(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	registrado, err := s.fechamentos.Registrar(&model.FechamentoDia{
+		UsuarioID:    usuarioID,
+		EmpresaID:    empresaID,
+		Dia:          diaDeCalendario(dia, fusoEmpresa),
+		SaldoMinutos: saldoDoDia,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !registrado {
+		return nil, ErrDiaJaFechado
+	}
+	return s.usuarioRepo.FindByID(usuarioID, empresaID)
 }
@@ -0,0 +1,212 @@
+package bancohoras
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+)
+
+// memoriaFechamentoRepository guarda os dias fechados e o banco de horas de um único funcionário.
+type memoriaFechamentoRepository struct {
+	dias  map[time.Time]int
+	banco int
+}
+
+func (m *memoriaFechamentoRepository) Registrar(fechamento *model.FechamentoDia) (bool, error) {
+	if _, fechado := m.dias[fechamento.Dia]; fechado {
+		return false, nil
+	}
+	m.dias[fechamento.Dia] = fechamento.SaldoMinutos
+	m.banco += fechamento.SaldoMinutos
+	return true, nil
+}
+
+func (m *memoriaFechamentoRepository) Ajustar(usuarioID uint, empresaID uint, dia time.Time, diferenca int) (bool, error) {
+	if _, fechado := m.dias[dia]; !fechado {
+		return false, nil
+	}
+	m.dias[dia] += diferenca
+	m.banco += diferenca
+	return true, nil
+}
+
+func (m *memoriaFechamentoRepository) UltimoDia(usuarioID uint, empresaID uint) (*time.Time, error) {
+	var ultimo *time.Time
+	for dia := range m.dias {
+		if ultimo == nil || dia.After(*ultimo) {
+			d := dia
+			ultimo = &d
+		}
+	}
+	return ultimo, nil
+}
+
+// mockUsuarioRepository devolve um funcionário de jornada de 8 horas.
+type mockUsuarioRepository struct {
+	usuario.UsuarioRepository
+}
+
+func (m *mockUsuarioRepository) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
+	return &model.Usuario{ID: id, EmpresaID: empresaID, Cargo: model.Cargo{CargaHorariaDiariaMinutos: 480}}, nil
+}
+
+// mockPontoRepository não tem pontos: cada dia fechado tira 8 horas do banco.
+type mockPontoRepository struct {
+	ponto.RegistroPontoRepository
+}
+
+func (m *mockPontoRepository) FindPontosByUserIDAndDate(userID uint, empresaID uint, dia time.Time) ([]model.RegistroPonto, error) {
+	return nil, nil
+}
+
+type mockEmpresaRepository struct {
+	empresa.EmpresaRepository
+	fuso string
+}
+
+func (m *mockEmpresaRepository) FindByID(id uint) (*model.Empresa, error) {
+	return &model.Empresa{ID: id, FusoHorario: m.fuso}, nil
+}
+
+func novoBancoHoras(t *testing.T, fuso string, fechados ...time.Time) (*memoriaFechamentoRepository, BancoHorasService) {
+	t.Helper()
+	if _, err := time.LoadLocation(fuso); err != nil {
+		t.Skipf("Base de fusos indisponível: %v", err)
+	}
+	repo := &memoriaFechamentoRepository{dias: map[time.Time]int{}}
+	for _, dia := range fechados {
+		repo.dias[dia] = 0
+	}
+	service := NewBancoHorasService(&mockPontoRepository{}, &mockUsuarioRepository{}, &mockEmpresaRepository{fuso: fuso}, nil, repo)
+	return repo, service
+}
+
+func data(ano int, mes time.Month, dia int) time.Time {
+	return time.Date(ano, mes, dia, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalcularSaldoDoDia_IgnoraPontosNaoAprovados(t *testing.T) {
+	dia := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	pontos := []model.RegistroPonto{
+		{Timestamp: dia.Add(8 * time.Hour)},
+		{Timestamp: dia.Add(12 * time.Hour), StatusRevisao: model.RevisaoRejeitada},
+		{Timestamp: dia.Add(13 * time.Hour), StatusRevisao: model.RevisaoPendente},
+		{Timestamp: dia.Add(16 * time.Hour), StatusRevisao: model.RevisaoAprovada},
+	}
+	saldo, err := CalcularSaldoDoDia(pontos, model.Cargo{CargaHorariaDiariaMinutos: 480})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	// Só contam a entrada das 8h e a saída aprovada das 16h: 8 horas, saldo zero.
+	if saldo != 0 {
+		t.Errorf("Esperava saldo zero, recebeu %d", saldo)
+	}
+}
+
+func TestDiaFechado(t *testing.T) {
+	agora := time.Date(2026, 3, 10, 0, 30, 0, 0, time.UTC)
+	casos := []struct {
+		nome     string
+		dia      time.Time
+		agora    time.Time
+		esperado bool
+	}{
+		{"hoje", agora, agora, false},
+		{"ontem, antes do fechamento", agora.AddDate(0, 0, -1), agora, false},
+		{"ontem, depois do fechamento", agora.AddDate(0, 0, -1), agora.Add(2 * time.Hour), true},
+		{"anteontem", agora.AddDate(0, 0, -2), agora, true},
+	}
+	for _, caso := range casos {
+		if DiaFechado(caso.dia, caso.agora) != caso.esperado {
+			t.Errorf("%s: esperava DiaFechado=%v", caso.nome, caso.esperado)
+		}
+	}
+}
+
+func TestDiaFechado_NoFusoDaEmpresa(t *testing.T) {
+	manaus, err := time.LoadLocation("America/Manaus")
+	if err != nil {
+		t.Skipf("Base de fusos indisponível: %v", err)
+	}
+	noronha, err := time.LoadLocation("America/Noronha")
+	if err != nil {
+		t.Skipf("Base de fusos indisponível: %v", err)
+	}
+	// 04h30 UTC: 00h30 em Manaus (UTC-4) e 02h30 em Noronha (UTC-2).
+	instante := time.Date(2026, 3, 10, 4, 30, 0, 0, time.UTC)
+	ontem := time.Date(2026, 3, 9, 12, 0, 0, 0, manaus)
+
+	if DiaFechado(ontem, instante.In(manaus)) {
+		t.Error("Em Manaus ainda não deu a hora do fechamento; o dia 9 não deveria estar fechado")
+	}
+	if !DiaFechado(ontem, instante.In(noronha)) {
+		t.Error("Em Noronha o dia 9 já deveria estar fechado")
+	}
+}
+
+func TestFecharDiasPendentes_HoraRepetidaNoFimDoHorarioDeVerao(t *testing.T) {
+	repo, service := novoBancoHoras(t, "America/New_York", data(2026, 10, 30))
+
+	// Em 1º/11/2026, 05h UTC e 06h UTC são ambas 1h da manhã em Nova York.
+	for _, instante := range []time.Time{
+		time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC),
+		time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC),
+		time.Date(2026, 11, 1, 7, 0, 0, 0, time.UTC),
+	} {
+		if _, err := service.FecharDiasPendentes(1, 1, instante); err != nil {
+			t.Fatalf("Erro inesperado às %s: %v", instante, err)
+		}
+	}
+	if len(repo.dias) != 2 || repo.banco != -480 {
+		t.Errorf("O dia 31/10 deveria ser fechado uma única vez; dias %v, banco %d", repo.dias, repo.banco)
+	}
+}
+
+func TestFecharDiasPendentes_HoraPuladaNoInicioDoHorarioDeVerao(t *testing.T) {
+	repo, service := novoBancoHoras(t, "Europe/London", data(2026, 3, 27))
+
+	// Em 29/03/2026 Londres pula da 1h para as 2h: a 1h da manhã não existe.
+	if fechados, _ := service.FecharDiasPendentes(1, 1, time.Date(2026, 3, 29, 0, 30, 0, 0, time.UTC)); fechados != 0 {
+		t.Errorf("À 0h30 o dia 28 ainda não deveria ser fechado, fechou %d", fechados)
+	}
+	fechados, err := service.FecharDiasPendentes(1, 1, time.Date(2026, 3, 29, 1, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if _, fechado := repo.dias[data(2026, 3, 28)]; fechados != 1 || !fechado {
+		t.Errorf("Às 2h (horário de verão) o dia 28 deveria ser fechado; fechou %d, dias %v", fechados, repo.dias)
+	}
+}
+
+func TestFecharDiasPendentes_RecuperaDiasPerdidos(t *testing.T) {
+	repo, service := novoBancoHoras(t, "America/Sao_Paulo", data(2026, 3, 5))
+
+	// O agendador ficou parado do dia 6 ao dia 9.
+	fechados, err := service.FecharDiasPendentes(1, 1, time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if fechados != 4 || repo.banco != -4*480 {
+		t.Errorf("Esperava fechar os dias 6 a 9, fechou %d com banco %d", fechados, repo.banco)
+	}
+}
+
+func TestFecharDia_UmaVezPorDia(t *testing.T) {
+	repo, service := novoBancoHoras(t, "America/Sao_Paulo")
+	dia := time.Date(2026, 3, 9, 15, 0, 0, 0, time.UTC)
+
+	if _, err := service.FecharDiaParaUsuario(1, 1, dia); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if _, err := service.FecharDiaParaUsuario(1, 1, dia.Add(3*time.Hour)); !errors.Is(err, ErrDiaJaFechado) {
+		t.Errorf("Esperava ErrDiaJaFechado ao fechar o mesmo dia de novo, recebeu %v", err)
+	}
+	if repo.banco != -480 {
+		t.Errorf("O dia deveria ser somado uma única vez, banco %d", repo.banco)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
 	"github.com/Loviiin/ponto-api-go/internal/model"
 	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
 	"github.com/gin-gonic/gin"
@@ -23,25 +24,41 @@ func NewCargoHandler(s CargoService, f funcoes.FuncoesInterface) *CargoHandler {
 }
 
 func (h *CargoHandler) CreateCargo(c *gin.Context) {
+	// O cargo é sempre criado na empresa do token, nunca numa empresa escolhida pelo corpo.
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// herda_de_id é opcional: o novo cargo recebe todas as permissões do cargo base.
 	type createRequest struct {
-		Nome      string `json:"nome" binding:"required"`
-		EmpresaID uint   `json:"empresa_id" binding:"required"`
+		Nome         string `json:"nome" binding:"required"`
+		HerdaDeID    *uint  `json:"herda_de_id"`
+		BatidaRemota string `json:"batida_remota"`
 	}
 	var req createRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'nome' e 'empresa_id' são obrigatórios."})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'nome' é obrigatório."})
 		return
 	}
 
 	cargo := model.Cargo{
-		Nome:      req.Nome,
-		EmpresaID: req.EmpresaID,
+		Nome:         req.Nome,
+		EmpresaID:    empresaID,
+		HerdaDeID:    req.HerdaDeID,
+		BatidaRemota: req.BatidaRemota,
 	}
 
 	if err := h.service.Create(&cargo); err != nil {
+		if errors.Is(err, ErrCargoBaseInvalido) || errors.Is(err, ErrBatidaRemotaInvalida) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao criar o cargo."})
 		return
 	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCargoCriado, Entidade: "cargos", EntidadeID: cargo.ID, Depois: cargo})
 
 	c.JSON(http.StatusCreated, cargo)
 }
@@ -81,15 +98,22 @@ func (h *CargoHandler) UpdateCargo(c *gin.Context) {
 		return
 	}
 
+	antes, _ := h.service.FindByID(cargoID, empresaID)
 	err = h.service.Update(cargoID, empresaID, dados)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Cargo não encontrado nesta empresa."})
 			return
 		}
+		if errors.Is(err, ErrCargoBaseInvalido) || errors.Is(err, ErrBatidaRemotaInvalida) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao atualizar o cargo."})
 		return
 	}
+	depois, _ := h.service.FindByID(cargoID, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCargoAtualizado, Entidade: "cargos", EntidadeID: cargoID, Antes: antes, Depois: depois})
 
 	c.Status(http.StatusNoContent)
 }
@@ -107,15 +131,48 @@ func (h *CargoHandler) DeleteCargo(c *gin.Context) {
 		return
 	}
 
+	antes, _ := h.service.FindByID(cargoID, empresaID)
 	err = h.service.Delete(cargoID, empresaID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Cargo não encontrado nesta empresa."})
 			return
 		}
+		if errors.Is(err, ErrCargoEmUso) || errors.Is(err, ErrCargoComUsuarios) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao deletar o cargo."})
 		return
 	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCargoExcluido, Entidade: "cargos", EntidadeID: cargoID, Antes: antes})
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CargoHandler) RestaurarCargo(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	cargoID, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do cargo inválido."})
+		return
+	}
+
+	if err := h.service.Restaurar(cargoID, empresaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nenhum cargo excluído com este ID nesta empresa."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao restaurar o cargo."})
+		return
+	}
+	depois, _ := h.service.FindByID(cargoID, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCargoRestaurado, Entidade: "cargos", EntidadeID: cargoID, Depois: depois})
 
 	c.Status(http.StatusNoContent)
 }
@@ -140,9 +197,24 @@ func (h *CargoHandler) AddPermissionToCargo(c *gin.Context) {
 		return
 	}
 
-	// AQUI ESTÁ A CORREÇÃO: Enviamos os 3 argumentos que o serviço espera.
-	err = h.service.AddPermissionToCargo(cargoID, permissaoID, empresaID)
+	// O corpo é opcional: {"escopo": "EQUIPE"} limita a permissão à equipe do usuário.
+	var request struct {
+		Escopo string `json:"escopo"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Corpo da requisição (JSON) inválido."})
+			return
+		}
+	}
+
+	antes, _ := h.service.FindByID(cargoID, empresaID)
+	err = h.service.AddPermissionToCargo(cargoID, permissaoID, empresaID, request.Escopo)
 	if err != nil {
+		if errors.Is(err, ErrEscopoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Cargo ou Permissão não encontrado."})
 			return
@@ -150,6 +222,43 @@ func (h *CargoHandler) AddPermissionToCargo(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao adicionar permissão ao cargo."})
 		return
 	}
+	depois, _ := h.service.FindByID(cargoID, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCargoPermissaoAdicionada, Entidade: "cargos", EntidadeID: cargoID, Antes: antes, Depois: depois})
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CargoHandler) RemovePermissionFromCargo(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	cargoID, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do cargo inválido."})
+		return
+	}
+
+	permissaoID, err := h.converter.StrParaUint(c.Param("permissaoId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da permissão inválido."})
+		return
+	}
+
+	antes, _ := h.service.FindByID(cargoID, empresaID)
+	err = h.service.RemovePermissionFromCargo(cargoID, permissaoID, empresaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cargo não encontrado ou a permissão não está concedida diretamente a ele."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao remover permissão do cargo."})
+		return
+	}
+	depois, _ := h.service.FindByID(cargoID, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCargoPermissaoRemovida, Entidade: "cargos", EntidadeID: cargoID, Antes: antes, Depois: depois})
 
 	c.Status(http.StatusNoContent)
 }
@@ -2,7 +2,9 @@ package cargo
 
 import (
 	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CargoRepository interface {
@@ -11,8 +13,14 @@ type CargoRepository interface {
 	GetAllByEmpresaID(empresaID uint) ([]model.Cargo, error)
 	Update(id uint, empresaID uint, dados map[string]interface{}) error
 	Delete(id uint, empresaID uint) error
-	AddPermissionToCargo(cargoID uint, permissaoID uint) error
+	AddPermissionToCargo(cargoID uint, permissaoID uint, empresaID uint, escopo string) error
 	FindByName(nome string, empresaID uint) (*model.Cargo, error)
+	RemovePermissionFromCargo(cargoID uint, permissaoID uint, empresaID uint) error
+	// CadeiaHeranca devolve o cargo e todos acima dele na herança, do próprio ao mais distante.
+	CadeiaHeranca(cargoID uint, empresaID uint) ([]uint, error)
+	ContarHerdeiros(cargoID uint, empresaID uint) (int64, error)
+	ContarUsuarios(cargoID uint, empresaID uint) (int64, error)
+	Restaurar(id uint, empresaID uint) error
 }
 
 type cargoRepository struct {
@@ -25,44 +33,105 @@ func NewCargoRepository(db *gorm.DB) CargoRepository {
 }
 
 func (r *cargoRepository) Create(cargo *model.Cargo) error {
-	return r.Db.Create(cargo).Error
+	return tenant.Escopo(r.Db, cargo.EmpresaID).Create(cargo).Error
 }
 
 func (r *cargoRepository) FindByID(id uint, empresaID uint) (*model.Cargo, error) {
 	var cargo model.Cargo
-	err := r.Db.Preload("Permissoes").Where("id = ? AND empresa_id = ?", id, empresaID).First(&cargo).Error
+	err := tenant.Escopo(r.Db, empresaID).Preload("Permissoes").Preload("Escopos").Where("id = ?", id).First(&cargo).Error
 	return &cargo, err
 }
 
 func (r *cargoRepository) GetAllByEmpresaID(empresaID uint) ([]model.Cargo, error) {
 	var cargos []model.Cargo
-	err := r.Db.Where("empresa_id = ?", empresaID).Order("id asc").Find(&cargos).Error
+	err := tenant.Escopo(r.Db, empresaID).Order("id asc").Find(&cargos).Error
 	return cargos, err
 }
 
 func (r *cargoRepository) Update(id uint, empresaID uint, dados map[string]interface{}) error {
-	return r.Db.Model(&model.Cargo{}).Where("id = ? AND empresa_id = ?", id, empresaID).Updates(dados).Error
+	return tenant.Escopo(r.Db, empresaID).Model(&model.Cargo{}).Where("id = ?", id).Updates(dados).Error
 }
 
 func (r *cargoRepository) Delete(id uint, empresaID uint) error {
-	return r.Db.Unscoped().Delete(&model.Cargo{}, "id = ? AND empresa_id = ?", id, empresaID).Error
+	return tenant.Escopo(r.Db, empresaID).Delete(&model.Cargo{}, "id = ?", id).Error
 }
 
-func (r *cargoRepository) AddPermissionToCargo(cargoID uint, permissaoID uint) error {
+func (r *cargoRepository) Restaurar(id uint, empresaID uint) error {
+	resultado := tenant.Escopo(r.Db, empresaID).Unscoped().Model(&model.Cargo{}).
+		Where("id = ? AND data_exclusao IS NOT NULL", id).
+		Update("data_exclusao", nil)
+	if resultado.Error == nil && resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return resultado.Error
+}
+
+// AddPermissionToCargo concede a permissão ao cargo no escopo informado. Se o cargo já tem a
+// permissão, apenas o escopo é atualizado.
+func (r *cargoRepository) AddPermissionToCargo(cargoID uint, permissaoID uint, empresaID uint, escopo string) error {
+	db := tenant.Escopo(r.Db, empresaID)
 	var cargo model.Cargo
 	var permissao model.Permissao
 
-	if err := r.Db.First(&cargo, cargoID).Error; err != nil {
+	if err := db.First(&cargo, cargoID).Error; err != nil {
 		return err
 	}
-	if err := r.Db.First(&permissao, permissaoID).Error; err != nil {
+	if err := db.First(&permissao, permissaoID).Error; err != nil {
 		return err
 	}
-	return r.Db.Model(&cargo).Association("Permissoes").Append(&permissao)
+	concessao := model.CargoPermissao{CargoID: cargo.ID, PermissaoID: permissao.ID, Escopo: escopo}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cargo_id"}, {Name: "permissao_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"escopo"}),
+	}).Create(&concessao).Error
 }
 
 func (r *cargoRepository) FindByName(nome string, empresaID uint) (*model.Cargo, error) {
 	var cargo model.Cargo
-	err := r.Db.Where("nome = ? AND empresa_id = ?", nome, empresaID).First(&cargo).Error
+	err := tenant.Escopo(r.Db, empresaID).Where("nome = ?", nome).First(&cargo).Error
 	return &cargo, err
 }
+
+// RemovePermissionFromCargo retira a concessão direta. Permissões herdadas de outro cargo continuam valendo.
+func (r *cargoRepository) RemovePermissionFromCargo(cargoID uint, permissaoID uint, empresaID uint) error {
+	db := tenant.Escopo(r.Db, empresaID)
+	// cargo_permissoes não tem empresa_id: o cargo é conferido na empresa antes de apagar a concessão.
+	if err := db.Select("id").First(&model.Cargo{}, cargoID).Error; err != nil {
+		return err
+	}
+	resultado := db.Where("cargo_id = ? AND permissao_id = ?", cargoID, permissaoID).Delete(&model.CargoPermissao{})
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *cargoRepository) CadeiaHeranca(cargoID uint, empresaID uint) ([]uint, error) {
+	var ids []uint
+	// UNION descarta linhas repetidas e encerra a recursão mesmo se já houver um ciclo gravado.
+	err := tenant.Escopo(r.Db, empresaID).Raw(`
+WITH RECURSIVE cadeia AS (
+	SELECT id, herda_de_id FROM cargos WHERE id = ? AND empresa_id = ?
+	UNION
+	SELECT c.id, c.herda_de_id FROM cargos c JOIN cadeia cd ON c.id = cd.herda_de_id WHERE c.empresa_id = ?
+)
+SELECT id FROM cadeia`, cargoID, empresaID, empresaID).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *cargoRepository) ContarHerdeiros(cargoID uint, empresaID uint) (int64, error) {
+	var total int64
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.Cargo{}).Where("herda_de_id = ?", cargoID).Count(&total).Error
+	return total, err
+}
+
+// ContarUsuarios conta os usuários ativos com o cargo. Usuários excluídos não contam: ao serem
+// restaurados, o serviço de usuários confere se o cargo ainda existe.
+func (r *cargoRepository) ContarUsuarios(cargoID uint, empresaID uint) (int64, error) {
+	var total int64
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.Usuario{}).Where("cargo_id = ?", cargoID).Count(&total).Error
+	return total, err
+}
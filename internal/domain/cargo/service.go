@@ -1,9 +1,21 @@
 package cargo
 
 import (
+	"errors"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/autorizacao"
+
 	"github.com/Loviiin/ponto-api-go/internal/model"
 )
 
+var (
+	ErrEscopoInvalido       = errors.New("escopo inválido: use PROPRIO, EQUIPE, DEPARTAMENTO ou EMPRESA")
+	ErrCargoBaseInvalido    = errors.New("cargo base inválido: deve ser outro cargo da empresa que não herde deste")
+	ErrCargoEmUso           = errors.New("outros cargos herdam deste cargo; altere-os antes de apagá-lo")
+	ErrCargoComUsuarios     = errors.New("há usuários com este cargo; mude-os de cargo antes de apagá-lo")
+	ErrBatidaRemotaInvalida = errors.New("regra de batida remota inválida: use PERMITIR, BLOQUEAR ou REVISAR")
+)
+
 // CargoService define a interface para os serviços de Cargo.
 type CargoService interface {
 	Create(cargo *model.Cargo) error
@@ -11,18 +23,33 @@ type CargoService interface {
 	GetAllByEmpresaID(empresaID uint) ([]model.Cargo, error)
 	Update(id uint, empresaID uint, dados map[string]interface{}) error
 	Delete(id uint, empresaID uint) error
-	AddPermissionToCargo(cargoID uint, permissaoID uint, empresaID uint) error
+	Restaurar(id uint, empresaID uint) error
+	AddPermissionToCargo(cargoID uint, permissaoID uint, empresaID uint, escopo string) error
+	RemovePermissionFromCargo(cargoID uint, permissaoID uint, empresaID uint) error
 }
 
 type cargoService struct {
-	repo CargoRepository
+	repo  CargoRepository
+	cache *autorizacao.Cache
 }
 
-func NewCargoService(repo CargoRepository) CargoService {
-	return &cargoService{repo: repo}
+// NewCargoService recebe o cache de permissões efetivas, invalidado a cada mudança nos cargos da empresa.
+func NewCargoService(repo CargoRepository, cache *autorizacao.Cache) CargoService {
+	return &cargoService{repo: repo, cache: cache}
 }
 
 func (s *cargoService) Create(cargo *model.Cargo) error {
+	if cargo.BatidaRemota == "" {
+		cargo.BatidaRemota = model.BatidaRemotaPermitir
+	}
+	if !model.BatidaRemotaValida(cargo.BatidaRemota) {
+		return ErrBatidaRemotaInvalida
+	}
+	if cargo.HerdaDeID != nil {
+		if err := s.validarBase(0, *cargo.HerdaDeID, cargo.EmpresaID); err != nil {
+			return err
+		}
+	}
 	return s.repo.Create(cargo)
 }
 
@@ -39,7 +66,44 @@ func (s *cargoService) Update(id uint, empresaID uint, dados map[string]interfac
 	if err != nil {
 		return err // Retorna o erro (ex: not found)
 	}
-	return s.repo.Update(id, empresaID, dados)
+	if valor, informado := dados["herda_de_id"]; informado && valor != nil {
+		baseID, ok := valor.(float64)
+		if !ok || baseID < 1 || baseID != float64(uint(baseID)) {
+			return ErrCargoBaseInvalido
+		}
+		if err := s.validarBase(id, uint(baseID), empresaID); err != nil {
+			return err
+		}
+		dados["herda_de_id"] = uint(baseID)
+	}
+	if valor, informado := dados["batida_remota"]; informado {
+		if regra, ok := valor.(string); !ok || !model.BatidaRemotaValida(regra) {
+			return ErrBatidaRemotaInvalida
+		}
+	}
+	if err := s.repo.Update(id, empresaID, dados); err != nil {
+		return err
+	}
+	s.cache.InvalidarEmpresa(empresaID)
+	return nil
+}
+
+// validarBase confere que o cargo base é da mesma empresa e que a herança não forma um ciclo,
+// ou seja, que o próprio cargo não aparece na cadeia acima da nova base.
+func (s *cargoService) validarBase(id uint, baseID uint, empresaID uint) error {
+	cadeia, err := s.repo.CadeiaHeranca(baseID, empresaID)
+	if err != nil {
+		return err
+	}
+	if len(cadeia) == 0 {
+		return ErrCargoBaseInvalido
+	}
+	for _, ancestral := range cadeia {
+		if id != 0 && ancestral == id {
+			return ErrCargoBaseInvalido
+		}
+	}
+	return nil
 }
 
 func (s *cargoService) Delete(id uint, empresaID uint) error {
@@ -47,14 +111,63 @@ func (s *cargoService) Delete(id uint, empresaID uint) error {
 	if err != nil {
 		return err
 	}
-	return s.repo.Delete(id, empresaID)
+	herdeiros, err := s.repo.ContarHerdeiros(id, empresaID)
+	if err != nil {
+		return err
+	}
+	if herdeiros > 0 {
+		return ErrCargoEmUso
+	}
+	usuarios, err := s.repo.ContarUsuarios(id, empresaID)
+	if err != nil {
+		return err
+	}
+	if usuarios > 0 {
+		return ErrCargoComUsuarios
+	}
+	if err := s.repo.Delete(id, empresaID); err != nil {
+		return err
+	}
+	s.cache.InvalidarEmpresa(empresaID)
+	return nil
 }
 
-func (s *cargoService) AddPermissionToCargo(cargoID uint, permissaoID uint, empresaID uint) error {
+func (s *cargoService) Restaurar(id uint, empresaID uint) error {
+	if err := s.repo.Restaurar(id, empresaID); err != nil {
+		return err
+	}
+	s.cache.InvalidarEmpresa(empresaID)
+	return nil
+}
+
+// AddPermissionToCargo concede a permissão ao cargo. Sem escopo informado, a permissão vale
+// para a empresa inteira, como antes da existência dos escopos.
+func (s *cargoService) AddPermissionToCargo(cargoID uint, permissaoID uint, empresaID uint, escopo string) error {
+	if escopo == "" {
+		escopo = model.EscopoEmpresa
+	}
+	if !model.EscopoValido(escopo) {
+		return ErrEscopoInvalido
+	}
 
 	_, err := s.repo.FindByID(cargoID, empresaID)
 	if err != nil {
 		return err
 	}
-	return s.repo.AddPermissionToCargo(cargoID, permissaoID)
+	if err := s.repo.AddPermissionToCargo(cargoID, permissaoID, empresaID, escopo); err != nil {
+		return err
+	}
+	s.cache.InvalidarEmpresa(empresaID)
+	return nil
+}
+
+func (s *cargoService) RemovePermissionFromCargo(cargoID uint, permissaoID uint, empresaID uint) error {
+	if _, err := s.repo.FindByID(cargoID, empresaID); err != nil {
+		return err
+	}
+	if err := s.repo.RemovePermissionFromCargo(cargoID, permissaoID, empresaID); err != nil {
+		return err
+	}
+	s.cache.InvalidarEmpresa(empresaID)
+	return nil
 }
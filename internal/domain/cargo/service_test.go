@@ -0,0 +1,135 @@
+package cargo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+// memoriaCargoRepository implementa só o necessário para a herança; o resto vem da interface embutida.
+type memoriaCargoRepository struct {
+	CargoRepository
+	cargos map[uint]*model.Cargo
+	// usuarios conta os usuários de cada cargo.
+	usuarios map[uint]int64
+}
+
+func (m *memoriaCargoRepository) Create(cargo *model.Cargo) error {
+	cargo.ID = uint(len(m.cargos) + 1)
+	m.cargos[cargo.ID] = cargo
+	return nil
+}
+
+func (m *memoriaCargoRepository) FindByID(id uint, empresaID uint) (*model.Cargo, error) {
+	if c, ok := m.cargos[id]; ok && c.EmpresaID == empresaID {
+		return c, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaCargoRepository) Update(id uint, empresaID uint, dados map[string]interface{}) error {
+	if base, ok := dados["herda_de_id"].(uint); ok {
+		m.cargos[id].HerdaDeID = &base
+	}
+	return nil
+}
+
+func (m *memoriaCargoRepository) Delete(id uint, empresaID uint) error {
+	delete(m.cargos, id)
+	return nil
+}
+
+func (m *memoriaCargoRepository) CadeiaHeranca(cargoID uint, empresaID uint) ([]uint, error) {
+	var cadeia []uint
+	for atual, ok := m.cargos[cargoID]; ok && atual.EmpresaID == empresaID; {
+		cadeia = append(cadeia, atual.ID)
+		if atual.HerdaDeID == nil {
+			break
+		}
+		atual, ok = m.cargos[*atual.HerdaDeID]
+	}
+	return cadeia, nil
+}
+
+func (m *memoriaCargoRepository) ContarHerdeiros(cargoID uint, empresaID uint) (int64, error) {
+	var total int64
+	for _, c := range m.cargos {
+		if c.HerdaDeID != nil && *c.HerdaDeID == cargoID {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (m *memoriaCargoRepository) ContarUsuarios(cargoID uint, empresaID uint) (int64, error) {
+	return m.usuarios[cargoID], nil
+}
+
+// novaHierarquia cria Funcionário (1) <- Gestor (2) <- Diretor (3) na empresa 1.
+func novaHierarquia(t *testing.T) (CargoService, *memoriaCargoRepository) {
+	t.Helper()
+	repo := &memoriaCargoRepository{cargos: map[uint]*model.Cargo{}}
+	service := NewCargoService(repo, nil)
+	var base *uint
+	for _, nome := range []string{"Funcionário", "Gestor", "Diretor"} {
+		cargo := &model.Cargo{Nome: nome, EmpresaID: 1, HerdaDeID: base}
+		if err := service.Create(cargo); err != nil {
+			t.Fatalf("Erro inesperado ao criar %s: %v", nome, err)
+		}
+		id := cargo.ID
+		base = &id
+	}
+	return service, repo
+}
+
+func TestUpdate_HerancaSemCiclo(t *testing.T) {
+	service, _ := novaHierarquia(t)
+
+	// Funcionário herdar de Diretor fecharia um ciclo; herdar de si mesmo também; 1.5 não é um ID.
+	for _, base := range []interface{}{float64(3), float64(1), float64(1.5)} {
+		err := service.Update(1, 1, map[string]interface{}{"herda_de_id": base})
+		if !errors.Is(err, ErrCargoBaseInvalido) {
+			t.Errorf("Base %v: esperava ErrCargoBaseInvalido, recebeu %v", base, err)
+		}
+	}
+
+	// Diretor pode passar a herdar direto de Funcionário.
+	if err := service.Update(3, 1, map[string]interface{}{"herda_de_id": float64(1)}); err != nil {
+		t.Errorf("Erro inesperado ao trocar a base: %v", err)
+	}
+}
+
+func TestCreate_BaseDeOutraEmpresa(t *testing.T) {
+	service, _ := novaHierarquia(t)
+
+	base := uint(1)
+	err := service.Create(&model.Cargo{Nome: "Intruso", EmpresaID: 2, HerdaDeID: &base})
+	if !errors.Is(err, ErrCargoBaseInvalido) {
+		t.Fatalf("Esperava ErrCargoBaseInvalido, recebeu %v", err)
+	}
+}
+
+func TestDelete_CargoComHerdeiros(t *testing.T) {
+	service, _ := novaHierarquia(t)
+
+	if err := service.Delete(2, 1); !errors.Is(err, ErrCargoEmUso) {
+		t.Errorf("Esperava ErrCargoEmUso, recebeu %v", err)
+	}
+	if err := service.Delete(3, 1); err != nil {
+		t.Errorf("Erro inesperado ao apagar cargo sem herdeiros: %v", err)
+	}
+}
+
+func TestDelete_CargoComUsuarios(t *testing.T) {
+	service, repo := novaHierarquia(t)
+	repo.usuarios = map[uint]int64{3: 2}
+
+	if err := service.Delete(3, 1); !errors.Is(err, ErrCargoComUsuarios) {
+		t.Errorf("Esperava ErrCargoComUsuarios, recebeu %v", err)
+	}
+	if _, ok := repo.cargos[3]; !ok {
+		t.Error("O cargo com usuários não deveria ter sido apagado")
+	}
+}
@@ -0,0 +1,143 @@
+package centrocusto
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service   CentroCustoService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s CentroCustoService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+type centroCustoRequest struct {
+	Codigo string `json:"codigo" binding:"required"`
+	Nome   string `json:"nome" binding:"required"`
+	Ativo  *bool  `json:"ativo"`
+}
+
+func (r centroCustoRequest) ativo() bool {
+	return r.Ativo == nil || *r.Ativo
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var req centroCustoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'codigo' e 'nome' são obrigatórios."})
+		return
+	}
+
+	centro := model.CentroCusto{EmpresaID: empresaID, Codigo: req.Codigo, Nome: req.Nome, Ativo: req.ativo()}
+	if err := h.service.Create(&centro); err != nil {
+		responderErro(c, err, "Falha ao criar o centro de custo.")
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCentroCustoCriado, Entidade: "centros-custo", EntidadeID: centro.ID, Depois: centro})
+	c.JSON(http.StatusCreated, centro)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	centros, err := h.service.GetAllByEmpresaID(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar os centros de custo."})
+		return
+	}
+	c.JSON(http.StatusOK, centros)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	centro, err := h.service.FindByID(id, empresaID)
+	if err != nil {
+		responderErro(c, err, "Falha ao buscar o centro de custo.")
+		return
+	}
+	c.JSON(http.StatusOK, centro)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	var req centroCustoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'codigo' e 'nome' são obrigatórios."})
+		return
+	}
+
+	centro := model.CentroCusto{ID: id, EmpresaID: empresaID, Codigo: req.Codigo, Nome: req.Nome, Ativo: req.ativo()}
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Update(&centro); err != nil {
+		responderErro(c, err, "Falha ao atualizar o centro de custo.")
+		return
+	}
+	depois, _ := h.service.FindByID(id, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCentroCustoAtualizado, Entidade: "centros-custo", EntidadeID: id, Antes: antes, Depois: depois})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Delete(id, empresaID); err != nil {
+		responderErro(c, err, "Falha ao deletar o centro de custo.")
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoCentroCustoExcluido, Entidade: "centros-custo", EntidadeID: id, Antes: antes})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ids(c *gin.Context) (uint, uint, bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do centro de custo inválido."})
+		return 0, 0, false
+	}
+	return empresaID, id, true
+}
+
+func responderErro(c *gin.Context, err error, mensagemPadrao string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Centro de custo não encontrado nesta empresa."})
+	case errors.Is(err, ErrCodigoDuplicado), errors.Is(err, ErrCentroCustoEmUso):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": mensagemPadrao})
+	}
+}
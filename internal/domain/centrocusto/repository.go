@@ -0,0 +1,63 @@
+package centrocusto
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type CentroCustoRepository interface {
+	Create(centro *model.CentroCusto) error
+	FindByID(id uint, empresaID uint) (*model.CentroCusto, error)
+	FindByCodigo(codigo string, empresaID uint) (*model.CentroCusto, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.CentroCusto, error)
+	Update(centro *model.CentroCusto) error
+	Delete(id uint, empresaID uint) error
+	ContarUsuarios(id uint, empresaID uint) (int64, error)
+}
+
+type centroCustoRepository struct {
+	Db *gorm.DB
+}
+
+func NewCentroCustoRepository(db *gorm.DB) CentroCustoRepository {
+	return &centroCustoRepository{Db: db}
+}
+
+func (r *centroCustoRepository) Create(centro *model.CentroCusto) error {
+	return tenant.Escopo(r.Db, centro.EmpresaID).Create(centro).Error
+}
+
+func (r *centroCustoRepository) FindByID(id uint, empresaID uint) (*model.CentroCusto, error) {
+	var centro model.CentroCusto
+	err := tenant.Escopo(r.Db, empresaID).Where("id = ?", id).First(&centro).Error
+	return &centro, err
+}
+
+func (r *centroCustoRepository) FindByCodigo(codigo string, empresaID uint) (*model.CentroCusto, error) {
+	var centro model.CentroCusto
+	err := tenant.Escopo(r.Db, empresaID).Where("codigo = ?", codigo).First(&centro).Error
+	return &centro, err
+}
+
+func (r *centroCustoRepository) GetAllByEmpresaID(empresaID uint) ([]model.CentroCusto, error) {
+	var centros []model.CentroCusto
+	err := tenant.Escopo(r.Db, empresaID).Order("codigo asc").Find(&centros).Error
+	return centros, err
+}
+
+func (r *centroCustoRepository) Update(centro *model.CentroCusto) error {
+	return tenant.Escopo(r.Db, centro.EmpresaID).Model(&model.CentroCusto{}).
+		Where("id = ?", centro.ID).
+		Updates(map[string]interface{}{"codigo": centro.Codigo, "nome": centro.Nome, "ativo": centro.Ativo}).Error
+}
+
+func (r *centroCustoRepository) Delete(id uint, empresaID uint) error {
+	return tenant.Escopo(r.Db, empresaID).Delete(&model.CentroCusto{}, "id = ?", id).Error
+}
+
+func (r *centroCustoRepository) ContarUsuarios(id uint, empresaID uint) (int64, error) {
+	var total int64
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.Usuario{}).Where("centro_custo_id = ?", id).Count(&total).Error
+	return total, err
+}
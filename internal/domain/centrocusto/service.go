@@ -0,0 +1,84 @@
+package centrocusto
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCodigoDuplicado  = errors.New("já existe um centro de custo com este código")
+	ErrCentroCustoEmUso = errors.New("o centro de custo tem funcionários; desative-o em vez de removê-lo")
+)
+
+type CentroCustoService interface {
+	Create(centro *model.CentroCusto) error
+	FindByID(id uint, empresaID uint) (*model.CentroCusto, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.CentroCusto, error)
+	Update(centro *model.CentroCusto) error
+	Delete(id uint, empresaID uint) error
+}
+
+type centroCustoService struct {
+	repo CentroCustoRepository
+}
+
+func NewCentroCustoService(repo CentroCustoRepository) CentroCustoService {
+	return &centroCustoService{repo: repo}
+}
+
+func (s *centroCustoService) Create(centro *model.CentroCusto) error {
+	centro.Codigo = strings.TrimSpace(centro.Codigo)
+	if err := s.validarCodigo(centro); err != nil {
+		return err
+	}
+	return s.repo.Create(centro)
+}
+
+func (s *centroCustoService) FindByID(id uint, empresaID uint) (*model.CentroCusto, error) {
+	return s.repo.FindByID(id, empresaID)
+}
+
+func (s *centroCustoService) GetAllByEmpresaID(empresaID uint) ([]model.CentroCusto, error) {
+	return s.repo.GetAllByEmpresaID(empresaID)
+}
+
+func (s *centroCustoService) Update(centro *model.CentroCusto) error {
+	if _, err := s.repo.FindByID(centro.ID, centro.EmpresaID); err != nil {
+		return err
+	}
+	centro.Codigo = strings.TrimSpace(centro.Codigo)
+	if err := s.validarCodigo(centro); err != nil {
+		return err
+	}
+	return s.repo.Update(centro)
+}
+
+// Delete só remove centros de custo sem funcionários; os demais devem ser desativados,
+// preservando o histórico dos relatórios.
+func (s *centroCustoService) Delete(id uint, empresaID uint) error {
+	if _, err := s.repo.FindByID(id, empresaID); err != nil {
+		return err
+	}
+	usuarios, err := s.repo.ContarUsuarios(id, empresaID)
+	if err != nil {
+		return err
+	}
+	if usuarios > 0 {
+		return ErrCentroCustoEmUso
+	}
+	return s.repo.Delete(id, empresaID)
+}
+
+func (s *centroCustoService) validarCodigo(centro *model.CentroCusto) error {
+	existente, err := s.repo.FindByCodigo(centro.Codigo, centro.EmpresaID)
+	if err == nil && existente.ID != centro.ID {
+		return ErrCodigoDuplicado
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package chaveapi
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service   ChaveAPIService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s ChaveAPIService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	criadorID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Chaves de API só podem ser criadas por usuários."})
+		return
+	}
+
+	type createRequest struct {
+		Nome     string     `json:"nome" binding:"required"`
+		Escopos  []string   `json:"escopos" binding:"required,min=1"`
+		ExpiraEm *time.Time `json:"expira_em"`
+	}
+	var req createRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'nome' e 'escopos' são obrigatórios."})
+		return
+	}
+	if req.ExpiraEm != nil && req.ExpiraEm.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "'expira_em' deve estar no futuro."})
+		return
+	}
+
+	chave, textoChave, err := h.service.Criar(empresaID, criadorID, req.Nome, req.Escopos, req.ExpiraEm)
+	if err != nil {
+		if errors.Is(err, ErrEscopoInvalido) || errors.Is(err, ErrEscopoNaoPermitido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao criar a chave de API."})
+		return
+	}
+
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoChaveAPICriada, Entidade: "chaves-api", EntidadeID: chave.ID, Depois: chave})
+
+	// A chave completa só é devolvida aqui; depois disso apenas o prefixo fica visível.
+	c.JSON(http.StatusCreated, gin.H{"chave": textoChave, "dados": chave})
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	chaves, err := h.service.Listar(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar as chaves de API."})
+		return
+	}
+	c.JSON(http.StatusOK, chaves)
+}
+
+func (h *Handler) Revoke(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da chave inválido."})
+		return
+	}
+
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Revogar(id, empresaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Chave não encontrada ou já revogada."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao revogar a chave de API."})
+		return
+	}
+	depois, _ := h.service.FindByID(id, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoChaveAPIRevogada, Entidade: "chaves-api", EntidadeID: id, Antes: antes, Depois: depois})
+	c.Status(http.StatusNoContent)
+}
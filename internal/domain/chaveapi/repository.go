@@ -0,0 +1,76 @@
+package chaveapi
+
+import (
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type ChaveAPIRepository interface {
+	Create(chave *model.ChaveAPI) error
+	// FindByPrefixo procura em todas as empresas: é ela que descobre a empresa de uma chave recebida.
+	// Chaves de empresas excluídas não são encontradas; voltam a valer se a empresa for restaurada.
+	FindByPrefixo(prefixo string) (*model.ChaveAPI, error)
+	FindByID(id uint, empresaID uint) (*model.ChaveAPI, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.ChaveAPI, error)
+	Revogar(id uint, empresaID uint, momento time.Time) error
+	RegistrarUso(id uint, empresaID uint, momento time.Time) error
+	FindPermissoesByNome(nomes []string) ([]model.Permissao, error)
+}
+
+type chaveAPIRepository struct {
+	Db *gorm.DB
+}
+
+func NewChaveAPIRepository(db *gorm.DB) ChaveAPIRepository {
+	return &chaveAPIRepository{Db: db}
+}
+
+func (r *chaveAPIRepository) Create(chave *model.ChaveAPI) error {
+	return tenant.Escopo(r.Db, chave.EmpresaID).Create(chave).Error
+}
+
+func (r *chaveAPIRepository) FindByPrefixo(prefixo string) (*model.ChaveAPI, error) {
+	var chave model.ChaveAPI
+	err := tenant.Plataforma(r.Db).Preload("Escopos").Where("prefixo = ?", prefixo).
+		Where("EXISTS (SELECT 1 FROM empresas WHERE empresas.id = chave_apis.empresa_id AND empresas.data_exclusao IS NULL)").
+		First(&chave).Error
+	return &chave, err
+}
+
+func (r *chaveAPIRepository) FindByID(id uint, empresaID uint) (*model.ChaveAPI, error) {
+	var chave model.ChaveAPI
+	err := tenant.Escopo(r.Db, empresaID).Preload("Escopos").Where("id = ?", id).First(&chave).Error
+	return &chave, err
+}
+
+func (r *chaveAPIRepository) GetAllByEmpresaID(empresaID uint) ([]model.ChaveAPI, error) {
+	var chaves []model.ChaveAPI
+	err := tenant.Escopo(r.Db, empresaID).Preload("Escopos").Order("id asc").Find(&chaves).Error
+	return chaves, err
+}
+
+func (r *chaveAPIRepository) Revogar(id uint, empresaID uint, momento time.Time) error {
+	resultado := tenant.Escopo(r.Db, empresaID).Model(&model.ChaveAPI{}).
+		Where("id = ? AND revogada_em IS NULL", id).
+		Update("revogada_em", momento)
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *chaveAPIRepository) RegistrarUso(id uint, empresaID uint, momento time.Time) error {
+	return tenant.Escopo(r.Db, empresaID).Model(&model.ChaveAPI{}).Where("id = ?", id).Update("ultimo_uso_em", momento).Error
+}
+
+func (r *chaveAPIRepository) FindPermissoesByNome(nomes []string) ([]model.Permissao, error) {
+	var permissoes []model.Permissao
+	err := r.Db.Where("nome IN ?", nomes).Find(&permissoes).Error
+	return permissoes, err
+}
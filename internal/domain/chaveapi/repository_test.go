@@ -0,0 +1,48 @@
+package chaveapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// novoBanco monta um GORM em modo DryRun, como em pkg/tenant: as consultas são geradas, mas nunca
+// enviadas ao Postgres.
+func novoBanco(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("Falha ao abrir o banco: %v", err)
+	}
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatalf("Falha ao instalar o plugin: %v", err)
+	}
+	return db
+}
+
+func TestFindByPrefixo_IgnoraEmpresasExcluidas(t *testing.T) {
+	db := novoBanco(t)
+	var sql string
+	db.Callback().Query().After("gorm:query").Register("teste:sql", func(tx *gorm.DB) {
+		if tx.Statement.Table == "chave_apis" {
+			sql = tx.Statement.SQL.String()
+		}
+	})
+
+	NewChaveAPIRepository(db).FindByPrefixo("abc123")
+	if !strings.Contains(sql, "empresas.data_exclusao IS NULL") {
+		t.Errorf("A busca da chave deveria ignorar empresas excluídas: %s", sql)
+	}
+	if !strings.Contains(sql, "prefixo = $1") {
+		t.Errorf("A busca da chave deveria filtrar pelo prefixo: %s", sql)
+	}
+}
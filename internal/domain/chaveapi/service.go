@@ -0,0 +1,127 @@
+package chaveapi
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/credencial"
+	"gorm.io/gorm"
+)
+
+// As chaves têm o formato "ponto_<prefixo>_<segredo>". O prefixo é público e indexado;
+// o segredo só é mostrado uma vez, na criação.
+const tipoChave = "ponto"
+
+var (
+	ErrChaveInvalida      = errors.New("chave de API inválida")
+	ErrChaveExpirada      = errors.New("chave de API expirada ou revogada")
+	ErrEscopoInvalido     = errors.New("escopo desconhecido")
+	ErrEscopoNaoPermitido = errors.New("não é possível conceder a uma chave um escopo que o seu cargo não possui")
+)
+
+type ChaveAPIService interface {
+	Criar(empresaID uint, criadorID uint, nome string, escopos []string, expiraEm *time.Time) (*model.ChaveAPI, string, error)
+	Listar(empresaID uint) ([]model.ChaveAPI, error)
+	FindByID(id uint, empresaID uint) (*model.ChaveAPI, error)
+	Revogar(id uint, empresaID uint) error
+	Validar(chave string) (*model.ChaveAPI, error)
+}
+
+type chaveAPIService struct {
+	repo        ChaveAPIRepository
+	usuarioRepo usuario.UsuarioRepository
+}
+
+func NewChaveAPIService(repo ChaveAPIRepository, usuarioRepo usuario.UsuarioRepository) ChaveAPIService {
+	return &chaveAPIService{
+		repo:        repo,
+		usuarioRepo: usuarioRepo,
+	}
+}
+
+func (s *chaveAPIService) Criar(empresaID uint, criadorID uint, nome string, escopos []string, expiraEm *time.Time) (*model.ChaveAPI, string, error) {
+	criador, err := s.usuarioRepo.FindByID(criadorID, empresaID)
+	if err != nil {
+		return nil, "", err
+	}
+	// A chave age sobre a empresa inteira, então só pode receber permissões que o criador
+	// tem com escopo de empresa (e não apenas sobre a própria equipe, por exemplo).
+	for _, escopo := range escopos {
+		if alcance, concedida := criador.Cargo.EscopoPermissao(escopo); !concedida || alcance != model.EscopoEmpresa {
+			return nil, "", ErrEscopoNaoPermitido
+		}
+	}
+
+	permissoes, err := s.repo.FindPermissoesByNome(escopos)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(permissoes) != len(escopos) {
+		return nil, "", ErrEscopoInvalido
+	}
+
+	textoChave, prefixo, hash, err := credencial.Gerar(tipoChave)
+	if err != nil {
+		return nil, "", err
+	}
+
+	chave := &model.ChaveAPI{
+		EmpresaID:   empresaID,
+		Nome:        nome,
+		Prefixo:     prefixo,
+		Hash:        hash,
+		Escopos:     permissoes,
+		CriadoPorID: criadorID,
+		ExpiraEm:    expiraEm,
+	}
+	if err := s.repo.Create(chave); err != nil {
+		return nil, "", err
+	}
+	return chave, textoChave, nil
+}
+
+func (s *chaveAPIService) Listar(empresaID uint) ([]model.ChaveAPI, error) {
+	return s.repo.GetAllByEmpresaID(empresaID)
+}
+
+func (s *chaveAPIService) FindByID(id uint, empresaID uint) (*model.ChaveAPI, error) {
+	return s.repo.FindByID(id, empresaID)
+}
+
+func (s *chaveAPIService) Revogar(id uint, empresaID uint) error {
+	return s.repo.Revogar(id, empresaID, time.Now())
+}
+
+// Validar confere a chave recebida e devolve o registro com os escopos carregados.
+func (s *chaveAPIService) Validar(textoChave string) (*model.ChaveAPI, error) {
+	prefixo, ok := credencial.Prefixo(tipoChave, textoChave)
+	if !ok {
+		return nil, ErrChaveInvalida
+	}
+
+	chave, err := s.repo.FindByPrefixo(prefixo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChaveInvalida
+		}
+		return nil, err
+	}
+	if !credencial.Confere(textoChave, chave.Hash) {
+		return nil, ErrChaveInvalida
+	}
+
+	agora := time.Now()
+	if chave.RevogadaEm != nil || (chave.ExpiraEm != nil && agora.After(*chave.ExpiraEm)) {
+		return nil, ErrChaveExpirada
+	}
+
+	if credencial.DeveRegistrarUso(chave.UltimoUsoEm, agora) {
+		if err := s.repo.RegistrarUso(chave.ID, chave.EmpresaID, agora); err != nil {
+			return nil, err
+		}
+		chave.UltimoUsoEm = &agora
+	}
+	return chave, nil
+}
@@ -0,0 +1,142 @@
+package chaveapi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+	"gorm.io/gorm"
+)
+
+type memoriaChaveAPIRepository struct {
+	chaves []*model.ChaveAPI
+}
+
+func (m *memoriaChaveAPIRepository) Create(chave *model.ChaveAPI) error {
+	chave.ID = uint(len(m.chaves) + 1)
+	m.chaves = append(m.chaves, chave)
+	return nil
+}
+
+func (m *memoriaChaveAPIRepository) FindByPrefixo(prefixo string) (*model.ChaveAPI, error) {
+	for _, c := range m.chaves {
+		if c.Prefixo == prefixo {
+			return c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaChaveAPIRepository) FindByID(id uint, empresaID uint) (*model.ChaveAPI, error) {
+	for _, c := range m.chaves {
+		if c.ID == id && c.EmpresaID == empresaID {
+			return c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaChaveAPIRepository) GetAllByEmpresaID(empresaID uint) ([]model.ChaveAPI, error) {
+	return nil, nil
+}
+
+func (m *memoriaChaveAPIRepository) Revogar(id uint, empresaID uint, momento time.Time) error {
+	for _, c := range m.chaves {
+		if c.ID == id && c.EmpresaID == empresaID {
+			c.RevogadaEm = &momento
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *memoriaChaveAPIRepository) RegistrarUso(id uint, empresaID uint, momento time.Time) error {
+	return nil
+}
+
+func (m *memoriaChaveAPIRepository) FindPermissoesByNome(nomes []string) ([]model.Permissao, error) {
+	permissoes := make([]model.Permissao, 0, len(nomes))
+	for _, nome := range nomes {
+		if nome == permissions.VER_SALDO_FUNCIONARIOS || nome == permissions.EDITAR_USUARIO {
+			permissoes = append(permissoes, model.Permissao{Nome: nome})
+		}
+	}
+	return permissoes, nil
+}
+
+type mockUsuarioRepository struct {
+	usuario.UsuarioRepository
+}
+
+func (m *mockUsuarioRepository) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
+	return &model.Usuario{ID: id, EmpresaID: empresaID, Cargo: model.Cargo{
+		Permissoes: []model.Permissao{{ID: 1, Nome: permissions.VER_SALDO_FUNCIONARIOS}, {ID: 2, Nome: permissions.EDITAR_USUARIO}},
+		Escopos:    []model.CargoPermissao{{PermissaoID: 1, Escopo: model.EscopoEmpresa}, {PermissaoID: 2, Escopo: model.EscopoEquipe}},
+	}}, nil
+}
+
+func TestCriarEValidarChave(t *testing.T) {
+	repo := &memoriaChaveAPIRepository{}
+	service := NewChaveAPIService(repo, &mockUsuarioRepository{})
+
+	chave, textoChave, err := service.Criar(3, 1, "folha", []string{permissions.VER_SALDO_FUNCIONARIOS}, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado ao criar chave: %v", err)
+	}
+	if !strings.HasPrefix(textoChave, "ponto_"+chave.Prefixo+"_") {
+		t.Errorf("Chave não começa com o prefixo identificável: %s", textoChave)
+	}
+	if strings.Contains(chave.Hash, textoChave) || chave.Hash == "" {
+		t.Errorf("A chave deveria ser guardada apenas como hash")
+	}
+
+	validada, err := service.Validar(textoChave)
+	if err != nil {
+		t.Fatalf("Erro inesperado ao validar chave: %v", err)
+	}
+	if validada.EmpresaID != 3 || validada.UltimoUsoEm == nil {
+		t.Errorf("Chave validada incorreta: %+v", validada)
+	}
+
+	if _, err := service.Validar(textoChave + "x"); !errors.Is(err, ErrChaveInvalida) {
+		t.Errorf("Esperava chave inválida com segredo alterado, recebeu: %v", err)
+	}
+}
+
+func TestValidar_ChaveRevogadaOuExpirada(t *testing.T) {
+	repo := &memoriaChaveAPIRepository{}
+	service := NewChaveAPIService(repo, &mockUsuarioRepository{})
+
+	chave, textoChave, _ := service.Criar(3, 1, "revogada", []string{permissions.VER_SALDO_FUNCIONARIOS}, nil)
+	if err := service.Revogar(chave.ID, 3); err != nil {
+		t.Fatalf("Erro inesperado ao revogar: %v", err)
+	}
+	if _, err := service.Validar(textoChave); !errors.Is(err, ErrChaveExpirada) {
+		t.Errorf("Esperava chave revogada, recebeu: %v", err)
+	}
+
+	passado := time.Now().Add(-time.Hour)
+	_, textoExpirada, _ := service.Criar(3, 1, "expirada", []string{permissions.VER_SALDO_FUNCIONARIOS}, &passado)
+	if _, err := service.Validar(textoExpirada); !errors.Is(err, ErrChaveExpirada) {
+		t.Errorf("Esperava chave expirada, recebeu: %v", err)
+	}
+}
+
+func TestCriar_EscopoForaDoCargo(t *testing.T) {
+	service := NewChaveAPIService(&memoriaChaveAPIRepository{}, &mockUsuarioRepository{})
+
+	_, _, err := service.Criar(3, 1, "abuso", []string{permissions.DELETAR_EMPRESA}, nil)
+	if !errors.Is(err, ErrEscopoNaoPermitido) {
+		t.Fatalf("Esperava escopo não permitido, recebeu: %v", err)
+	}
+
+	// O criador só pode editar usuários da própria equipe; a chave valeria para a empresa toda.
+	_, _, err = service.Criar(3, 1, "abuso", []string{permissions.EDITAR_USUARIO}, nil)
+	if !errors.Is(err, ErrEscopoNaoPermitido) {
+		t.Fatalf("Esperava escopo não permitido para permissão de equipe, recebeu: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package convite
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service   ConviteService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s ConviteService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	type criarConviteRequest struct {
+		Email   string `json:"email" binding:"required,email"`
+		Nome    string `json:"nome"`
+		CargoID uint   `json:"cargo_id" binding:"required"`
+	}
+	var request criarConviteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Convites só podem ser enviados por um usuário."})
+		return
+	}
+
+	convite, err := h.service.Convidar(empresaID, userID, request.Email, request.Nome, request.CargoID)
+	if err != nil {
+		responderErro(c, err, "Falha ao enviar o convite.")
+		return
+	}
+	c.JSON(http.StatusCreated, convite)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	convites, err := h.service.Listar(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar convites."})
+		return
+	}
+	c.JSON(http.StatusOK, convites)
+}
+
+func (h *Handler) Cancel(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do convite inválido."})
+		return
+	}
+	if err := h.service.Cancelar(id, empresaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Convite não encontrado ou já utilizado."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao cancelar o convite."})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Consultar é público: a tela de aceite usa o token do link para mostrar e-mail e nome do convite.
+func (h *Handler) Consultar(c *gin.Context) {
+	convite, err := h.service.Consultar(c.Query("token"))
+	if err != nil {
+		responderErro(c, err, "Falha ao consultar o convite.")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"email":     convite.Email,
+		"nome":      convite.Nome,
+		"expira_em": convite.ExpiraEm,
+	})
+}
+
+// Aceitar é público: o token assinado é a única credencial do convidado.
+func (h *Handler) Aceitar(c *gin.Context) {
+	type aceitarRequest struct {
+		Token string `json:"token" binding:"required"`
+		Nome  string `json:"nome"`
+		Senha string `json:"senha" binding:"required,min=6"`
+	}
+	var request aceitarRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	usuario, err := h.service.Aceitar(request.Token, request.Nome, request.Senha)
+	if err != nil {
+		responderErro(c, err, "Falha ao aceitar o convite.")
+		return
+	}
+	c.JSON(http.StatusCreated, usuario)
+}
+
+// SolicitarCadastro é público e só funciona para empresas com o cadastro público ativado. Cada IP
+// tem um limite de pedidos; acima dele a resposta é 429.
+func (h *Handler) SolicitarCadastro(c *gin.Context) {
+	type solicitarRequest struct {
+		Nome  string `json:"nome" binding:"required"`
+		Email string `json:"email" binding:"required,email"`
+	}
+	var request solicitarRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	empresaID, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O ID da empresa deve ser um número válido"})
+		return
+	}
+
+	if err := h.service.SolicitarCadastro(empresaID, request.Nome, request.Email, c.ClientIP()); err != nil {
+		var bloqueio *auth.BloqueioError
+		if errors.As(err, &bloqueio) {
+			segundos := math.Ceil(time.Until(bloqueio.Ate).Seconds())
+			c.Header("Retry-After", strconv.Itoa(int(math.Max(segundos, 1))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Muitos pedidos de cadastro. Tente novamente mais tarde."})
+			return
+		}
+		responderErro(c, err, "Falha ao registrar o pedido de cadastro.")
+		return
+	}
+	// A mesma resposta para pedidos registrados e ignorados, para não revelar e-mails cadastrados.
+	c.JSON(http.StatusAccepted, gin.H{"mensagem": "Pedido recebido. Se for aprovado, você receberá um convite por e-mail."})
+}
+
+func (h *Handler) GetSolicitacoes(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	solicitacoes, err := h.service.ListarSolicitacoes(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar solicitações."})
+		return
+	}
+	c.JSON(http.StatusOK, solicitacoes)
+}
+
+func (h *Handler) AprovarSolicitacao(c *gin.Context) {
+	type aprovarRequest struct {
+		CargoID uint `json:"cargo_id"`
+	}
+	var request aprovarRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	empresaID, userID, id, ok := h.dadosAvaliacao(c)
+	if !ok {
+		return
+	}
+
+	convite, err := h.service.AprovarSolicitacao(id, empresaID, userID, request.CargoID)
+	if err != nil {
+		responderErro(c, err, "Falha ao aprovar a solicitação.")
+		return
+	}
+	c.JSON(http.StatusOK, convite)
+}
+
+func (h *Handler) RejeitarSolicitacao(c *gin.Context) {
+	empresaID, userID, id, ok := h.dadosAvaliacao(c)
+	if !ok {
+		return
+	}
+	if err := h.service.RejeitarSolicitacao(id, empresaID, userID); err != nil {
+		responderErro(c, err, "Falha ao rejeitar a solicitação.")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) dadosAvaliacao(c *gin.Context) (empresaID uint, userID uint, id uint, ok bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, 0, 0, false
+	}
+	userID, err = h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Solicitações só podem ser avaliadas por um usuário."})
+		return 0, 0, 0, false
+	}
+	id, err = h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da solicitação inválido."})
+		return 0, 0, 0, false
+	}
+	return empresaID, userID, id, true
+}
+
+func responderErro(c *gin.Context, err error, mensagemPadrao string) {
+	switch {
+	case errors.Is(err, ErrConviteInvalido):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrEmailJaCadastrado), errors.Is(err, usuario.ErrEmailEmUso), errors.Is(err, ErrConvitePendente), errors.Is(err, ErrSolicitacaoAvaliada):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCargoInvalido), errors.Is(err, ErrCargoObrigatorio):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, politica.ErrNegadoPorPolitica):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCadastroDesativado), errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": mensagemPadrao})
+	}
+}
@@ -0,0 +1,114 @@
+package convite
+
+import (
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type ConviteRepository interface {
+	Create(convite *model.Convite) error
+	// FindByID procura em todas as empresas: o link de aceite traz só o ID do convite.
+	FindByID(id uint) (*model.Convite, error)
+	FindPendenteByEmail(email string, empresaID uint, agora time.Time) (*model.Convite, error)
+	GetPendentesByEmpresaID(empresaID uint, agora time.Time) ([]model.Convite, error)
+	Cancelar(id uint, empresaID uint, momento time.Time) error
+	MarcarAceito(id uint, usuarioID uint, empresaID uint, momento time.Time) error
+
+	CreateSolicitacao(solicitacao *model.SolicitacaoCadastro) error
+	FindSolicitacao(id uint, empresaID uint) (*model.SolicitacaoCadastro, error)
+	FindSolicitacaoPendenteByEmail(email string, empresaID uint) (*model.SolicitacaoCadastro, error)
+	GetSolicitacoesPendentes(empresaID uint) ([]model.SolicitacaoCadastro, error)
+	AtualizarSolicitacao(solicitacao *model.SolicitacaoCadastro) error
+}
+
+type conviteRepository struct {
+	Db *gorm.DB
+}
+
+func NewConviteRepository(db *gorm.DB) ConviteRepository {
+	return &conviteRepository{Db: db}
+}
+
+func (r *conviteRepository) Create(convite *model.Convite) error {
+	return tenant.Escopo(r.Db, convite.EmpresaID).Create(convite).Error
+}
+
+func (r *conviteRepository) FindByID(id uint) (*model.Convite, error) {
+	var convite model.Convite
+	err := tenant.Plataforma(r.Db).Where("id = ?", id).First(&convite).Error
+	return &convite, err
+}
+
+func (r *conviteRepository) FindPendenteByEmail(email string, empresaID uint, agora time.Time) (*model.Convite, error) {
+	var convite model.Convite
+	err := tenant.Escopo(r.Db, empresaID).Where("email = ?", email).
+		Where("aceito_em IS NULL AND cancelado_em IS NULL AND expira_em > ?", agora).
+		First(&convite).Error
+	return &convite, err
+}
+
+func (r *conviteRepository) GetPendentesByEmpresaID(empresaID uint, agora time.Time) ([]model.Convite, error) {
+	var convites []model.Convite
+	err := tenant.Escopo(r.Db, empresaID).
+		Where("aceito_em IS NULL AND cancelado_em IS NULL AND expira_em > ?", agora).
+		Order("id asc").Find(&convites).Error
+	return convites, err
+}
+
+func (r *conviteRepository) Cancelar(id uint, empresaID uint, momento time.Time) error {
+	resultado := tenant.Escopo(r.Db, empresaID).Model(&model.Convite{}).
+		Where("id = ? AND aceito_em IS NULL AND cancelado_em IS NULL", id).
+		Update("cancelado_em", momento)
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarcarAceito só altera convites ainda abertos, para que o mesmo link não seja usado duas vezes.
+func (r *conviteRepository) MarcarAceito(id uint, usuarioID uint, empresaID uint, momento time.Time) error {
+	resultado := tenant.Escopo(r.Db, empresaID).Model(&model.Convite{}).
+		Where("id = ? AND aceito_em IS NULL AND cancelado_em IS NULL", id).
+		Updates(map[string]interface{}{"aceito_em": momento, "usuario_id": usuarioID})
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *conviteRepository) CreateSolicitacao(solicitacao *model.SolicitacaoCadastro) error {
+	return tenant.Escopo(r.Db, solicitacao.EmpresaID).Create(solicitacao).Error
+}
+
+func (r *conviteRepository) FindSolicitacao(id uint, empresaID uint) (*model.SolicitacaoCadastro, error) {
+	var solicitacao model.SolicitacaoCadastro
+	err := tenant.Escopo(r.Db, empresaID).Where("id = ?", id).First(&solicitacao).Error
+	return &solicitacao, err
+}
+
+func (r *conviteRepository) FindSolicitacaoPendenteByEmail(email string, empresaID uint) (*model.SolicitacaoCadastro, error) {
+	var solicitacao model.SolicitacaoCadastro
+	err := tenant.Escopo(r.Db, empresaID).Where("email = ? AND status = ?", email, model.SolicitacaoPendente).
+		First(&solicitacao).Error
+	return &solicitacao, err
+}
+
+func (r *conviteRepository) GetSolicitacoesPendentes(empresaID uint) ([]model.SolicitacaoCadastro, error) {
+	var solicitacoes []model.SolicitacaoCadastro
+	err := tenant.Escopo(r.Db, empresaID).Where("status = ?", model.SolicitacaoPendente).
+		Order("id asc").Find(&solicitacoes).Error
+	return solicitacoes, err
+}
+
+func (r *conviteRepository) AtualizarSolicitacao(solicitacao *model.SolicitacaoCadastro) error {
+	return tenant.Escopo(r.Db, solicitacao.EmpresaID).Save(solicitacao).Error
+}
@@ -0,0 +1,334 @@
+package convite
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/cargo"
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"github.com/Loviiin/ponto-api-go/pkg/mailer"
+	"gorm.io/gorm"
+)
+
+// validadeConvite é o tempo que o link enviado por e-mail continua válido.
+const validadeConvite = 7 * 24 * time.Hour
+
+var (
+	ErrConviteInvalido     = errors.New("convite inválido, expirado ou já utilizado")
+	ErrEmailJaCadastrado   = errors.New("e-mail já cadastrado")
+	ErrConvitePendente     = errors.New("já existe um convite pendente para este e-mail")
+	ErrCargoInvalido       = errors.New("o cargo especificado não existe ou não pertence a esta empresa")
+	ErrCadastroDesativado  = errors.New("esta empresa não aceita pedidos de cadastro")
+	ErrSolicitacaoAvaliada = errors.New("esta solicitação já foi avaliada")
+	ErrCargoObrigatorio    = errors.New("informe o cargo do novo funcionário")
+)
+
+var agora = time.Now
+
+type ConviteService interface {
+	Convidar(empresaID uint, convidadoPorID uint, email string, nome string, cargoID uint) (*model.Convite, error)
+	Listar(empresaID uint) ([]model.Convite, error)
+	Cancelar(id uint, empresaID uint) error
+	Consultar(token string) (*model.Convite, error)
+	Aceitar(token string, nome string, senha string) (*model.Usuario, error)
+
+	// SolicitarCadastro não diz se o pedido foi registrado: a resposta é a mesma para e-mails com
+	// conta, com convite ou pedido em aberto, para que a rota pública não revele quem já está cadastrado.
+	SolicitarCadastro(empresaID uint, nome string, email string, ip string) error
+	ListarSolicitacoes(empresaID uint) ([]model.SolicitacaoCadastro, error)
+	AprovarSolicitacao(id uint, empresaID uint, avaliadorID uint, cargoID uint) (*model.Convite, error)
+	RejeitarSolicitacao(id uint, empresaID uint, avaliadorID uint) error
+}
+
+type conviteService struct {
+	repo           ConviteRepository
+	usuarioRepo    usuario.UsuarioRepository
+	usuarioService usuario.UsuarioService
+	cargoRepo      cargo.CargoRepository
+	empresaRepo    empresa.EmpresaRepository
+	jwtService     *jwt.JWTService
+	mailer         mailer.Mailer
+	urlBase        string
+	politicas      politica.Verificador
+	limitador      *auth.Limitador
+	maxPedidosIP   int
+}
+
+// NewConviteService cria o serviço de convites. urlBase é o endereço da tela de aceite,
+// ao qual o token é acrescentado como parâmetro "token". Os pedidos de cadastro público contam
+// no mesmo armazenamento de tentativas do login: cada IP pode fazer até maxPedidosIP pedidos antes
+// de ficar bloqueado pela duração de bloqueio da política.
+func NewConviteService(repo ConviteRepository, usuarioRepo usuario.UsuarioRepository, usuarioService usuario.UsuarioService,
+	cargoRepo cargo.CargoRepository, empresaRepo empresa.EmpresaRepository, jwtService *jwt.JWTService, m mailer.Mailer, urlBase string,
+	politicas politica.Verificador, tentativas auth.TentativaLoginStore, politicaBloqueio auth.PoliticaBloqueio, maxPedidosIP int) ConviteService {
+	politicaBloqueio.AtrasoBase = 0
+	return &conviteService{
+		repo:           repo,
+		usuarioRepo:    usuarioRepo,
+		usuarioService: usuarioService,
+		cargoRepo:      cargoRepo,
+		empresaRepo:    empresaRepo,
+		jwtService:     jwtService,
+		mailer:         m,
+		urlBase:        urlBase,
+		politicas:      politicas,
+		limitador:      auth.NewLimitador(tentativas, politicaBloqueio),
+		maxPedidosIP:   maxPedidosIP,
+	}
+}
+
+func (s *conviteService) Convidar(empresaID uint, convidadoPorID uint, email string, nome string, cargoID uint) (*model.Convite, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := s.cargoRepo.FindByID(cargoID, empresaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCargoInvalido
+		}
+		return nil, err
+	}
+	if err := s.emailDisponivel(email, empresaID); err != nil {
+		return nil, err
+	}
+
+	convite := &model.Convite{
+		EmpresaID:      empresaID,
+		Email:          email,
+		Nome:           nome,
+		CargoID:        cargoID,
+		ConvidadoPorID: convidadoPorID,
+		ExpiraEm:       agora().Add(validadeConvite),
+	}
+	if err := s.repo.Create(convite); err != nil {
+		return nil, err
+	}
+	if err := s.enviar(convite); err != nil {
+		return nil, err
+	}
+	return convite, nil
+}
+
+func (s *conviteService) Listar(empresaID uint) ([]model.Convite, error) {
+	return s.repo.GetPendentesByEmpresaID(empresaID, agora())
+}
+
+func (s *conviteService) Cancelar(id uint, empresaID uint) error {
+	return s.repo.Cancelar(id, empresaID, agora())
+}
+
+// Consultar devolve o convite de um token ainda utilizável, para a tela de aceite mostrar os dados.
+func (s *conviteService) Consultar(token string) (*model.Convite, error) {
+	conviteID, err := s.jwtService.ValidateConviteToken(token)
+	if err != nil {
+		return nil, ErrConviteInvalido
+	}
+	convite, err := s.repo.FindByID(conviteID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConviteInvalido
+		}
+		return nil, err
+	}
+	if convite.AceitoEm != nil || convite.CanceladoEm != nil || !agora().Before(convite.ExpiraEm) {
+		return nil, ErrConviteInvalido
+	}
+	return convite, nil
+}
+
+// Aceitar cria o usuário com o e-mail, a empresa e o cargo definidos no convite;
+// o convidado escolhe apenas o nome e a senha.
+func (s *conviteService) Aceitar(token string, nome string, senha string) (*model.Usuario, error) {
+	convite, err := s.Consultar(token)
+	if err != nil {
+		return nil, err
+	}
+	if nome == "" {
+		nome = convite.Nome
+	}
+
+	novoUsuario := &model.Usuario{
+		Nome:      nome,
+		Email:     convite.Email,
+		Senha:     senha,
+		EmpresaID: convite.EmpresaID,
+		CargoID:   convite.CargoID,
+	}
+	if err := s.usuarioService.CriarUsuario(novoUsuario); err != nil {
+		return nil, err
+	}
+	if err := s.repo.MarcarAceito(convite.ID, novoUsuario.ID, convite.EmpresaID, agora()); err != nil {
+		return nil, err
+	}
+	return novoUsuario, nil
+}
+
+// SolicitarCadastro registra um pedido de cadastro público. Nenhuma conta é criada aqui:
+// se aprovado, o solicitante recebe um convite e só então define a senha. E-mails que já têm
+// conta, convite ou pedido em aberto são ignorados em silêncio, sem um segundo pedido.
+func (s *conviteService) SolicitarCadastro(empresaID uint, nome string, email string, ip string) error {
+	// Todo pedido conta, aceito ou não: o limite é de volume, não de falhas.
+	chave := "cadastro:ip:" + ip
+	momento := agora()
+	if err := s.limitador.Verificar(chave, momento); err != nil {
+		return err
+	}
+	if _, err := s.limitador.Falhou(chave, ip, s.maxPedidosIP, momento); err != nil {
+		return err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	empresaAlvo, err := s.empresaRepo.FindByID(empresaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCadastroDesativado
+		}
+		return err
+	}
+	if !empresaAlvo.CadastroPublico {
+		return ErrCadastroDesativado
+	}
+	if err := s.emailDisponivel(email, empresaID); err != nil {
+		if errors.Is(err, ErrEmailJaCadastrado) || errors.Is(err, ErrConvitePendente) {
+			return nil
+		}
+		return err
+	}
+	_, err = s.repo.FindSolicitacaoPendenteByEmail(email, empresaID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return s.repo.CreateSolicitacao(&model.SolicitacaoCadastro{
+		EmpresaID: empresaID,
+		Nome:      nome,
+		Email:     email,
+		Status:    model.SolicitacaoPendente,
+	})
+}
+
+func (s *conviteService) ListarSolicitacoes(empresaID uint) ([]model.SolicitacaoCadastro, error) {
+	return s.repo.GetSolicitacoesPendentes(empresaID)
+}
+
+// AprovarSolicitacao transforma o pedido num convite. Sem cargo informado, usa o cargo
+// padrão de cadastro público configurado na empresa.
+func (s *conviteService) AprovarSolicitacao(id uint, empresaID uint, avaliadorID uint, cargoID uint) (*model.Convite, error) {
+	solicitacao, err := s.buscarPendente(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if cargoID == 0 {
+		empresaAlvo, err := s.empresaRepo.FindByID(empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if empresaAlvo.CargoCadastroPublicoID == nil {
+			return nil, ErrCargoObrigatorio
+		}
+		cargoID = *empresaAlvo.CargoCadastroPublicoID
+	}
+
+	recurso := politica.Atributos{"solicitacao_id": solicitacao.ID, "cargo_id": cargoID, "email": solicitacao.Email}
+	if err := s.exigirPolitica(politica.AcaoCadastroAprovar, empresaID, avaliadorID, recurso); err != nil {
+		return nil, err
+	}
+
+	convite, err := s.Convidar(empresaID, avaliadorID, solicitacao.Email, solicitacao.Nome, cargoID)
+	if err != nil {
+		return nil, err
+	}
+
+	momento := agora()
+	solicitacao.Status = model.SolicitacaoAprovada
+	solicitacao.AvaliadoPorID = &avaliadorID
+	solicitacao.AvaliadoEm = &momento
+	solicitacao.ConviteID = &convite.ID
+	if err := s.repo.AtualizarSolicitacao(solicitacao); err != nil {
+		return nil, err
+	}
+	return convite, nil
+}
+
+func (s *conviteService) RejeitarSolicitacao(id uint, empresaID uint, avaliadorID uint) error {
+	solicitacao, err := s.buscarPendente(id, empresaID)
+	if err != nil {
+		return err
+	}
+	recurso := politica.Atributos{"solicitacao_id": solicitacao.ID, "email": solicitacao.Email}
+	if err := s.exigirPolitica(politica.AcaoCadastroRejeitar, empresaID, avaliadorID, recurso); err != nil {
+		return err
+	}
+	momento := agora()
+	solicitacao.Status = model.SolicitacaoRejeitada
+	solicitacao.AvaliadoPorID = &avaliadorID
+	solicitacao.AvaliadoEm = &momento
+	return s.repo.AtualizarSolicitacao(solicitacao)
+}
+
+// exigirPolitica consulta as políticas da empresa tendo o avaliador como sujeito.
+func (s *conviteService) exigirPolitica(acao string, empresaID uint, avaliadorID uint, recurso politica.Atributos) error {
+	avaliador, err := s.usuarioRepo.FindByID(avaliadorID, empresaID)
+	if err != nil {
+		return err
+	}
+	return s.politicas.Exigir(empresaID, politica.Requisicao{
+		Acao:    acao,
+		Sujeito: politica.AtributosUsuario(avaliador),
+		Recurso: recurso,
+		Momento: agora(),
+	})
+}
+
+func (s *conviteService) buscarPendente(id uint, empresaID uint) (*model.SolicitacaoCadastro, error) {
+	solicitacao, err := s.repo.FindSolicitacao(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if solicitacao.Status != model.SolicitacaoPendente {
+		return nil, ErrSolicitacaoAvaliada
+	}
+	return solicitacao, nil
+}
+
+// emailDisponivel garante que o e-mail não tem conta, nem mesmo excluída, nem convite em aberto na
+// empresa. Sem olhar as contas excluídas, o aceite esbarraria no índice único de e-mail.
+func (s *conviteService) emailDisponivel(email string, empresaID uint) error {
+	emUso, err := s.usuarioRepo.EmailEmUso(email)
+	if err != nil {
+		return err
+	}
+	if emUso {
+		return ErrEmailJaCadastrado
+	}
+	_, err = s.repo.FindPendenteByEmail(email, empresaID, agora())
+	if err == nil {
+		return ErrConvitePendente
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s *conviteService) enviar(convite *model.Convite) error {
+	token, err := s.jwtService.GenerateConviteToken(convite.ID, convite.ExpiraEm)
+	if err != nil {
+		return err
+	}
+	link := s.urlBase + "?token=" + url.QueryEscape(token)
+	return s.mailer.Enviar(mailer.Mensagem{
+		Para:    convite.Email,
+		Assunto: "Convite para o sistema de ponto",
+		Corpo: fmt.Sprintf("Olá %s,\n\nVocê foi convidado para registrar o seu ponto. Para criar o seu acesso, abra o link abaixo até %s:\n\n%s\n",
+			convite.Nome, convite.ExpiraEm.Format("02/01/2006 15:04"), link),
+	})
+}
@@ -0,0 +1,435 @@
+package convite
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/cargo"
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"github.com/Loviiin/ponto-api-go/pkg/mailer"
+	"gorm.io/gorm"
+)
+
+type memoriaConviteRepository struct {
+	convites     []*model.Convite
+	solicitacoes []*model.SolicitacaoCadastro
+}
+
+func (m *memoriaConviteRepository) Create(c *model.Convite) error {
+	c.ID = uint(len(m.convites) + 1)
+	m.convites = append(m.convites, c)
+	return nil
+}
+
+func (m *memoriaConviteRepository) FindByID(id uint) (*model.Convite, error) {
+	for _, c := range m.convites {
+		if c.ID == id {
+			copia := *c
+			return &copia, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaConviteRepository) aberto(c *model.Convite, agora time.Time) bool {
+	return c.AceitoEm == nil && c.CanceladoEm == nil && c.ExpiraEm.After(agora)
+}
+
+func (m *memoriaConviteRepository) FindPendenteByEmail(email string, empresaID uint, agora time.Time) (*model.Convite, error) {
+	for _, c := range m.convites {
+		if c.Email == email && c.EmpresaID == empresaID && m.aberto(c, agora) {
+			return c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaConviteRepository) GetPendentesByEmpresaID(empresaID uint, agora time.Time) ([]model.Convite, error) {
+	var convites []model.Convite
+	for _, c := range m.convites {
+		if c.EmpresaID == empresaID && m.aberto(c, agora) {
+			convites = append(convites, *c)
+		}
+	}
+	return convites, nil
+}
+
+func (m *memoriaConviteRepository) Cancelar(id uint, empresaID uint, momento time.Time) error {
+	for _, c := range m.convites {
+		if c.ID == id && c.EmpresaID == empresaID && c.AceitoEm == nil && c.CanceladoEm == nil {
+			c.CanceladoEm = &momento
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *memoriaConviteRepository) MarcarAceito(id uint, usuarioID uint, empresaID uint, momento time.Time) error {
+	for _, c := range m.convites {
+		if c.ID == id && c.EmpresaID == empresaID && c.AceitoEm == nil && c.CanceladoEm == nil {
+			c.AceitoEm = &momento
+			c.UsuarioID = &usuarioID
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (m *memoriaConviteRepository) CreateSolicitacao(s *model.SolicitacaoCadastro) error {
+	s.ID = uint(len(m.solicitacoes) + 1)
+	m.solicitacoes = append(m.solicitacoes, s)
+	return nil
+}
+
+func (m *memoriaConviteRepository) FindSolicitacao(id uint, empresaID uint) (*model.SolicitacaoCadastro, error) {
+	for _, s := range m.solicitacoes {
+		if s.ID == id && s.EmpresaID == empresaID {
+			return s, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaConviteRepository) FindSolicitacaoPendenteByEmail(email string, empresaID uint) (*model.SolicitacaoCadastro, error) {
+	for _, s := range m.solicitacoes {
+		if s.Email == email && s.EmpresaID == empresaID && s.Status == model.SolicitacaoPendente {
+			return s, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaConviteRepository) GetSolicitacoesPendentes(empresaID uint) ([]model.SolicitacaoCadastro, error) {
+	var solicitacoes []model.SolicitacaoCadastro
+	for _, s := range m.solicitacoes {
+		if s.EmpresaID == empresaID && s.Status == model.SolicitacaoPendente {
+			solicitacoes = append(solicitacoes, *s)
+		}
+	}
+	return solicitacoes, nil
+}
+
+func (m *memoriaConviteRepository) AtualizarSolicitacao(s *model.SolicitacaoCadastro) error {
+	return nil
+}
+
+type mockUsuarioRepository struct {
+	usuario.UsuarioRepository
+	usuarios []*model.Usuario
+}
+
+// FindByEmail esconde os usuários excluídos, como o repositório real; EmailEmUso os enxerga.
+func (m *mockUsuarioRepository) FindByEmail(email string) (*model.Usuario, error) {
+	for _, u := range m.usuarios {
+		if u.Email == email && !u.ExcluidoEm.Valid {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *mockUsuarioRepository) EmailEmUso(email string) (bool, error) {
+	for _, u := range m.usuarios {
+		if u.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockUsuarioRepository) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
+	return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+}
+
+// verificadorFixo responde sempre com o mesmo erro; nil permite tudo.
+type verificadorFixo struct {
+	err     error
+	pedidos []politica.Requisicao
+}
+
+func (v *verificadorFixo) Exigir(empresaID uint, req politica.Requisicao) error {
+	v.pedidos = append(v.pedidos, req)
+	return v.err
+}
+
+// mockUsuarioService cria usuários sem criptografar a senha, para os testes não pagarem o custo do bcrypt.
+type mockUsuarioService struct {
+	usuario.UsuarioService
+	repo *mockUsuarioRepository
+}
+
+func (m *mockUsuarioService) CriarUsuario(u *model.Usuario) error {
+	u.ID = uint(len(m.repo.usuarios) + 1)
+	m.repo.usuarios = append(m.repo.usuarios, u)
+	return nil
+}
+
+type mockCargoRepository struct {
+	cargo.CargoRepository
+}
+
+func (m *mockCargoRepository) FindByID(id uint, empresaID uint) (*model.Cargo, error) {
+	if empresaID == 1 && (id == 2 || id == 3) {
+		return &model.Cargo{ID: id, EmpresaID: empresaID}, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type mockEmpresaRepository struct {
+	empresa.EmpresaRepository
+	empresas map[uint]*model.Empresa
+}
+
+func (m *mockEmpresaRepository) FindByID(id uint) (*model.Empresa, error) {
+	if e, ok := m.empresas[id]; ok {
+		return e, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type mailerCapturador struct {
+	mensagens []mailer.Mensagem
+}
+
+func (m *mailerCapturador) Enviar(msg mailer.Mensagem) error {
+	m.mensagens = append(m.mensagens, msg)
+	return nil
+}
+
+type cenario struct {
+	service     ConviteService
+	repo        *memoriaConviteRepository
+	usuarioRepo *mockUsuarioRepository
+	empresas    *mockEmpresaRepository
+	mailer      *mailerCapturador
+	politicas   *verificadorFixo
+}
+
+func novoCenario() *cenario {
+	repo := &memoriaConviteRepository{}
+	usuarioRepo := &mockUsuarioRepository{}
+	cargoPadrao := uint(3)
+	empresas := &mockEmpresaRepository{empresas: map[uint]*model.Empresa{
+		1: {ID: 1, CadastroPublico: true, CargoCadastroPublicoID: &cargoPadrao},
+		2: {ID: 2},
+	}}
+	m := &mailerCapturador{}
+	politicas := &verificadorFixo{}
+	service := NewConviteService(repo, usuarioRepo, &mockUsuarioService{repo: usuarioRepo}, &mockCargoRepository{},
+		empresas, jwt.NewJWTService("segredo-de-teste", "ponto-api-go"), m, "https://app.exemplo.com/convite", politicas,
+		auth.NewMemoriaTentativaLoginStore(), auth.PoliticaBloqueio{DuracaoBloqueio: 15 * time.Minute}, 3)
+	return &cenario{service: service, repo: repo, usuarioRepo: usuarioRepo, empresas: empresas, mailer: m, politicas: politicas}
+}
+
+// tokenDoEmail extrai o token do link enviado na última mensagem.
+func (c *cenario) tokenDoEmail(t *testing.T) string {
+	t.Helper()
+	if len(c.mailer.mensagens) == 0 {
+		t.Fatal("Nenhum e-mail foi enviado")
+	}
+	corpo := c.mailer.mensagens[len(c.mailer.mensagens)-1].Corpo
+	inicio := strings.Index(corpo, "https://app.exemplo.com/convite?")
+	if inicio < 0 {
+		t.Fatalf("Link do convite não encontrado no e-mail: %s", corpo)
+	}
+	link, err := url.Parse(strings.Fields(corpo[inicio:])[0])
+	if err != nil {
+		t.Fatalf("Link inválido: %v", err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestConvidarEAceitar_CriaUsuarioComCargoDoConvite(t *testing.T) {
+	c := novoCenario()
+
+	convite, err := c.service.Convidar(1, 10, "Novo@Exemplo.com", "Novo", 2)
+	if err != nil {
+		t.Fatalf("Erro inesperado ao convidar: %v", err)
+	}
+	if convite.Email != "novo@exemplo.com" {
+		t.Errorf("O e-mail deveria ser normalizado, recebeu %q", convite.Email)
+	}
+
+	usuarioCriado, err := c.service.Aceitar(c.tokenDoEmail(t), "", "senha123")
+	if err != nil {
+		t.Fatalf("Erro inesperado ao aceitar: %v", err)
+	}
+	if usuarioCriado.EmpresaID != 1 || usuarioCriado.CargoID != 2 || usuarioCriado.Nome != "Novo" {
+		t.Errorf("Usuário criado com dados errados: %+v", usuarioCriado)
+	}
+	if c.repo.convites[0].AceitoEm == nil || *c.repo.convites[0].UsuarioID != usuarioCriado.ID {
+		t.Error("O convite deveria ficar marcado como aceito")
+	}
+}
+
+func TestAceitar_TokenReutilizadoERecusado(t *testing.T) {
+	c := novoCenario()
+	if _, err := c.service.Convidar(1, 10, "novo@exemplo.com", "Novo", 2); err != nil {
+		t.Fatalf("Erro inesperado ao convidar: %v", err)
+	}
+	token := c.tokenDoEmail(t)
+	if _, err := c.service.Aceitar(token, "Novo", "senha123"); err != nil {
+		t.Fatalf("Erro inesperado ao aceitar: %v", err)
+	}
+
+	_, err := c.service.Aceitar(token, "Outro", "outrasenha")
+	if !errors.Is(err, ErrConviteInvalido) {
+		t.Errorf("Esperava ErrConviteInvalido, recebeu %v", err)
+	}
+}
+
+func TestAceitar_ConviteExpiradoOuCancelado(t *testing.T) {
+	c := novoCenario()
+	original := agora
+	defer func() { agora = original }()
+
+	convite, _ := c.service.Convidar(1, 10, "expira@exemplo.com", "", 2)
+	tokenExpirado := c.tokenDoEmail(t)
+	agora = func() time.Time { return convite.ExpiraEm.Add(time.Second) }
+	if _, err := c.service.Aceitar(tokenExpirado, "X", "senha123"); !errors.Is(err, ErrConviteInvalido) {
+		t.Errorf("Convite expirado deveria ser recusado, recebeu %v", err)
+	}
+	agora = original
+
+	cancelado, _ := c.service.Convidar(1, 10, "cancela@exemplo.com", "", 2)
+	tokenCancelado := c.tokenDoEmail(t)
+	if err := c.service.Cancelar(cancelado.ID, 1); err != nil {
+		t.Fatalf("Erro inesperado ao cancelar: %v", err)
+	}
+	if _, err := c.service.Aceitar(tokenCancelado, "X", "senha123"); !errors.Is(err, ErrConviteInvalido) {
+		t.Errorf("Convite cancelado deveria ser recusado, recebeu %v", err)
+	}
+}
+
+func TestAceitar_TokenDeLoginNaoServeComoConvite(t *testing.T) {
+	c := novoCenario()
+	tokenLogin, err := jwt.NewJWTService("segredo-de-teste", "ponto-api-go").GenerateToken(1, 1)
+	if err != nil {
+		t.Fatalf("Erro inesperado ao gerar token: %v", err)
+	}
+	if _, err := c.service.Aceitar(tokenLogin, "X", "senha123"); !errors.Is(err, ErrConviteInvalido) {
+		t.Errorf("Esperava ErrConviteInvalido, recebeu %v", err)
+	}
+}
+
+func TestConvidar_Validacoes(t *testing.T) {
+	c := novoCenario()
+	c.usuarioRepo.usuarios = append(c.usuarioRepo.usuarios, &model.Usuario{ID: 1, Email: "existente@exemplo.com"})
+
+	if _, err := c.service.Convidar(1, 10, "a@exemplo.com", "", 99); !errors.Is(err, ErrCargoInvalido) {
+		t.Errorf("Cargo de outra empresa deveria ser recusado, recebeu %v", err)
+	}
+	if _, err := c.service.Convidar(1, 10, "existente@exemplo.com", "", 2); !errors.Is(err, ErrEmailJaCadastrado) {
+		t.Errorf("E-mail cadastrado deveria ser recusado, recebeu %v", err)
+	}
+	// A conta excluída ainda ocupa o e-mail no índice único, e pode ser restaurada.
+	c.usuarioRepo.usuarios = append(c.usuarioRepo.usuarios, &model.Usuario{ID: 2, Email: "excluido@exemplo.com",
+		ExcluidoEm: gorm.DeletedAt{Time: time.Now(), Valid: true}})
+	if _, err := c.service.Convidar(1, 10, "excluido@exemplo.com", "", 2); !errors.Is(err, ErrEmailJaCadastrado) {
+		t.Errorf("E-mail de usuário excluído deveria ser recusado, recebeu %v", err)
+	}
+	if _, err := c.service.Convidar(1, 10, "b@exemplo.com", "", 2); err != nil {
+		t.Fatalf("Erro inesperado ao convidar: %v", err)
+	}
+	if _, err := c.service.Convidar(1, 10, "b@exemplo.com", "", 2); !errors.Is(err, ErrConvitePendente) {
+		t.Errorf("Convite duplicado deveria ser recusado, recebeu %v", err)
+	}
+}
+
+func TestSolicitarCadastro_EmpresaSemCadastroPublico(t *testing.T) {
+	c := novoCenario()
+	if err := c.service.SolicitarCadastro(2, "Alguém", "alguem@exemplo.com", "10.0.0.1"); !errors.Is(err, ErrCadastroDesativado) {
+		t.Errorf("Esperava ErrCadastroDesativado, recebeu %v", err)
+	}
+	if len(c.repo.solicitacoes) != 0 {
+		t.Error("Nenhuma solicitação deveria ser registrada")
+	}
+}
+
+func TestAprovarSolicitacao_EnviaConviteComCargoPadrao(t *testing.T) {
+	c := novoCenario()
+	if err := c.service.SolicitarCadastro(1, "Alguém", "alguem@exemplo.com", "10.0.0.1"); err != nil {
+		t.Fatalf("Erro inesperado ao solicitar: %v", err)
+	}
+	solicitacao := c.repo.solicitacoes[0]
+	if len(c.mailer.mensagens) != 0 {
+		t.Fatal("Nenhum convite deveria ser enviado antes da aprovação")
+	}
+
+	convite, err := c.service.AprovarSolicitacao(solicitacao.ID, 1, 10, 0)
+	if err != nil {
+		t.Fatalf("Erro inesperado ao aprovar: %v", err)
+	}
+	if convite.CargoID != 3 {
+		t.Errorf("Esperava o cargo padrão 3, recebeu %d", convite.CargoID)
+	}
+	if solicitacao.Status != model.SolicitacaoAprovada || *solicitacao.ConviteID != convite.ID {
+		t.Errorf("Solicitação não foi marcada como aprovada: %+v", solicitacao)
+	}
+	if err := c.service.RejeitarSolicitacao(solicitacao.ID, 1, 10); !errors.Is(err, ErrSolicitacaoAvaliada) {
+		t.Errorf("Solicitação já avaliada não deveria ser rejeitada, recebeu %v", err)
+	}
+}
+
+func TestAprovarSolicitacao_NegadaPorPolitica(t *testing.T) {
+	c := novoCenario()
+	if err := c.service.SolicitarCadastro(1, "Alguém", "alguem@exemplo.com", "10.0.0.1"); err != nil {
+		t.Fatalf("Erro inesperado ao solicitar: %v", err)
+	}
+	solicitacao := c.repo.solicitacoes[0]
+	c.politicas.err = politica.ErrNegadoPorPolitica
+
+	if _, err := c.service.AprovarSolicitacao(solicitacao.ID, 1, 10, 0); !errors.Is(err, politica.ErrNegadoPorPolitica) {
+		t.Fatalf("Esperava ErrNegadoPorPolitica, recebeu %v", err)
+	}
+	if len(c.mailer.mensagens) != 0 || solicitacao.Status != model.SolicitacaoPendente {
+		t.Error("Uma aprovação negada não deveria enviar convite nem avaliar a solicitação")
+	}
+	pedido := c.politicas.pedidos[0]
+	if pedido.Acao != politica.AcaoCadastroAprovar || pedido.Sujeito["id"] != uint(10) || pedido.Recurso["cargo_id"] != uint(3) {
+		t.Errorf("Requisição enviada ao motor inesperada: %+v", pedido)
+	}
+}
+
+func TestSolicitarCadastro_NaoRevelaNemDuplicaEmails(t *testing.T) {
+	c := novoCenario()
+	c.usuarioRepo.usuarios = append(c.usuarioRepo.usuarios, &model.Usuario{ID: 7, EmpresaID: 1, Email: "existente@exemplo.com"})
+
+	for _, email := range []string{"existente@exemplo.com", "novo@exemplo.com", "NOVO@exemplo.com"} {
+		if err := c.service.SolicitarCadastro(1, "Alguém", email, "10.0.0.1"); err != nil {
+			t.Fatalf("Pedido para %s deveria ter a mesma resposta, recebeu %v", email, err)
+		}
+	}
+	if len(c.repo.solicitacoes) != 1 || c.repo.solicitacoes[0].Email != "novo@exemplo.com" {
+		t.Errorf("Esperava um único pedido, para novo@exemplo.com, recebeu %+v", c.repo.solicitacoes)
+	}
+}
+
+func TestSolicitarCadastro_LimitaPedidosPorIP(t *testing.T) {
+	c := novoCenario()
+	for i := 0; i < 3; i++ {
+		if err := c.service.SolicitarCadastro(1, "Alguém", "pessoa"+strconv.Itoa(i)+"@exemplo.com", "10.0.0.1"); err != nil {
+			t.Fatalf("Pedido %d: erro inesperado %v", i+1, err)
+		}
+	}
+
+	var bloqueio *auth.BloqueioError
+	if err := c.service.SolicitarCadastro(1, "Alguém", "outra@exemplo.com", "10.0.0.1"); !errors.As(err, &bloqueio) {
+		t.Fatalf("Esperava o IP bloqueado, recebeu %v", err)
+	}
+	if err := c.service.SolicitarCadastro(1, "Alguém", "outra@exemplo.com", "10.0.0.2"); err != nil {
+		t.Errorf("Outro IP não deveria ser afetado, recebeu %v", err)
+	}
+	if len(c.repo.solicitacoes) != 4 {
+		t.Errorf("Esperava 4 pedidos registrados, recebeu %d", len(c.repo.solicitacoes))
+	}
+}
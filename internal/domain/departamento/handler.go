@@ -0,0 +1,149 @@
+package departamento
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service   DepartamentoService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s DepartamentoService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+type departamentoRequest struct {
+	Nome              string `json:"nome" binding:"required"`
+	DepartamentoPaiID *uint  `json:"departamento_pai_id"`
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var req departamentoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'nome' é obrigatório."})
+		return
+	}
+
+	departamento := model.Departamento{
+		EmpresaID:         empresaID,
+		Nome:              req.Nome,
+		DepartamentoPaiID: req.DepartamentoPaiID,
+	}
+	if err := h.service.Create(&departamento); err != nil {
+		responderErro(c, err, "Falha ao criar o departamento.")
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoDepartamentoCriado, Entidade: "departamentos", EntidadeID: departamento.ID, Depois: departamento})
+	c.JSON(http.StatusCreated, departamento)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	departamentos, err := h.service.GetAllByEmpresaID(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar os departamentos."})
+		return
+	}
+	c.JSON(http.StatusOK, departamentos)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	departamento, err := h.service.FindByID(id, empresaID)
+	if err != nil {
+		responderErro(c, err, "Falha ao buscar o departamento.")
+		return
+	}
+	c.JSON(http.StatusOK, departamento)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	var req departamentoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'nome' é obrigatório."})
+		return
+	}
+
+	departamento := model.Departamento{
+		ID:                id,
+		EmpresaID:         empresaID,
+		Nome:              req.Nome,
+		DepartamentoPaiID: req.DepartamentoPaiID,
+	}
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Update(&departamento); err != nil {
+		responderErro(c, err, "Falha ao atualizar o departamento.")
+		return
+	}
+	depois, _ := h.service.FindByID(id, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoDepartamentoAtualizado, Entidade: "departamentos", EntidadeID: id, Antes: antes, Depois: depois})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Delete(id, empresaID); err != nil {
+		responderErro(c, err, "Falha ao deletar o departamento.")
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoDepartamentoExcluido, Entidade: "departamentos", EntidadeID: id, Antes: antes})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ids(c *gin.Context) (uint, uint, bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do departamento inválido."})
+		return 0, 0, false
+	}
+	return empresaID, id, true
+}
+
+func responderErro(c *gin.Context, err error, mensagemPadrao string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Departamento não encontrado nesta empresa."})
+	case errors.Is(err, ErrDepartamentoPaiInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrDepartamentoEmUso):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": mensagemPadrao})
+	}
+}
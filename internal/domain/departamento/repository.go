@@ -0,0 +1,78 @@
+package departamento
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type DepartamentoRepository interface {
+	Create(departamento *model.Departamento) error
+	FindByID(id uint, empresaID uint) (*model.Departamento, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.Departamento, error)
+	Update(departamento *model.Departamento) error
+	Delete(id uint, empresaID uint) error
+	// EhDescendente informa se id está abaixo de ancestralID na árvore de departamentos.
+	EhDescendente(ancestralID uint, id uint, empresaID uint) (bool, error)
+	ContarDependentes(id uint, empresaID uint) (subdepartamentos int64, usuarios int64, err error)
+}
+
+type departamentoRepository struct {
+	Db *gorm.DB
+}
+
+func NewDepartamentoRepository(db *gorm.DB) DepartamentoRepository {
+	return &departamentoRepository{Db: db}
+}
+
+func (r *departamentoRepository) Create(departamento *model.Departamento) error {
+	return tenant.Escopo(r.Db, departamento.EmpresaID).Create(departamento).Error
+}
+
+func (r *departamentoRepository) FindByID(id uint, empresaID uint) (*model.Departamento, error) {
+	var departamento model.Departamento
+	err := tenant.Escopo(r.Db, empresaID).Where("id = ?", id).First(&departamento).Error
+	return &departamento, err
+}
+
+func (r *departamentoRepository) GetAllByEmpresaID(empresaID uint) ([]model.Departamento, error) {
+	var departamentos []model.Departamento
+	err := tenant.Escopo(r.Db, empresaID).Order("id asc").Find(&departamentos).Error
+	return departamentos, err
+}
+
+func (r *departamentoRepository) Update(departamento *model.Departamento) error {
+	return tenant.Escopo(r.Db, departamento.EmpresaID).Model(&model.Departamento{}).
+		Where("id = ?", departamento.ID).
+		Updates(map[string]interface{}{
+			"nome":                departamento.Nome,
+			"departamento_pai_id": departamento.DepartamentoPaiID,
+		}).Error
+}
+
+func (r *departamentoRepository) Delete(id uint, empresaID uint) error {
+	return tenant.Escopo(r.Db, empresaID).Delete(&model.Departamento{}, "id = ?", id).Error
+}
+
+func (r *departamentoRepository) EhDescendente(ancestralID uint, id uint, empresaID uint) (bool, error) {
+	var existe bool
+	err := tenant.Escopo(r.Db, empresaID).Raw(`
+WITH RECURSIVE arvore AS (
+	SELECT id FROM departamentos WHERE departamento_pai_id = ? AND empresa_id = ?
+	UNION
+	SELECT d.id FROM departamentos d JOIN arvore a ON d.departamento_pai_id = a.id WHERE d.empresa_id = ?
+)
+SELECT EXISTS (SELECT 1 FROM arvore WHERE id = ?)`, ancestralID, empresaID, empresaID, id).Scan(&existe).Error
+	return existe, err
+}
+
+func (r *departamentoRepository) ContarDependentes(id uint, empresaID uint) (int64, int64, error) {
+	var subdepartamentos, usuarios int64
+	if err := tenant.Escopo(r.Db, empresaID).Model(&model.Departamento{}).Where("departamento_pai_id = ?", id).Count(&subdepartamentos).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := tenant.Escopo(r.Db, empresaID).Model(&model.Usuario{}).Where("departamento_id = ?", id).Count(&usuarios).Error; err != nil {
+		return 0, 0, err
+	}
+	return subdepartamentos, usuarios, nil
+}
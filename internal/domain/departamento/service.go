@@ -0,0 +1,96 @@
+package departamento
+
+import (
+	"errors"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDepartamentoPaiInvalido = errors.New("departamento pai inválido: deve existir nesta empresa e não pode estar abaixo do próprio departamento")
+	ErrDepartamentoEmUso       = errors.New("o departamento tem subdepartamentos ou funcionários e não pode ser removido")
+)
+
+type DepartamentoService interface {
+	Create(departamento *model.Departamento) error
+	FindByID(id uint, empresaID uint) (*model.Departamento, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.Departamento, error)
+	Update(departamento *model.Departamento) error
+	Delete(id uint, empresaID uint) error
+}
+
+type departamentoService struct {
+	repo DepartamentoRepository
+}
+
+func NewDepartamentoService(repo DepartamentoRepository) DepartamentoService {
+	return &departamentoService{repo: repo}
+}
+
+func (s *departamentoService) Create(departamento *model.Departamento) error {
+	if err := s.validarPai(departamento); err != nil {
+		return err
+	}
+	return s.repo.Create(departamento)
+}
+
+func (s *departamentoService) FindByID(id uint, empresaID uint) (*model.Departamento, error) {
+	return s.repo.FindByID(id, empresaID)
+}
+
+func (s *departamentoService) GetAllByEmpresaID(empresaID uint) ([]model.Departamento, error) {
+	return s.repo.GetAllByEmpresaID(empresaID)
+}
+
+func (s *departamentoService) Update(departamento *model.Departamento) error {
+	if _, err := s.repo.FindByID(departamento.ID, departamento.EmpresaID); err != nil {
+		return err
+	}
+	if err := s.validarPai(departamento); err != nil {
+		return err
+	}
+	return s.repo.Update(departamento)
+}
+
+// Delete só remove departamentos vazios, para não deixar funcionários ou subdepartamentos órfãos.
+func (s *departamentoService) Delete(id uint, empresaID uint) error {
+	if _, err := s.repo.FindByID(id, empresaID); err != nil {
+		return err
+	}
+	subdepartamentos, usuarios, err := s.repo.ContarDependentes(id, empresaID)
+	if err != nil {
+		return err
+	}
+	if subdepartamentos > 0 || usuarios > 0 {
+		return ErrDepartamentoEmUso
+	}
+	return s.repo.Delete(id, empresaID)
+}
+
+// validarPai garante que o pai é da mesma empresa e que a árvore continua sem ciclos.
+func (s *departamentoService) validarPai(departamento *model.Departamento) error {
+	if departamento.DepartamentoPaiID == nil {
+		return nil
+	}
+	paiID := *departamento.DepartamentoPaiID
+	if departamento.ID != 0 && paiID == departamento.ID {
+		return ErrDepartamentoPaiInvalido
+	}
+	if _, err := s.repo.FindByID(paiID, departamento.EmpresaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDepartamentoPaiInvalido
+		}
+		return err
+	}
+	if departamento.ID != 0 {
+		ciclo, err := s.repo.EhDescendente(departamento.ID, paiID, departamento.EmpresaID)
+		if err != nil {
+			return err
+		}
+		if ciclo {
+			return ErrDepartamentoPaiInvalido
+		}
+	}
+	return nil
+}
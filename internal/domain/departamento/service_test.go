@@ -0,0 +1,122 @@
+package departamento
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+type memoriaDepartamentoRepository struct {
+	departamentos map[uint]*model.Departamento
+	usuarios      map[uint]int64
+}
+
+func (m *memoriaDepartamentoRepository) Create(departamento *model.Departamento) error {
+	departamento.ID = uint(len(m.departamentos) + 1)
+	m.departamentos[departamento.ID] = departamento
+	return nil
+}
+
+func (m *memoriaDepartamentoRepository) FindByID(id uint, empresaID uint) (*model.Departamento, error) {
+	if d, ok := m.departamentos[id]; ok && d.EmpresaID == empresaID {
+		return d, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaDepartamentoRepository) GetAllByEmpresaID(empresaID uint) ([]model.Departamento, error) {
+	return nil, nil
+}
+
+func (m *memoriaDepartamentoRepository) Update(departamento *model.Departamento) error {
+	m.departamentos[departamento.ID] = departamento
+	return nil
+}
+
+func (m *memoriaDepartamentoRepository) Delete(id uint, empresaID uint) error {
+	delete(m.departamentos, id)
+	return nil
+}
+
+func (m *memoriaDepartamentoRepository) EhDescendente(ancestralID uint, id uint, empresaID uint) (bool, error) {
+	for atual, ok := m.departamentos[id]; ok && atual.DepartamentoPaiID != nil; atual, ok = m.departamentos[*atual.DepartamentoPaiID] {
+		if *atual.DepartamentoPaiID == ancestralID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoriaDepartamentoRepository) ContarDependentes(id uint, empresaID uint) (int64, int64, error) {
+	var filhos int64
+	for _, d := range m.departamentos {
+		if d.DepartamentoPaiID != nil && *d.DepartamentoPaiID == id {
+			filhos++
+		}
+	}
+	return filhos, m.usuarios[id], nil
+}
+
+// novaArvore monta Diretoria (1) > Tecnologia (2) > Plataforma (3) na empresa 1.
+func novaArvore(t *testing.T) (DepartamentoService, *memoriaDepartamentoRepository) {
+	t.Helper()
+	repo := &memoriaDepartamentoRepository{departamentos: map[uint]*model.Departamento{}, usuarios: map[uint]int64{}}
+	service := NewDepartamentoService(repo)
+	var pai *uint
+	for _, nome := range []string{"Diretoria", "Tecnologia", "Plataforma"} {
+		d := &model.Departamento{EmpresaID: 1, Nome: nome, DepartamentoPaiID: pai}
+		if err := service.Create(d); err != nil {
+			t.Fatalf("Erro inesperado ao criar %s: %v", nome, err)
+		}
+		id := d.ID
+		pai = &id
+	}
+	return service, repo
+}
+
+func TestUpdate_RecusaCiclo(t *testing.T) {
+	service, _ := novaArvore(t)
+
+	// Colocar a Diretoria abaixo da Plataforma fecharia um ciclo; abaixo de si mesma também.
+	for _, paiID := range []uint{3, 1} {
+		pai := paiID
+		err := service.Update(&model.Departamento{ID: 1, EmpresaID: 1, Nome: "Diretoria", DepartamentoPaiID: &pai})
+		if !errors.Is(err, ErrDepartamentoPaiInvalido) {
+			t.Errorf("Pai %d: esperava ErrDepartamentoPaiInvalido, recebeu %v", paiID, err)
+		}
+	}
+
+	// Mover a Plataforma para debaixo da Diretoria é válido.
+	diretoria := uint(1)
+	if err := service.Update(&model.Departamento{ID: 3, EmpresaID: 1, Nome: "Plataforma", DepartamentoPaiID: &diretoria}); err != nil {
+		t.Errorf("Erro inesperado ao mover departamento: %v", err)
+	}
+}
+
+func TestCreate_PaiDeOutraEmpresa(t *testing.T) {
+	service, _ := novaArvore(t)
+
+	pai := uint(1)
+	err := service.Create(&model.Departamento{EmpresaID: 2, Nome: "Intruso", DepartamentoPaiID: &pai})
+	if !errors.Is(err, ErrDepartamentoPaiInvalido) {
+		t.Fatalf("Esperava ErrDepartamentoPaiInvalido, recebeu %v", err)
+	}
+}
+
+func TestDelete_DepartamentoEmUso(t *testing.T) {
+	service, repo := novaArvore(t)
+
+	if err := service.Delete(2, 1); !errors.Is(err, ErrDepartamentoEmUso) {
+		t.Errorf("Departamento com subdepartamento: esperava ErrDepartamentoEmUso, recebeu %v", err)
+	}
+	repo.usuarios[3] = 1
+	if err := service.Delete(3, 1); !errors.Is(err, ErrDepartamentoEmUso) {
+		t.Errorf("Departamento com funcionários: esperava ErrDepartamentoEmUso, recebeu %v", err)
+	}
+	repo.usuarios[3] = 0
+	if err := service.Delete(3, 1); err != nil {
+		t.Errorf("Erro inesperado ao apagar departamento vazio: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package dispositivo
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service        DispositivoService
+	usuarioService usuario.UsuarioService
+	converter      funcoes.FuncoesInterface
+}
+
+func NewHandler(s DispositivoService, u usuario.UsuarioService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:        s,
+		usuarioService: u,
+		converter:      f,
+	}
+}
+
+// usuarioDaRequisicao lê a empresa e o usuário autenticado. Chaves de API não têm dispositivos
+// nem decidem sobre eles: a decisão fica registrada em nome de uma pessoa.
+func (h *Handler) usuarioDaRequisicao(c *gin.Context) (uint, uint, bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+	usuarioID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem gerenciar dispositivos."})
+		return 0, 0, false
+	}
+	return usuarioID, empresaID, true
+}
+
+func (h *Handler) requisitante(c *gin.Context) (*model.Usuario, bool) {
+	usuarioID, empresaID, ok := h.usuarioDaRequisicao(c)
+	if !ok {
+		return nil, false
+	}
+	requisitante, err := usuario.Requisitante(c, h.usuarioService, usuarioID, empresaID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+		return nil, false
+	}
+	return requisitante, true
+}
+
+// GetMeus lista os dispositivos do próprio usuário, para o aplicativo mostrar a situação de cada um.
+func (h *Handler) GetMeus(c *gin.Context) {
+	usuarioID, empresaID, ok := h.usuarioDaRequisicao(c)
+	if !ok {
+		return
+	}
+	dispositivos, err := h.service.Meus(usuarioID, empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar os dispositivos."})
+		return
+	}
+	c.JSON(http.StatusOK, dispositivos)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	requisitante, ok := h.requisitante(c)
+	if !ok {
+		return
+	}
+	var filtro Filtro
+	if status := strings.ToUpper(c.Query("status")); status != "" {
+		switch status {
+		case model.DispositivoPendente, model.DispositivoAprovado, model.DispositivoRejeitado, model.DispositivoRevogado:
+			filtro.Status = status
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "'status' deve ser PENDENTE, APROVADO, REJEITADO ou REVOGADO."})
+			return
+		}
+	}
+	if texto := c.Query("usuario_id"); texto != "" {
+		id, err := h.converter.StrParaUint(texto)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "'usuario_id' inválido."})
+			return
+		}
+		filtro.UsuarioID = id
+	}
+
+	dispositivos, err := h.service.Listar(requisitante, filtro)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar os dispositivos."})
+		return
+	}
+	c.JSON(http.StatusOK, dispositivos)
+}
+
+// GetCompartilhados lista os aparelhos usados por mais de um funcionário, sinal de que alguém
+// pode estar batendo o ponto pelo colega.
+func (h *Handler) GetCompartilhados(c *gin.Context) {
+	requisitante, ok := h.requisitante(c)
+	if !ok {
+		return
+	}
+	compartilhados, err := h.service.Compartilhados(requisitante)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar os dispositivos compartilhados."})
+		return
+	}
+	c.JSON(http.StatusOK, compartilhados)
+}
+
+func (h *Handler) Aprovar(c *gin.Context) {
+	h.decidir(c, h.service.Aprovar)
+}
+
+func (h *Handler) Rejeitar(c *gin.Context) {
+	h.decidir(c, h.service.Rejeitar)
+}
+
+func (h *Handler) Revogar(c *gin.Context) {
+	h.decidir(c, h.service.Revogar)
+}
+
+func (h *Handler) decidir(c *gin.Context, decisao func(*model.Usuario, uint) (*model.Dispositivo, *model.Dispositivo, error)) {
+	requisitante, ok := h.requisitante(c)
+	if !ok {
+		return
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do dispositivo inválido."})
+		return
+	}
+
+	antes, depois, err := decisao(requisitante, id)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoDispositivoDecidido, Entidade: "dispositivos", EntidadeID: id, Antes: antes, Depois: depois})
+	c.JSON(http.StatusOK, depois)
+}
+
+// GerarCodigo cria um código de ativação para o funcionário; o código só aparece nesta resposta.
+func (h *Handler) GerarCodigo(c *gin.Context) {
+	requisitante, ok := h.requisitante(c)
+	if !ok {
+		return
+	}
+	usuarioID, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do usuário inválido."})
+		return
+	}
+
+	codigo, err := h.service.GerarCodigo(requisitante, usuarioID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado nesta empresa."})
+			return
+		}
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, codigo)
+}
+
+// Ativar aprova o aparelho do usuário autenticado com o código que o gestor lhe passou.
+func (h *Handler) Ativar(c *gin.Context) {
+	usuarioID, empresaID, ok := h.usuarioDaRequisicao(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Dispositivo string `json:"dispositivo" binding:"required"`
+		Nome        string `json:"nome"`
+		Codigo      string `json:"codigo" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "'dispositivo' e 'codigo' são obrigatórios."})
+		return
+	}
+
+	dispositivo, err := h.service.Ativar(usuarioID, empresaID, req.Dispositivo, req.Nome, req.Codigo)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dispositivo)
+}
+
+func responderErro(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispositivo não encontrado nesta empresa."})
+	case errors.Is(err, ErrIdentificadorInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCodigoInvalido):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrSituacaoInvalida), errors.Is(err, ErrLimiteDispositivos):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrDecisaoPropria), errors.Is(err, ErrForaDoEscopo):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao processar o dispositivo."})
+	}
+}
@@ -0,0 +1,204 @@
+package dispositivo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Filtro restringe a listagem de dispositivos; campos vazios não filtram.
+type Filtro struct {
+	Status    string
+	UsuarioID uint
+}
+
+type DispositivoRepository interface {
+	// FindOrCreate devolve o dispositivo do funcionário com o identificador, cadastrando-o com os
+	// dados recebidos se ainda não existe.
+	FindOrCreate(dispositivo *model.Dispositivo) (*model.Dispositivo, error)
+	FindByID(id uint, empresaID uint) (*model.Dispositivo, error)
+	Buscar(empresaID uint, filtro Filtro) ([]model.Dispositivo, error)
+	// Compartilhados devolve os dispositivos cujo identificador foi usado por mais de um funcionário.
+	Compartilhados(empresaID uint) ([]model.Dispositivo, error)
+	// ContarBatidas devolve quantos pontos foram batidos de cada dispositivo.
+	ContarBatidas(ids []uint, empresaID uint) (map[uint]int64, error)
+	// MudarStatus muda a situação do dispositivo, desde que a atual seja uma das esperadas.
+	MudarStatus(id uint, empresaID uint, de []string, para string, decididoPorID uint, momento time.Time) error
+	// Aprovar aprova o dispositivo se o funcionário tem menos de maximo dispositivos aprovados.
+	Aprovar(dispositivo *model.Dispositivo, decididoPorID uint, momento time.Time, maximo int) error
+	// AtivarComCodigo consome o código e aprova o dispositivo, tudo ou nada.
+	AtivarComCodigo(dispositivo *model.Dispositivo, codigoID uint, momento time.Time, maximo int) error
+	RegistrarUso(id uint, empresaID uint, momento time.Time) error
+
+	// SalvarCodigo substitui o código do funcionário, se houver um.
+	SalvarCodigo(codigo *model.CodigoDispositivo) error
+	FindCodigo(usuarioID uint, empresaID uint) (*model.CodigoDispositivo, error)
+	ContarTentativa(id uint, empresaID uint) error
+	ApagarCodigo(id uint, empresaID uint) error
+}
+
+type dispositivoRepository struct {
+	Db *gorm.DB
+}
+
+func NewDispositivoRepository(db *gorm.DB) DispositivoRepository {
+	return &dispositivoRepository{Db: db}
+}
+
+func (r *dispositivoRepository) FindOrCreate(dispositivo *model.Dispositivo) (*model.Dispositivo, error) {
+	db := tenant.Escopo(r.Db, dispositivo.EmpresaID)
+	// Dois primeiros usos simultâneos do mesmo aparelho não criam dois registros: o segundo
+	// esbarra no índice único e lê o que o primeiro gravou.
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(dispositivo).Error; err != nil {
+		return nil, err
+	}
+	var existente model.Dispositivo
+	err := db.Where("usuario_id = ? AND identificador = ?", dispositivo.UsuarioID, dispositivo.Identificador).
+		First(&existente).Error
+	return &existente, err
+}
+
+func (r *dispositivoRepository) FindByID(id uint, empresaID uint) (*model.Dispositivo, error) {
+	var dispositivo model.Dispositivo
+	err := tenant.Escopo(r.Db, empresaID).Preload("Usuario").Where("id = ?", id).First(&dispositivo).Error
+	return &dispositivo, err
+}
+
+func (r *dispositivoRepository) Buscar(empresaID uint, filtro Filtro) ([]model.Dispositivo, error) {
+	var dispositivos []model.Dispositivo
+	query := tenant.Escopo(r.Db, empresaID).Preload("Usuario")
+	if filtro.Status != "" {
+		query = query.Where("status = ?", filtro.Status)
+	}
+	if filtro.UsuarioID != 0 {
+		query = query.Where("usuario_id = ?", filtro.UsuarioID)
+	}
+	err := query.Order("id asc").Find(&dispositivos).Error
+	return dispositivos, err
+}
+
+func (r *dispositivoRepository) Compartilhados(empresaID uint) ([]model.Dispositivo, error) {
+	db := tenant.Escopo(r.Db, empresaID)
+	repetidos := db.Model(&model.Dispositivo{}).Select("identificador").
+		Group("identificador").Having("COUNT(DISTINCT usuario_id) > 1")
+	var dispositivos []model.Dispositivo
+	err := db.Preload("Usuario").Where("identificador IN (?)", repetidos).
+		Order("identificador asc, usuario_id asc").Find(&dispositivos).Error
+	return dispositivos, err
+}
+
+func (r *dispositivoRepository) ContarBatidas(ids []uint, empresaID uint) (map[uint]int64, error) {
+	contagens := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return contagens, nil
+	}
+	var linhas []struct {
+		DispositivoID uint
+		Total         int64
+	}
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.RegistroPonto{}).
+		Select("dispositivo_id, COUNT(*) AS total").
+		Where("dispositivo_id IN ?", ids).
+		Group("dispositivo_id").Scan(&linhas).Error
+	for _, linha := range linhas {
+		contagens[linha.DispositivoID] = linha.Total
+	}
+	return contagens, err
+}
+
+func (r *dispositivoRepository) MudarStatus(id uint, empresaID uint, de []string, para string, decididoPorID uint, momento time.Time) error {
+	resultado := tenant.Escopo(r.Db, empresaID).Model(&model.Dispositivo{}).
+		Where("id = ? AND status IN ?", id, de).
+		Updates(map[string]interface{}{"status": para, "decidido_por_id": decididoPorID, "decidido_em": momento})
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return ErrSituacaoInvalida
+	}
+	return nil
+}
+
+func (r *dispositivoRepository) Aprovar(dispositivo *model.Dispositivo, decididoPorID uint, momento time.Time, maximo int) error {
+	return tenant.Escopo(r.Db, dispositivo.EmpresaID).Transaction(func(tx *gorm.DB) error {
+		return aprovar(tx, dispositivo, &decididoPorID, momento, maximo)
+	})
+}
+
+func (r *dispositivoRepository) AtivarComCodigo(dispositivo *model.Dispositivo, codigoID uint, momento time.Time, maximo int) error {
+	return tenant.Escopo(r.Db, dispositivo.EmpresaID).Transaction(func(tx *gorm.DB) error {
+		resultado := tx.Where("id = ?", codigoID).Delete(&model.CodigoDispositivo{})
+		if resultado.Error != nil {
+			return resultado.Error
+		}
+		// Outra ativação já consumiu o código.
+		if resultado.RowsAffected == 0 {
+			return ErrCodigoInvalido
+		}
+		return aprovar(tx, dispositivo, nil, momento, maximo)
+	})
+}
+
+// aprovar trava o funcionário, como SavePonto, para que duas aprovações simultâneas não
+// ultrapassem o limite de dispositivos.
+func aprovar(tx *gorm.DB, dispositivo *model.Dispositivo, decididoPorID *uint, momento time.Time, maximo int) error {
+	var usuario model.Usuario
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ?", dispositivo.UsuarioID).First(&usuario).Error
+	if err != nil {
+		return err
+	}
+	var aprovados int64
+	err = tx.Model(&model.Dispositivo{}).
+		Where("usuario_id = ? AND status = ? AND id <> ?", dispositivo.UsuarioID, model.DispositivoAprovado, dispositivo.ID).
+		Count(&aprovados).Error
+	if err != nil {
+		return err
+	}
+	if aprovados >= int64(maximo) {
+		return ErrLimiteDispositivos
+	}
+	resultado := tx.Model(&model.Dispositivo{}).
+		Where("id = ? AND status <> ?", dispositivo.ID, model.DispositivoAprovado).
+		Updates(map[string]interface{}{"status": model.DispositivoAprovado, "decidido_por_id": decididoPorID, "decidido_em": momento})
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return ErrSituacaoInvalida
+	}
+	return nil
+}
+
+func (r *dispositivoRepository) RegistrarUso(id uint, empresaID uint, momento time.Time) error {
+	return tenant.Escopo(r.Db, empresaID).Model(&model.Dispositivo{}).Where("id = ?", id).Update("ultimo_uso_em", momento).Error
+}
+
+func (r *dispositivoRepository) SalvarCodigo(codigo *model.CodigoDispositivo) error {
+	return tenant.Escopo(r.Db, codigo.EmpresaID).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "usuario_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data_criacao", "hash", "expira_em", "tentativas", "criado_por_id"}),
+	}).Create(codigo).Error
+}
+
+func (r *dispositivoRepository) FindCodigo(usuarioID uint, empresaID uint) (*model.CodigoDispositivo, error) {
+	var codigo model.CodigoDispositivo
+	err := tenant.Escopo(r.Db, empresaID).Where("usuario_id = ?", usuarioID).First(&codigo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCodigoInvalido
+	}
+	return &codigo, err
+}
+
+func (r *dispositivoRepository) ContarTentativa(id uint, empresaID uint) error {
+	return tenant.Escopo(r.Db, empresaID).Model(&model.CodigoDispositivo{}).Where("id = ?", id).
+		Update("tentativas", gorm.Expr("tentativas + 1")).Error
+}
+
+func (r *dispositivoRepository) ApagarCodigo(id uint, empresaID uint) error {
+	return tenant.Escopo(r.Db, empresaID).Where("id = ?", id).Delete(&model.CodigoDispositivo{}).Error
+}
@@ -0,0 +1,422 @@
+// Package dispositivo controla de quais aparelhos cada funcionário bate o ponto. O aplicativo
+// envia um identificador do aparelho em cada batida; o primeiro uso cadastra o aparelho como
+// pendente, e nas empresas que exigem dispositivos autorizados a batida só é aceita depois que
+// um gestor aprova o aparelho ou que o funcionário o ativa com um código de uso único.
+package dispositivo
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+)
+
+// TamanhoMaximoIdentificador é o maior identificador de aparelho aceito.
+const TamanhoMaximoIdentificador = 200
+
+// ValidadeCodigo é por quanto tempo um código de ativação pode ser usado.
+const ValidadeCodigo = 24 * time.Hour
+
+// TentativasCodigo é quantos códigos errados invalidam o código de ativação do funcionário.
+const TentativasCodigo = 5
+
+// alfabetoCodigo deixa de fora 0, O, 1 e I, fáceis de confundir ao digitar. Oito caracteres
+// dão 40 bits, mais que suficiente para um código de 24 horas e cinco tentativas.
+const alfabetoCodigo = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const tamanhoCodigo = 8
+
+// intervaloRegistroUso evita uma escrita no banco a cada batida do mesmo aparelho.
+const intervaloRegistroUso = time.Minute
+
+var (
+	ErrIdentificadorInvalido  = fmt.Errorf("o identificador do dispositivo deve ter de 1 a %d caracteres", TamanhoMaximoIdentificador)
+	ErrDispositivoObrigatorio = errors.New("esta empresa só aceita batidas de dispositivos autorizados; envie o identificador do dispositivo")
+	ErrLimiteDispositivos     = errors.New("o funcionário já tem o número máximo de dispositivos aprovados; revogue um deles antes")
+	ErrCodigoInvalido         = errors.New("código de ativação inválido ou expirado")
+	ErrSituacaoInvalida       = errors.New("a situação atual do dispositivo não permite esta operação")
+	ErrDecisaoPropria         = errors.New("você não pode decidir sobre os seus próprios dispositivos")
+	ErrForaDoEscopo           = errors.New("você não tem permissão para gerenciar os dispositivos deste funcionário")
+)
+
+var agora = time.Now
+
+// NaoAutorizadoError recusa a batida de um dispositivo que não está aprovado. O dispositivo vai
+// na resposta para que o aplicativo mostre ao funcionário o que pedir ao gestor.
+type NaoAutorizadoError struct {
+	Dispositivo *model.Dispositivo
+}
+
+func (e *NaoAutorizadoError) Error() string {
+	switch e.Dispositivo.Status {
+	case model.DispositivoRejeitado:
+		return "este dispositivo foi rejeitado para bater o ponto"
+	case model.DispositivoRevogado:
+		return "a autorização deste dispositivo foi revogada"
+	default:
+		return "este dispositivo ainda não foi autorizado: peça a aprovação ao gestor ou ative-o com um código"
+	}
+}
+
+// Compartilhamento é um aparelho usado por mais de um funcionário, com o registro de cada um.
+type Compartilhamento struct {
+	Identificador string                 `json:"identificador"`
+	Dispositivos  []DispositivoDoUsuario `json:"dispositivos"`
+}
+
+type DispositivoDoUsuario struct {
+	model.Dispositivo
+	UsuarioNome string `json:"usuario_nome"`
+	Batidas     int64  `json:"batidas"`
+}
+
+// CodigoAtivacao é o código gerado para o funcionário, mostrado só uma vez.
+type CodigoAtivacao struct {
+	Codigo   string    `json:"codigo"`
+	ExpiraEm time.Time `json:"expira_em"`
+}
+
+// Autorizador é o que o registro de ponto usa para identificar o aparelho de cada batida.
+type Autorizador interface {
+	// Autorizar devolve o dispositivo do funcionário com o identificador, cadastrando-o como
+	// pendente no primeiro uso. Com exigir, recusa identificador vazio e dispositivo não aprovado;
+	// sem exigir, um identificador vazio devolve nil.
+	Autorizar(usuarioID uint, empresaID uint, identificador string, nome string, exigir bool) (*model.Dispositivo, error)
+}
+
+type DispositivoService interface {
+	Autorizador
+	Meus(usuarioID uint, empresaID uint) ([]model.Dispositivo, error)
+	Listar(requisitante *model.Usuario, filtro Filtro) ([]model.Dispositivo, error)
+	Compartilhados(requisitante *model.Usuario) ([]Compartilhamento, error)
+	// Aprovar, Rejeitar e Revogar devolvem o dispositivo antes e depois da decisão.
+	Aprovar(requisitante *model.Usuario, id uint) (*model.Dispositivo, *model.Dispositivo, error)
+	Rejeitar(requisitante *model.Usuario, id uint) (*model.Dispositivo, *model.Dispositivo, error)
+	Revogar(requisitante *model.Usuario, id uint) (*model.Dispositivo, *model.Dispositivo, error)
+	// GerarCodigo cria um código de ativação para o funcionário, substituindo o anterior.
+	GerarCodigo(requisitante *model.Usuario, usuarioID uint) (*CodigoAtivacao, error)
+	// Ativar aprova o aparelho do próprio funcionário com o código gerado pelo gestor.
+	Ativar(usuarioID uint, empresaID uint, identificador string, nome string, codigo string) (*model.Dispositivo, error)
+}
+
+type dispositivoService struct {
+	repo           DispositivoRepository
+	usuarioService usuario.UsuarioService
+	empresas       empresa.EmpresaService
+}
+
+func NewDispositivoService(repo DispositivoRepository, usuarioService usuario.UsuarioService, empresas empresa.EmpresaService) DispositivoService {
+	return &dispositivoService{
+		repo:           repo,
+		usuarioService: usuarioService,
+		empresas:       empresas,
+	}
+}
+
+func (s *dispositivoService) Autorizar(usuarioID uint, empresaID uint, identificador string, nome string, exigir bool) (*model.Dispositivo, error) {
+	identificador = strings.TrimSpace(identificador)
+	if identificador == "" {
+		if exigir {
+			return nil, ErrDispositivoObrigatorio
+		}
+		return nil, nil
+	}
+	dispositivo, err := s.identificar(usuarioID, empresaID, identificador, nome)
+	if err != nil {
+		return nil, err
+	}
+	if exigir && dispositivo.Status != model.DispositivoAprovado {
+		return nil, &NaoAutorizadoError{Dispositivo: dispositivo}
+	}
+
+	momento := agora()
+	if dispositivo.UltimoUsoEm == nil || momento.Sub(*dispositivo.UltimoUsoEm) > intervaloRegistroUso {
+		if err := s.repo.RegistrarUso(dispositivo.ID, empresaID, momento); err != nil {
+			return nil, err
+		}
+		dispositivo.UltimoUsoEm = &momento
+	}
+	return dispositivo, nil
+}
+
+func (s *dispositivoService) identificar(usuarioID uint, empresaID uint, identificador string, nome string) (*model.Dispositivo, error) {
+	identificador = strings.TrimSpace(identificador)
+	if identificador == "" || utf8.RuneCountInString(identificador) > TamanhoMaximoIdentificador {
+		return nil, ErrIdentificadorInvalido
+	}
+	nome = strings.TrimSpace(nome)
+	if utf8.RuneCountInString(nome) > TamanhoMaximoIdentificador {
+		nome = string([]rune(nome)[:TamanhoMaximoIdentificador])
+	}
+	return s.repo.FindOrCreate(&model.Dispositivo{
+		Identificador: identificador,
+		Nome:          nome,
+		Status:        model.DispositivoPendente,
+		UsuarioID:     usuarioID,
+		EmpresaID:     empresaID,
+	})
+}
+
+func (s *dispositivoService) Meus(usuarioID uint, empresaID uint) ([]model.Dispositivo, error) {
+	return s.repo.Buscar(empresaID, Filtro{UsuarioID: usuarioID})
+}
+
+func (s *dispositivoService) Listar(requisitante *model.Usuario, filtro Filtro) ([]model.Dispositivo, error) {
+	dispositivos, err := s.repo.Buscar(requisitante.EmpresaID, filtro)
+	if err != nil {
+		return nil, err
+	}
+	noEscopo, err := s.usuariosNoEscopo(requisitante, dispositivos)
+	if err != nil {
+		return nil, err
+	}
+	visiveis := make([]model.Dispositivo, 0, len(dispositivos))
+	for _, d := range dispositivos {
+		if noEscopo[d.UsuarioID] {
+			visiveis = append(visiveis, d)
+		}
+	}
+	return visiveis, nil
+}
+
+func (s *dispositivoService) Compartilhados(requisitante *model.Usuario) ([]Compartilhamento, error) {
+	dispositivos, err := s.repo.Compartilhados(requisitante.EmpresaID)
+	if err != nil {
+		return nil, err
+	}
+	noEscopo, err := s.usuariosNoEscopo(requisitante, dispositivos)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(dispositivos))
+	for i, d := range dispositivos {
+		ids[i] = d.ID
+	}
+	batidas, err := s.repo.ContarBatidas(ids, requisitante.EmpresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Um aparelho aparece se ao menos um dos funcionários que o usaram está no escopo: é o
+	// que basta para o gestor desconfiar de quem bate o ponto pelo colega.
+	var grupos []Compartilhamento
+	indice := map[string]int{}
+	visivel := map[string]bool{}
+	for _, d := range dispositivos {
+		i, existe := indice[d.Identificador]
+		if !existe {
+			i = len(grupos)
+			indice[d.Identificador] = i
+			grupos = append(grupos, Compartilhamento{Identificador: d.Identificador})
+		}
+		grupos[i].Dispositivos = append(grupos[i].Dispositivos, DispositivoDoUsuario{
+			Dispositivo: d,
+			UsuarioNome: d.Usuario.Nome,
+			Batidas:     batidas[d.ID],
+		})
+		if noEscopo[d.UsuarioID] {
+			visivel[d.Identificador] = true
+		}
+	}
+	resultado := make([]Compartilhamento, 0, len(visivel))
+	for _, grupo := range grupos {
+		if visivel[grupo.Identificador] {
+			resultado = append(resultado, grupo)
+		}
+	}
+	return resultado, nil
+}
+
+// usuariosNoEscopo devolve quais donos dos dispositivos estão no escopo de GERENCIAR_DISPOSITIVOS.
+func (s *dispositivoService) usuariosNoEscopo(requisitante *model.Usuario, dispositivos []model.Dispositivo) (map[uint]bool, error) {
+	usuarios := make([]model.Usuario, 0, len(dispositivos))
+	vistos := map[uint]bool{}
+	for _, d := range dispositivos {
+		if !vistos[d.UsuarioID] {
+			vistos[d.UsuarioID] = true
+			usuarios = append(usuarios, d.Usuario)
+		}
+	}
+	permitidos, err := s.usuarioService.FiltrarPorEscopo(requisitante, permissions.GERENCIAR_DISPOSITIVOS, usuarios)
+	if err != nil {
+		return nil, err
+	}
+	noEscopo := make(map[uint]bool, len(permitidos))
+	for _, u := range permitidos {
+		noEscopo[u.ID] = true
+	}
+	return noEscopo, nil
+}
+
+// Aprovar aceita também um dispositivo rejeitado ou revogado: o gestor pode voltar atrás.
+func (s *dispositivoService) Aprovar(requisitante *model.Usuario, id uint) (*model.Dispositivo, *model.Dispositivo, error) {
+	antes, err := s.paraDecidir(requisitante, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	maximo, err := s.maximo(requisitante.EmpresaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.Aprovar(antes, requisitante.ID, agora(), maximo); err != nil {
+		return nil, nil, err
+	}
+	depois, err := s.repo.FindByID(id, requisitante.EmpresaID)
+	return antes, depois, err
+}
+
+func (s *dispositivoService) Rejeitar(requisitante *model.Usuario, id uint) (*model.Dispositivo, *model.Dispositivo, error) {
+	return s.mudarStatus(requisitante, id, []string{model.DispositivoPendente}, model.DispositivoRejeitado)
+}
+
+func (s *dispositivoService) Revogar(requisitante *model.Usuario, id uint) (*model.Dispositivo, *model.Dispositivo, error) {
+	return s.mudarStatus(requisitante, id, []string{model.DispositivoAprovado}, model.DispositivoRevogado)
+}
+
+func (s *dispositivoService) mudarStatus(requisitante *model.Usuario, id uint, de []string, para string) (*model.Dispositivo, *model.Dispositivo, error) {
+	antes, err := s.paraDecidir(requisitante, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.MudarStatus(id, requisitante.EmpresaID, de, para, requisitante.ID, agora()); err != nil {
+		return nil, nil, err
+	}
+	depois, err := s.repo.FindByID(id, requisitante.EmpresaID)
+	return antes, depois, err
+}
+
+// paraDecidir busca o dispositivo e confere se o requisitante pode decidir sobre ele.
+func (s *dispositivoService) paraDecidir(requisitante *model.Usuario, id uint) (*model.Dispositivo, error) {
+	dispositivo, err := s.repo.FindByID(id, requisitante.EmpresaID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificarAlvo(requisitante, dispositivo.UsuarioID); err != nil {
+		return nil, err
+	}
+	return dispositivo, nil
+}
+
+func (s *dispositivoService) verificarAlvo(requisitante *model.Usuario, alvoID uint) error {
+	if alvoID == requisitante.ID {
+		return ErrDecisaoPropria
+	}
+	permitido, err := s.usuarioService.AlvoNoEscopo(requisitante, permissions.GERENCIAR_DISPOSITIVOS, alvoID)
+	if err != nil {
+		return err
+	}
+	if !permitido {
+		return ErrForaDoEscopo
+	}
+	return nil
+}
+
+func (s *dispositivoService) GerarCodigo(requisitante *model.Usuario, usuarioID uint) (*CodigoAtivacao, error) {
+	if _, err := s.usuarioService.FindByID(usuarioID, requisitante.EmpresaID); err != nil {
+		return nil, err
+	}
+	if err := s.verificarAlvo(requisitante, usuarioID); err != nil {
+		return nil, err
+	}
+
+	codigo, err := gerarCodigo()
+	if err != nil {
+		return nil, err
+	}
+	momento := agora()
+	registro := &model.CodigoDispositivo{
+		CreatedAt:   momento,
+		UsuarioID:   usuarioID,
+		EmpresaID:   requisitante.EmpresaID,
+		Hash:        hashCodigo(codigo),
+		ExpiraEm:    momento.Add(ValidadeCodigo),
+		CriadoPorID: requisitante.ID,
+	}
+	if err := s.repo.SalvarCodigo(registro); err != nil {
+		return nil, err
+	}
+	return &CodigoAtivacao{Codigo: codigo[:4] + "-" + codigo[4:], ExpiraEm: registro.ExpiraEm}, nil
+}
+
+func (s *dispositivoService) Ativar(usuarioID uint, empresaID uint, identificador string, nome string, codigo string) (*model.Dispositivo, error) {
+	registro, err := s.repo.FindCodigo(usuarioID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	momento := agora()
+	if !momento.Before(registro.ExpiraEm) || registro.Tentativas >= TentativasCodigo {
+		if err := s.repo.ApagarCodigo(registro.ID, empresaID); err != nil {
+			return nil, err
+		}
+		return nil, ErrCodigoInvalido
+	}
+	if subtle.ConstantTimeCompare([]byte(registro.Hash), []byte(hashCodigo(normalizarCodigo(codigo)))) != 1 {
+		if registro.Tentativas+1 >= TentativasCodigo {
+			err = s.repo.ApagarCodigo(registro.ID, empresaID)
+		} else {
+			err = s.repo.ContarTentativa(registro.ID, empresaID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrCodigoInvalido
+	}
+
+	dispositivo, err := s.identificar(usuarioID, empresaID, identificador, nome)
+	if err != nil {
+		return nil, err
+	}
+	if dispositivo.Status == model.DispositivoAprovado {
+		return dispositivo, nil
+	}
+	maximo, err := s.maximo(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AtivarComCodigo(dispositivo, registro.ID, momento, maximo); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(dispositivo.ID, empresaID)
+}
+
+func (s *dispositivoService) maximo(empresaID uint) (int, error) {
+	e, err := s.empresas.GetEmpresaByIDSer(empresaID)
+	if err != nil {
+		return 0, err
+	}
+	return e.MaximoDispositivos, nil
+}
+
+func gerarCodigo() (string, error) {
+	aleatorio := make([]byte, tamanhoCodigo)
+	if _, err := rand.Read(aleatorio); err != nil {
+		return "", err
+	}
+	codigo := make([]byte, tamanhoCodigo)
+	for i, b := range aleatorio {
+		// 256 é múltiplo de 32, então o resto não favorece nenhum caractere.
+		codigo[i] = alfabetoCodigo[int(b)%len(alfabetoCodigo)]
+	}
+	return string(codigo), nil
+}
+
+// normalizarCodigo aceita o código como foi exibido ("ABCD-EFGH"), em minúsculas ou com espaços.
+func normalizarCodigo(codigo string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(codigo)))
+}
+
+// O código é de uso único, curto e limitado a poucas tentativas, então um SHA-256 simples basta.
+func hashCodigo(codigo string) string {
+	soma := sha256.Sum256([]byte(codigo))
+	return hex.EncodeToString(soma[:])
+}
@@ -0,0 +1,313 @@
+package dispositivo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+type memoriaDispositivoRepository struct {
+	DispositivoRepository
+	dispositivos map[uint]*model.Dispositivo
+	codigos      map[uint]*model.CodigoDispositivo
+	usos         int
+}
+
+func (m *memoriaDispositivoRepository) FindOrCreate(dispositivo *model.Dispositivo) (*model.Dispositivo, error) {
+	for _, d := range m.dispositivos {
+		if d.UsuarioID == dispositivo.UsuarioID && d.Identificador == dispositivo.Identificador {
+			copia := *d
+			return &copia, nil
+		}
+	}
+	dispositivo.ID = uint(len(m.dispositivos) + 1)
+	m.dispositivos[dispositivo.ID] = dispositivo
+	copia := *dispositivo
+	return &copia, nil
+}
+
+func (m *memoriaDispositivoRepository) FindByID(id uint, empresaID uint) (*model.Dispositivo, error) {
+	if d, ok := m.dispositivos[id]; ok && d.EmpresaID == empresaID {
+		copia := *d
+		copia.Usuario = model.Usuario{ID: d.UsuarioID, Nome: "Usuário"}
+		return &copia, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaDispositivoRepository) Compartilhados(empresaID uint) ([]model.Dispositivo, error) {
+	usuarios := map[string]map[uint]bool{}
+	for _, d := range m.dispositivos {
+		if usuarios[d.Identificador] == nil {
+			usuarios[d.Identificador] = map[uint]bool{}
+		}
+		usuarios[d.Identificador][d.UsuarioID] = true
+	}
+	var compartilhados []model.Dispositivo
+	for id := uint(1); id <= uint(len(m.dispositivos)); id++ {
+		d := m.dispositivos[id]
+		if len(usuarios[d.Identificador]) > 1 {
+			copia := *d
+			copia.Usuario = model.Usuario{ID: d.UsuarioID}
+			compartilhados = append(compartilhados, copia)
+		}
+	}
+	return compartilhados, nil
+}
+
+func (m *memoriaDispositivoRepository) ContarBatidas(ids []uint, empresaID uint) (map[uint]int64, error) {
+	contagens := map[uint]int64{}
+	for _, id := range ids {
+		contagens[id] = int64(id) * 10
+	}
+	return contagens, nil
+}
+
+func (m *memoriaDispositivoRepository) MudarStatus(id uint, empresaID uint, de []string, para string, decididoPorID uint, momento time.Time) error {
+	d := m.dispositivos[id]
+	for _, status := range de {
+		if d.Status == status {
+			d.Status = para
+			return nil
+		}
+	}
+	return ErrSituacaoInvalida
+}
+
+func (m *memoriaDispositivoRepository) Aprovar(dispositivo *model.Dispositivo, decididoPorID uint, momento time.Time, maximo int) error {
+	aprovados := 0
+	for _, d := range m.dispositivos {
+		if d.UsuarioID == dispositivo.UsuarioID && d.Status == model.DispositivoAprovado && d.ID != dispositivo.ID {
+			aprovados++
+		}
+	}
+	if aprovados >= maximo {
+		return ErrLimiteDispositivos
+	}
+	m.dispositivos[dispositivo.ID].Status = model.DispositivoAprovado
+	return nil
+}
+
+func (m *memoriaDispositivoRepository) AtivarComCodigo(dispositivo *model.Dispositivo, codigoID uint, momento time.Time, maximo int) error {
+	if err := m.Aprovar(dispositivo, 0, momento, maximo); err != nil {
+		return err
+	}
+	return m.ApagarCodigo(codigoID, dispositivo.EmpresaID)
+}
+
+func (m *memoriaDispositivoRepository) RegistrarUso(id uint, empresaID uint, momento time.Time) error {
+	m.usos++
+	return nil
+}
+
+func (m *memoriaDispositivoRepository) SalvarCodigo(codigo *model.CodigoDispositivo) error {
+	codigo.ID = codigo.UsuarioID
+	m.codigos[codigo.UsuarioID] = codigo
+	return nil
+}
+
+func (m *memoriaDispositivoRepository) FindCodigo(usuarioID uint, empresaID uint) (*model.CodigoDispositivo, error) {
+	if c, ok := m.codigos[usuarioID]; ok {
+		copia := *c
+		return &copia, nil
+	}
+	return nil, ErrCodigoInvalido
+}
+
+func (m *memoriaDispositivoRepository) ContarTentativa(id uint, empresaID uint) error {
+	m.codigos[id].Tentativas++
+	return nil
+}
+
+func (m *memoriaDispositivoRepository) ApagarCodigo(id uint, empresaID uint) error {
+	delete(m.codigos, id)
+	return nil
+}
+
+// mockUsuarioService põe no escopo do gestor só os funcionários da sua equipe.
+type mockUsuarioService struct {
+	usuario.UsuarioService
+	equipe map[uint]bool
+}
+
+func (m *mockUsuarioService) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
+	return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+}
+
+func (m *mockUsuarioService) AlvoNoEscopo(requisitante *model.Usuario, permissao string, alvoID uint) (bool, error) {
+	return m.equipe[alvoID], nil
+}
+
+func (m *mockUsuarioService) FiltrarPorEscopo(requisitante *model.Usuario, permissao string, usuarios []model.Usuario) ([]model.Usuario, error) {
+	var visiveis []model.Usuario
+	for _, u := range usuarios {
+		if m.equipe[u.ID] {
+			visiveis = append(visiveis, u)
+		}
+	}
+	return visiveis, nil
+}
+
+type mockEmpresas struct {
+	empresa.EmpresaService
+}
+
+func (m *mockEmpresas) GetEmpresaByIDSer(idempresa uint) (*model.Empresa, error) {
+	return &model.Empresa{ID: idempresa, MaximoDispositivos: 1}, nil
+}
+
+var gestor = &model.Usuario{ID: 1, EmpresaID: 1}
+
+func novoServico(t *testing.T) (*memoriaDispositivoRepository, DispositivoService) {
+	t.Helper()
+	momento := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	agora = func() time.Time { return momento }
+	t.Cleanup(func() { agora = time.Now })
+	repo := &memoriaDispositivoRepository{dispositivos: map[uint]*model.Dispositivo{}, codigos: map[uint]*model.CodigoDispositivo{}}
+	usuarios := &mockUsuarioService{equipe: map[uint]bool{7: true, 8: true}}
+	return repo, NewDispositivoService(repo, usuarios, &mockEmpresas{})
+}
+
+func TestAutorizar(t *testing.T) {
+	repo, service := novoServico(t)
+
+	if d, err := service.Autorizar(7, 1, " ", "", false); d != nil || err != nil {
+		t.Errorf("Sem exigência, a batida sem aparelho deveria passar, recebeu %v, %v", d, err)
+	}
+	if _, err := service.Autorizar(7, 1, "", "", true); !errors.Is(err, ErrDispositivoObrigatorio) {
+		t.Errorf("Esperava ErrDispositivoObrigatorio, recebeu %v", err)
+	}
+	if _, err := service.Autorizar(7, 1, strings.Repeat("a", TamanhoMaximoIdentificador+1), "", true); !errors.Is(err, ErrIdentificadorInvalido) {
+		t.Errorf("Esperava ErrIdentificadorInvalido, recebeu %v", err)
+	}
+
+	// O primeiro uso cadastra o aparelho como pendente, e a batida é recusada.
+	_, err := service.Autorizar(7, 1, "celular-a", "Moto G", true)
+	var naoAutorizado *NaoAutorizadoError
+	if !errors.As(err, &naoAutorizado) || naoAutorizado.Dispositivo.Status != model.DispositivoPendente {
+		t.Fatalf("Esperava NaoAutorizadoError com o aparelho pendente, recebeu %v", err)
+	}
+	if len(repo.dispositivos) != 1 || repo.dispositivos[1].Nome != "Moto G" {
+		t.Errorf("O aparelho deveria ter sido cadastrado, recebeu %+v", repo.dispositivos)
+	}
+
+	repo.dispositivos[1].Status = model.DispositivoAprovado
+	d, err := service.Autorizar(7, 1, "celular-a", "", true)
+	if err != nil || d.ID != 1 {
+		t.Fatalf("O aparelho aprovado deveria ser aceito, recebeu %v, %v", d, err)
+	}
+	if repo.usos != 1 {
+		t.Errorf("O uso do aparelho deveria ser registrado uma vez, recebeu %d", repo.usos)
+	}
+	// Sem a exigência, um aparelho pendente não impede a batida, mas fica cadastrado.
+	if d, err := service.Autorizar(7, 1, "celular-b", "", false); err != nil || d.Status != model.DispositivoPendente {
+		t.Errorf("Sem exigência, o aparelho pendente deveria ser aceito, recebeu %v, %v", d, err)
+	}
+}
+
+func TestDecidir(t *testing.T) {
+	repo, service := novoServico(t)
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-a", Status: model.DispositivoPendente, UsuarioID: 7, EmpresaID: 1})
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-b", Status: model.DispositivoPendente, UsuarioID: 7, EmpresaID: 1})
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-c", Status: model.DispositivoPendente, UsuarioID: 9, EmpresaID: 1})
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-d", Status: model.DispositivoPendente, UsuarioID: 1, EmpresaID: 1})
+
+	if _, _, err := service.Aprovar(gestor, 3); !errors.Is(err, ErrForaDoEscopo) {
+		t.Errorf("Esperava ErrForaDoEscopo fora da equipe, recebeu %v", err)
+	}
+	if _, _, err := service.Aprovar(gestor, 4); !errors.Is(err, ErrDecisaoPropria) {
+		t.Errorf("Esperava ErrDecisaoPropria para o próprio aparelho, recebeu %v", err)
+	}
+
+	antes, depois, err := service.Aprovar(gestor, 1)
+	if err != nil || antes.Status != model.DispositivoPendente || depois.Status != model.DispositivoAprovado {
+		t.Fatalf("Esperava o aparelho aprovado, recebeu %v, %v, %v", antes, depois, err)
+	}
+	// A empresa permite um aparelho aprovado por funcionário.
+	if _, _, err := service.Aprovar(gestor, 2); !errors.Is(err, ErrLimiteDispositivos) {
+		t.Errorf("Esperava ErrLimiteDispositivos, recebeu %v", err)
+	}
+	if _, _, err := service.Rejeitar(gestor, 1); !errors.Is(err, ErrSituacaoInvalida) {
+		t.Errorf("Um aparelho aprovado não deveria ser rejeitado, recebeu %v", err)
+	}
+	if _, depois, err := service.Revogar(gestor, 1); err != nil || depois.Status != model.DispositivoRevogado {
+		t.Fatalf("Esperava o aparelho revogado, recebeu %v, %v", depois, err)
+	}
+	if _, depois, err := service.Aprovar(gestor, 2); err != nil || depois.Status != model.DispositivoAprovado {
+		t.Errorf("Depois da revogação, outro aparelho deveria caber no limite, recebeu %v, %v", depois, err)
+	}
+}
+
+func TestAtivarComCodigo(t *testing.T) {
+	_, service := novoServico(t)
+
+	if _, err := service.GerarCodigo(gestor, 9); !errors.Is(err, ErrForaDoEscopo) {
+		t.Errorf("Esperava ErrForaDoEscopo fora da equipe, recebeu %v", err)
+	}
+	codigo, err := service.GerarCodigo(gestor, 7)
+	if err != nil {
+		t.Fatalf("Erro ao gerar o código: %v", err)
+	}
+	if len(codigo.Codigo) != tamanhoCodigo+1 || !codigo.ExpiraEm.Equal(agora().Add(ValidadeCodigo)) {
+		t.Errorf("Código inesperado: %+v", codigo)
+	}
+
+	if _, err := service.Ativar(7, 1, "celular-a", "", "AAAA-AAAA"); !errors.Is(err, ErrCodigoInvalido) {
+		t.Errorf("Esperava ErrCodigoInvalido para um código errado, recebeu %v", err)
+	}
+	// Minúsculas e sem o hífen também valem.
+	d, err := service.Ativar(7, 1, "celular-a", "Moto G", strings.ToLower(strings.ReplaceAll(codigo.Codigo, "-", "")))
+	if err != nil || d.Status != model.DispositivoAprovado {
+		t.Fatalf("Esperava o aparelho aprovado, recebeu %v, %v", d, err)
+	}
+	if _, err := service.Ativar(7, 1, "celular-b", "", codigo.Codigo); !errors.Is(err, ErrCodigoInvalido) {
+		t.Errorf("O código é de uso único, recebeu %v", err)
+	}
+}
+
+func TestAtivarComCodigo_TentativasEExpiracao(t *testing.T) {
+	repo, service := novoServico(t)
+	codigo, _ := service.GerarCodigo(gestor, 7)
+	for i := 0; i < TentativasCodigo; i++ {
+		service.Ativar(7, 1, "celular-a", "", "AAAA-AAAA")
+	}
+	if _, err := service.Ativar(7, 1, "celular-a", "", codigo.Codigo); !errors.Is(err, ErrCodigoInvalido) {
+		t.Errorf("Depois de %d erros, o código deveria estar invalidado, recebeu %v", TentativasCodigo, err)
+	}
+
+	codigo, _ = service.GerarCodigo(gestor, 7)
+	repo.codigos[7].ExpiraEm = agora()
+	if _, err := service.Ativar(7, 1, "celular-a", "", codigo.Codigo); !errors.Is(err, ErrCodigoInvalido) {
+		t.Errorf("Esperava ErrCodigoInvalido para um código expirado, recebeu %v", err)
+	}
+	if len(repo.codigos) != 0 {
+		t.Error("O código expirado deveria ter sido apagado")
+	}
+}
+
+func TestCompartilhados(t *testing.T) {
+	repo, service := novoServico(t)
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-a", UsuarioID: 7, EmpresaID: 1})
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-a", UsuarioID: 9, EmpresaID: 1})
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-b", UsuarioID: 9, EmpresaID: 1})
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-b", UsuarioID: 10, EmpresaID: 1})
+	repo.FindOrCreate(&model.Dispositivo{Identificador: "celular-c", UsuarioID: 8, EmpresaID: 1})
+
+	compartilhados, err := service.Compartilhados(gestor)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	// celular-b só foi usado por funcionários de fora da equipe.
+	if len(compartilhados) != 1 || compartilhados[0].Identificador != "celular-a" || len(compartilhados[0].Dispositivos) != 2 {
+		t.Fatalf("Esperava só celular-a, com os dois funcionários, recebeu %+v", compartilhados)
+	}
+	if compartilhados[0].Dispositivos[1].UsuarioID != 9 || compartilhados[0].Dispositivos[1].Batidas != 20 {
+		t.Errorf("O colega de fora da equipe deveria aparecer com as suas batidas, recebeu %+v", compartilhados[0].Dispositivos[1])
+	}
+}
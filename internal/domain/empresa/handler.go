@@ -5,8 +5,10 @@ import (
 	"github.com/Loviiin/ponto-api-go/internal/config"
 	"net/http"
 
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
 	"github.com/Loviiin/ponto-api-go/internal/model"
 	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -33,6 +35,7 @@ func (h *EmpresaHandler) CriarEmpresaHandler(c *gin.Context) {
 		SedeLatitude       float64 `json:"sedeLatitude" binding:"required"`
 		SedeLongitude      float64 `json:"sedeLongitude" binding:"required"`
 		RaioGeofenceMetros float64 `json:"raioGeofenceMetros" binding:"required"`
+		FusoHorario        string  `json:"fuso_horario"`
 	}
 
 	var request criaEmpresaRequest
@@ -45,10 +48,15 @@ func (h *EmpresaHandler) CriarEmpresaHandler(c *gin.Context) {
 		SedeLatitude:       request.SedeLatitude,
 		SedeLongitude:      request.SedeLongitude,
 		RaioGeofenceMetros: request.RaioGeofenceMetros,
+		FusoHorario:        request.FusoHorario,
 	}
 
 	err := h.service.CreateEmpresa(&empresa)
 	if err != nil {
+		if errors.Is(err, fuso.ErrFusoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -68,6 +76,27 @@ func (h *EmpresaHandler) GetAllEmpresasHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, empresas)
 }
 
+// GetMinhaEmpresaHandler devolve apenas a empresa do usuário logado.
+// A listagem de todas as empresas fica restrita aos operadores da plataforma.
+func (h *EmpresaHandler) GetMinhaEmpresaHandler(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	empresa, err := h.service.GetEmpresaByIDSer(empresaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Empresa não encontrada"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar empresa"})
+		return
+	}
+	c.JSON(http.StatusOK, empresa)
+}
+
 // GetEmpresaByIDHandler não precisa de alterações.
 func (h *EmpresaHandler) GetEmpresaByIDHandler(c *gin.Context) {
 	id, err := h.converter.StrParaUint(c.Param("id"))
@@ -88,11 +117,29 @@ func (h *EmpresaHandler) GetEmpresaByIDHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, empresa)
 }
 
-// UpdateEmpresaHandler não precisa de alterações.
-func (h *EmpresaHandler) UpdateEmpresaHandler(c *gin.Context) {
+// idDaPropriaEmpresa lê o ID da URL e exige que seja a empresa do token: as permissões de editar e
+// apagar valem apenas para a própria empresa. Em caso de erro a resposta já foi escrita.
+func (h *EmpresaHandler) idDaPropriaEmpresa(c *gin.Context) (uint, bool) {
 	idEmpresa, err := h.converter.StrParaUint(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "O ID da empresa na URL é inválido."})
+		return 0, false
+	}
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	if idEmpresa != empresaID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Você só pode alterar a sua própria empresa."})
+		return 0, false
+	}
+	return idEmpresa, true
+}
+
+func (h *EmpresaHandler) UpdateEmpresaHandler(c *gin.Context) {
+	idEmpresa, ok := h.idDaPropriaEmpresa(c)
+	if !ok {
 		return
 	}
 
@@ -102,23 +149,28 @@ func (h *EmpresaHandler) UpdateEmpresaHandler(c *gin.Context) {
 		return
 	}
 
+	antes, _ := h.service.GetEmpresaByIDSer(idEmpresa)
 	if err := h.service.UpdateEmpresaSer(idEmpresa, dadosParaAtualizar); err != nil {
+		if errors.Is(err, ErrIntervaloMinimoInvalido) || errors.Is(err, ErrRetencaoFotosInvalida) || errors.Is(err, ErrMaximoDispositivos) || errors.Is(err, fuso.ErrFusoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao atualizar a empresa"})
 		return
 	}
+	depois, _ := h.service.GetEmpresaByIDSer(idEmpresa)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoEmpresaAtualizada, Entidade: "empresas", EntidadeID: idEmpresa, Antes: antes, Depois: depois})
 
 	c.Status(http.StatusNoContent)
 }
 
-// DeleteEmpresaHandler não precisa de alterações.
 func (h *EmpresaHandler) DeleteEmpresaHandler(c *gin.Context) {
-	idEmpresa, err := h.converter.StrParaUint(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "O ID da empresa na URL é inválido."})
+	idEmpresa, ok := h.idDaPropriaEmpresa(c)
+	if !ok {
 		return
 	}
 
-	err = h.service.DeleteEmpresaSer(idEmpresa)
+	err := h.service.DeleteEmpresaSer(idEmpresa)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Empresa não encontrada para deletar."})
@@ -130,3 +182,24 @@ func (h *EmpresaHandler) DeleteEmpresaHandler(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// RestaurarEmpresaHandler desfaz a exclusão de uma empresa. É rota da plataforma: com a empresa
+// excluída, nenhum usuário dela consegue entrar para pedir a restauração.
+func (h *EmpresaHandler) RestaurarEmpresaHandler(c *gin.Context) {
+	idEmpresa, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da empresa inválido."})
+		return
+	}
+
+	if err := h.service.RestaurarEmpresaSer(idEmpresa); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nenhuma empresa excluída com este ID."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao restaurar a empresa."})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -12,6 +12,7 @@ type EmpresaRepository interface {
 	GetEmpresaByID(idempresa uint) (*model.Empresa, error)
 	UpdateEmpresa(idempresa uint, dados map[string]interface{}) error
 	DeleteEmpresa(idempresa uint) error
+	RestaurarEmpresa(idempresa uint) error
 }
 
 type empresaRepository struct {
@@ -49,9 +50,16 @@ func (r *empresaRepository) UpdateEmpresa(idempresa uint, dados map[string]inter
 	return err
 }
 
-// DeleteEmpresa remove uma empresa do banco de dados.
+// DeleteEmpresa faz a exclusão lógica da empresa. Os dados continuam no banco até o fim do prazo
+// de retenção legal, quando o expurgo (pacote retencao) anonimiza os funcionários.
 func (r *empresaRepository) DeleteEmpresa(idempresa uint) error {
-	// A função Unscoped() garante uma exclusão permanente (hard delete).
-	// Sem ela, o GORM faria um soft delete se o modelo tivesse um campo gorm.DeletedAt.
-	return r.Db.Unscoped().Delete(&model.Empresa{}, idempresa).Error
+	return r.Db.Delete(&model.Empresa{}, idempresa).Error
+}
+
+func (r *empresaRepository) RestaurarEmpresa(idempresa uint) error {
+	resultado := r.Db.Unscoped().Model(&model.Empresa{}).Where("id = ? AND data_exclusao IS NOT NULL", idempresa).Update("data_exclusao", nil)
+	if resultado.Error == nil && resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return resultado.Error
 }
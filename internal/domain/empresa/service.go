@@ -1,7 +1,28 @@
 package empresa
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
+)
+
+// IntervaloMinimoMaximoSegundos limita a configuração a uma hora, para que um valor errado não
+// impeça o funcionário de registrar a saída.
+const IntervaloMinimoMaximoSegundos = 3600
+
+// RetencaoFotosMaximaDias limita a guarda das selfies a dez anos.
+const RetencaoFotosMaximaDias = 3650
+
+// MaximoDispositivosLimite é o maior número de aparelhos aprovados que a empresa pode permitir por funcionário.
+const MaximoDispositivosLimite = 20
+
+var (
+	ErrIntervaloMinimoInvalido = errors.New("'intervalo_minimo_batida_segundos' deve ser um número inteiro de 0 a 3600")
+	ErrRetencaoFotosInvalida   = errors.New("'retencao_fotos_dias' deve ser um número inteiro de 0 a 3650")
+	ErrMaximoDispositivos      = errors.New("'maximo_dispositivos' deve ser um número inteiro de 1 a 20")
 )
 
 type EmpresaService interface {
@@ -10,6 +31,18 @@ type EmpresaService interface {
 	GetEmpresaByIDSer(idempresa uint) (*model.Empresa, error)
 	UpdateEmpresaSer(idempresa uint, dados map[string]interface{}) error
 	DeleteEmpresaSer(idempresa uint) error
+	RestaurarEmpresaSer(idempresa uint) error
+	// Fuso devolve o fuso horário da empresa, usado para ler as datas (AAAA-MM-DD) recebidas.
+	Fuso(idempresa uint) (*time.Location, error)
+}
+
+// FusoDaEmpresa carrega o fuso horário configurado na empresa.
+func FusoDaEmpresa(repo EmpresaRepository, idempresa uint) (*time.Location, error) {
+	dados, err := repo.FindByID(idempresa)
+	if err != nil {
+		return nil, err
+	}
+	return fuso.Carregar(dados.FusoHorario)
 }
 
 type empresaService struct {
@@ -23,6 +56,13 @@ func NewEmpresaService(repo EmpresaRepository) EmpresaService {
 }
 
 func (s *empresaService) CreateEmpresa(empresa *model.Empresa) error {
+	empresa.FusoHorario = strings.TrimSpace(empresa.FusoHorario)
+	if empresa.FusoHorario == "" {
+		empresa.FusoHorario = fuso.Padrao
+	}
+	if err := fuso.Validar(empresa.FusoHorario); err != nil {
+		return err
+	}
 	return s.empresaRepo.CreateEmpresa(empresa)
 }
 
@@ -35,6 +75,31 @@ func (s *empresaService) GetEmpresaByIDSer(idempresa uint) (*model.Empresa, erro
 }
 
 func (s *empresaService) UpdateEmpresaSer(idempresa uint, dados map[string]interface{}) error {
+	if valor, ok := dados["intervalo_minimo_batida_segundos"]; ok {
+		segundos, numero := valor.(float64)
+		if !numero || segundos != float64(int(segundos)) || segundos < 0 || segundos > IntervaloMinimoMaximoSegundos {
+			return ErrIntervaloMinimoInvalido
+		}
+	}
+	if valor, ok := dados["retencao_fotos_dias"]; ok {
+		dias, numero := valor.(float64)
+		if !numero || dias != float64(int(dias)) || dias < 0 || dias > RetencaoFotosMaximaDias {
+			return ErrRetencaoFotosInvalida
+		}
+	}
+	if valor, ok := dados["maximo_dispositivos"]; ok {
+		maximo, numero := valor.(float64)
+		if !numero || maximo != float64(int(maximo)) || maximo < 1 || maximo > MaximoDispositivosLimite {
+			return ErrMaximoDispositivos
+		}
+	}
+	if valor, ok := dados["fuso_horario"]; ok {
+		nome, texto := valor.(string)
+		if !texto || fuso.Validar(nome) != nil {
+			return fuso.ErrFusoInvalido
+		}
+		dados["fuso_horario"] = strings.TrimSpace(nome)
+	}
 	_, err := s.empresaRepo.FindByID(idempresa)
 	if err != nil {
 		return err
@@ -50,3 +115,11 @@ func (s *empresaService) DeleteEmpresaSer(idempresa uint) error {
 
 	return s.empresaRepo.DeleteEmpresa(idempresa)
 }
+
+func (s *empresaService) Fuso(idempresa uint) (*time.Location, error) {
+	return FusoDaEmpresa(s.empresaRepo, idempresa)
+}
+
+func (s *empresaService) RestaurarEmpresaSer(idempresa uint) error {
+	return s.empresaRepo.RestaurarEmpresa(idempresa)
+}
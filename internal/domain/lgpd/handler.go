@@ -0,0 +1,108 @@
+package lgpd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service        LGPDService
+	usuarioService usuario.UsuarioService
+	converter      funcoes.FuncoesInterface
+}
+
+func NewHandler(s LGPDService, u usuario.UsuarioService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:        s,
+		usuarioService: u,
+		converter:      f,
+	}
+}
+
+// autorizar confere se o requisitante pode agir sobre os dados do titular da URL. O próprio titular
+// sempre pode exportar os seus dados; para os demais casos vale o escopo de GERENCIAR_DADOS_PESSOAIS.
+func (h *Handler) autorizar(c *gin.Context, proprioTitularPode bool) (titularID uint, empresaID uint, ok bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+	requisitanteID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem acessar dados pessoais."})
+		return 0, 0, false
+	}
+	titularID, err = h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do usuário inválido."})
+		return 0, 0, false
+	}
+	if proprioTitularPode && titularID == requisitanteID {
+		return titularID, empresaID, true
+	}
+
+	requisitante, err := usuario.Requisitante(c, h.usuarioService, requisitanteID, empresaID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+		return 0, 0, false
+	}
+	permitido, err := h.usuarioService.AlvoNoEscopo(requisitante, permissions.GERENCIAR_DADOS_PESSOAIS, titularID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao verificar permissões."})
+		return 0, 0, false
+	}
+	if !permitido {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado. Você não tem permissão para realizar esta ação."})
+		return 0, 0, false
+	}
+	return titularID, empresaID, true
+}
+
+func (h *Handler) Exportar(c *gin.Context) {
+	titularID, empresaID, ok := h.autorizar(c, true)
+	if !ok {
+		return
+	}
+
+	arquivo, err := h.service.Exportar(titularID, empresaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao exportar os dados pessoais."})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="dados-usuario-%d.zip"`, titularID))
+	c.Data(http.StatusOK, "application/zip", arquivo)
+}
+
+func (h *Handler) Anonimizar(c *gin.Context) {
+	titularID, empresaID, ok := h.autorizar(c, false)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Anonimizar(titularID, empresaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado."})
+			return
+		}
+		if errors.Is(err, ErrJaAnonimizado) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao anonimizar os dados pessoais."})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
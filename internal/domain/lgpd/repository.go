@@ -0,0 +1,179 @@
+package lgpd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+// NomeAnonimizado substitui o nome de quem teve os dados pessoais apagados.
+const NomeAnonimizado = "Usuário anonimizado"
+
+// DadosTitular é tudo o que a empresa guarda sobre um usuário, o titular dos dados na LGPD.
+type DadosTitular struct {
+	Perfil       model.Usuario
+	Pontos       []model.RegistroPonto
+	Auditoria    []model.RegistroAuditoria
+	Identidades  []model.IdentidadeExterna
+	Convites     []model.Convite
+	Solicitacoes []model.SolicitacaoCadastro
+	Dispositivos []model.Dispositivo
+}
+
+type LGPDRepository interface {
+	// FindTitular busca o usuário mesmo que excluído: ex-funcionários também têm direito de acesso.
+	FindTitular(usuarioID uint, empresaID uint) (*model.Usuario, error)
+	ColetarDados(titular *model.Usuario) (*DadosTitular, error)
+	// Anonimizar devolve as chaves das fotos de batida que deixaram de ser referenciadas, para que
+	// os arquivos sejam apagados depois da transação.
+	Anonimizar(titular model.Usuario, momento time.Time) ([]string, error)
+}
+
+type lgpdRepository struct {
+	Db *gorm.DB
+}
+
+func NewLGPDRepository(db *gorm.DB) LGPDRepository {
+	return &lgpdRepository{Db: db}
+}
+
+func (r *lgpdRepository) FindTitular(usuarioID uint, empresaID uint) (*model.Usuario, error) {
+	var usuario model.Usuario
+	err := tenant.Escopo(r.Db, empresaID).Unscoped().Preload("Cargo").
+		Where("id = ?", usuarioID).First(&usuario).Error
+	return &usuario, err
+}
+
+func (r *lgpdRepository) ColetarDados(titular *model.Usuario) (*DadosTitular, error) {
+	db := tenant.Escopo(r.Db, titular.EmpresaID)
+	dados := &DadosTitular{Perfil: *titular}
+
+	if err := db.Where("usuario_id = ?", titular.ID).Order("timestamp asc").Find(&dados.Pontos).Error; err != nil {
+		return nil, err
+	}
+	// Entra o que o usuário fez e o que foi feito com o cadastro dele (inclusive os fechamentos do banco de horas).
+	err := db.Where("(ator_tipo = ? AND ator_id = ?) OR (entidade = ? AND entidade_id = ?)",
+		model.AtorUsuario, titular.ID, "usuarios", strconv.FormatUint(uint64(titular.ID), 10)).
+		Order("id asc").Find(&dados.Auditoria).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Where("usuario_id = ?", titular.ID).Find(&dados.Identidades).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("usuario_id = ?", titular.ID).Order("id asc").Find(&dados.Convites).Error; err != nil {
+		return nil, err
+	}
+	convites := db.Model(&model.Convite{}).Select("id").Where("usuario_id = ?", titular.ID)
+	if err := db.Where("convite_id IN (?)", convites).Order("id asc").Find(&dados.Solicitacoes).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("usuario_id = ?", titular.ID).Order("id asc").Find(&dados.Dispositivos).Error; err != nil {
+		return nil, err
+	}
+	return dados, nil
+}
+
+func (r *lgpdRepository) Anonimizar(titular model.Usuario, momento time.Time) ([]string, error) {
+	var fotos []string
+	err := tenant.Escopo(r.Db, titular.EmpresaID).Transaction(func(tx *gorm.DB) error {
+		var err error
+		fotos, err = AnonimizarDadosPessoais(tx, titular, momento)
+		return err
+	})
+	return fotos, err
+}
+
+// ChavesDasFotos lista os arquivos das selfies ainda guardadas dos pontos do usuário.
+func ChavesDasFotos(tx *gorm.DB, usuarioID uint) ([]string, error) {
+	var chaves []string
+	err := tx.Model(&model.RegistroPonto{}).Where("usuario_id = ? AND foto_chave IS NOT NULL", usuarioID).
+		Pluck("foto_chave", &chaves).Error
+	return chaves, err
+}
+
+// emailAnonimizado mantém o e-mail único sem guardar nada da pessoa; o domínio .invalid nunca recebe mensagens.
+func emailAnonimizado(usuarioID uint) string {
+	return fmt.Sprintf("anonimizado-%d@anonimizado.invalid", usuarioID)
+}
+
+// AnonimizarDadosPessoais troca, de forma irreversível, os dados pessoais do usuário: nome, e-mail,
+// senha, matrícula e PIN no cadastro, nos convites e pedidos de cadastro, o nome e o identificador dos
+// aparelhos, e as coordenadas e justificativas dos
+// registros de ponto, além das selfies das batidas, cujas chaves são devolvidas para que os arquivos
+// sejam apagados depois da transação. Os horários dos pontos continuam, pois são exigidos pela legislação
+// trabalhista. O usuário também passa a constar como excluído. Deve rodar dentro de uma transação
+// restrita à empresa do usuário.
+//
+// O log de auditoria é somente de inserção e não é alterado. Ele não guarda os dados pessoais
+// apagados aqui: as diferenças antes/depois registram só que esses campos mudaram
+// (auditoria.DiferencasDaEntidade).
+func AnonimizarDadosPessoais(tx *gorm.DB, usuario model.Usuario, momento time.Time) ([]string, error) {
+	fotos, err := ChavesDasFotos(tx, usuario.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Model(&model.RegistroPonto{}).Where("usuario_id = ?", usuario.ID).
+		Updates(map[string]interface{}{"latitude": 0, "longitude": 0, "justificativa": ""}).Error
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Model(&model.RegistroPonto{}).Where("usuario_id = ? AND foto_chave IS NOT NULL", usuario.ID).
+		Updates(map[string]interface{}{"foto_chave": nil, "foto_expurgada_em": momento}).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("usuario_id = ?", usuario.ID).Delete(&model.IdentidadeExterna{}).Error; err != nil {
+		return nil, err
+	}
+	// Os aparelhos continuam, porque os pontos apontam para eles, mas sem nada que os identifique.
+	err = tx.Model(&model.Dispositivo{}).Where("usuario_id = ?", usuario.ID).
+		Updates(map[string]interface{}{"nome": "", "identificador": gorm.Expr("'anonimizado-' || id")}).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("usuario_id = ?", usuario.ID).Delete(&model.CodigoDispositivo{}).Error; err != nil {
+		return nil, err
+	}
+
+	email := emailAnonimizado(usuario.ID)
+	convites := tx.Model(&model.Convite{}).Select("id").Where("usuario_id = ?", usuario.ID)
+	err = tx.Model(&model.SolicitacaoCadastro{}).Where("convite_id IN (?)", convites).
+		Updates(map[string]interface{}{"nome": NomeAnonimizado, "email": email}).Error
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Model(&model.Convite{}).Where("usuario_id = ?", usuario.ID).
+		Updates(map[string]interface{}{"nome": NomeAnonimizado, "email": email}).Error
+	if err != nil {
+		return nil, err
+	}
+	// As tentativas de login são guardadas pela chave do e-mail, que também é dado pessoal.
+	err = tx.Where("chave = ?", "email:"+strings.ToLower(usuario.Email)).Delete(&model.TentativaLogin{}).Error
+	if err != nil {
+		return nil, err
+	}
+	// Assim como as de PIN nos quiosques, guardadas pela matrícula.
+	if usuario.Matricula != nil {
+		err = tx.Where("chave = ?", fmt.Sprintf("pin:%d:%s", usuario.EmpresaID, *usuario.Matricula)).Delete(&model.TentativaLogin{}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Unscoped().Model(&model.Usuario{}).Where("id = ?", usuario.ID).Updates(map[string]interface{}{
+		"nome":              NomeAnonimizado,
+		"email":             email,
+		"senha":             "",
+		"matricula":         nil,
+		"pin_hash":          "",
+		"data_anonimizacao": momento,
+		"data_exclusao":     gorm.Expr("COALESCE(data_exclusao, ?)", momento),
+	}).Error
+	return fotos, err
+}
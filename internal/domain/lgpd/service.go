@@ -0,0 +1,152 @@
+// Package lgpd atende os direitos do titular dos dados: acesso (exportação de tudo o que a
+// empresa guarda sobre o usuário) e eliminação (anonimização irreversível dos dados pessoais).
+package lgpd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/domain/autorizacao"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/armazenamento"
+)
+
+var ErrJaAnonimizado = errors.New("os dados pessoais deste usuário já foram anonimizados")
+
+type LGPDService interface {
+	// Exportar monta um arquivo zip com um JSON para cada tipo de dado guardado sobre o usuário e,
+	// na pasta fotos/, as selfies das batidas.
+	Exportar(usuarioID uint, empresaID uint) ([]byte, error)
+	// Anonimizar substitui de forma irreversível os dados pessoais do usuário e o exclui. Os
+	// registros de ponto continuam, sem as coordenadas e as selfies, para o cumprimento da legislação
+	// trabalhista.
+	Anonimizar(usuarioID uint, empresaID uint) error
+}
+
+var agora = time.Now
+
+type lgpdService struct {
+	repo     LGPDRepository
+	cache    *autorizacao.Cache
+	arquivos armazenamento.Armazenamento
+}
+
+// NewLGPDService recebe o cache de permissões efetivas, para que o usuário anonimizado saia dele na
+// hora, e o armazenamento onde estão as selfies das batidas.
+func NewLGPDService(repo LGPDRepository, cache *autorizacao.Cache, arquivos armazenamento.Armazenamento) LGPDService {
+	return &lgpdService{repo: repo, cache: cache, arquivos: arquivos}
+}
+
+// perfilExportado acrescenta ao cadastro o que o JSON do usuário normalmente esconde.
+type perfilExportado struct {
+	model.Usuario
+	Cargo         string     `json:"cargo"`
+	ExcluidoEm    *time.Time `json:"data_exclusao,omitempty"`
+	AnonimizadoEm *time.Time `json:"data_anonimizacao,omitempty"`
+}
+
+type bancoHorasExportado struct {
+	SaldoMinutos int                       `json:"saldo_banco_horas_minutos"`
+	Fechamentos  []model.RegistroAuditoria `json:"fechamentos"`
+}
+
+func (s *lgpdService) Exportar(usuarioID uint, empresaID uint) ([]byte, error) {
+	titular, err := s.repo.FindTitular(usuarioID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	dados, err := s.repo.ColetarDados(titular)
+	if err != nil {
+		return nil, err
+	}
+
+	perfil := perfilExportado{Usuario: dados.Perfil, Cargo: dados.Perfil.Cargo.Nome, AnonimizadoEm: dados.Perfil.AnonimizadoEm}
+	if dados.Perfil.ExcluidoEm.Valid {
+		perfil.ExcluidoEm = &dados.Perfil.ExcluidoEm.Time
+	}
+	bancoHoras := bancoHorasExportado{SaldoMinutos: dados.Perfil.SaldoBancoHorasMinutos, Fechamentos: []model.RegistroAuditoria{}}
+	for _, registro := range dados.Auditoria {
+		if registro.Acao == auditoria.AcaoDiaFechado {
+			bancoHoras.Fechamentos = append(bancoHoras.Fechamentos, registro)
+		}
+	}
+
+	arquivos := []struct {
+		nome     string
+		conteudo interface{}
+	}{
+		{"perfil.json", perfil},
+		{"registros_ponto.json", dados.Pontos},
+		{"banco_horas.json", bancoHoras},
+		{"auditoria.json", dados.Auditoria},
+		{"identidades_sso.json", dados.Identidades},
+		{"convites.json", dados.Convites},
+		{"solicitacoes_cadastro.json", dados.Solicitacoes},
+		{"dispositivos.json", dados.Dispositivos},
+	}
+
+	var buffer bytes.Buffer
+	arquivoZip := zip.NewWriter(&buffer)
+	momento := agora()
+	for _, arquivo := range arquivos {
+		conteudo, err := json.MarshalIndent(arquivo.conteudo, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		escritor, err := arquivoZip.CreateHeader(&zip.FileHeader{Name: arquivo.nome, Method: zip.Deflate, Modified: momento})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := escritor.Write(conteudo); err != nil {
+			return nil, err
+		}
+	}
+	// As imagens já são comprimidas e vão sem compressão.
+	for _, ponto := range dados.Pontos {
+		if !ponto.TemFoto() {
+			continue
+		}
+		conteudo, err := s.arquivos.Ler(*ponto.FotoChave)
+		if errors.Is(err, armazenamento.ErrNaoEncontrado) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		nome := fmt.Sprintf("fotos/ponto-%d%s", ponto.ID, path.Ext(*ponto.FotoChave))
+		escritor, err := arquivoZip.CreateHeader(&zip.FileHeader{Name: nome, Method: zip.Store, Modified: momento})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := escritor.Write(conteudo); err != nil {
+			return nil, err
+		}
+	}
+	if err := arquivoZip.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s *lgpdService) Anonimizar(usuarioID uint, empresaID uint) error {
+	titular, err := s.repo.FindTitular(usuarioID, empresaID)
+	if err != nil {
+		return err
+	}
+	if titular.AnonimizadoEm != nil {
+		return ErrJaAnonimizado
+	}
+	fotos, err := s.repo.Anonimizar(*titular, agora())
+	if err != nil {
+		return err
+	}
+	armazenamento.ApagarTodos(s.arquivos, fotos)
+	s.cache.InvalidarUsuario(usuarioID, empresaID)
+	return nil
+}
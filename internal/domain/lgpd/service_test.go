@@ -0,0 +1,166 @@
+package lgpd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/armazenamento"
+)
+
+type memoriaLGPDRepository struct {
+	LGPDRepository
+	titular     model.Usuario
+	dados       DadosTitular
+	anonimizado bool
+	// fotos são as chaves que a anonimização deixa de referenciar.
+	fotos []string
+}
+
+func (m *memoriaLGPDRepository) FindTitular(usuarioID uint, empresaID uint) (*model.Usuario, error) {
+	titular := m.titular
+	return &titular, nil
+}
+
+func (m *memoriaLGPDRepository) ColetarDados(titular *model.Usuario) (*DadosTitular, error) {
+	dados := m.dados
+	dados.Perfil = *titular
+	return &dados, nil
+}
+
+func (m *memoriaLGPDRepository) Anonimizar(titular model.Usuario, momento time.Time) ([]string, error) {
+	m.anonimizado = true
+	return m.fotos, nil
+}
+
+func novoArmazenamento(t *testing.T) armazenamento.Armazenamento {
+	t.Helper()
+	arquivos, err := armazenamento.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Erro ao criar o armazenamento: %v", err)
+	}
+	return arquivos
+}
+
+// lerZip devolve o conteúdo de cada arquivo do zip, pelo nome.
+func lerZip(t *testing.T, dados []byte) map[string][]byte {
+	t.Helper()
+	leitor, err := zip.NewReader(bytes.NewReader(dados), int64(len(dados)))
+	if err != nil {
+		t.Fatalf("Arquivo exportado não é um zip válido: %v", err)
+	}
+	arquivos := make(map[string][]byte)
+	for _, arquivo := range leitor.File {
+		aberto, err := arquivo.Open()
+		if err != nil {
+			t.Fatalf("Falha ao abrir %s: %v", arquivo.Name, err)
+		}
+		conteudo, _ := io.ReadAll(aberto)
+		aberto.Close()
+		arquivos[arquivo.Name] = conteudo
+	}
+	return arquivos
+}
+
+func TestExportar_ReuneOsDadosDoTitular(t *testing.T) {
+	arquivos := novoArmazenamento(t)
+	chaveFoto := "fotos/empresa-1/usuario-7/2026/03/abc.jpg"
+	if err := arquivos.Gravar(chaveFoto, []byte("selfie"), "image/jpeg"); err != nil {
+		t.Fatalf("Erro ao gravar a foto: %v", err)
+	}
+	repo := &memoriaLGPDRepository{
+		titular: model.Usuario{ID: 7, EmpresaID: 1, Nome: "Ana", Email: "ana@empresa.com", Senha: "hash", SaldoBancoHorasMinutos: 45,
+			Cargo: model.Cargo{Nome: "Analista"}},
+		dados: DadosTitular{
+			Pontos: []model.RegistroPonto{
+				{ID: 1, UsuarioID: 7, Latitude: -23.5, Longitude: -46.6, Tipo: "Presencial", FotoChave: &chaveFoto, FotoTipo: "image/jpeg"},
+			},
+			Auditoria: []model.RegistroAuditoria{
+				{ID: 1, Acao: auditoria.AcaoUsuarioAtualizado},
+				{ID: 2, Acao: auditoria.AcaoDiaFechado},
+			},
+			Dispositivos: []model.Dispositivo{{ID: 3, UsuarioID: 7, Identificador: "uuid-do-aparelho", Nome: "Moto G de Ana"}},
+		},
+	}
+	arquivo, err := NewLGPDService(repo, nil, arquivos).Exportar(7, 1)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	exportados := lerZip(t, arquivo)
+
+	var perfil map[string]interface{}
+	if err := json.Unmarshal(exportados["perfil.json"], &perfil); err != nil {
+		t.Fatalf("perfil.json inválido: %v", err)
+	}
+	if perfil["email"] != "ana@empresa.com" || perfil["cargo"] != "Analista" {
+		t.Errorf("Perfil incompleto: %v", perfil)
+	}
+	if _, temSenha := perfil["senha"]; temSenha || bytes.Contains(exportados["perfil.json"], []byte("hash")) {
+		t.Error("O hash da senha não deveria ser exportado")
+	}
+
+	var pontos []model.RegistroPonto
+	if err := json.Unmarshal(exportados["registros_ponto.json"], &pontos); err != nil || len(pontos) != 1 || pontos[0].Latitude != -23.5 {
+		t.Errorf("Os pontos deveriam ser exportados com a geolocalização (err=%v): %v", err, pontos)
+	}
+
+	var bancoHoras bancoHorasExportado
+	if err := json.Unmarshal(exportados["banco_horas.json"], &bancoHoras); err != nil {
+		t.Fatalf("banco_horas.json inválido: %v", err)
+	}
+	if bancoHoras.SaldoMinutos != 45 || len(bancoHoras.Fechamentos) != 1 {
+		t.Errorf("Banco de horas deveria ter o saldo e só o fechamento de dia: %+v", bancoHoras)
+	}
+	var dispositivos []model.Dispositivo
+	if err := json.Unmarshal(exportados["dispositivos.json"], &dispositivos); err != nil || len(dispositivos) != 1 || dispositivos[0].Identificador != "uuid-do-aparelho" {
+		t.Errorf("Os aparelhos deveriam ser exportados com nome e identificador (err=%v): %v", err, dispositivos)
+	}
+	for _, nome := range []string{"auditoria.json", "identidades_sso.json", "convites.json", "solicitacoes_cadastro.json"} {
+		if _, ok := exportados[nome]; !ok {
+			t.Errorf("Faltou %s no arquivo exportado", nome)
+		}
+	}
+	if string(exportados["fotos/ponto-1.jpg"]) != "selfie" {
+		t.Error("A selfie do ponto deveria ser exportada")
+	}
+}
+
+func TestAnonimizar_SoUmaVez(t *testing.T) {
+	repo := &memoriaLGPDRepository{titular: model.Usuario{ID: 7, EmpresaID: 1}}
+	service := NewLGPDService(repo, nil, novoArmazenamento(t))
+	if err := service.Anonimizar(7, 1); err != nil || !repo.anonimizado {
+		t.Fatalf("Esperava a anonimização, recebeu %v", err)
+	}
+
+	momento := time.Now()
+	repo.titular.AnonimizadoEm = &momento
+	repo.anonimizado = false
+	if err := service.Anonimizar(7, 1); !errors.Is(err, ErrJaAnonimizado) {
+		t.Errorf("Esperava ErrJaAnonimizado, recebeu %v", err)
+	}
+	if repo.anonimizado {
+		t.Error("Um titular já anonimizado não deveria passar de novo pelo repositório")
+	}
+}
+
+func TestAnonimizar_ApagaAsFotos(t *testing.T) {
+	arquivos := novoArmazenamento(t)
+	chaveFoto := "fotos/empresa-1/usuario-7/2026/03/abc.jpg"
+	if err := arquivos.Gravar(chaveFoto, []byte("selfie"), "image/jpeg"); err != nil {
+		t.Fatalf("Erro ao gravar a foto: %v", err)
+	}
+	repo := &memoriaLGPDRepository{titular: model.Usuario{ID: 7, EmpresaID: 1}, fotos: []string{chaveFoto}}
+
+	if err := NewLGPDService(repo, nil, arquivos).Anonimizar(7, 1); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if _, err := arquivos.Ler(chaveFoto); !errors.Is(err, armazenamento.ErrNaoEncontrado) {
+		t.Errorf("A selfie deveria ter sido apagada, recebeu %v", err)
+	}
+}
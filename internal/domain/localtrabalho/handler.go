@@ -0,0 +1,194 @@
+package localtrabalho
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
+	"github.com/Loviiin/ponto-api-go/pkg/geofence"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service   LocalTrabalhoService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s LocalTrabalhoService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+type localTrabalhoRequest struct {
+	Nome       string          `json:"nome" binding:"required"`
+	Geometria  json.RawMessage `json:"geometria" binding:"required"`
+	RaioMetros float64         `json:"raio_metros"`
+	Ativo      *bool           `json:"ativo"`
+	// FusoHorario vazio ou nulo segue o fuso da empresa.
+	FusoHorario *string `json:"fuso_horario"`
+}
+
+func (r localTrabalhoRequest) local(id uint, empresaID uint) model.LocalTrabalho {
+	return model.LocalTrabalho{
+		ID:          id,
+		EmpresaID:   empresaID,
+		Nome:        r.Nome,
+		Geometria:   model.JSONB(r.Geometria),
+		RaioMetros:  r.RaioMetros,
+		Ativo:       r.Ativo == nil || *r.Ativo,
+		FusoHorario: r.FusoHorario,
+	}
+}
+
+const mensagemCorpoInvalido = "O corpo da requisição é inválido. 'nome' e 'geometria' (GeoJSON) são obrigatórios."
+
+func (h *Handler) Create(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var req localTrabalhoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensagemCorpoInvalido})
+		return
+	}
+
+	local := req.local(0, empresaID)
+	if err := h.service.Create(&local); err != nil {
+		responderErro(c, err, "Falha ao criar o local de trabalho.")
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoLocalTrabalhoCriado, Entidade: "locais-trabalho", EntidadeID: local.ID, Depois: local})
+	c.JSON(http.StatusCreated, local)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	locais, err := h.service.GetAllByEmpresaID(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar os locais de trabalho."})
+		return
+	}
+	c.JSON(http.StatusOK, locais)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	local, err := h.service.FindByID(id, empresaID)
+	if err != nil {
+		responderErro(c, err, "Falha ao buscar o local de trabalho.")
+		return
+	}
+	c.JSON(http.StatusOK, local)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	var req localTrabalhoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": mensagemCorpoInvalido})
+		return
+	}
+
+	local := req.local(id, empresaID)
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Update(&local); err != nil {
+		responderErro(c, err, "Falha ao atualizar o local de trabalho.")
+		return
+	}
+	depois, _ := h.service.FindByID(id, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoLocalTrabalhoAtualizado, Entidade: "locais-trabalho", EntidadeID: id, Antes: antes, Depois: depois})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Delete(id, empresaID); err != nil {
+		responderErro(c, err, "Falha ao deletar o local de trabalho.")
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoLocalTrabalhoExcluido, Entidade: "locais-trabalho", EntidadeID: id, Antes: antes})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) GetAtribuicoes(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	atribuicoes, err := h.service.GetAtribuicoes(id, empresaID)
+	if err != nil {
+		responderErro(c, err, "Falha ao buscar as atribuições do local de trabalho.")
+		return
+	}
+	c.JSON(http.StatusOK, atribuicoes)
+}
+
+func (h *Handler) SetAtribuicoes(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	var req Atribuicoes
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. Envie 'usuario_ids' e 'cargo_ids'."})
+		return
+	}
+	antes, _ := h.service.GetAtribuicoes(id, empresaID)
+	if err := h.service.SetAtribuicoes(id, empresaID, req); err != nil {
+		responderErro(c, err, "Falha ao atualizar as atribuições do local de trabalho.")
+		return
+	}
+	depois, _ := h.service.GetAtribuicoes(id, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoLocalTrabalhoAtribuicoes, Entidade: "locais-trabalho", EntidadeID: id, Antes: antes, Depois: depois})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ids(c *gin.Context) (uint, uint, bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do local de trabalho inválido."})
+		return 0, 0, false
+	}
+	return empresaID, id, true
+}
+
+func responderErro(c *gin.Context, err error, mensagemPadrao string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Local de trabalho não encontrado nesta empresa."})
+	case errors.Is(err, geofence.ErrGeometriaInvalida), errors.Is(err, ErrAlvoForaDaEmpresa), errors.Is(err, fuso.ErrFusoInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrLocalEmUso):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": mensagemPadrao})
+	}
+}
@@ -0,0 +1,113 @@
+package localtrabalho
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type LocalTrabalhoRepository interface {
+	Create(local *model.LocalTrabalho) error
+	FindByID(id uint, empresaID uint) (*model.LocalTrabalho, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.LocalTrabalho, error)
+	GetAtivos(empresaID uint) ([]model.LocalTrabalho, error)
+	Update(local *model.LocalTrabalho) error
+	Delete(id uint, empresaID uint) error
+	ContarPontos(id uint, empresaID uint) (int64, error)
+	GetAtribuicoes(id uint, empresaID uint) ([]model.LocalTrabalhoAtribuicao, error)
+	GetAtribuicoesDaEmpresa(empresaID uint) ([]model.LocalTrabalhoAtribuicao, error)
+	SubstituirAtribuicoes(id uint, empresaID uint, atribuicoes []model.LocalTrabalhoAtribuicao) error
+	ContarAlvos(usuarioIDs []uint, cargoIDs []uint, empresaID uint) (usuarios int64, cargos int64, err error)
+}
+
+type localTrabalhoRepository struct {
+	Db *gorm.DB
+}
+
+func NewLocalTrabalhoRepository(db *gorm.DB) LocalTrabalhoRepository {
+	return &localTrabalhoRepository{Db: db}
+}
+
+func (r *localTrabalhoRepository) Create(local *model.LocalTrabalho) error {
+	return tenant.Escopo(r.Db, local.EmpresaID).Create(local).Error
+}
+
+func (r *localTrabalhoRepository) FindByID(id uint, empresaID uint) (*model.LocalTrabalho, error) {
+	var local model.LocalTrabalho
+	err := tenant.Escopo(r.Db, empresaID).Where("id = ?", id).First(&local).Error
+	return &local, err
+}
+
+func (r *localTrabalhoRepository) GetAllByEmpresaID(empresaID uint) ([]model.LocalTrabalho, error) {
+	var locais []model.LocalTrabalho
+	err := tenant.Escopo(r.Db, empresaID).Order("nome asc").Find(&locais).Error
+	return locais, err
+}
+
+func (r *localTrabalhoRepository) GetAtivos(empresaID uint) ([]model.LocalTrabalho, error) {
+	var locais []model.LocalTrabalho
+	err := tenant.Escopo(r.Db, empresaID).Where("ativo = ?", true).Order("id asc").Find(&locais).Error
+	return locais, err
+}
+
+func (r *localTrabalhoRepository) Update(local *model.LocalTrabalho) error {
+	return tenant.Escopo(r.Db, local.EmpresaID).Model(&model.LocalTrabalho{}).
+		Where("id = ?", local.ID).
+		Updates(map[string]interface{}{"nome": local.Nome, "geometria": local.Geometria, "raio_metros": local.RaioMetros, "ativo": local.Ativo, "fuso_horario": local.FusoHorario}).Error
+}
+
+// Delete remove o local junto com as suas atribuições.
+func (r *localTrabalhoRepository) Delete(id uint, empresaID uint) error {
+	return tenant.Escopo(r.Db, empresaID).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.LocalTrabalhoAtribuicao{}, "local_trabalho_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.LocalTrabalho{}, "id = ?", id).Error
+	})
+}
+
+func (r *localTrabalhoRepository) ContarPontos(id uint, empresaID uint) (int64, error) {
+	var total int64
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.RegistroPonto{}).Where("local_trabalho_id = ?", id).Count(&total).Error
+	return total, err
+}
+
+func (r *localTrabalhoRepository) GetAtribuicoes(id uint, empresaID uint) ([]model.LocalTrabalhoAtribuicao, error) {
+	var atribuicoes []model.LocalTrabalhoAtribuicao
+	err := tenant.Escopo(r.Db, empresaID).Where("local_trabalho_id = ?", id).Order("id asc").Find(&atribuicoes).Error
+	return atribuicoes, err
+}
+
+func (r *localTrabalhoRepository) GetAtribuicoesDaEmpresa(empresaID uint) ([]model.LocalTrabalhoAtribuicao, error) {
+	var atribuicoes []model.LocalTrabalhoAtribuicao
+	err := tenant.Escopo(r.Db, empresaID).Find(&atribuicoes).Error
+	return atribuicoes, err
+}
+
+func (r *localTrabalhoRepository) SubstituirAtribuicoes(id uint, empresaID uint, atribuicoes []model.LocalTrabalhoAtribuicao) error {
+	return tenant.Escopo(r.Db, empresaID).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.LocalTrabalhoAtribuicao{}, "local_trabalho_id = ?", id).Error; err != nil {
+			return err
+		}
+		if len(atribuicoes) == 0 {
+			return nil
+		}
+		return tx.Create(&atribuicoes).Error
+	})
+}
+
+// ContarAlvos conta quantos dos usuários e cargos informados existem na empresa.
+func (r *localTrabalhoRepository) ContarAlvos(usuarioIDs []uint, cargoIDs []uint, empresaID uint) (int64, int64, error) {
+	var usuarios, cargos int64
+	if len(usuarioIDs) > 0 {
+		if err := tenant.Escopo(r.Db, empresaID).Model(&model.Usuario{}).Where("id IN ?", usuarioIDs).Count(&usuarios).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(cargoIDs) > 0 {
+		if err := tenant.Escopo(r.Db, empresaID).Model(&model.Cargo{}).Where("id IN ?", cargoIDs).Count(&cargos).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+	return usuarios, cargos, nil
+}
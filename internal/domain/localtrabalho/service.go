@@ -0,0 +1,228 @@
+package localtrabalho
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
+	"github.com/Loviiin/ponto-api-go/pkg/geofence"
+)
+
+var (
+	ErrLocalEmUso        = errors.New("o local de trabalho já tem pontos registrados; desative-o em vez de removê-lo")
+	ErrAlvoForaDaEmpresa = errors.New("usuário ou cargo não encontrado nesta empresa")
+)
+
+// Atribuicoes são os funcionários e cargos para os quais um local está liberado.
+type Atribuicoes struct {
+	UsuarioIDs []uint `json:"usuario_ids"`
+	CargoIDs   []uint `json:"cargo_ids"`
+}
+
+// Resolucao é o resultado de situar uma batida de ponto entre os locais de trabalho.
+type Resolucao struct {
+	// Configurado é falso quando nenhum local ativo vale para o funcionário; nesse caso quem
+	// chamou deve recorrer à sede da empresa.
+	Configurado bool
+	// Local é o local em cuja cerca a batida caiu, ou nil se ela caiu fora de todos.
+	Local *model.LocalTrabalho
+	// DistanciaMetros é a distância até a cerca do local mais próximo (zero dentro de um local).
+	DistanciaMetros float64
+}
+
+// Resolvedor situa uma coordenada entre os locais de trabalho liberados para o funcionário.
+type Resolvedor interface {
+	Resolver(usuario *model.Usuario, latitude, longitude float64) (Resolucao, error)
+}
+
+type LocalTrabalhoService interface {
+	Resolvedor
+	Create(local *model.LocalTrabalho) error
+	FindByID(id uint, empresaID uint) (*model.LocalTrabalho, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.LocalTrabalho, error)
+	Update(local *model.LocalTrabalho) error
+	Delete(id uint, empresaID uint) error
+	GetAtribuicoes(id uint, empresaID uint) (*Atribuicoes, error)
+	SetAtribuicoes(id uint, empresaID uint, atribuicoes Atribuicoes) error
+}
+
+type localTrabalhoService struct {
+	repo LocalTrabalhoRepository
+}
+
+func NewLocalTrabalhoService(repo LocalTrabalhoRepository) LocalTrabalhoService {
+	return &localTrabalhoService{repo: repo}
+}
+
+func (s *localTrabalhoService) Create(local *model.LocalTrabalho) error {
+	if err := validar(local); err != nil {
+		return err
+	}
+	return s.repo.Create(local)
+}
+
+func (s *localTrabalhoService) FindByID(id uint, empresaID uint) (*model.LocalTrabalho, error) {
+	return s.repo.FindByID(id, empresaID)
+}
+
+func (s *localTrabalhoService) GetAllByEmpresaID(empresaID uint) ([]model.LocalTrabalho, error) {
+	return s.repo.GetAllByEmpresaID(empresaID)
+}
+
+func (s *localTrabalhoService) Update(local *model.LocalTrabalho) error {
+	if _, err := s.repo.FindByID(local.ID, local.EmpresaID); err != nil {
+		return err
+	}
+	if err := validar(local); err != nil {
+		return err
+	}
+	return s.repo.Update(local)
+}
+
+// Delete só remove locais sem pontos; os demais devem ser desativados, para que os registros
+// continuem apontando para o local onde foram batidos.
+func (s *localTrabalhoService) Delete(id uint, empresaID uint) error {
+	if _, err := s.repo.FindByID(id, empresaID); err != nil {
+		return err
+	}
+	pontos, err := s.repo.ContarPontos(id, empresaID)
+	if err != nil {
+		return err
+	}
+	if pontos > 0 {
+		return ErrLocalEmUso
+	}
+	return s.repo.Delete(id, empresaID)
+}
+
+func (s *localTrabalhoService) GetAtribuicoes(id uint, empresaID uint) (*Atribuicoes, error) {
+	if _, err := s.repo.FindByID(id, empresaID); err != nil {
+		return nil, err
+	}
+	existentes, err := s.repo.GetAtribuicoes(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	atribuicoes := &Atribuicoes{UsuarioIDs: []uint{}, CargoIDs: []uint{}}
+	for _, a := range existentes {
+		if a.UsuarioID != nil {
+			atribuicoes.UsuarioIDs = append(atribuicoes.UsuarioIDs, *a.UsuarioID)
+		}
+		if a.CargoID != nil {
+			atribuicoes.CargoIDs = append(atribuicoes.CargoIDs, *a.CargoID)
+		}
+	}
+	return atribuicoes, nil
+}
+
+// SetAtribuicoes substitui a lista de funcionários e cargos do local. Uma lista vazia libera o
+// local para toda a empresa.
+func (s *localTrabalhoService) SetAtribuicoes(id uint, empresaID uint, atribuicoes Atribuicoes) error {
+	if _, err := s.repo.FindByID(id, empresaID); err != nil {
+		return err
+	}
+	usuarioIDs, cargoIDs := semRepetidos(atribuicoes.UsuarioIDs), semRepetidos(atribuicoes.CargoIDs)
+	usuarios, cargos, err := s.repo.ContarAlvos(usuarioIDs, cargoIDs, empresaID)
+	if err != nil {
+		return err
+	}
+	if usuarios != int64(len(usuarioIDs)) || cargos != int64(len(cargoIDs)) {
+		return ErrAlvoForaDaEmpresa
+	}
+
+	novas := make([]model.LocalTrabalhoAtribuicao, 0, len(usuarioIDs)+len(cargoIDs))
+	for _, usuarioID := range usuarioIDs {
+		usuarioID := usuarioID
+		novas = append(novas, model.LocalTrabalhoAtribuicao{EmpresaID: empresaID, LocalTrabalhoID: id, UsuarioID: &usuarioID})
+	}
+	for _, cargoID := range cargoIDs {
+		cargoID := cargoID
+		novas = append(novas, model.LocalTrabalhoAtribuicao{EmpresaID: empresaID, LocalTrabalhoID: id, CargoID: &cargoID})
+	}
+	return s.repo.SubstituirAtribuicoes(id, empresaID, novas)
+}
+
+// Resolver considera os locais ativos liberados para o funcionário: os sem atribuição valem para
+// todos; os com atribuição, só para os funcionários e cargos atribuídos. Se a batida cair em mais
+// de um local, vence o de menor área, que é o mais específico (uma sala dentro do campus).
+func (s *localTrabalhoService) Resolver(usuario *model.Usuario, latitude, longitude float64) (Resolucao, error) {
+	ativos, err := s.repo.GetAtivos(usuario.EmpresaID)
+	if err != nil || len(ativos) == 0 {
+		return Resolucao{}, err
+	}
+	atribuicoes, err := s.repo.GetAtribuicoesDaEmpresa(usuario.EmpresaID)
+	if err != nil {
+		return Resolucao{}, err
+	}
+	restritos := make(map[uint]bool)
+	liberados := make(map[uint]bool)
+	for _, a := range atribuicoes {
+		restritos[a.LocalTrabalhoID] = true
+		if (a.UsuarioID != nil && *a.UsuarioID == usuario.ID) || (a.CargoID != nil && *a.CargoID == usuario.CargoID) {
+			liberados[a.LocalTrabalhoID] = true
+		}
+	}
+
+	batida := geofence.Ponto{Lat: latitude, Lon: longitude}
+	resolucao := Resolucao{DistanciaMetros: math.Inf(1)}
+	menorArea := math.Inf(1)
+	for i := range ativos {
+		local := &ativos[i]
+		if restritos[local.ID] && !liberados[local.ID] {
+			continue
+		}
+		geometria, err := geofence.Parse(local.Geometria, local.RaioMetros)
+		if err != nil {
+			// A geometria foi validada ao salvar; um registro corrompido não derruba os demais.
+			continue
+		}
+		resolucao.Configurado = true
+		distancia := geometria.DistanciaMetros(batida)
+		resolucao.DistanciaMetros = math.Min(resolucao.DistanciaMetros, distancia)
+		if distancia == 0 && geometria.AreaMetros2() < menorArea {
+			menorArea = geometria.AreaMetros2()
+			resolucao.Local = local
+		}
+	}
+	if !resolucao.Configurado {
+		return Resolucao{}, nil
+	}
+	return resolucao, nil
+}
+
+func validar(local *model.LocalTrabalho) error {
+	local.Nome = strings.TrimSpace(local.Nome)
+	geometria, err := geofence.Parse(local.Geometria, local.RaioMetros)
+	if err != nil {
+		return err
+	}
+	if geometria.Tipo != geofence.TipoPonto {
+		local.RaioMetros = 0
+	}
+	if local.FusoHorario != nil {
+		nome := strings.TrimSpace(*local.FusoHorario)
+		if nome == "" {
+			local.FusoHorario = nil
+			return nil
+		}
+		if err := fuso.Validar(nome); err != nil {
+			return err
+		}
+		local.FusoHorario = &nome
+	}
+	return nil
+}
+
+func semRepetidos(ids []uint) []uint {
+	vistos := make(map[uint]bool, len(ids))
+	unicos := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !vistos[id] {
+			vistos[id] = true
+			unicos = append(unicos, id)
+		}
+	}
+	return unicos
+}
@@ -0,0 +1,171 @@
+package localtrabalho
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+type memoriaLocalTrabalhoRepository struct {
+	LocalTrabalhoRepository
+	locais      []model.LocalTrabalho
+	atribuicoes []model.LocalTrabalhoAtribuicao
+	pontos      map[uint]int64
+}
+
+func (m *memoriaLocalTrabalhoRepository) FindByID(id uint, empresaID uint) (*model.LocalTrabalho, error) {
+	for i := range m.locais {
+		if m.locais[i].ID == id && m.locais[i].EmpresaID == empresaID {
+			return &m.locais[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaLocalTrabalhoRepository) GetAtivos(empresaID uint) ([]model.LocalTrabalho, error) {
+	var ativos []model.LocalTrabalho
+	for _, local := range m.locais {
+		if local.EmpresaID == empresaID && local.Ativo {
+			ativos = append(ativos, local)
+		}
+	}
+	return ativos, nil
+}
+
+func (m *memoriaLocalTrabalhoRepository) GetAtribuicoesDaEmpresa(empresaID uint) ([]model.LocalTrabalhoAtribuicao, error) {
+	return m.atribuicoes, nil
+}
+
+func (m *memoriaLocalTrabalhoRepository) ContarPontos(id uint, empresaID uint) (int64, error) {
+	return m.pontos[id], nil
+}
+
+func (m *memoriaLocalTrabalhoRepository) ContarAlvos(usuarioIDs []uint, cargoIDs []uint, empresaID uint) (int64, int64, error) {
+	// Nesta empresa existem só o usuário 7 e o cargo 3.
+	var usuarios, cargos int64
+	for _, id := range usuarioIDs {
+		if id == 7 {
+			usuarios++
+		}
+	}
+	for _, id := range cargoIDs {
+		if id == 3 {
+			cargos++
+		}
+	}
+	return usuarios, cargos, nil
+}
+
+func (m *memoriaLocalTrabalhoRepository) SubstituirAtribuicoes(id uint, empresaID uint, atribuicoes []model.LocalTrabalhoAtribuicao) error {
+	m.atribuicoes = atribuicoes
+	return nil
+}
+
+// O campus cobre de -46.70 a -46.69 de longitude e de -23.56 a -23.55 de latitude; o
+// laboratório é um círculo de 50 m no meio dele; o cliente fica a uns 10 km dali.
+const (
+	geometriaCampus  = `{"type":"Polygon","coordinates":[[[-46.70,-23.56],[-46.69,-23.56],[-46.69,-23.55],[-46.70,-23.55],[-46.70,-23.56]]]}`
+	geometriaLab     = `{"type":"Point","coordinates":[-46.695,-23.555]}`
+	geometriaCliente = `{"type":"Point","coordinates":[-46.60,-23.555]}`
+)
+
+func novoRepoComLocais() *memoriaLocalTrabalhoRepository {
+	return &memoriaLocalTrabalhoRepository{
+		locais: []model.LocalTrabalho{
+			{ID: 1, EmpresaID: 1, Nome: "Campus", Geometria: model.JSONB(geometriaCampus), Ativo: true},
+			{ID: 2, EmpresaID: 1, Nome: "Laboratório", Geometria: model.JSONB(geometriaLab), RaioMetros: 50, Ativo: true},
+			{ID: 3, EmpresaID: 1, Nome: "Cliente", Geometria: model.JSONB(geometriaCliente), RaioMetros: 200, Ativo: true},
+		},
+		pontos: map[uint]int64{},
+	}
+}
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestResolver_PrefereOLocalMaisEspecifico(t *testing.T) {
+	service := NewLocalTrabalhoService(novoRepoComLocais())
+	usuario := &model.Usuario{ID: 7, EmpresaID: 1, CargoID: 3}
+
+	resolucao, err := service.Resolver(usuario, -23.555, -46.695)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if resolucao.Local == nil || resolucao.Local.ID != 2 {
+		t.Fatalf("Esperava o laboratório, que está dentro do campus, recebeu %+v", resolucao.Local)
+	}
+
+	resolucao, _ = service.Resolver(usuario, -23.551, -46.699)
+	if resolucao.Local == nil || resolucao.Local.ID != 1 {
+		t.Errorf("Fora do laboratório a batida deveria cair no campus, recebeu %+v", resolucao.Local)
+	}
+}
+
+func TestResolver_ForaDeTodosInformaADistancia(t *testing.T) {
+	service := NewLocalTrabalhoService(novoRepoComLocais())
+	resolucao, err := service.Resolver(&model.Usuario{ID: 7, EmpresaID: 1}, -23.54, -46.695)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if !resolucao.Configurado || resolucao.Local != nil {
+		t.Fatalf("Esperava uma batida fora dos locais configurados: %+v", resolucao)
+	}
+	if resolucao.DistanciaMetros < 1000 || resolucao.DistanciaMetros > 1200 {
+		t.Errorf("A distância até o campus deveria ser ~1,1 km, foi %.0f", resolucao.DistanciaMetros)
+	}
+}
+
+func TestResolver_RespeitaAtribuicoes(t *testing.T) {
+	repo := novoRepoComLocais()
+	// O cliente só está liberado para o cargo 3.
+	repo.atribuicoes = []model.LocalTrabalhoAtribuicao{{EmpresaID: 1, LocalTrabalhoID: 3, CargoID: uintPtr(3)}}
+	service := NewLocalTrabalhoService(repo)
+
+	resolucao, _ := service.Resolver(&model.Usuario{ID: 7, EmpresaID: 1, CargoID: 3}, -23.555, -46.60)
+	if resolucao.Local == nil || resolucao.Local.ID != 3 {
+		t.Errorf("O cargo 3 deveria bater ponto no cliente, recebeu %+v", resolucao.Local)
+	}
+	resolucao, _ = service.Resolver(&model.Usuario{ID: 8, EmpresaID: 1, CargoID: 4}, -23.555, -46.60)
+	if resolucao.Local != nil {
+		t.Errorf("Outro cargo não deveria ter o cliente como local, recebeu %+v", resolucao.Local)
+	}
+}
+
+func TestResolver_SemLocaisRecorreASede(t *testing.T) {
+	service := NewLocalTrabalhoService(&memoriaLocalTrabalhoRepository{})
+	resolucao, err := service.Resolver(&model.Usuario{ID: 7, EmpresaID: 1}, -23.555, -46.695)
+	if err != nil || resolucao.Configurado {
+		t.Errorf("Sem locais a resolução não deveria estar configurada (err=%v): %+v", err, resolucao)
+	}
+}
+
+func TestCreate_RecusaGeometriaInvalida(t *testing.T) {
+	service := NewLocalTrabalhoService(novoRepoComLocais())
+	local := &model.LocalTrabalho{EmpresaID: 1, Nome: "Filial", Geometria: model.JSONB(`{"type":"Point","coordinates":[-46.6,-23.5]}`)}
+	if err := service.Create(local); err == nil {
+		t.Error("Um Point sem raio deveria ser recusado")
+	}
+}
+
+func TestDelete_LocalComPontos(t *testing.T) {
+	repo := novoRepoComLocais()
+	repo.pontos[1] = 12
+	if err := NewLocalTrabalhoService(repo).Delete(1, 1); !errors.Is(err, ErrLocalEmUso) {
+		t.Errorf("Esperava ErrLocalEmUso, recebeu %v", err)
+	}
+}
+
+func TestSetAtribuicoes_RecusaAlvoDeOutraEmpresa(t *testing.T) {
+	repo := novoRepoComLocais()
+	service := NewLocalTrabalhoService(repo)
+	if err := service.SetAtribuicoes(1, 1, Atribuicoes{UsuarioIDs: []uint{7, 99}}); !errors.Is(err, ErrAlvoForaDaEmpresa) {
+		t.Errorf("Esperava ErrAlvoForaDaEmpresa, recebeu %v", err)
+	}
+	if err := service.SetAtribuicoes(1, 1, Atribuicoes{UsuarioIDs: []uint{7, 7}, CargoIDs: []uint{3}}); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(repo.atribuicoes) != 2 {
+		t.Errorf("Esperava duas atribuições sem repetição, recebeu %d", len(repo.atribuicoes))
+	}
+}
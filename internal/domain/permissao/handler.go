@@ -1,7 +1,6 @@
 package permissao
 
 import (
-	"github.com/Loviiin/ponto-api-go/internal/model"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -14,20 +13,7 @@ func NewHandler(s Service) *Handler {
 	return &Handler{service: s}
 }
 
-func (h *Handler) Create(c *gin.Context) {
-	var permissao model.Permissao
-	if err := c.ShouldBindJSON(&permissao); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "corpo da requisição inválido"})
-		return
-	}
-
-	if err := h.service.Create(&permissao); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "falha ao criar permissão"})
-		return
-	}
-	c.JSON(http.StatusCreated, permissao)
-}
-
+// FindAll lista o catálogo de permissões. Permissões novas só surgem no código (permissions.Catalogo).
 func (h *Handler) FindAll(c *gin.Context) {
 	permissoes, err := h.service.FindAll()
 	if err != nil {
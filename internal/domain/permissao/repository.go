@@ -6,7 +6,6 @@ import (
 )
 
 type Repository interface {
-	Create(permissao *model.Permissao) error
 	FindAll() ([]model.Permissao, error)
 }
 
@@ -18,10 +17,6 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{Db: db}
 }
 
-func (r *repository) Create(permissao *model.Permissao) error {
-	return r.Db.Create(permissao).Error
-}
-
 func (r *repository) FindAll() ([]model.Permissao, error) {
 	var permissoes []model.Permissao
 	err := r.Db.Find(&permissoes).Error
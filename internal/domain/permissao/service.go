@@ -3,7 +3,6 @@ package permissao
 import "github.com/Loviiin/ponto-api-go/internal/model"
 
 type Service interface {
-	Create(permissao *model.Permissao) error
 	FindAll() ([]model.Permissao, error)
 }
 
@@ -15,10 +14,6 @@ func NewService(repo Repository) Service {
 	return &service{repo: repo}
 }
 
-func (s *service) Create(permissao *model.Permissao) error {
-	return s.repo.Create(permissao)
-}
-
 func (s *service) FindAll() ([]model.Permissao, error) {
 	return s.repo.FindAll()
 }
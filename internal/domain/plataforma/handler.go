@@ -0,0 +1,107 @@
+package plataforma
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service   PlataformaService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s PlataformaService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+func (h *Handler) Login(c *gin.Context) {
+	type loginRequest struct {
+		Email    string `json:"email" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.service.Login(req.Email, req.Password)
+	if err != nil {
+		var bloqueio *auth.BloqueioError
+		switch {
+		case errors.As(err, &bloqueio):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, auth.ErrCredenciaisInvalidas):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao autenticar."})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// Impersonar emite um token para o operador agir como um usuário da empresa informada.
+func (h *Handler) Impersonar(c *gin.Context) {
+	operadorID, err := h.converter.GetUintIDFromContext(c, "operadorID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	empresaID, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O ID da empresa deve ser um número válido"})
+		return
+	}
+
+	type impersonarRequest struct {
+		UsuarioID uint   `json:"usuario_id"`
+		Motivo    string `json:"motivo" binding:"required"`
+	}
+	var req impersonarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O campo 'motivo' é obrigatório."})
+		return
+	}
+
+	token, impersonacao, err := h.service.Impersonar(operadorID, empresaID, req.UsuarioID, req.Motivo, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrMotivoObrigatorio):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuário alvo não encontrado nesta empresa."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao impersonar o usuário."})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"token": token, "impersonacao": impersonacao})
+}
+
+func (h *Handler) GetImpersonacoes(c *gin.Context) {
+	var empresaID uint
+	if valor := c.Query("empresa_id"); valor != "" {
+		id, err := h.converter.StrParaUint(valor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "O parâmetro 'empresa_id' deve ser um número"})
+			return
+		}
+		empresaID = id
+	}
+
+	impersonacoes, err := h.service.ListarImpersonacoes(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar as impersonações."})
+		return
+	}
+	c.JSON(http.StatusOK, impersonacoes)
+}
@@ -0,0 +1,61 @@
+package plataforma
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type PlataformaRepository interface {
+	FindOperadorByEmail(email string) (*model.OperadorPlataforma, error)
+	FindOperadorByID(id uint) (*model.OperadorPlataforma, error)
+	FindAdminDaEmpresa(empresaID uint) (*model.Usuario, error)
+	CreateImpersonacao(impersonacao *model.Impersonacao) error
+	GetImpersonacoes(empresaID uint) ([]model.Impersonacao, error)
+}
+
+type plataformaRepository struct {
+	Db *gorm.DB
+}
+
+// NewPlataformaRepository cria o repositório dos operadores, que por definição enxerga todas as empresas.
+func NewPlataformaRepository(db *gorm.DB) PlataformaRepository {
+	return &plataformaRepository{Db: tenant.Plataforma(db)}
+}
+
+func (r *plataformaRepository) FindOperadorByEmail(email string) (*model.OperadorPlataforma, error) {
+	var operador model.OperadorPlataforma
+	err := r.Db.Where("email = ?", email).First(&operador).Error
+	return &operador, err
+}
+
+func (r *plataformaRepository) FindOperadorByID(id uint) (*model.OperadorPlataforma, error) {
+	var operador model.OperadorPlataforma
+	err := r.Db.Where("id = ?", id).First(&operador).Error
+	return &operador, err
+}
+
+// FindAdminDaEmpresa devolve o usuário mais antigo com o cargo "Admin" criado pelo seeder da empresa.
+func (r *plataformaRepository) FindAdminDaEmpresa(empresaID uint) (*model.Usuario, error) {
+	var usuario model.Usuario
+	err := r.Db.Joins("JOIN cargos ON cargos.id = usuarios.cargo_id").
+		Where("usuarios.empresa_id = ? AND cargos.nome = ?", empresaID, "Admin").
+		Order("usuarios.id asc").
+		First(&usuario).Error
+	return &usuario, err
+}
+
+func (r *plataformaRepository) CreateImpersonacao(impersonacao *model.Impersonacao) error {
+	return r.Db.Create(impersonacao).Error
+}
+
+// GetImpersonacoes lista as impersonações mais recentes; empresaID zero traz todas as empresas.
+func (r *plataformaRepository) GetImpersonacoes(empresaID uint) ([]model.Impersonacao, error) {
+	var impersonacoes []model.Impersonacao
+	query := r.Db.Order("id desc")
+	if empresaID != 0 {
+		query = query.Where("empresa_id = ?", empresaID)
+	}
+	err := query.Find(&impersonacoes).Error
+	return impersonacoes, err
+}
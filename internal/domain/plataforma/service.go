@@ -0,0 +1,151 @@
+package plataforma
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"github.com/Loviiin/ponto-api-go/pkg/password"
+	"gorm.io/gorm"
+)
+
+// duracaoImpersonacao é curta de propósito: o operador deve pedir um novo token para cada intervenção.
+const duracaoImpersonacao = time.Hour
+
+var ErrMotivoObrigatorio = errors.New("informe o motivo da impersonação")
+
+type PlataformaService interface {
+	Login(email string, senha string) (string, error)
+	OperadorAtivo(id uint) (bool, error)
+	Impersonar(operadorID uint, empresaID uint, usuarioID uint, motivo string, ip string) (string, *model.Impersonacao, error)
+	ListarImpersonacoes(empresaID uint) ([]model.Impersonacao, error)
+}
+
+type plataformaService struct {
+	repo        PlataformaRepository
+	usuarioRepo usuario.UsuarioRepository
+	jwtService  *jwt.JWTService
+	limitador   *auth.Limitador
+	eventos     auth.RegistradorEventos
+	politica    auth.PoliticaBloqueio
+}
+
+func NewPlataformaService(
+	repo PlataformaRepository,
+	usuarioRepo usuario.UsuarioRepository,
+	jwtService *jwt.JWTService,
+	tentativas auth.TentativaLoginStore,
+	eventos auth.RegistradorEventos,
+	politica auth.PoliticaBloqueio,
+) PlataformaService {
+	return &plataformaService{
+		repo:        repo,
+		usuarioRepo: usuarioRepo,
+		jwtService:  jwtService,
+		limitador:   auth.NewLimitador(tentativas, politica),
+		eventos:     eventos,
+		politica:    politica,
+	}
+}
+
+// Login autentica um operador. As falhas usam o mesmo limitador do login de usuários, com chave
+// própria, e bloqueiam a conta do operador pelo mesmo limite; o bloqueio vai para a auditoria.
+func (s *plataformaService) Login(email string, senha string) (string, error) {
+	chave := "operador:" + strings.ToLower(strings.TrimSpace(email))
+	agora := time.Now()
+
+	if err := s.limitador.Verificar(chave, agora); err != nil {
+		return "", err
+	}
+
+	operador, err := s.repo.FindOperadorByEmail(email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if err != nil || !operador.Ativo || !password.VerificaHashSenha(senha, operador.Senha) {
+		ate, err := s.limitador.Falhou(chave, "", s.politica.MaxTentativasConta, agora)
+		if err != nil {
+			return "", err
+		}
+		if ate != nil {
+			evento := auth.EventoSeguranca{Tipo: auth.EventoBloqueioOperador, Chave: chave, Ate: ate, Momento: agora}
+			if operador != nil {
+				evento.OperadorID = operador.ID
+			}
+			s.eventos.Registrar(evento)
+		}
+		return "", auth.ErrCredenciaisInvalidas
+	}
+
+	if err := s.limitador.Limpar(chave); err != nil {
+		return "", err
+	}
+	return s.jwtService.GenerateOperadorToken(operador.ID)
+}
+
+func (s *plataformaService) OperadorAtivo(id uint) (bool, error) {
+	operador, err := s.repo.FindOperadorByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return operador.Ativo, nil
+}
+
+// Impersonar emite um token de usuário em nome do operador e grava o registro, e o evento na
+// auditoria da empresa, antes de devolvê-lo. Sem usuarioID, o alvo é o administrador padrão da empresa.
+func (s *plataformaService) Impersonar(operadorID uint, empresaID uint, usuarioID uint, motivo string, ip string) (string, *model.Impersonacao, error) {
+	if strings.TrimSpace(motivo) == "" {
+		return "", nil, ErrMotivoObrigatorio
+	}
+
+	var alvo *model.Usuario
+	var err error
+	if usuarioID != 0 {
+		alvo, err = s.usuarioRepo.FindByID(usuarioID, empresaID)
+	} else {
+		alvo, err = s.repo.FindAdminDaEmpresa(empresaID)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	momento := time.Now()
+	impersonacao := &model.Impersonacao{
+		OperadorID: operadorID,
+		EmpresaID:  empresaID,
+		UsuarioID:  alvo.ID,
+		Motivo:     motivo,
+		IP:         ip,
+		ExpiraEm:   momento.Add(duracaoImpersonacao),
+	}
+	if err := s.repo.CreateImpersonacao(impersonacao); err != nil {
+		return "", nil, err
+	}
+	s.eventos.Registrar(auth.EventoSeguranca{
+		Tipo:       auth.EventoImpersonacao,
+		EmpresaID:  empresaID,
+		UsuarioID:  alvo.ID,
+		OperadorID: operadorID,
+		Motivo:     motivo,
+		IP:         ip,
+		Ate:        &impersonacao.ExpiraEm,
+		Momento:    momento,
+	})
+
+	token, err := s.jwtService.GenerateImpersonationToken(alvo.ID, empresaID, operadorID, duracaoImpersonacao)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, impersonacao, nil
+}
+
+func (s *plataformaService) ListarImpersonacoes(empresaID uint) ([]model.Impersonacao, error) {
+	return s.repo.GetImpersonacoes(empresaID)
+}
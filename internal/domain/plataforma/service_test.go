@@ -0,0 +1,150 @@
+package plataforma
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type mockPlataformaRepository struct {
+	PlataformaRepository
+	operadores    []model.OperadorPlataforma
+	admin         *model.Usuario
+	impersonacoes []model.Impersonacao
+}
+
+func (m *mockPlataformaRepository) FindOperadorByEmail(email string) (*model.OperadorPlataforma, error) {
+	for _, o := range m.operadores {
+		if o.Email == email {
+			copia := o
+			return &copia, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *mockPlataformaRepository) FindAdminDaEmpresa(empresaID uint) (*model.Usuario, error) {
+	if m.admin == nil || m.admin.EmpresaID != empresaID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return m.admin, nil
+}
+
+func (m *mockPlataformaRepository) CreateImpersonacao(impersonacao *model.Impersonacao) error {
+	impersonacao.ID = uint(len(m.impersonacoes) + 1)
+	m.impersonacoes = append(m.impersonacoes, *impersonacao)
+	return nil
+}
+
+type mockUsuarioRepository struct {
+	usuario.UsuarioRepository
+}
+
+type mockRegistradorEventos struct {
+	eventos []auth.EventoSeguranca
+}
+
+func (m *mockRegistradorEventos) Registrar(evento auth.EventoSeguranca) {
+	m.eventos = append(m.eventos, evento)
+}
+
+func novoServicoDeTeste(t *testing.T) (PlataformaService, *mockPlataformaRepository, *mockRegistradorEventos) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("senha-certa"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("falha ao gerar hash: %v", err)
+	}
+	repo := &mockPlataformaRepository{
+		operadores: []model.OperadorPlataforma{{ID: 4, Email: "op@plataforma.com", Senha: string(hash), Ativo: true}},
+		admin:      &model.Usuario{ID: 12, EmpresaID: 3},
+	}
+	eventos := &mockRegistradorEventos{}
+	politica := auth.PoliticaBloqueio{MaxTentativasConta: 3, DuracaoBloqueio: 15 * time.Minute}
+	service := NewPlataformaService(repo, &mockUsuarioRepository{}, jwt.NewJWTService("segredo", "teste"), auth.NewMemoriaTentativaLoginStore(), eventos, politica)
+	return service, repo, eventos
+}
+
+func TestLogin_BloqueiaOperadorAposLimite(t *testing.T) {
+	service, _, eventos := novoServicoDeTeste(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.Login("op@plataforma.com", "errada"); !errors.Is(err, auth.ErrCredenciaisInvalidas) {
+			t.Fatalf("Tentativa %d: esperava credenciais inválidas, recebeu %v", i+1, err)
+		}
+	}
+	var bloqueio *auth.BloqueioError
+	if _, err := service.Login("op@plataforma.com", "senha-certa"); !errors.As(err, &bloqueio) || !bloqueio.Bloqueio {
+		t.Fatalf("Mesmo com a senha certa o operador deveria estar bloqueado, recebeu %v", err)
+	}
+
+	if len(eventos.eventos) != 1 {
+		t.Fatalf("Esperava um evento de bloqueio, recebeu %+v", eventos.eventos)
+	}
+	evento := eventos.eventos[0]
+	if evento.Tipo != auth.EventoBloqueioOperador || evento.OperadorID != 4 || evento.Ate == nil {
+		t.Errorf("Evento de bloqueio incorreto: %+v", evento)
+	}
+}
+
+func TestLogin_LimpaFalhasAoAcertar(t *testing.T) {
+	service, _, _ := novoServicoDeTeste(t)
+
+	for i := 0; i < 2; i++ {
+		_, _ = service.Login("op@plataforma.com", "errada")
+	}
+	if _, err := service.Login("op@plataforma.com", "senha-certa"); err != nil {
+		t.Fatalf("Esperava login com sucesso, recebeu %v", err)
+	}
+	// Com a contagem zerada, duas novas falhas ainda não bloqueiam.
+	for i := 0; i < 2; i++ {
+		_, _ = service.Login("op@plataforma.com", "errada")
+	}
+	if _, err := service.Login("op@plataforma.com", "senha-certa"); err != nil {
+		t.Errorf("A contagem deveria ter recomeçado após o acerto, recebeu %v", err)
+	}
+}
+
+func TestImpersonar_ExigeMotivo(t *testing.T) {
+	service, repo, eventos := novoServicoDeTeste(t)
+
+	if _, _, err := service.Impersonar(4, 3, 0, "  ", "10.0.0.1"); !errors.Is(err, ErrMotivoObrigatorio) {
+		t.Fatalf("Esperava ErrMotivoObrigatorio, recebeu %v", err)
+	}
+	if len(repo.impersonacoes) != 0 || len(eventos.eventos) != 0 {
+		t.Errorf("Nada deveria ser registrado sem motivo: %+v %+v", repo.impersonacoes, eventos.eventos)
+	}
+}
+
+func TestImpersonar_RegistraAntesDeEmitirOToken(t *testing.T) {
+	service, repo, eventos := novoServicoDeTeste(t)
+
+	token, impersonacao, err := service.Impersonar(4, 3, 0, "Chamado 123", "10.0.0.1")
+	if err != nil || token == "" {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(repo.impersonacoes) != 1 {
+		t.Fatalf("Esperava uma impersonação gravada, recebeu %+v", repo.impersonacoes)
+	}
+	gravada := repo.impersonacoes[0]
+	if gravada.OperadorID != 4 || gravada.EmpresaID != 3 || gravada.UsuarioID != 12 || gravada.Motivo != "Chamado 123" || gravada.IP != "10.0.0.1" {
+		t.Errorf("Impersonação gravada incorretamente: %+v", gravada)
+	}
+	if impersonacao.ID != gravada.ID {
+		t.Errorf("A impersonação devolvida deveria ser a gravada: %+v", impersonacao)
+	}
+
+	if len(eventos.eventos) != 1 {
+		t.Fatalf("Esperava um evento de impersonação, recebeu %+v", eventos.eventos)
+	}
+	evento := eventos.eventos[0]
+	if evento.Tipo != auth.EventoImpersonacao || evento.OperadorID != 4 || evento.EmpresaID != 3 || evento.UsuarioID != 12 || evento.Motivo != "Chamado 123" {
+		t.Errorf("Evento de impersonação incorreto: %+v", evento)
+	}
+}
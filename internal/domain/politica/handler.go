@@ -0,0 +1,210 @@
+package politica
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service        PoliticaService
+	usuarioService usuario.UsuarioService
+	converter      funcoes.FuncoesInterface
+}
+
+func NewHandler(s PoliticaService, u usuario.UsuarioService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:        s,
+		usuarioService: u,
+		converter:      f,
+	}
+}
+
+type politicaRequest struct {
+	Nome       string      `json:"nome" binding:"required"`
+	Descricao  string      `json:"descricao"`
+	Acao       string      `json:"acao" binding:"required"`
+	Efeito     string      `json:"efeito" binding:"required"`
+	Prioridade int         `json:"prioridade"`
+	Condicoes  model.JSONB `json:"condicoes"`
+	Ativa      *bool       `json:"ativa"`
+}
+
+func (r politicaRequest) politica(id uint, empresaID uint) model.Politica {
+	return model.Politica{
+		ID:         id,
+		EmpresaID:  empresaID,
+		Nome:       r.Nome,
+		Descricao:  r.Descricao,
+		Acao:       r.Acao,
+		Efeito:     r.Efeito,
+		Prioridade: r.Prioridade,
+		Condicoes:  r.Condicoes,
+		Ativa:      r.Ativa == nil || *r.Ativa,
+	}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var req politicaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'nome', 'acao' e 'efeito' são obrigatórios."})
+		return
+	}
+
+	politica := req.politica(0, empresaID)
+	if err := h.service.Create(&politica); err != nil {
+		responderErro(c, err, "Falha ao criar a política.")
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoPoliticaCriada, Entidade: "politicas", EntidadeID: politica.ID, Depois: politica})
+	c.JSON(http.StatusCreated, politica)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	politicas, err := h.service.GetAllByEmpresaID(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar as políticas."})
+		return
+	}
+	c.JSON(http.StatusOK, politicas)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	politica, err := h.service.FindByID(id, empresaID)
+	if err != nil {
+		responderErro(c, err, "Falha ao buscar a política.")
+		return
+	}
+	c.JSON(http.StatusOK, politica)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	var req politicaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'nome', 'acao' e 'efeito' são obrigatórios."})
+		return
+	}
+
+	politica := req.politica(id, empresaID)
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Update(&politica); err != nil {
+		responderErro(c, err, "Falha ao atualizar a política.")
+		return
+	}
+	depois, _ := h.service.FindByID(id, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoPoliticaAtualizada, Entidade: "politicas", EntidadeID: id, Antes: antes, Depois: depois})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	empresaID, id, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Delete(id, empresaID); err != nil {
+		responderErro(c, err, "Falha ao deletar a política.")
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoPoliticaExcluida, Entidade: "politicas", EntidadeID: id, Antes: antes})
+	c.Status(http.StatusNoContent)
+}
+
+// Simular avalia uma decisão sem executar a ação e devolve a explicação. Sem 'usuario_id',
+// o sujeito é quem faz a requisição; sem 'momento', vale o instante atual.
+func (h *Handler) Simular(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var req struct {
+		Acao      string     `json:"acao" binding:"required"`
+		UsuarioID *uint      `json:"usuario_id"`
+		Recurso   Atributos  `json:"recurso"`
+		Momento   *time.Time `json:"momento"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'acao' é obrigatória."})
+		return
+	}
+
+	requisicao := Requisicao{Acao: req.Acao, Recurso: req.Recurso}
+	if req.Momento != nil {
+		requisicao.Momento = *req.Momento
+	}
+
+	usuarioID := req.UsuarioID
+	if usuarioID == nil {
+		if id, err := h.converter.GetUintIDFromContext(c, "userID"); err == nil {
+			usuarioID = &id
+		}
+	}
+	if usuarioID != nil {
+		sujeito, err := h.usuarioService.FindByID(*usuarioID, empresaID)
+		if err != nil {
+			responderErro(c, err, "Falha ao buscar o usuário.")
+			return
+		}
+		requisicao.Sujeito = AtributosUsuario(sujeito)
+	} else if chaveID, err := h.converter.GetUintIDFromContext(c, "chaveAPIID"); err == nil {
+		requisicao.Sujeito = AtributosChaveAPI(chaveID)
+	}
+
+	decisao, err := h.service.Avaliar(empresaID, requisicao)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao avaliar as políticas."})
+		return
+	}
+	c.JSON(http.StatusOK, decisao)
+}
+
+func (h *Handler) ids(c *gin.Context) (uint, uint, bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID da política inválido."})
+		return 0, 0, false
+	}
+	return empresaID, id, true
+}
+
+func responderErro(c *gin.Context, err error, mensagemPadrao string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registro não encontrado nesta empresa."})
+	case errors.Is(err, ErrPoliticaInvalida):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": mensagemPadrao})
+	}
+}
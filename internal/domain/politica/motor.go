@@ -0,0 +1,230 @@
+package politica
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+)
+
+// Ações que passam pelo motor de políticas. Cada uma documenta os atributos de recurso que envia.
+const (
+	// AcaoPontoBater: recurso.tipo ("Presencial"/"Remoto"), recurso.distancia_metros, recurso.risco
+	// (pontuação de 0 a 100), dentro de um local de trabalho recurso.local_trabalho_id e, batido
+	// num quiosque, recurso.quiosque_id.
+	AcaoPontoBater = "PONTO_BATER"
+	// AcaoCadastroAprovar: recurso.solicitacao_id, recurso.cargo_id, recurso.email.
+	AcaoCadastroAprovar = "CADASTRO_APROVAR"
+	// AcaoCadastroRejeitar: recurso.solicitacao_id, recurso.email.
+	AcaoCadastroRejeitar = "CADASTRO_REJEITAR"
+	// AcaoBancoHorasFecharDia: recurso.usuario_id, recurso.saldo_minutos, recurso.saldo_minutos_absoluto.
+	AcaoBancoHorasFecharDia = "BANCO_HORAS_FECHAR_DIA"
+	// AcaoPontoRevisar: recurso.ponto_id, recurso.usuario_id, recurso.decisao ("APROVADA"/"REJEITADA"),
+	// recurso.tipo, recurso.motivo, recurso.risco, recurso.minutos_afetados (quanto a decisão muda o
+	// saldo do dia) e recurso.minutos_afetados_absoluto.
+	AcaoPontoRevisar = "PONTO_REVISAR"
+	// AcaoTodas faz a política valer para qualquer ação.
+	AcaoTodas = "*"
+)
+
+var acoes = []string{AcaoPontoBater, AcaoCadastroAprovar, AcaoCadastroRejeitar, AcaoBancoHorasFecharDia, AcaoPontoRevisar, AcaoTodas}
+
+const (
+	OperadorIgual      = "igual"
+	OperadorDiferente  = "diferente"
+	OperadorEm         = "em"
+	OperadorForaDe     = "fora_de"
+	OperadorMaior      = "maior"
+	OperadorMaiorIgual = "maior_igual"
+	OperadorMenor      = "menor"
+	OperadorMenorIgual = "menor_igual"
+)
+
+var operadores = []string{OperadorIgual, OperadorDiferente, OperadorEm, OperadorForaDe, OperadorMaior, OperadorMaiorIgual, OperadorMenor, OperadorMenorIgual}
+
+// Atributos são os valores de um lado da requisição (sujeito ou recurso), por nome.
+type Atributos map[string]interface{}
+
+// Requisicao é o que se pede ao motor: quem (sujeito) quer fazer o quê (ação) sobre o quê (recurso), e quando.
+type Requisicao struct {
+	Acao    string
+	Sujeito Atributos
+	Recurso Atributos
+	Momento time.Time
+	// Fuso é onde os atributos do ambiente (hora, dia da semana, data) são lidos; nil usa o fuso
+	// da empresa.
+	Fuso *time.Location
+}
+
+// Decisao é o resultado da avaliação, com uma linha de explicação por política considerada.
+type Decisao struct {
+	Permitido  bool     `json:"permitido"`
+	PoliticaID *uint    `json:"politica_id,omitempty"`
+	Politica   string   `json:"politica,omitempty"`
+	Explicacao []string `json:"explicacao"`
+}
+
+// AtributosUsuario descreve um usuário como sujeito.
+func AtributosUsuario(u *model.Usuario) Atributos {
+	atributos := Atributos{
+		"tipo":     model.AtorUsuario,
+		"id":       u.ID,
+		"cargo_id": u.CargoID,
+		"cargo":    u.Cargo.Nome,
+	}
+	opcionais := map[string]*uint{"departamento_id": u.DepartamentoID, "centro_custo_id": u.CentroCustoID, "gestor_id": u.GestorID}
+	for nome, valor := range opcionais {
+		if valor != nil {
+			atributos[nome] = *valor
+		}
+	}
+	return atributos
+}
+
+// AtributosChaveAPI descreve uma chave de API como sujeito.
+func AtributosChaveAPI(chaveID uint) Atributos {
+	return Atributos{"tipo": model.AtorChaveAPI, "id": chaveID}
+}
+
+// atributosAmbiente derivam do momento da requisição. dia_semana segue time.Weekday (0 = domingo).
+func atributosAmbiente(momento time.Time) Atributos {
+	return Atributos{
+		"dia_semana":    int(momento.Weekday()),
+		"hora":          momento.Hour(),
+		"minuto_do_dia": momento.Hour()*60 + momento.Minute(),
+		"data":          momento.Format("2006-01-02"),
+	}
+}
+
+// politicaDecodificada é uma política com as condições já lidas do JSON.
+type politicaDecodificada struct {
+	model.Politica
+	condicoes []model.CondicaoPolitica
+}
+
+// avaliar aplica as políticas à requisição. A primeira aplicável, em ordem de prioridade, decide;
+// sem nenhuma aplicável, a ação é permitida, como antes da existência das políticas.
+func avaliar(politicas []politicaDecodificada, req Requisicao) Decisao {
+	sort.SliceStable(politicas, func(i, j int) bool {
+		if politicas[i].Prioridade != politicas[j].Prioridade {
+			return politicas[i].Prioridade > politicas[j].Prioridade
+		}
+		return politicas[i].ID < politicas[j].ID
+	})
+
+	lados := map[string]Atributos{
+		"sujeito":  req.Sujeito,
+		"recurso":  req.Recurso,
+		"ambiente": atributosAmbiente(req.Momento),
+	}
+
+	decisao := Decisao{Permitido: true, Explicacao: []string{}}
+	for _, p := range politicas {
+		if p.Acao != req.Acao && p.Acao != AcaoTodas {
+			continue
+		}
+		aplicavel := true
+		for _, condicao := range p.condicoes {
+			if ok, motivo := verificar(condicao, lados); !ok {
+				decisao.Explicacao = append(decisao.Explicacao, fmt.Sprintf("Política %q (prioridade %d) não se aplica: %s.", p.Nome, p.Prioridade, motivo))
+				aplicavel = false
+				break
+			}
+		}
+		if !aplicavel {
+			continue
+		}
+		id := p.ID
+		decisao.Permitido = p.Efeito == model.EfeitoPermitir
+		decisao.PoliticaID = &id
+		decisao.Politica = p.Nome
+		decisao.Explicacao = append(decisao.Explicacao, fmt.Sprintf("Política %q (prioridade %d) se aplica: %s.", p.Nome, p.Prioridade, strings.ToLower(p.Efeito)))
+		return decisao
+	}
+	decisao.Explicacao = append(decisao.Explicacao, "Nenhuma política se aplica; a ação é permitida por padrão.")
+	return decisao
+}
+
+// verificar testa uma condição. Um atributo ausente nunca satisfaz a condição.
+func verificar(condicao model.CondicaoPolitica, lados map[string]Atributos) (bool, string) {
+	lado, nome, _ := strings.Cut(condicao.Atributo, ".")
+	valor, existe := lados[lado][nome]
+	if !existe {
+		return false, fmt.Sprintf("%s ausente", condicao.Atributo)
+	}
+
+	var ok bool
+	switch condicao.Operador {
+	case OperadorIgual:
+		ok = iguais(valor, condicao.Valor)
+	case OperadorDiferente:
+		ok = !iguais(valor, condicao.Valor)
+	case OperadorEm, OperadorForaDe:
+		lista, _ := condicao.Valor.([]interface{})
+		for _, item := range lista {
+			if iguais(valor, item) {
+				ok = true
+				break
+			}
+		}
+		if condicao.Operador == OperadorForaDe {
+			ok = !ok
+		}
+	default:
+		a, okA := numero(valor)
+		b, okB := numero(condicao.Valor)
+		if !okA || !okB {
+			return false, fmt.Sprintf("%s não é numérico", condicao.Atributo)
+		}
+		switch condicao.Operador {
+		case OperadorMaior:
+			ok = a > b
+		case OperadorMaiorIgual:
+			ok = a >= b
+		case OperadorMenor:
+			ok = a < b
+		case OperadorMenorIgual:
+			ok = a <= b
+		}
+	}
+	if !ok {
+		return false, fmt.Sprintf("%s %s %v é falso (valor atual: %v)", condicao.Atributo, condicao.Operador, condicao.Valor, valor)
+	}
+	return true, ""
+}
+
+// iguais compara números pelo valor, independentemente do tipo, e o resto como texto.
+func iguais(a interface{}, b interface{}) bool {
+	if x, ok := numero(a); ok {
+		y, ok := numero(b)
+		return ok && x == y
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func numero(valor interface{}) (float64, bool) {
+	switch v := valor.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package politica
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type PoliticaRepository interface {
+	Create(politica *model.Politica) error
+	FindByID(id uint, empresaID uint) (*model.Politica, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.Politica, error)
+	Update(politica *model.Politica) error
+	Delete(id uint, empresaID uint) error
+	// GetAtivas devolve as políticas ativas da empresa para a ação, incluindo as que valem para todas.
+	GetAtivas(empresaID uint, acao string) ([]model.Politica, error)
+	// FusoHorario devolve o fuso configurado na empresa (vazio se ela não for encontrada).
+	FusoHorario(empresaID uint) (string, error)
+}
+
+type politicaRepository struct {
+	Db *gorm.DB
+}
+
+func NewPoliticaRepository(db *gorm.DB) PoliticaRepository {
+	return &politicaRepository{Db: db}
+}
+
+func (r *politicaRepository) Create(politica *model.Politica) error {
+	return tenant.Escopo(r.Db, politica.EmpresaID).Create(politica).Error
+}
+
+func (r *politicaRepository) FindByID(id uint, empresaID uint) (*model.Politica, error) {
+	var politica model.Politica
+	err := tenant.Escopo(r.Db, empresaID).Where("id = ?", id).First(&politica).Error
+	return &politica, err
+}
+
+func (r *politicaRepository) GetAllByEmpresaID(empresaID uint) ([]model.Politica, error) {
+	var politicas []model.Politica
+	err := tenant.Escopo(r.Db, empresaID).Order("prioridade desc, id asc").Find(&politicas).Error
+	return politicas, err
+}
+
+func (r *politicaRepository) Update(politica *model.Politica) error {
+	return tenant.Escopo(r.Db, politica.EmpresaID).Model(&model.Politica{}).
+		Where("id = ?", politica.ID).
+		Updates(map[string]interface{}{
+			"nome":       politica.Nome,
+			"descricao":  politica.Descricao,
+			"acao":       politica.Acao,
+			"efeito":     politica.Efeito,
+			"prioridade": politica.Prioridade,
+			"condicoes":  politica.Condicoes,
+			"ativa":      politica.Ativa,
+		}).Error
+}
+
+func (r *politicaRepository) Delete(id uint, empresaID uint) error {
+	return tenant.Escopo(r.Db, empresaID).Delete(&model.Politica{}, "id = ?", id).Error
+}
+
+func (r *politicaRepository) GetAtivas(empresaID uint, acao string) ([]model.Politica, error) {
+	var politicas []model.Politica
+	err := tenant.Escopo(r.Db, empresaID).Where("ativa AND acao IN ?", []string{acao, AcaoTodas}).
+		Order("prioridade desc, id asc").Find(&politicas).Error
+	return politicas, err
+}
+
+func (r *politicaRepository) FusoHorario(empresaID uint) (string, error) {
+	var nome string
+	err := r.Db.Model(&model.Empresa{}).Select("fuso_horario").Where("id = ?", empresaID).Scan(&nome).Error
+	return nome, err
+}
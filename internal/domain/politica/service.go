@@ -0,0 +1,159 @@
+package politica
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
+)
+
+var (
+	ErrPoliticaInvalida  = errors.New("política inválida")
+	ErrNegadoPorPolitica = errors.New("ação negada por política da empresa")
+)
+
+var agora = time.Now
+
+// Verificador é a parte do motor usada pelos outros domínios antes de executar uma ação.
+type Verificador interface {
+	// Exigir devolve ErrNegadoPorPolitica (com a política responsável) se a ação não for permitida.
+	Exigir(empresaID uint, req Requisicao) error
+}
+
+type PoliticaService interface {
+	Verificador
+	Create(politica *model.Politica) error
+	FindByID(id uint, empresaID uint) (*model.Politica, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.Politica, error)
+	Update(politica *model.Politica) error
+	Delete(id uint, empresaID uint) error
+	// Avaliar decide sem executar nada; é a base do endpoint de simulação.
+	Avaliar(empresaID uint, req Requisicao) (*Decisao, error)
+}
+
+type politicaService struct {
+	repo PoliticaRepository
+}
+
+func NewPoliticaService(repo PoliticaRepository) PoliticaService {
+	return &politicaService{repo: repo}
+}
+
+func (s *politicaService) Create(politica *model.Politica) error {
+	if err := validar(politica); err != nil {
+		return err
+	}
+	return s.repo.Create(politica)
+}
+
+func (s *politicaService) FindByID(id uint, empresaID uint) (*model.Politica, error) {
+	return s.repo.FindByID(id, empresaID)
+}
+
+func (s *politicaService) GetAllByEmpresaID(empresaID uint) ([]model.Politica, error) {
+	return s.repo.GetAllByEmpresaID(empresaID)
+}
+
+func (s *politicaService) Update(politica *model.Politica) error {
+	if _, err := s.repo.FindByID(politica.ID, politica.EmpresaID); err != nil {
+		return err
+	}
+	if err := validar(politica); err != nil {
+		return err
+	}
+	return s.repo.Update(politica)
+}
+
+func (s *politicaService) Delete(id uint, empresaID uint) error {
+	if _, err := s.repo.FindByID(id, empresaID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id, empresaID)
+}
+
+func (s *politicaService) Avaliar(empresaID uint, req Requisicao) (*Decisao, error) {
+	if req.Momento.IsZero() {
+		req.Momento = agora()
+	}
+	if req.Fuso == nil {
+		nome, err := s.repo.FusoHorario(empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Fuso, err = fuso.Carregar(nome); err != nil {
+			return nil, err
+		}
+	}
+	req.Momento = req.Momento.In(req.Fuso)
+	politicas, err := s.repo.GetAtivas(empresaID, req.Acao)
+	if err != nil {
+		return nil, err
+	}
+	decodificadas := make([]politicaDecodificada, 0, len(politicas))
+	for _, p := range politicas {
+		condicoes, err := decodificar(p.Condicoes)
+		if err != nil {
+			return nil, fmt.Errorf("política %d com condições ilegíveis: %w", p.ID, err)
+		}
+		decodificadas = append(decodificadas, politicaDecodificada{Politica: p, condicoes: condicoes})
+	}
+	decisao := avaliar(decodificadas, req)
+	return &decisao, nil
+}
+
+func (s *politicaService) Exigir(empresaID uint, req Requisicao) error {
+	decisao, err := s.Avaliar(empresaID, req)
+	if err != nil {
+		return err
+	}
+	if !decisao.Permitido {
+		return fmt.Errorf("%w: %s", ErrNegadoPorPolitica, decisao.Politica)
+	}
+	return nil
+}
+
+func decodificar(dados model.JSONB) ([]model.CondicaoPolitica, error) {
+	var condicoes []model.CondicaoPolitica
+	if len(dados) == 0 {
+		return condicoes, nil
+	}
+	err := json.Unmarshal(dados, &condicoes)
+	return condicoes, err
+}
+
+// validar recusa políticas que o motor não saberia avaliar, para o erro aparecer ao gravar
+// e não silenciosamente a cada requisição.
+func validar(politica *model.Politica) error {
+	if strings.TrimSpace(politica.Nome) == "" {
+		return fmt.Errorf("%w: 'nome' é obrigatório", ErrPoliticaInvalida)
+	}
+	if !contem(acoes, politica.Acao) {
+		return fmt.Errorf("%w: ação desconhecida %q (use uma de %s)", ErrPoliticaInvalida, politica.Acao, strings.Join(acoes, ", "))
+	}
+	if politica.Efeito != model.EfeitoPermitir && politica.Efeito != model.EfeitoNegar {
+		return fmt.Errorf("%w: efeito deve ser %s ou %s", ErrPoliticaInvalida, model.EfeitoPermitir, model.EfeitoNegar)
+	}
+	condicoes, err := decodificar(politica.Condicoes)
+	if err != nil {
+		return fmt.Errorf("%w: 'condicoes' deve ser uma lista de {atributo, operador, valor}", ErrPoliticaInvalida)
+	}
+	for _, condicao := range condicoes {
+		lado, nome, _ := strings.Cut(condicao.Atributo, ".")
+		if nome == "" || !contem([]string{"sujeito", "recurso", "ambiente"}, lado) {
+			return fmt.Errorf("%w: atributo %q deve começar com sujeito., recurso. ou ambiente.", ErrPoliticaInvalida, condicao.Atributo)
+		}
+		if !contem(operadores, condicao.Operador) {
+			return fmt.Errorf("%w: operador %q desconhecido (use um de %s)", ErrPoliticaInvalida, condicao.Operador, strings.Join(operadores, ", "))
+		}
+		if condicao.Operador == OperadorEm || condicao.Operador == OperadorForaDe {
+			if _, ok := condicao.Valor.([]interface{}); !ok {
+				return fmt.Errorf("%w: o operador %q exige uma lista em 'valor'", ErrPoliticaInvalida, condicao.Operador)
+			}
+		}
+	}
+	return nil
+}
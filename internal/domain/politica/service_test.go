@@ -0,0 +1,171 @@
+package politica
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+)
+
+type memoriaPoliticaRepository struct {
+	PoliticaRepository
+	politicas []model.Politica
+	fuso      string
+}
+
+func (m *memoriaPoliticaRepository) GetAtivas(empresaID uint, acao string) ([]model.Politica, error) {
+	var ativas []model.Politica
+	for _, p := range m.politicas {
+		if p.EmpresaID == empresaID && p.Ativa && (p.Acao == acao || p.Acao == AcaoTodas) {
+			ativas = append(ativas, p)
+		}
+	}
+	return ativas, nil
+}
+
+func (m *memoriaPoliticaRepository) FusoHorario(empresaID uint) (string, error) {
+	return m.fuso, nil
+}
+
+func condicoes(t *testing.T, lista ...model.CondicaoPolitica) model.JSONB {
+	t.Helper()
+	dados, err := json.Marshal(lista)
+	if err != nil {
+		t.Fatalf("Falha ao serializar condições: %v", err)
+	}
+	return dados
+}
+
+// remotoSoCargoNasSextas monta o exemplo clássico: trabalho remoto só para o cargo 7, e só às sextas.
+func remotoSoCargoNasSextas(t *testing.T) PoliticaService {
+	repo := &memoriaPoliticaRepository{politicas: []model.Politica{
+		{ID: 1, EmpresaID: 1, Nome: "Remoto proibido", Acao: AcaoPontoBater, Efeito: model.EfeitoNegar, Prioridade: 10, Ativa: true,
+			Condicoes: condicoes(t, model.CondicaoPolitica{Atributo: "recurso.tipo", Operador: OperadorIgual, Valor: "Remoto"})},
+		{ID: 2, EmpresaID: 1, Nome: "Remoto do suporte às sextas", Acao: AcaoPontoBater, Efeito: model.EfeitoPermitir, Prioridade: 20, Ativa: true,
+			Condicoes: condicoes(t,
+				model.CondicaoPolitica{Atributo: "recurso.tipo", Operador: OperadorIgual, Valor: "Remoto"},
+				model.CondicaoPolitica{Atributo: "sujeito.cargo_id", Operador: OperadorEm, Valor: []interface{}{7}},
+				model.CondicaoPolitica{Atributo: "ambiente.dia_semana", Operador: OperadorIgual, Valor: int(time.Friday)},
+			)},
+		{ID: 3, EmpresaID: 1, Nome: "Inativa", Acao: AcaoTodas, Efeito: model.EfeitoNegar, Prioridade: 99, Ativa: false},
+	}}
+	return NewPoliticaService(repo)
+}
+
+var (
+	sexta  = time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	quinta = time.Date(2024, 5, 9, 9, 0, 0, 0, time.UTC)
+)
+
+func TestExigir_PrimeiraPoliticaAplicavelDecide(t *testing.T) {
+	service := remotoSoCargoNasSextas(t)
+	casos := []struct {
+		nome     string
+		cargoID  uint
+		tipo     string
+		momento  time.Time
+		esperado bool
+	}{
+		{"cargo liberado na sexta", 7, "Remoto", sexta, true},
+		{"cargo liberado na quinta", 7, "Remoto", quinta, false},
+		{"outro cargo na sexta", 3, "Remoto", sexta, false},
+		{"presencial não é afetado", 3, "Presencial", quinta, true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			err := service.Exigir(1, Requisicao{
+				Acao:    AcaoPontoBater,
+				Sujeito: AtributosUsuario(&model.Usuario{ID: 1, CargoID: caso.cargoID}),
+				Recurso: Atributos{"tipo": caso.tipo},
+				Momento: caso.momento,
+			})
+			if caso.esperado && err != nil {
+				t.Errorf("Esperava permissão, recebeu %v", err)
+			}
+			if !caso.esperado && !errors.Is(err, ErrNegadoPorPolitica) {
+				t.Errorf("Esperava ErrNegadoPorPolitica, recebeu %v", err)
+			}
+		})
+	}
+}
+
+func TestExigir_AmbienteNoFusoDaEmpresa(t *testing.T) {
+	// 01:30 UTC de sexta ainda é quinta em São Paulo (UTC-3).
+	madrugada := time.Date(2024, 5, 10, 1, 30, 0, 0, time.UTC)
+	requisicao := Requisicao{
+		Acao:    AcaoPontoBater,
+		Sujeito: AtributosUsuario(&model.Usuario{ID: 1, CargoID: 7}),
+		Recurso: Atributos{"tipo": "Remoto"},
+		Momento: madrugada,
+	}
+	service := remotoSoCargoNasSextas(t)
+	if err := service.Exigir(1, requisicao); !errors.Is(err, ErrNegadoPorPolitica) {
+		t.Errorf("Em São Paulo ainda é quinta; esperava ErrNegadoPorPolitica, recebeu %v", err)
+	}
+
+	utc, _ := time.LoadLocation("UTC")
+	requisicao.Fuso = utc
+	if err := service.Exigir(1, requisicao); err != nil {
+		t.Errorf("Num local em UTC já é sexta; esperava permissão, recebeu %v", err)
+	}
+}
+
+func TestAvaliar_ExplicaADecisao(t *testing.T) {
+	service := remotoSoCargoNasSextas(t)
+	decisao, err := service.Avaliar(1, Requisicao{
+		Acao:    AcaoPontoBater,
+		Sujeito: AtributosUsuario(&model.Usuario{ID: 1, CargoID: 3}),
+		Recurso: Atributos{"tipo": "Remoto"},
+		Momento: sexta,
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if decisao.Permitido || decisao.PoliticaID == nil || *decisao.PoliticaID != 1 {
+		t.Fatalf("Esperava negação pela política 1, recebeu %+v", decisao)
+	}
+	if len(decisao.Explicacao) != 2 {
+		t.Errorf("Esperava uma linha para a política descartada e outra para a que decidiu, recebeu %v", decisao.Explicacao)
+	}
+}
+
+func TestAvaliar_AtributoAusenteNaoSatisfazCondicao(t *testing.T) {
+	repo := &memoriaPoliticaRepository{politicas: []model.Politica{
+		{ID: 1, EmpresaID: 1, Nome: "Fora do departamento 2", Acao: AcaoTodas, Efeito: model.EfeitoNegar, Ativa: true,
+			Condicoes: condicoes(t, model.CondicaoPolitica{Atributo: "sujeito.departamento_id", Operador: OperadorDiferente, Valor: 2})},
+	}}
+	service := NewPoliticaService(repo)
+
+	// Chaves de API não têm departamento: a condição é falsa e a negação não se aplica.
+	err := service.Exigir(1, Requisicao{Acao: AcaoBancoHorasFecharDia, Sujeito: AtributosChaveAPI(5), Momento: sexta})
+	if err != nil {
+		t.Errorf("Atributo ausente não deveria ativar a política, recebeu %v", err)
+	}
+	departamento := uint(4)
+	err = service.Exigir(1, Requisicao{Acao: AcaoBancoHorasFecharDia, Sujeito: AtributosUsuario(&model.Usuario{ID: 1, DepartamentoID: &departamento}), Momento: sexta})
+	if !errors.Is(err, ErrNegadoPorPolitica) {
+		t.Errorf("Esperava ErrNegadoPorPolitica, recebeu %v", err)
+	}
+}
+
+func TestCreate_RecusaPoliticaInvalida(t *testing.T) {
+	service := NewPoliticaService(&memoriaPoliticaRepository{})
+	casos := map[string]model.Politica{
+		"sem nome":            {Acao: AcaoPontoBater, Efeito: model.EfeitoNegar},
+		"ação desconhecida":   {Nome: "x", Acao: "VOAR", Efeito: model.EfeitoNegar},
+		"efeito inválido":     {Nome: "x", Acao: AcaoPontoBater, Efeito: "TALVEZ"},
+		"atributo sem lado":   {Nome: "x", Acao: AcaoPontoBater, Efeito: model.EfeitoNegar, Condicoes: condicoes(t, model.CondicaoPolitica{Atributo: "cargo_id", Operador: OperadorIgual, Valor: 1})},
+		"operador inválido":   {Nome: "x", Acao: AcaoPontoBater, Efeito: model.EfeitoNegar, Condicoes: condicoes(t, model.CondicaoPolitica{Atributo: "sujeito.id", Operador: "parecido", Valor: 1})},
+		"'em' sem lista":      {Nome: "x", Acao: AcaoPontoBater, Efeito: model.EfeitoNegar, Condicoes: condicoes(t, model.CondicaoPolitica{Atributo: "sujeito.id", Operador: OperadorEm, Valor: 1})},
+		"condições não lista": {Nome: "x", Acao: AcaoPontoBater, Efeito: model.EfeitoNegar, Condicoes: model.JSONB(`{"a":1}`)},
+	}
+	for nome, politica := range casos {
+		t.Run(nome, func(t *testing.T) {
+			if err := service.Create(&politica); !errors.Is(err, ErrPoliticaInvalida) {
+				t.Errorf("Esperava ErrPoliticaInvalida, recebeu %v", err)
+			}
+		})
+	}
+}
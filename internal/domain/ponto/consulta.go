@@ -0,0 +1,239 @@
+package ponto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+)
+
+const (
+	limitePadraoConsulta = 50
+	limiteMaximoConsulta = 500
+)
+
+// Origens aceitas no filtro "origem". Não são exclusivas: uma batida sincronizada pode ter sido
+// revisada e aparece nas duas; "original" é a que não tem nenhuma das outras marcas.
+const (
+	OrigemOriginal     = "original"
+	OrigemSincronizada = "sincronizada"
+	OrigemQuiosque     = "quiosque"
+	OrigemRevisada     = "revisada"
+)
+
+var (
+	ErrPeriodoInvalido      = errors.New("o início do período deve ser anterior ao fim")
+	ErrTipoInvalido         = errors.New("'tipo' deve ser 'presencial' ou 'remoto'")
+	ErrOrdemInvalida        = errors.New("'ordem' deve ser 'asc' ou 'desc'")
+	ErrOrigemInvalida       = errors.New("'origem' deve ser 'original', 'sincronizada', 'quiosque' ou 'revisada'")
+	ErrCursorInvalido       = errors.New("'cursor' inválido: use o 'proximo_cursor' devolvido pela página anterior, com os mesmos filtros")
+	ErrConsultaForaDoEscopo = errors.New("você não tem permissão para ver os pontos deste funcionário")
+)
+
+// FiltroConsulta são os filtros de GET /pontos. Usuarios restringe pelos dados do funcionário
+// (departamento, centro de custo, gestor); Cursor é o proximo_cursor da página anterior.
+type FiltroConsulta struct {
+	De              *time.Time
+	Ate             *time.Time
+	UsuarioID       *uint
+	Usuarios        usuario.FiltroUsuarios
+	LocalTrabalhoID *uint
+	Tipo            string
+	Origem          string
+	Ordem           string
+	Limite          int
+	Cursor          string
+}
+
+// PontoConsultado é o ponto com o nome de quem o bateu.
+type PontoConsultado struct {
+	model.RegistroPonto
+	UsuarioNome string `json:"usuario_nome"`
+}
+
+// PaginaPontos é uma página da consulta; ProximoCursor vem vazio na última.
+type PaginaPontos struct {
+	Pontos        []PontoConsultado `json:"pontos"`
+	ProximoCursor string            `json:"proximo_cursor,omitempty"`
+}
+
+type ConsultaService interface {
+	// Consultar devolve os pontos da empresa que atendem ao filtro, entre os funcionários no
+	// escopo de VER_SALDO_FUNCIONARIOS do requisitante, além dos dele. Sem requisitante, a consulta
+	// é de uma chave de API com esse escopo e alcança a empresa toda.
+	Consultar(empresaID uint, requisitante *model.Usuario, filtro FiltroConsulta) (*PaginaPontos, error)
+}
+
+type consultaService struct {
+	pontoRepo      RegistroPontoRepository
+	usuarioService usuario.UsuarioService
+}
+
+func NewConsultaService(pontoRepo RegistroPontoRepository, usuarioService usuario.UsuarioService) ConsultaService {
+	return &consultaService{
+		pontoRepo:      pontoRepo,
+		usuarioService: usuarioService,
+	}
+}
+
+func (s *consultaService) Consultar(empresaID uint, requisitante *model.Usuario, filtro FiltroConsulta) (*PaginaPontos, error) {
+	busca := FiltroPontos{De: filtro.De, Ate: filtro.Ate, LocalTrabalhoID: filtro.LocalTrabalhoID}
+	if filtro.De != nil && filtro.Ate != nil && !filtro.De.Before(*filtro.Ate) {
+		return nil, ErrPeriodoInvalido
+	}
+	switch strings.ToLower(filtro.Tipo) {
+	case "":
+	case "presencial":
+		busca.Tipo = "Presencial"
+	case "remoto":
+		busca.Tipo = "Remoto"
+	default:
+		return nil, ErrTipoInvalido
+	}
+	switch origem := strings.ToLower(filtro.Origem); origem {
+	case "":
+	case OrigemOriginal, OrigemSincronizada, OrigemQuiosque, OrigemRevisada:
+		busca.Origem = origem
+	default:
+		return nil, ErrOrigemInvalida
+	}
+	switch strings.ToLower(filtro.Ordem) {
+	case "", "desc":
+		busca.Decrescente = true
+	case "asc":
+	default:
+		return nil, ErrOrdemInvalida
+	}
+	if filtro.Cursor != "" {
+		posicao, err := lerCursor(filtro.Cursor, busca.Decrescente)
+		if err != nil {
+			return nil, err
+		}
+		busca.Depois = &posicao
+	}
+	limite := filtro.Limite
+	if limite <= 0 {
+		limite = limitePadraoConsulta
+	}
+	if limite > limiteMaximoConsulta {
+		limite = limiteMaximoConsulta
+	}
+	// Um ponto a mais revela se existe uma próxima página.
+	busca.Limite = limite + 1
+
+	usuarioIDs, err := s.usuariosVisiveis(empresaID, requisitante, filtro)
+	if err != nil {
+		return nil, err
+	}
+	busca.UsuarioIDs = usuarioIDs
+
+	pontos, err := s.pontoRepo.Buscar(empresaID, busca)
+	if err != nil {
+		return nil, err
+	}
+	pagina := &PaginaPontos{Pontos: make([]PontoConsultado, 0, len(pontos))}
+	if len(pontos) > limite {
+		pontos = pontos[:limite]
+		ultimo := pontos[limite-1]
+		pagina.ProximoCursor = escreverCursor(Posicao{Timestamp: ultimo.Timestamp, ID: ultimo.ID}, busca.Decrescente)
+	}
+	for _, p := range pontos {
+		pagina.Pontos = append(pagina.Pontos, PontoConsultado{RegistroPonto: p, UsuarioNome: p.Usuario.Nome})
+	}
+	return pagina, nil
+}
+
+// usuariosVisiveis resolve, antes da consulta, de quais funcionários o requisitante pode ver os
+// pontos: filtrar depois quebraria a paginação. Nulo significa a empresa toda, sem restrição.
+func (s *consultaService) usuariosVisiveis(empresaID uint, requisitante *model.Usuario, filtro FiltroConsulta) ([]uint, error) {
+	todaEmpresa := requisitante == nil
+	if !todaEmpresa {
+		escopo, concedida := requisitante.Cargo.EscopoPermissao(permissions.VER_SALDO_FUNCIONARIOS)
+		todaEmpresa = concedida && escopo == model.EscopoEmpresa
+	}
+	if filtro.UsuarioID != nil && !todaEmpresa && *filtro.UsuarioID != requisitante.ID {
+		permitido, err := s.usuarioService.AlvoNoEscopo(requisitante, permissions.VER_SALDO_FUNCIONARIOS, *filtro.UsuarioID)
+		if err != nil {
+			return nil, err
+		}
+		if !permitido {
+			return nil, ErrConsultaForaDoEscopo
+		}
+	}
+
+	semFiltroDeUsuarios := filtro.Usuarios == usuario.FiltroUsuarios{}
+	if semFiltroDeUsuarios && filtro.UsuarioID != nil {
+		return []uint{*filtro.UsuarioID}, nil
+	}
+	if semFiltroDeUsuarios && todaEmpresa {
+		return nil, nil
+	}
+
+	usuarios, err := s.usuarioService.Buscar(empresaID, filtro.Usuarios)
+	if err != nil {
+		return nil, err
+	}
+	visiveis := make(map[uint]bool, len(usuarios))
+	if todaEmpresa {
+		for _, u := range usuarios {
+			visiveis[u.ID] = true
+		}
+	} else {
+		permitidos, err := s.usuarioService.FiltrarPorEscopo(requisitante, permissions.VER_SALDO_FUNCIONARIOS, usuarios)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range permitidos {
+			visiveis[u.ID] = true
+		}
+		// Os próprios pontos são sempre visíveis, mesmo sem a permissão.
+		for _, u := range usuarios {
+			if u.ID == requisitante.ID {
+				visiveis[u.ID] = true
+			}
+		}
+	}
+	ids := make([]uint, 0, len(visiveis))
+	for _, u := range usuarios {
+		if visiveis[u.ID] && (filtro.UsuarioID == nil || u.ID == *filtro.UsuarioID) {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
+
+// O cursor leva a ordem em que foi gerado, para que não seja usado na ordem inversa.
+func escreverCursor(posicao Posicao, decrescente bool) string {
+	ordem := "a"
+	if decrescente {
+		ordem = "d"
+	}
+	texto := fmt.Sprintf("%s:%d:%d", ordem, posicao.Timestamp.UnixNano(), posicao.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(texto))
+}
+
+func lerCursor(cursor string, decrescente bool) (Posicao, error) {
+	bruto, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Posicao{}, ErrCursorInvalido
+	}
+	partes := strings.Split(string(bruto), ":")
+	if len(partes) != 3 || (partes[0] == "d") != decrescente || (partes[0] != "d" && partes[0] != "a") {
+		return Posicao{}, ErrCursorInvalido
+	}
+	nanos, err := strconv.ParseInt(partes[1], 10, 64)
+	if err != nil {
+		return Posicao{}, ErrCursorInvalido
+	}
+	id, err := strconv.ParseUint(partes[2], 10, 64)
+	if err != nil || id == 0 {
+		return Posicao{}, ErrCursorInvalido
+	}
+	return Posicao{Timestamp: time.Unix(0, nanos).UTC(), ID: uint(id)}, nil
+}
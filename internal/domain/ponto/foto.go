@@ -0,0 +1,108 @@
+package ponto
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/armazenamento"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+)
+
+// TamanhoMaximoFoto é o maior arquivo aceito como selfie (2 MB).
+const TamanhoMaximoFoto = 2 << 20
+
+// extensoesFoto são os formatos aceitos, pelo tipo detectado no conteúdo (e não pelo declarado).
+var extensoesFoto = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+var (
+	ErrFotoObrigatoria  = errors.New("esta empresa exige uma selfie em cada batida")
+	ErrFotoGrande       = fmt.Errorf("a foto deve ter no máximo %d MB", TamanhoMaximoFoto>>20)
+	ErrFotoTipoInvalido = errors.New("a foto deve ser uma imagem JPEG, PNG ou WebP")
+	ErrSemFoto          = errors.New("este ponto não tem foto guardada")
+	ErrFotoForaDoEscopo = errors.New("você não tem permissão para ver as fotos dos pontos deste funcionário")
+)
+
+// Foto é a selfie enviada com a batida.
+type Foto struct {
+	Conteudo []byte
+}
+
+// TipoDaFoto verifica o tamanho e o formato da foto e devolve o seu Content-Type.
+func TipoDaFoto(conteudo []byte) (string, error) {
+	if len(conteudo) > TamanhoMaximoFoto {
+		return "", ErrFotoGrande
+	}
+	if len(conteudo) == 0 {
+		return "", ErrFotoTipoInvalido
+	}
+	tipo := http.DetectContentType(conteudo)
+	if _, aceito := extensoesFoto[tipo]; !aceito {
+		return "", ErrFotoTipoInvalido
+	}
+	return tipo, nil
+}
+
+// chaveDaFoto gera um nome imprevisível para o arquivo, agrupado por empresa, usuário e mês.
+func chaveDaFoto(empresaID uint, usuarioID uint, momento time.Time, tipo string) (string, error) {
+	aleatorio := make([]byte, 16)
+	if _, err := rand.Read(aleatorio); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("fotos/empresa-%d/usuario-%d/%s/%s.%s", empresaID, usuarioID,
+		momento.UTC().Format("2006/01"), hex.EncodeToString(aleatorio), extensoesFoto[tipo]), nil
+}
+
+type FotoService interface {
+	// Ver devolve o ponto e a sua selfie, se o funcionário que bateu o ponto está no escopo de
+	// VER_FOTOS_PONTO do requisitante.
+	Ver(requisitante *model.Usuario, pontoID uint) (*model.RegistroPonto, []byte, error)
+}
+
+type fotoService struct {
+	pontoRepo      RegistroPontoRepository
+	usuarioService usuario.UsuarioService
+	arquivos       armazenamento.Armazenamento
+}
+
+func NewFotoService(pontoRepo RegistroPontoRepository, usuarioService usuario.UsuarioService, arquivos armazenamento.Armazenamento) FotoService {
+	return &fotoService{
+		pontoRepo:      pontoRepo,
+		usuarioService: usuarioService,
+		arquivos:       arquivos,
+	}
+}
+
+func (s *fotoService) Ver(requisitante *model.Usuario, pontoID uint) (*model.RegistroPonto, []byte, error) {
+	ponto, err := s.pontoRepo.FindByID(pontoID, requisitante.EmpresaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	permitido, err := s.usuarioService.AlvoNoEscopo(requisitante, permissions.VER_FOTOS_PONTO, ponto.UsuarioID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !permitido {
+		return nil, nil, ErrFotoForaDoEscopo
+	}
+	if !ponto.TemFoto() {
+		return nil, nil, ErrSemFoto
+	}
+	conteudo, err := s.arquivos.Ler(*ponto.FotoChave)
+	if errors.Is(err, armazenamento.ErrNaoEncontrado) {
+		return nil, nil, ErrSemFoto
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return ponto, conteudo, nil
+}
@@ -1,27 +1,57 @@
 package ponto
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/dispositivo"
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/risco"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// limiteCorpoBatida comporta a maior foto aceita codificada em base64, com folga para os demais campos.
+const limiteCorpoBatida = TamanhoMaximoFoto*4/3 + 64<<10
+
 type PontoHandler struct {
-	service PontoService
+	service        PontoService
+	fotos          FotoService
+	consultas      ConsultaService
+	usuarioService usuario.UsuarioService
+	empresas       empresa.EmpresaService
+	converter      funcoes.FuncoesInterface
 }
 
-func NewPontoHandler(service PontoService) *PontoHandler {
+func NewPontoHandler(service PontoService, fotos FotoService, consultas ConsultaService, usuarioService usuario.UsuarioService, empresas empresa.EmpresaService, f funcoes.FuncoesInterface) *PontoHandler {
 	return &PontoHandler{
-		service: service,
+		service:        service,
+		fotos:          fotos,
+		consultas:      consultas,
+		usuarioService: usuarioService,
+		empresas:       empresas,
+		converter:      f,
 	}
 }
 
 func (h *PontoHandler) BaterPonto(c *gin.Context) {
 	valorIDToken, existe := c.Get("userID")
 	if !existe {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ID do usuário não encontrado no contexto"})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem bater ponto."})
 		return
 	}
 	idTokenString, ok := valorIDToken.(string)
@@ -50,33 +80,123 @@ func (h *PontoHandler) BaterPonto(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ID da empresa no token é inválido"})
 		return
 	}
+	// A selfie opcional vem como o arquivo "foto" de um formulário multipart ou, no JSON, em
+	// base64 no campo "foto" (com ou sem o prefixo "data:image/...;base64,").
 	type BaterPontoRequest struct {
-		Latitude  float64 `json:"latitude"`
-		Longitude float64 `json:"longitude"`
+		Latitude       float64  `json:"latitude" form:"latitude"`
+		Longitude      float64  `json:"longitude" form:"longitude"`
+		PrecisaoMetros *float64 `json:"precisao_metros" form:"precisao_metros" binding:"omitempty,gte=0"`
+		Justificativa  string   `json:"justificativa" form:"justificativa"`
+		Foto           string   `json:"foto" form:"-"`
+		// Dispositivo identifica o aparelho; é obrigatório nas empresas que exigem dispositivos autorizados.
+		Dispositivo     string `json:"dispositivo" form:"dispositivo"`
+		DispositivoNome string `json:"dispositivo_nome" form:"dispositivo_nome"`
 	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limiteCorpoBatida)
 	var requisicao BaterPontoRequest
-	if err := c.ShouldBindJSON(&requisicao); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Corpo da requisição (JSON) inválido"})
-		return
+	var foto *Foto
+	if c.ContentType() == "multipart/form-data" {
+		if err := c.ShouldBind(&requisicao); err != nil {
+			responderCorpoInvalido(c, err, "Formulário da requisição inválido")
+			return
+		}
+		if arquivo, err := c.FormFile("foto"); err == nil {
+			conteudo, err := lerArquivo(arquivo)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Não foi possível ler a foto enviada"})
+				return
+			}
+			foto = &Foto{Conteudo: conteudo}
+		}
+	} else {
+		if err := c.ShouldBindJSON(&requisicao); err != nil {
+			responderCorpoInvalido(c, err, "Corpo da requisição (JSON) inválido")
+			return
+		}
+		if requisicao.Foto != "" {
+			conteudo, err := decodificarFoto(requisicao.Foto)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "A foto deve estar codificada em base64"})
+				return
+			}
+			foto = &Foto{Conteudo: conteudo}
+		}
 	}
 
-	pontoRegistrado, err := h.service.BaterPonto(uint(usuarioID), uint(empresaID), requisicao.Latitude, requisicao.Longitude)
+	pontoRegistrado, err := h.service.BaterPonto(uint(usuarioID), uint(empresaID), Batida{
+		Latitude:        requisicao.Latitude,
+		Longitude:       requisicao.Longitude,
+		PrecisaoMetros:  requisicao.PrecisaoMetros,
+		Justificativa:   requisicao.Justificativa,
+		Foto:            foto,
+		Dispositivo:     requisicao.Dispositivo,
+		DispositivoNome: requisicao.DispositivoNome,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao registrar o ponto"})
+		ResponderErroBatida(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, pontoRegistrado)
 }
 
+// ResponderErroBatida traduz os erros de BaterPonto em respostas HTTP. É usado também pelas rotas
+// de quiosque, que terminam na mesma batida.
+func ResponderErroBatida(c *gin.Context, err error) {
+	var intervalo *IntervaloMinimoError
+	var naoAutorizado *dispositivo.NaoAutorizadoError
+	switch {
+	// O aplicativo usa os campos para explicar a recusa e liberar o botão na hora certa.
+	case errors.As(err, &intervalo):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":                     err.Error(),
+			"motivo":                    "INTERVALO_MINIMO",
+			"batida_anterior":           intervalo.Anterior,
+			"intervalo_minimo_segundos": int(intervalo.IntervaloMinimo.Seconds()),
+			"liberada_em":               intervalo.LiberadaEm(),
+		})
+	case errors.As(err, &naoAutorizado):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":          err.Error(),
+			"motivo":         "DISPOSITIVO_NAO_AUTORIZADO",
+			"dispositivo_id": naoAutorizado.Dispositivo.ID,
+			"status":         naoAutorizado.Dispositivo.Status,
+		})
+	case errors.Is(err, politica.ErrNegadoPorPolitica), errors.Is(err, ErrBatidaRemotaBloqueada), errors.Is(err, risco.ErrRiscoAlto):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrJustificativaObrigatoria), errors.Is(err, ErrBatidaNoFuturo), errors.Is(err, ErrBatidaOfflineAntiga),
+		errors.Is(err, ErrFotoObrigatoria), errors.Is(err, ErrFotoTipoInvalido),
+		errors.Is(err, dispositivo.ErrDispositivoObrigatorio), errors.Is(err, dispositivo.ErrIdentificadorInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrFotoGrande):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	// Usuário ou empresa excluídos: o token ainda é válido, mas o ponto não pode ser batido.
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Usuário ou empresa desativados"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao registrar o ponto"})
+	}
+}
+
 func (h *PontoHandler) GetMeusRegistos(c *gin.Context) {
-	valorIDToken, _ := c.Get("userID")
+	valorIDToken, existe := c.Get("userID")
+	if !existe {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários têm registros de ponto."})
+		return
+	}
 	idTokenString, _ := valorIDToken.(string)
 	usuarioID, err := strconv.ParseUint(idTokenString, 10, 64)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ID do utilizador no token é inválido"})
 		return
 	}
+	valorEmpresaID, _ := c.Get("empresaID")
+	idEmpresaString, _ := valorEmpresaID.(string)
+	empresaID, err := strconv.ParseUint(idEmpresaString, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ID da empresa no token é inválido"})
+		return
+	}
 
 	diaQuery := c.Query("dia")
 	var dia time.Time
@@ -84,14 +204,20 @@ func (h *PontoHandler) GetMeusRegistos(c *gin.Context) {
 	if diaQuery == "" {
 		dia = time.Now()
 	} else {
-		dia, err = time.Parse("2006-01-02", diaQuery)
+		fusoEmpresa, err := h.empresas.Fuso(uint(empresaID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar o fuso horário da empresa"})
+			return
+		}
+		// A data é o dia de calendário da empresa, não o dia em UTC.
+		dia, err = fuso.ParseDia(diaQuery, fusoEmpresa)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de data inválido. Use AAAA-MM-DD."})
 			return
 		}
 	}
 
-	registos, err := h.service.GetPontosDoDia(uint(usuarioID), dia)
+	registos, err := h.service.GetPontosDoDia(uint(usuarioID), uint(empresaID), dia)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar os registos de ponto"})
 		return
@@ -99,3 +225,194 @@ func (h *PontoHandler) GetMeusRegistos(c *gin.Context) {
 
 	c.JSON(http.StatusOK, registos)
 }
+
+// responderCorpoInvalido distingue o corpo grande demais (413) do malformado (400).
+func responderCorpoInvalido(c *gin.Context, err error, mensagem string) {
+	var grande *http.MaxBytesError
+	if errors.As(err, &grande) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": ErrFotoGrande.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": mensagem})
+}
+
+// lerArquivo lê até um byte além do limite, o bastante para TipoDaFoto recusar o excesso.
+func lerArquivo(arquivo *multipart.FileHeader) ([]byte, error) {
+	aberto, err := arquivo.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer aberto.Close()
+	return io.ReadAll(io.LimitReader(aberto, TamanhoMaximoFoto+1))
+}
+
+func decodificarFoto(valor string) ([]byte, error) {
+	if strings.HasPrefix(valor, "data:") {
+		_, depois, ok := strings.Cut(valor, ";base64,")
+		if !ok {
+			return nil, errors.New("data URL sem base64")
+		}
+		valor = depois
+	}
+	return base64.StdEncoding.DecodeString(valor)
+}
+
+// GetFoto devolve a selfie de uma batida, para quem tem VER_FOTOS_PONTO sobre o funcionário.
+func (h *PontoHandler) GetFoto(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	requisitanteID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem ver as fotos dos pontos."})
+		return
+	}
+	requisitante, err := usuario.Requisitante(c, h.usuarioService, requisitanteID, empresaID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+		return
+	}
+	pontoID, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do ponto inválido."})
+		return
+	}
+
+	ponto, conteudo, err := h.fotos.Ver(requisitante, pontoID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ponto não encontrado nesta empresa."})
+		case errors.Is(err, ErrSemFoto):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrFotoForaDoEscopo):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar a foto do ponto."})
+		}
+		return
+	}
+	// A selfie é dado pessoal: nada de cache compartilhado.
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, ponto.FotoTipo, conteudo)
+}
+
+// GetPontos consulta as batidas por período, com filtros e paginação por cursor. Cada usuário vê
+// os próprios pontos e os dos funcionários no escopo de VER_SALDO_FUNCIONARIOS.
+func (h *PontoHandler) GetPontos(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	// As datas sem hora são dias no fuso da empresa.
+	var fusoEmpresa *time.Location
+	if c.Query("de") != "" || c.Query("ate") != "" {
+		if fusoEmpresa, err = h.empresas.Fuso(empresaID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar o fuso horário da empresa."})
+			return
+		}
+	}
+	filtro, err := h.lerFiltroConsulta(c, fusoEmpresa)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Chaves de API não representam um funcionário: só consultam com o escopo adequado, e então
+	// alcançam a empresa toda.
+	var requisitante *model.Usuario
+	if _, ehChaveAPI := auth.EscoposChaveAPI(c); ehChaveAPI {
+		if !auth.ChaveAPITemEscopo(c, permissions.VER_SALDO_FUNCIONARIOS) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "A chave de API não tem o escopo para consultar pontos."})
+			return
+		}
+	} else {
+		requisitanteID, err := h.converter.GetUintIDFromContext(c, "userID")
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários e chaves de API podem consultar pontos."})
+			return
+		}
+		requisitante, err = usuario.Requisitante(c, h.usuarioService, requisitanteID, empresaID)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+			return
+		}
+	}
+
+	pagina, err := h.consultas.Consultar(empresaID, requisitante, filtro)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrPeriodoInvalido), errors.Is(err, ErrTipoInvalido), errors.Is(err, ErrOrigemInvalida), errors.Is(err, ErrOrdemInvalida), errors.Is(err, ErrCursorInvalido):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrConsultaForaDoEscopo):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao consultar os pontos."})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, pagina)
+}
+
+// lerFiltroConsulta interpreta de e ate (AAAA-MM-DD no fuso da empresa, com o dia de "ate"
+// incluído, ou RFC 3339, com "ate" exclusivo), usuario_id, departamento_id, centro_custo_id,
+// gestor_id, local_trabalho_id, tipo, origem, ordem, limite e cursor.
+func (h *PontoHandler) lerFiltroConsulta(c *gin.Context, fusoEmpresa *time.Location) (FiltroConsulta, error) {
+	filtro := FiltroConsulta{Tipo: c.Query("tipo"), Origem: c.Query("origem"), Ordem: c.Query("ordem"), Cursor: c.Query("cursor")}
+	var err error
+	if filtro.Usuarios, err = usuario.FiltroDaQuery(c, h.converter); err != nil {
+		return filtro, err
+	}
+	for _, campo := range []struct {
+		nome    string
+		destino **uint
+	}{
+		{"usuario_id", &filtro.UsuarioID},
+		{"local_trabalho_id", &filtro.LocalTrabalhoID},
+	} {
+		valor := c.Query(campo.nome)
+		if valor == "" {
+			continue
+		}
+		id, err := h.converter.StrParaUint(valor)
+		if err != nil {
+			return filtro, fmt.Errorf("o parâmetro '%s' deve ser um ID válido", campo.nome)
+		}
+		*campo.destino = &id
+	}
+	if valor := c.Query("limite"); valor != "" {
+		if filtro.Limite, err = strconv.Atoi(valor); err != nil {
+			return filtro, fmt.Errorf("o parâmetro 'limite' deve ser um número")
+		}
+	}
+
+	if filtro.De, err = lerMomento(c.Query("de"), "de", fusoEmpresa, false); err != nil {
+		return filtro, err
+	}
+	if filtro.Ate, err = lerMomento(c.Query("ate"), "ate", fusoEmpresa, true); err != nil {
+		return filtro, err
+	}
+	return filtro, nil
+}
+
+// lerMomento lê um instante RFC 3339 ou uma data no fuso da empresa. Com fimDoDia, a data vale
+// até o fim do dia, ou seja, até a meia-noite do dia seguinte.
+func lerMomento(valor string, parametro string, loc *time.Location, fimDoDia bool) (*time.Time, error) {
+	if valor == "" {
+		return nil, nil
+	}
+	if momento, err := time.Parse(time.RFC3339, valor); err == nil {
+		return &momento, nil
+	}
+	dia, err := fuso.ParseDia(valor, loc)
+	if err != nil {
+		return nil, fmt.Errorf("formato de data inválido em '%s'. Use AAAA-MM-DD ou RFC 3339", parametro)
+	}
+	if fimDoDia {
+		dia = dia.AddDate(0, 0, 1)
+	}
+	return &dia, nil
+}
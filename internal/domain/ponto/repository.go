@@ -1,14 +1,49 @@
 package ponto
 
 import (
+	"errors"
 	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
 type RegistroPontoRepository interface {
-	SavePonto(ponto *model.RegistroPonto) error
-	FindPontosByUserIDAndDate(userID uint, dia time.Time) ([]model.RegistroPonto, error)
+	// SavePonto grava o ponto se nenhuma outra batida do usuário estiver a menos de intervaloMinimo
+	// dele, antes ou depois; senão devolve *IntervaloMinimoError. A consulta e a gravação acontecem
+	// numa transação que trava a linha do usuário, então batidas simultâneas dele esperam uma pela
+	// outra em vez de passarem juntas pela verificação.
+	SavePonto(ponto *model.RegistroPonto, intervaloMinimo time.Duration) error
+	// FindPontosByUserIDAndDate devolve os pontos do dia de calendário de dia, no fuso em que ele
+	// está (dia.Location()).
+	FindPontosByUserIDAndDate(userID uint, empresaID uint, dia time.Time) ([]model.RegistroPonto, error)
+	FindByID(pontoID uint, empresaID uint) (*model.RegistroPonto, error)
+	// Buscar devolve os pontos da empresa que atendem ao filtro, em ordem de Timestamp e ID,
+	// com o usuário carregado.
+	Buscar(empresaID uint, filtro FiltroPontos) ([]model.RegistroPonto, error)
+}
+
+// FiltroPontos restringe a consulta de pontos; campos nulos ou vazios não filtram, exceto
+// UsuarioIDs, em que uma lista vazia (e não nula) não devolve nada. Depois, quando preenchido, é a
+// posição do último ponto da página anterior.
+type FiltroPontos struct {
+	De              *time.Time
+	Ate             *time.Time
+	UsuarioIDs      []uint
+	LocalTrabalhoID *uint
+	Tipo            string
+	// Origem é uma das constantes Origem*, já validada.
+	Origem      string
+	Decrescente bool
+	Depois      *Posicao
+	Limite      int
+}
+
+// Posicao é o lugar de um ponto na ordenação da consulta.
+type Posicao struct {
+	Timestamp time.Time
+	ID        uint
 }
 
 type pontoRepository struct {
@@ -19,18 +54,97 @@ func NewPontoRepository(db *gorm.DB) RegistroPontoRepository {
 	return &pontoRepository{Db: db}
 }
 
-func (r *pontoRepository) SavePonto(ponto *model.RegistroPonto) error {
-	return r.Db.Create(ponto).Error
+func (r *pontoRepository) SavePonto(ponto *model.RegistroPonto, intervaloMinimo time.Duration) error {
+	return tenant.Escopo(r.Db, ponto.EmpresaID).Transaction(func(tx *gorm.DB) error {
+		var usuario model.Usuario
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", ponto.UsuarioID).First(&usuario).Error
+		if err != nil {
+			return err
+		}
+
+		if intervaloMinimo > 0 {
+			var vizinho model.RegistroPonto
+			err := tx.Where("usuario_id = ?", ponto.UsuarioID).
+				Where("timestamp > ? AND timestamp < ?", ponto.Timestamp.Add(-intervaloMinimo), ponto.Timestamp.Add(intervaloMinimo)).
+				Order("timestamp desc").First(&vizinho).Error
+			if err == nil {
+				return &IntervaloMinimoError{Anterior: vizinho.Timestamp, IntervaloMinimo: intervaloMinimo}
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		return tx.Create(ponto).Error
+	})
 }
 
-func (r *pontoRepository) FindPontosByUserIDAndDate(userID uint, dia time.Time) ([]model.RegistroPonto, error) {
+func (r *pontoRepository) FindPontosByUserIDAndDate(userID uint, empresaID uint, dia time.Time) ([]model.RegistroPonto, error) {
 	ano, mes, diaDoMes := dia.Date()
 	inicioDoDia := time.Date(ano, mes, diaDoMes, 0, 0, 0, 0, dia.Location())
-	fimDoDia := time.Date(ano, mes, diaDoMes, 23, 59, 59, 0, dia.Location())
+	// AddDate, e não 24 horas, para os dias em que o horário de verão começa ou termina.
+	inicioDoDiaSeguinte := inicioDoDia.AddDate(0, 0, 1)
 
 	var pontos []model.RegistroPonto
-	err := r.Db.Where("usuario_id = ?", userID).
-		Where("timestamp BETWEEN ? AND ?", inicioDoDia, fimDoDia).
+	err := tenant.Escopo(r.Db, empresaID).Where("usuario_id = ?", userID).
+		Where("timestamp >= ? AND timestamp < ?", inicioDoDia.UTC(), inicioDoDiaSeguinte.UTC()).
 		Find(&pontos).Error
 	return pontos, err
 }
+
+func (r *pontoRepository) FindByID(pontoID uint, empresaID uint) (*model.RegistroPonto, error) {
+	var ponto model.RegistroPonto
+	err := tenant.Escopo(r.Db, empresaID).Where("id = ?", pontoID).First(&ponto).Error
+	return &ponto, err
+}
+
+func (r *pontoRepository) Buscar(empresaID uint, filtro FiltroPontos) ([]model.RegistroPonto, error) {
+	pontos := []model.RegistroPonto{}
+	if filtro.UsuarioIDs != nil && len(filtro.UsuarioIDs) == 0 {
+		return pontos, nil
+	}
+	consulta := tenant.Escopo(r.Db, empresaID).Preload("Usuario", func(db *gorm.DB) *gorm.DB { return db.Unscoped() })
+	if filtro.UsuarioIDs != nil {
+		consulta = consulta.Where("usuario_id IN ?", filtro.UsuarioIDs)
+	}
+	if filtro.De != nil {
+		consulta = consulta.Where("timestamp >= ?", filtro.De.UTC())
+	}
+	if filtro.Ate != nil {
+		consulta = consulta.Where("timestamp < ?", filtro.Ate.UTC())
+	}
+	if filtro.LocalTrabalhoID != nil {
+		consulta = consulta.Where("local_trabalho_id = ?", *filtro.LocalTrabalhoID)
+	}
+	if filtro.Tipo != "" {
+		consulta = consulta.Where("tipo = ?", filtro.Tipo)
+	}
+	switch filtro.Origem {
+	case OrigemSincronizada:
+		consulta = consulta.Where("sincronizado_em IS NOT NULL")
+	case OrigemQuiosque:
+		consulta = consulta.Where("quiosque_id IS NOT NULL")
+	case OrigemRevisada:
+		consulta = consulta.Where("status_revisao <> ''")
+	case OrigemOriginal:
+		// Pontos gravados antes da coluna status_revisao existir a têm nula.
+		consulta = consulta.Where("sincronizado_em IS NULL AND quiosque_id IS NULL AND (status_revisao IS NULL OR status_revisao = '')")
+	}
+	// Paginação por posição (keyset): a página seguinte começa depois do último ponto entregue, o
+	// que não pula nem repete pontos quando outros são gravados entre uma página e outra.
+	ordem := "timestamp asc, id asc"
+	if filtro.Decrescente {
+		ordem = "timestamp desc, id desc"
+		if filtro.Depois != nil {
+			consulta = consulta.Where("(timestamp, id) < (?, ?)", filtro.Depois.Timestamp.UTC(), filtro.Depois.ID)
+		}
+	} else if filtro.Depois != nil {
+		consulta = consulta.Where("(timestamp, id) > (?, ?)", filtro.Depois.Timestamp.UTC(), filtro.Depois.ID)
+	}
+	if filtro.Limite > 0 {
+		consulta = consulta.Limit(filtro.Limite)
+	}
+	err := consulta.Order(ordem).Find(&pontos).Error
+	return pontos, err
+}
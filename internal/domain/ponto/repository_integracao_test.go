@@ -0,0 +1,132 @@
+//go:build integracao
+
+package ponto
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Rode com: TESTE_POSTGRES_DSN="host=localhost user=pontouser password=... dbname=ponto_api_db" go test -tags integracao ./internal/domain/ponto/
+//
+// O teste cria e apaga um esquema próprio, então o DSN precisa poder criar esquemas.
+const esquemaTeste = "ponto_repositorio_teste"
+
+// prepararBanco cria o esquema de teste com as tabelas de pontos e devolve a conexão, com o plugin
+// de isolamento instalado, e um usuário da empresa 1.
+func prepararBanco(t *testing.T) (*gorm.DB, model.Usuario) {
+	t.Helper()
+	dsn := os.Getenv("TESTE_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TESTE_POSTGRES_DSN não definido")
+	}
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("DSN inválido: %v", err)
+	}
+	config.RuntimeParams["search_path"] = esquemaTeste
+
+	sqlDB := stdlib.OpenDB(*config)
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                                   logger.Discard,
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("Falha ao abrir o banco: %v", err)
+	}
+	err = db.Exec(`DROP SCHEMA IF EXISTS ` + esquemaTeste + ` CASCADE; CREATE SCHEMA ` + esquemaTeste).Error
+	if err != nil {
+		t.Fatalf("Falha ao criar o esquema de teste: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DROP SCHEMA IF EXISTS ` + esquemaTeste + ` CASCADE`)
+	})
+	if err := db.AutoMigrate(&model.Usuario{}, &model.RegistroPonto{}); err != nil {
+		t.Fatalf("Falha na migração: %v", err)
+	}
+	if err := db.Use(tenant.Plugin{}); err != nil {
+		t.Fatalf("Falha ao instalar o plugin: %v", err)
+	}
+
+	usuario := model.Usuario{Nome: "Fulano", Email: "fulano@empresa.com", Senha: "x", EmpresaID: 1, CargoID: 1}
+	if err := tenant.Escopo(db, 1).Omit("Empresa", "Cargo").Create(&usuario).Error; err != nil {
+		t.Fatalf("Falha ao criar o usuário: %v", err)
+	}
+	return db, usuario
+}
+
+func TestSavePonto_IntervaloMinimo(t *testing.T) {
+	db, usuario := prepararBanco(t)
+	repo := NewPontoRepository(db)
+	intervalo := 2 * time.Minute
+	existente := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	novoPonto := func(ts time.Time) *model.RegistroPonto {
+		return &model.RegistroPonto{Timestamp: ts, Tipo: "ENTRADA", UsuarioID: usuario.ID, EmpresaID: usuario.EmpresaID}
+	}
+	if err := repo.SavePonto(novoPonto(existente), intervalo); err != nil {
+		t.Fatalf("O primeiro ponto deveria ser gravado: %v", err)
+	}
+
+	casos := []struct {
+		nome      string
+		timestamp time.Time
+		recusado  bool
+	}{
+		// Uma batida sincronizada depois pode ter horário anterior ao do ponto já gravado.
+		{"vizinho depois, dentro do intervalo", existente.Add(-time.Minute), true},
+		{"vizinho antes, dentro do intervalo", existente.Add(time.Minute), true},
+		{"exatamente no intervalo, antes", existente.Add(-intervalo), false},
+		{"exatamente no intervalo, depois", existente.Add(intervalo), false},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			err := repo.SavePonto(novoPonto(caso.timestamp), intervalo)
+			var intervaloErr *IntervaloMinimoError
+			if caso.recusado {
+				if !errors.As(err, &intervaloErr) {
+					t.Fatalf("Esperava IntervaloMinimoError, recebeu %v", err)
+				}
+				if !intervaloErr.Anterior.Equal(existente) {
+					t.Errorf("O erro deveria apontar o ponto existente (%v), apontou %v", existente, intervaloErr.Anterior)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("O ponto fora do intervalo deveria ser gravado: %v", err)
+			}
+		})
+	}
+
+	var total int64
+	if err := tenant.Escopo(db, usuario.EmpresaID).Model(&model.RegistroPonto{}).Where("usuario_id = ?", usuario.ID).Count(&total).Error; err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Esperava 3 pontos gravados, encontrou %d", total)
+	}
+}
+
+func TestSavePonto_SemIntervaloMinimoAceitaPontosSeguidos(t *testing.T) {
+	db, usuario := prepararBanco(t)
+	repo := NewPontoRepository(db)
+	agora := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	for _, ts := range []time.Time{agora, agora.Add(time.Second)} {
+		p := &model.RegistroPonto{Timestamp: ts, Tipo: "ENTRADA", UsuarioID: usuario.ID, EmpresaID: usuario.EmpresaID}
+		if err := repo.SavePonto(p, 0); err != nil {
+			t.Fatalf("Sem intervalo mínimo o ponto deveria ser gravado: %v", err)
+		}
+	}
+}
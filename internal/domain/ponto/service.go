@@ -1,78 +1,343 @@
 package ponto
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/dispositivo"
 	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/localtrabalho"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/risco"
 	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
 	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/armazenamento"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
 	"github.com/umahmood/haversine"
 	"time"
 )
 
+var (
+	ErrBatidaRemotaBloqueada    = errors.New("batidas fora dos locais de trabalho não são permitidas para este funcionário")
+	ErrJustificativaObrigatoria = errors.New("batidas fora dos locais de trabalho exigem uma justificativa, que será revisada pelo gestor")
+	ErrBatidaNoFuturo           = errors.New("o horário da batida offline é posterior ao envio")
+	ErrBatidaOfflineAntiga      = errors.New("batidas offline só são aceitas até 7 dias depois de feitas")
+)
+
+// IntervaloMinimoError recusa uma batida próxima demais de outra do mesmo funcionário.
+type IntervaloMinimoError struct {
+	// Anterior é o horário da batida já registrada.
+	Anterior        time.Time
+	IntervaloMinimo time.Duration
+}
+
+func (e *IntervaloMinimoError) Error() string {
+	return fmt.Sprintf("batida recusada: já existe uma batida às %s e o intervalo mínimo entre batidas é de %d segundos",
+		e.Anterior.Format("15:04:05"), int(e.IntervaloMinimo.Seconds()))
+}
+
+// LiberadaEm é a partir de quando uma nova batida ao vivo é aceita.
+func (e *IntervaloMinimoError) LiberadaEm() time.Time {
+	return e.Anterior.Add(e.IntervaloMinimo)
+}
+
+// IdadeMaximaOffline é por quanto tempo uma batida feita sem conexão ainda pode ser sincronizada.
+const IdadeMaximaOffline = 7 * 24 * time.Hour
+
+// IdadeSemRevisaoOffline é até quanto tempo depois de feita uma batida offline entra direto no
+// banco de horas; sincronizada mais tarde, ela aguarda a revisão de um gestor.
+const IdadeSemRevisaoOffline = 12 * time.Hour
+
+// Offline descreve uma batida feita sem conexão e enviada depois pelo aplicativo.
+type Offline struct {
+	// Chave é o UUID gerado pelo aplicativo para a batida.
+	Chave string
+	// Momento é o horário da batida no relógio do aparelho.
+	Momento time.Time
+	// RecebidoEm é quando o servidor recebeu a batida.
+	RecebidoEm time.Time
+	// DesvioRelogio é quanto o relógio do aparelho estava atrás do servidor (negativo se adiantado),
+	// medido pela hora de envio que o aplicativo informa. O aplicativo pode zerá-lo à vontade, então
+	// um desvio grande pesa contra a batida, mas um desvio pequeno não a torna confiável.
+	DesvioRelogio time.Duration
+	// DiaFechado indica que o saldo do dia da batida já foi somado ao banco de horas.
+	DiaFechado bool
+}
+
+// motivoRevisao diz por que a batida offline não pode entrar direto no banco de horas, ou "" se
+// pode. O horário dela vem do aparelho: fechar de novo um dia, aceitar uma batida antiga ou de um
+// relógio fora da hora depende de um gestor.
+func (o *Offline) motivoRevisao() string {
+	switch {
+	case o.DiaFechado:
+		return model.MotivoRevisaoDiaFechado
+	case o.RecebidoEm.Sub(o.Momento) > IdadeSemRevisaoOffline:
+		return model.MotivoRevisaoSincronizacaoTardia
+	case o.DesvioRelogio > risco.DesvioRelogioTolerado || o.DesvioRelogio < -risco.DesvioRelogioTolerado:
+		return model.MotivoRevisaoRelogioDivergente
+	}
+	return ""
+}
+
+// Batida é o que o aparelho envia ao bater o ponto.
+type Batida struct {
+	Latitude  float64
+	Longitude float64
+	// PrecisaoMetros é o raio de precisão da localização informado pelo aparelho, se houver.
+	PrecisaoMetros *float64
+	// Justificativa só é exigida quando a batida é remota e a regra do funcionário é REVISAR.
+	Justificativa string
+	// Quiosque é o terminal que atestou a presença; com ele, as coordenadas e a precisão enviadas
+	// são ignoradas e valem a posição e o local do terminal.
+	Quiosque *model.Quiosque
+	// Offline é preenchido nas batidas sincronizadas depois de feitas sem conexão.
+	Offline *Offline
+	// Foto é a selfie opcional enviada pelo aplicativo.
+	Foto *Foto
+	// Dispositivo é o identificador do aparelho enviado pelo aplicativo, e DispositivoNome o nome
+	// que o funcionário vê na lista dos seus aparelhos.
+	Dispositivo     string
+	DispositivoNome string
+}
+
 type PontoService interface {
-	BaterPonto(usuarioID uint, empresaID uint, latitude, longitude float64) (*model.RegistroPonto, error)
-	GetPontosDoDia(usuarioID uint, dia time.Time) ([]model.RegistroPonto, error)
+	BaterPonto(usuarioID uint, empresaID uint, batida Batida) (*model.RegistroPonto, error)
+	// GetPontosDoDia devolve os pontos do dia, no fuso da empresa, em que cai o instante dia.
+	GetPontosDoDia(usuarioID uint, empresaID uint, dia time.Time) ([]model.RegistroPonto, error)
 }
 
 type pontoService struct {
 	pontoRepo   RegistroPontoRepository
 	empresaRepo empresa.EmpresaRepository
 	userRepo    usuario.UsuarioRepository
+	politicas   politica.Verificador
+	locais      localtrabalho.Resolvedor
+	riscos      risco.Avaliador
+	arquivos    armazenamento.Armazenamento
+	aparelhos   dispositivo.Autorizador
 }
 
 func NewPontoService(
 	pontoRepo RegistroPontoRepository,
 	userRepo usuario.UsuarioRepository,
 	empresaRepo empresa.EmpresaRepository,
+	politicas politica.Verificador,
+	locais localtrabalho.Resolvedor,
+	riscos risco.Avaliador,
+	arquivos armazenamento.Armazenamento,
+	aparelhos dispositivo.Autorizador,
 ) PontoService {
 	return &pontoService{
 		pontoRepo:   pontoRepo,
 		userRepo:    userRepo,
 		empresaRepo: empresaRepo,
+		politicas:   politicas,
+		locais:      locais,
+		riscos:      riscos,
+		arquivos:    arquivos,
+		aparelhos:   aparelhos,
 	}
 }
 
-func (s *pontoService) BaterPonto(usuarioID uint, empresaID uint, latitude, longitude float64) (*model.RegistroPonto, error) {
-	_, err := s.userRepo.FindByID(usuarioID, empresaID)
+func (s *pontoService) BaterPonto(usuarioID uint, empresaID uint, batida Batida) (*model.RegistroPonto, error) {
+	latitude, longitude := batida.Latitude, batida.Longitude
+	usuari, err := s.userRepo.FindByID(usuarioID, empresaID)
 	if err != nil {
 		return nil, err
 	}
-
 	dadoEmpresa, err := s.empresaRepo.FindByID(empresaID)
 	if err != nil {
 		return nil, err
 	}
+	// A exigência de selfie vale para o aplicativo: no quiosque a presença é atestada pelo terminal.
+	// As batidas offline chegam sem foto e, se a empresa a exige, aguardam a revisão de um gestor.
+	var tipoFoto string
+	semFotoExigida := false
+	if batida.Foto != nil {
+		if tipoFoto, err = TipoDaFoto(batida.Foto.Conteudo); err != nil {
+			return nil, err
+		}
+	} else if dadoEmpresa.ExigirFotoBatida && batida.Quiosque == nil {
+		if batida.Offline == nil {
+			return nil, ErrFotoObrigatoria
+		}
+		semFotoExigida = true
+	}
+	// O quiosque é ele mesmo o aparelho da batida. As batidas offline também passam pela
+	// verificação: o aparelho já precisava estar autorizado quando elas foram feitas.
+	var aparelho *model.Dispositivo
+	if batida.Quiosque == nil {
+		aparelho, err = s.aparelhos.Autorizar(usuarioID, empresaID, batida.Dispositivo, batida.DispositivoNome, dadoEmpresa.ExigirDispositivoAutorizado)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	pontoSede := haversine.Coord{Lat: dadoEmpresa.SedeLatitude, Lon: dadoEmpresa.SedeLongitude}
-	pontoBatida := haversine.Coord{Lat: latitude, Lon: longitude}
+	// Num quiosque a presença já foi atestada pelo terminal, e o ponto fica no local dele. Fora
+	// disso, com locais de trabalho configurados, a batida é presencial dentro de um deles; sem
+	// nenhum, vale o círculo da sede da empresa.
+	var resolucao localtrabalho.Resolucao
+	if batida.Quiosque != nil {
+		latitude, longitude = batida.Quiosque.Latitude, batida.Quiosque.Longitude
+		resolucao = localtrabalho.Resolucao{Configurado: true, Local: &batida.Quiosque.LocalTrabalho}
+	} else {
+		resolucao, err = s.locais.Resolver(usuari, latitude, longitude)
+		if err != nil {
+			return nil, err
+		}
+	}
+	distanciaEmMetros := resolucao.DistanciaMetros
+	presencial := resolucao.Local != nil
+	if !resolucao.Configurado {
+		pontoSede := haversine.Coord{Lat: dadoEmpresa.SedeLatitude, Lon: dadoEmpresa.SedeLongitude}
+		pontoBatida := haversine.Coord{Lat: latitude, Lon: longitude}
 
-	km, _ := haversine.Distance(pontoSede, pontoBatida)
-	distanciaEmMetros := km * 1000
+		_, km := haversine.Distance(pontoSede, pontoBatida)
+		distanciaEmMetros = km * 1000
+		presencial = distanciaEmMetros <= dadoEmpresa.RaioGeofenceMetros
+	}
 
 	var tipoBatida string
 
-	if distanciaEmMetros > dadoEmpresa.RaioGeofenceMetros {
-		tipoBatida = "Remoto"
-	} else {
+	if presencial {
 		tipoBatida = "Presencial"
+	} else {
+		tipoBatida = "Remoto"
+	}
+
+	// A hora local da batida segue o fuso do local de trabalho, quando ele tem um, ou o da empresa.
+	nomeFuso := dadoEmpresa.FusoHorario
+	if resolucao.Local != nil && resolucao.Local.FusoHorario != nil {
+		nomeFuso = *resolucao.Local.FusoHorario
+	}
+	fusoBatida, err := fuso.Carregar(nomeFuso)
+	if err != nil {
+		return nil, err
 	}
 
 	registroPonto := &model.RegistroPonto{
-		UsuarioID: usuarioID,
-		Latitude:  latitude,
-		Longitude: longitude,
-		Timestamp: time.Now(),
-		EmpresaID: empresaID,
-		Tipo:      tipoBatida,
+		UsuarioID:   usuarioID,
+		Latitude:    latitude,
+		Longitude:   longitude,
+		Timestamp:   time.Now().UTC(),
+		FusoHorario: fusoBatida.String(),
+		EmpresaID:   empresaID,
+		Tipo:        tipoBatida,
+	}
+	if aparelho != nil {
+		registroPonto.DispositivoID = &aparelho.ID
+	}
+	var desvioRelogio time.Duration
+	if offline := batida.Offline; offline != nil {
+		// Uma pequena folga no futuro cobre relógios levemente adiantados; o desvio em si vira
+		// indício de risco.
+		if offline.Momento.After(offline.RecebidoEm.Add(risco.DesvioRelogioTolerado)) {
+			return nil, ErrBatidaNoFuturo
+		}
+		if offline.RecebidoEm.Sub(offline.Momento) > IdadeMaximaOffline {
+			return nil, ErrBatidaOfflineAntiga
+		}
+		chave, recebidoEm, desvio := offline.Chave, offline.RecebidoEm, int(offline.DesvioRelogio.Seconds())
+		registroPonto.Timestamp = offline.Momento.UTC()
+		registroPonto.ChaveIdempotencia = &chave
+		registroPonto.SincronizadoEm = &recebidoEm
+		registroPonto.DesvioRelogioSegundos = &desvio
+		desvioRelogio = offline.DesvioRelogio
+	}
+	registroPonto.Justificativa = strings.TrimSpace(batida.Justificativa)
+	if !presencial {
+		switch usuari.RegraBatidaRemota() {
+		case model.BatidaRemotaBloquear:
+			return nil, ErrBatidaRemotaBloqueada
+		case model.BatidaRemotaRevisar:
+			if registroPonto.Justificativa == "" {
+				return nil, ErrJustificativaObrigatoria
+			}
+			registroPonto.StatusRevisao = model.RevisaoPendente
+			registroPonto.MotivoRevisao = model.MotivoRevisaoRemota
+		}
+	}
+	if batida.Offline != nil && registroPonto.StatusRevisao == "" {
+		motivo := batida.Offline.motivoRevisao()
+		if motivo == "" && semFotoExigida {
+			motivo = model.MotivoRevisaoSemFoto
+		}
+		if motivo != "" {
+			registroPonto.StatusRevisao = model.RevisaoPendente
+			registroPonto.MotivoRevisao = motivo
+		}
+	}
+
+	// A localização de um quiosque não vem do aparelho do funcionário e não passa pela análise de risco.
+	var avaliacao risco.Avaliacao
+	if batida.Quiosque == nil {
+		avaliacao, err = s.riscos.Avaliar(usuari, latitude, longitude, batida.PrecisaoMetros, registroPonto.Timestamp, desvioRelogio)
+		if err != nil {
+			return nil, err
+		}
+		if avaliacao.Bloquear {
+			return nil, risco.ErrRiscoAlto
+		}
+		registroPonto.PrecisaoMetros = batida.PrecisaoMetros
+		registroPonto.RiscoPontuacao = avaliacao.Pontuacao
+		registroPonto.RiscoSinais = avaliacao.SinaisJSON()
 	}
 
-	err = s.pontoRepo.SavePonto(registroPonto)
+	recurso := politica.Atributos{"tipo": tipoBatida, "distancia_metros": distanciaEmMetros, "risco": avaliacao.Pontuacao}
+	if resolucao.Local != nil {
+		registroPonto.LocalTrabalhoID = &resolucao.Local.ID
+		recurso["local_trabalho_id"] = resolucao.Local.ID
+	}
+	if batida.Quiosque != nil {
+		registroPonto.QuiosqueID = &batida.Quiosque.ID
+		recurso["quiosque_id"] = batida.Quiosque.ID
+	}
+
+	err = s.politicas.Exigir(empresaID, politica.Requisicao{
+		Acao:    politica.AcaoPontoBater,
+		Sujeito: politica.AtributosUsuario(usuari),
+		Recurso: recurso,
+		Momento: registroPonto.Timestamp,
+		Fuso:    fusoBatida,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A foto é gravada só depois de todas as verificações, e apagada se o ponto não for gravado.
+	if batida.Foto != nil {
+		chave, err := chaveDaFoto(empresaID, usuarioID, registroPonto.Timestamp, tipoFoto)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.arquivos.Gravar(chave, batida.Foto.Conteudo, tipoFoto); err != nil {
+			return nil, err
+		}
+		registroPonto.FotoChave = &chave
+		registroPonto.FotoTipo = tipoFoto
+	}
+
+	intervaloMinimo := time.Duration(dadoEmpresa.IntervaloMinimoBatidaSegundos) * time.Second
+	err = s.pontoRepo.SavePonto(registroPonto, intervaloMinimo)
 	if err != nil {
+		if registroPonto.FotoChave != nil {
+			armazenamento.ApagarTodos(s.arquivos, []string{*registroPonto.FotoChave})
+		}
+		var intervalo *IntervaloMinimoError
+		if errors.As(err, &intervalo) {
+			intervalo.Anterior = intervalo.Anterior.In(fusoBatida)
+		}
 		return nil, err
 	}
 
 	return registroPonto, nil
 }
 
-func (s *pontoService) GetPontosDoDia(usuarioID uint, dia time.Time) ([]model.RegistroPonto, error) {
-	return s.pontoRepo.FindPontosByUserIDAndDate(usuarioID, dia)
+func (s *pontoService) GetPontosDoDia(usuarioID uint, empresaID uint, dia time.Time) ([]model.RegistroPonto, error) {
+	fusoEmpresa, err := empresa.FusoDaEmpresa(s.empresaRepo, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	return s.pontoRepo.FindPontosByUserIDAndDate(usuarioID, empresaID, dia.In(fusoEmpresa))
 }
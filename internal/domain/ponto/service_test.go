@@ -0,0 +1,336 @@
+package ponto
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/localtrabalho"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/risco"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+)
+
+// memoriaPontoRepository guarda os pontos em ordem crescente de Timestamp e ID e aplica só o
+// que a consulta precisa: usuários, ordem, posição e limite.
+type memoriaPontoRepository struct {
+	RegistroPontoRepository
+	pontos []model.RegistroPonto
+	buscas []FiltroPontos
+}
+
+func (m *memoriaPontoRepository) Buscar(empresaID uint, filtro FiltroPontos) ([]model.RegistroPonto, error) {
+	m.buscas = append(m.buscas, filtro)
+	permitidos := map[uint]bool{}
+	for _, id := range filtro.UsuarioIDs {
+		permitidos[id] = true
+	}
+	ordenados := append([]model.RegistroPonto(nil), m.pontos...)
+	if filtro.Decrescente {
+		for i, j := 0, len(ordenados)-1; i < j; i, j = i+1, j-1 {
+			ordenados[i], ordenados[j] = ordenados[j], ordenados[i]
+		}
+	}
+	encontrados := []model.RegistroPonto{}
+	for _, p := range ordenados {
+		if filtro.UsuarioIDs != nil && !permitidos[p.UsuarioID] {
+			continue
+		}
+		if filtro.Depois != nil {
+			antes := p.Timestamp.Before(filtro.Depois.Timestamp) || (p.Timestamp.Equal(filtro.Depois.Timestamp) && p.ID <= filtro.Depois.ID)
+			depois := p.Timestamp.After(filtro.Depois.Timestamp) || (p.Timestamp.Equal(filtro.Depois.Timestamp) && p.ID >= filtro.Depois.ID)
+			if (!filtro.Decrescente && antes) || (filtro.Decrescente && depois) {
+				continue
+			}
+		}
+		encontrados = append(encontrados, p)
+		if len(encontrados) == filtro.Limite {
+			break
+		}
+	}
+	return encontrados, nil
+}
+
+// mockUsuarioService tem os funcionários 7, 8 e 9, no departamento 3 só o 7 e o 8, e põe na
+// equipe do gestor só o 7.
+type mockUsuarioService struct {
+	usuario.UsuarioService
+}
+
+func (m *mockUsuarioService) Buscar(empresaID uint, filtro usuario.FiltroUsuarios) ([]model.Usuario, error) {
+	if filtro.DepartamentoID != nil {
+		return []model.Usuario{{ID: 7}, {ID: 8}}, nil
+	}
+	return []model.Usuario{{ID: 7}, {ID: 8}, {ID: 9}}, nil
+}
+
+func (m *mockUsuarioService) AlvoNoEscopo(requisitante *model.Usuario, permissao string, alvoID uint) (bool, error) {
+	return alvoID == 7, nil
+}
+
+func (m *mockUsuarioService) FiltrarPorEscopo(requisitante *model.Usuario, permissao string, usuarios []model.Usuario) ([]model.Usuario, error) {
+	if _, concedida := requisitante.Cargo.EscopoPermissao(permissao); !concedida {
+		return []model.Usuario{}, nil
+	}
+	var visiveis []model.Usuario
+	for _, u := range usuarios {
+		if u.ID == 7 {
+			visiveis = append(visiveis, u)
+		}
+	}
+	return visiveis, nil
+}
+
+func comEscopo(id uint, escopo string) *model.Usuario {
+	return &model.Usuario{ID: id, EmpresaID: 1, Cargo: model.Cargo{
+		Permissoes: []model.Permissao{{ID: 1, Nome: permissions.VER_SALDO_FUNCIONARIOS}},
+		Escopos:    []model.CargoPermissao{{PermissaoID: 1, Escopo: escopo}},
+	}}
+}
+
+func novaConsulta() (*memoriaPontoRepository, ConsultaService) {
+	repo := &memoriaPontoRepository{}
+	inicio := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		// Os dois últimos pontos empatam no horário: o ID desempata.
+		momento := inicio.Add(time.Duration(min(i, 3)) * time.Hour)
+		repo.pontos = append(repo.pontos, model.RegistroPonto{ID: uint(i + 1), UsuarioID: 7, EmpresaID: 1, Timestamp: momento})
+	}
+	return repo, NewConsultaService(repo, &mockUsuarioService{})
+}
+
+func TestConsultar_PaginaComCursor(t *testing.T) {
+	for _, ordem := range []string{"asc", "desc"} {
+		_, service := novaConsulta()
+		var ids []uint
+		cursor := ""
+		for paginas := 0; ; paginas++ {
+			if paginas > 5 {
+				t.Fatalf("Ordem %s: a paginação não terminou", ordem)
+			}
+			pagina, err := service.Consultar(1, nil, FiltroConsulta{Ordem: ordem, Limite: 2, Cursor: cursor})
+			if err != nil {
+				t.Fatalf("Ordem %s: erro inesperado: %v", ordem, err)
+			}
+			for _, p := range pagina.Pontos {
+				ids = append(ids, p.ID)
+			}
+			if pagina.ProximoCursor == "" {
+				break
+			}
+			cursor = pagina.ProximoCursor
+		}
+		esperado := []uint{1, 2, 3, 4, 5}
+		if ordem == "desc" {
+			esperado = []uint{5, 4, 3, 2, 1}
+		}
+		if len(ids) != len(esperado) {
+			t.Fatalf("Ordem %s: esperava %v, recebeu %v", ordem, esperado, ids)
+		}
+		for i := range esperado {
+			if ids[i] != esperado[i] {
+				t.Fatalf("Ordem %s: esperava %v, recebeu %v", ordem, esperado, ids)
+			}
+		}
+	}
+}
+
+func TestConsultar_FiltrosInvalidos(t *testing.T) {
+	_, service := novaConsulta()
+	cursorCrescente := escreverCursor(Posicao{Timestamp: time.Now(), ID: 3}, false)
+	agora := time.Now()
+	antes := agora.Add(-time.Hour)
+	casos := []struct {
+		filtro   FiltroConsulta
+		esperado error
+	}{
+		{FiltroConsulta{Tipo: "hibrido"}, ErrTipoInvalido},
+		{FiltroConsulta{Ordem: "aleatoria"}, ErrOrdemInvalida},
+		{FiltroConsulta{Origem: "manual"}, ErrOrigemInvalida},
+		{FiltroConsulta{Cursor: "???"}, ErrCursorInvalido},
+		// Um cursor da ordem crescente não vale na decrescente.
+		{FiltroConsulta{Cursor: cursorCrescente}, ErrCursorInvalido},
+		{FiltroConsulta{De: &agora, Ate: &antes}, ErrPeriodoInvalido},
+	}
+	for _, caso := range casos {
+		if _, err := service.Consultar(1, nil, caso.filtro); !errors.Is(err, caso.esperado) {
+			t.Errorf("Filtro %+v: esperava %v, recebeu %v", caso.filtro, caso.esperado, err)
+		}
+	}
+}
+
+func TestConsultar_Origem(t *testing.T) {
+	repo, service := novaConsulta()
+	if _, err := service.Consultar(1, nil, FiltroConsulta{Origem: "Quiosque"}); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if origem := repo.buscas[0].Origem; origem != OrigemQuiosque {
+		t.Errorf("Esperava a origem %q na busca, recebeu %q", OrigemQuiosque, origem)
+	}
+}
+
+func TestConsultar_Escopo(t *testing.T) {
+	departamento := uint(3)
+	fora := uint(9)
+	casos := []struct {
+		nome         string
+		requisitante *model.Usuario
+		filtro       FiltroConsulta
+		esperado     []uint
+	}{
+		{"chave de API vê a empresa toda", nil, FiltroConsulta{}, nil},
+		{"escopo EMPRESA vê a empresa toda", comEscopo(1, model.EscopoEmpresa), FiltroConsulta{}, nil},
+		{"escopo EMPRESA com departamento", comEscopo(1, model.EscopoEmpresa), FiltroConsulta{Usuarios: usuario.FiltroUsuarios{DepartamentoID: &departamento}}, []uint{7, 8}},
+		{"gestor vê a equipe e a si mesmo", comEscopo(8, model.EscopoEquipe), FiltroConsulta{}, []uint{7, 8}},
+		{"sem a permissão, só os próprios pontos", &model.Usuario{ID: 9, EmpresaID: 1}, FiltroConsulta{}, []uint{9}},
+		{"sem a permissão e fora do departamento", &model.Usuario{ID: 9, EmpresaID: 1}, FiltroConsulta{Usuarios: usuario.FiltroUsuarios{DepartamentoID: &departamento}}, []uint{}},
+	}
+	for _, caso := range casos {
+		repo, service := novaConsulta()
+		if _, err := service.Consultar(1, caso.requisitante, caso.filtro); err != nil {
+			t.Fatalf("%s: erro inesperado: %v", caso.nome, err)
+		}
+		recebido := repo.buscas[0].UsuarioIDs
+		if (caso.esperado == nil) != (recebido == nil) || len(recebido) != len(caso.esperado) {
+			t.Errorf("%s: esperava %v, recebeu %v", caso.nome, caso.esperado, recebido)
+			continue
+		}
+		for i := range caso.esperado {
+			if recebido[i] != caso.esperado[i] {
+				t.Errorf("%s: esperava %v, recebeu %v", caso.nome, caso.esperado, recebido)
+			}
+		}
+	}
+
+	_, service := novaConsulta()
+	if _, err := service.Consultar(1, comEscopo(8, model.EscopoEquipe), FiltroConsulta{UsuarioID: &fora}); !errors.Is(err, ErrConsultaForaDoEscopo) {
+		t.Errorf("Esperava ErrConsultaForaDoEscopo para um funcionário fora da equipe, recebeu %v", err)
+	}
+}
+
+func TestOffline_MotivoRevisao(t *testing.T) {
+	recebidoEm := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	casos := []struct {
+		nome     string
+		offline  Offline
+		esperado string
+	}{
+		{"recente e com o relógio certo", Offline{Momento: recebidoEm.Add(-time.Hour)}, ""},
+		{"dia já fechado", Offline{Momento: recebidoEm.Add(-time.Hour), DiaFechado: true}, model.MotivoRevisaoDiaFechado},
+		{"sincronizada tarde", Offline{Momento: recebidoEm.Add(-IdadeSemRevisaoOffline - time.Minute)}, model.MotivoRevisaoSincronizacaoTardia},
+		{"relógio atrasado", Offline{Momento: recebidoEm.Add(-time.Hour), DesvioRelogio: 10 * time.Minute}, model.MotivoRevisaoRelogioDivergente},
+		{"relógio adiantado", Offline{Momento: recebidoEm.Add(-time.Hour), DesvioRelogio: -10 * time.Minute}, model.MotivoRevisaoRelogioDivergente},
+	}
+	for _, caso := range casos {
+		caso.offline.RecebidoEm = recebidoEm
+		if motivo := caso.offline.motivoRevisao(); motivo != caso.esperado {
+			t.Errorf("%s: esperava %q, recebeu %q", caso.nome, caso.esperado, motivo)
+		}
+	}
+}
+
+// Dependências da batida: um funcionário dentro do local 1, sem risco e sem políticas.
+type mockUsuarioRepository struct{ usuario.UsuarioRepository }
+
+func (m *mockUsuarioRepository) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
+	return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+}
+
+type mockEmpresaRepository struct {
+	empresa.EmpresaRepository
+	empresa model.Empresa
+}
+
+func (m *mockEmpresaRepository) FindByID(id uint) (*model.Empresa, error) {
+	return &m.empresa, nil
+}
+
+type mockResolvedor struct{}
+
+func (mockResolvedor) Resolver(usuario *model.Usuario, latitude, longitude float64) (localtrabalho.Resolucao, error) {
+	return localtrabalho.Resolucao{Configurado: true, Local: &model.LocalTrabalho{ID: 1}}, nil
+}
+
+type mockAvaliador struct{}
+
+func (mockAvaliador) Avaliar(usuario *model.Usuario, latitude, longitude float64, precisaoMetros *float64, momento time.Time, desvioRelogio time.Duration) (risco.Avaliacao, error) {
+	return risco.Avaliacao{}, nil
+}
+
+type mockVerificador struct{}
+
+func (mockVerificador) Exigir(empresaID uint, req politica.Requisicao) error { return nil }
+
+type mockAutorizador struct{}
+
+func (mockAutorizador) Autorizar(usuarioID uint, empresaID uint, identificador string, nome string, exigir bool) (*model.Dispositivo, error) {
+	return nil, nil
+}
+
+type gravadorPontoRepository struct {
+	RegistroPontoRepository
+	gravados []*model.RegistroPonto
+}
+
+func (m *gravadorPontoRepository) SavePonto(ponto *model.RegistroPonto, intervaloMinimo time.Duration) error {
+	m.gravados = append(m.gravados, ponto)
+	return nil
+}
+
+func TestBaterPonto_FotoExigida(t *testing.T) {
+	repo := &gravadorPontoRepository{}
+	empresas := &mockEmpresaRepository{empresa: model.Empresa{ID: 1, ExigirFotoBatida: true, FusoHorario: "America/Sao_Paulo"}}
+	service := NewPontoService(repo, &mockUsuarioRepository{}, empresas, mockVerificador{}, mockResolvedor{}, mockAvaliador{}, nil, mockAutorizador{})
+
+	if _, err := service.BaterPonto(7, 1, Batida{}); !errors.Is(err, ErrFotoObrigatoria) {
+		t.Fatalf("A batida ao vivo sem foto deveria ser recusada, recebeu %v", err)
+	}
+
+	// Sincronizar com o horário de agora não dispensa a selfie: a batida aguarda revisão.
+	agora := time.Now()
+	registrado, err := service.BaterPonto(7, 1, Batida{Offline: &Offline{Chave: "0f8fad5b-d9cb-469f-a165-70867728950e", Momento: agora, RecebidoEm: agora}})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if registrado.StatusRevisao != model.RevisaoPendente || registrado.MotivoRevisao != model.MotivoRevisaoSemFoto {
+		t.Errorf("A batida offline sem foto deveria ficar pendente por SEM_FOTO, ficou %q/%q", registrado.StatusRevisao, registrado.MotivoRevisao)
+	}
+	if len(repo.gravados) != 1 {
+		t.Errorf("Esperava 1 ponto gravado, recebeu %d", len(repo.gravados))
+	}
+}
+
+// resolvedorSemLocais simula uma empresa sem locais de trabalho, que cai na cerca da sede.
+type resolvedorSemLocais struct{}
+
+func (resolvedorSemLocais) Resolver(usuario *model.Usuario, latitude, longitude float64) (localtrabalho.Resolucao, error) {
+	return localtrabalho.Resolucao{}, nil
+}
+
+func TestBaterPonto_CercaDaSedeEmQuilometros(t *testing.T) {
+	empresas := &mockEmpresaRepository{empresa: model.Empresa{ID: 1, FusoHorario: "America/Sao_Paulo",
+		SedeLatitude: -23.5505, SedeLongitude: -46.6333, RaioGeofenceMetros: 600}}
+
+	// 0,0072° de latitude são uns 800 m, ou 0,5 milha: fora de um raio de 600 m.
+	casos := []struct {
+		latitude float64
+		tipo     string
+	}{
+		{-23.5505 + 0.0036, "Presencial"},
+		{-23.5505 + 0.0072, "Remoto"},
+	}
+	for _, caso := range casos {
+		repo := &gravadorPontoRepository{}
+		service := NewPontoService(repo, &mockUsuarioRepository{}, empresas, mockVerificador{}, resolvedorSemLocais{}, mockAvaliador{}, nil, mockAutorizador{})
+		registrado, err := service.BaterPonto(7, 1, Batida{Latitude: caso.latitude, Longitude: -46.6333})
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if registrado.Tipo != caso.tipo {
+			t.Errorf("Latitude %v: esperava %s, recebeu %s", caso.latitude, caso.tipo, registrado.Tipo)
+		}
+	}
+}
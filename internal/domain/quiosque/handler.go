@@ -0,0 +1,222 @@
+package quiosque
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Chaves do contexto preenchidas quando a requisição vem de um quiosque.
+const (
+	ContextoQuiosqueID = "quiosqueID"
+	contextoQuiosque   = "quiosque"
+)
+
+// Middleware autentica o terminal pela credencial ("Authorization: Quiosque ..."). O quiosque age
+// em nome da empresa, mas só alcança as rotas de quiosque.
+func Middleware(service QuiosqueService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credencial, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Quiosque ")
+		if !ok || credencial == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Credencial do quiosque não fornecida"})
+			return
+		}
+		quiosque, err := service.Validar(credencial)
+		if err != nil {
+			if errors.Is(err, ErrCredencialInvalida) || errors.Is(err, ErrQuiosqueRevogado) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Credencial do quiosque inválida ou revogada"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Falha ao validar o quiosque"})
+			return
+		}
+
+		c.Set("empresaID", fmt.Sprintf("%d", quiosque.EmpresaID))
+		c.Set(ContextoQuiosqueID, fmt.Sprintf("%d", quiosque.ID))
+		c.Set(contextoQuiosque, quiosque)
+		c.Next()
+	}
+}
+
+type Handler struct {
+	service   QuiosqueService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s QuiosqueService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	criadorID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem cadastrar quiosques."})
+		return
+	}
+
+	var req struct {
+		Nome            string   `json:"nome" binding:"required"`
+		LocalTrabalhoID uint     `json:"local_trabalho_id" binding:"required"`
+		Latitude        *float64 `json:"latitude" binding:"required"`
+		Longitude       *float64 `json:"longitude" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. 'nome', 'local_trabalho_id', 'latitude' e 'longitude' são obrigatórios."})
+		return
+	}
+
+	quiosque := &model.Quiosque{
+		EmpresaID:       empresaID,
+		Nome:            strings.TrimSpace(req.Nome),
+		LocalTrabalhoID: req.LocalTrabalhoID,
+		Latitude:        *req.Latitude,
+		Longitude:       *req.Longitude,
+		CriadoPorID:     criadorID,
+	}
+	credencial, err := h.service.Criar(quiosque)
+	if err != nil {
+		if errors.Is(err, ErrLocalInvalido) || errors.Is(err, ErrForaDoLocal) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao cadastrar o quiosque."})
+		return
+	}
+
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoQuiosqueCriado, Entidade: "quiosques", EntidadeID: quiosque.ID, Depois: quiosque})
+
+	// A credencial só aparece nesta resposta; depois disso apenas o hash fica guardado.
+	c.JSON(http.StatusCreated, gin.H{"quiosque": quiosque, "credencial": credencial})
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	quiosques, err := h.service.Listar(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao listar os quiosques."})
+		return
+	}
+	c.JSON(http.StatusOK, quiosques)
+}
+
+func (h *Handler) Revogar(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do quiosque inválido."})
+		return
+	}
+
+	antes, _ := h.service.FindByID(id, empresaID)
+	if err := h.service.Revogar(id, empresaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Quiosque não encontrado ou já revogado."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao revogar o quiosque."})
+		return
+	}
+	depois, _ := h.service.FindByID(id, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoQuiosqueRevogado, Entidade: "quiosques", EntidadeID: id, Antes: antes, Depois: depois})
+	c.Status(http.StatusNoContent)
+}
+
+// GetCodigo devolve o código que o quiosque deve exibir agora; o terminal consulta de novo em ExpiraEm.
+func (h *Handler) GetCodigo(c *gin.Context) {
+	quiosque := c.MustGet(contextoQuiosque).(*model.Quiosque)
+	c.JSON(http.StatusOK, h.service.CodigoAtual(quiosque))
+}
+
+// BaterComPin registra o ponto de quem se identificou no quiosque com matrícula e PIN.
+func (h *Handler) BaterComPin(c *gin.Context) {
+	quiosque := c.MustGet(contextoQuiosque).(*model.Quiosque)
+	var req struct {
+		Matricula string `json:"matricula" binding:"required"`
+		Pin       string `json:"pin" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "'matricula' e 'pin' são obrigatórios."})
+		return
+	}
+
+	pontoRegistrado, err := h.service.BaterComPin(quiosque, req.Matricula, req.Pin)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, pontoRegistrado)
+}
+
+// BaterComCodigo registra o ponto do usuário autenticado que leu com o celular o QR code do quiosque.
+func (h *Handler) BaterComCodigo(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	usuarioID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem bater ponto."})
+		return
+	}
+	var req struct {
+		QRCode string `json:"qr_code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "'qr_code' é obrigatório: envie o conteúdo lido no quiosque."})
+		return
+	}
+
+	pontoRegistrado, err := h.service.BaterComCodigo(usuarioID, empresaID, req.QRCode)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, pontoRegistrado)
+}
+
+func responderErro(c *gin.Context, err error) {
+	var bloqueio *auth.BloqueioError
+	switch {
+	case errors.As(err, &bloqueio):
+		segundos := math.Ceil(time.Until(bloqueio.Ate).Seconds())
+		c.Header("Retry-After", strconv.Itoa(int(math.Max(segundos, 1))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPinIncorreto):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCodigoInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrQuiosqueRevogado), errors.Is(err, ErrLocalInvalido):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		ponto.ResponderErroBatida(c, err)
+	}
+}
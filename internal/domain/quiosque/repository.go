@@ -0,0 +1,66 @@
+package quiosque
+
+import (
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type QuiosqueRepository interface {
+	Create(quiosque *model.Quiosque) error
+	// FindByPrefixo procura em todas as empresas: é ela que descobre a empresa de uma credencial recebida.
+	FindByPrefixo(prefixo string) (*model.Quiosque, error)
+	FindByID(id uint, empresaID uint) (*model.Quiosque, error)
+	GetAllByEmpresaID(empresaID uint) ([]model.Quiosque, error)
+	Revogar(id uint, empresaID uint, momento time.Time) error
+	RegistrarUso(id uint, empresaID uint, momento time.Time) error
+}
+
+type quiosqueRepository struct {
+	Db *gorm.DB
+}
+
+func NewQuiosqueRepository(db *gorm.DB) QuiosqueRepository {
+	return &quiosqueRepository{Db: db}
+}
+
+func (r *quiosqueRepository) Create(quiosque *model.Quiosque) error {
+	return tenant.Escopo(r.Db, quiosque.EmpresaID).Create(quiosque).Error
+}
+
+func (r *quiosqueRepository) FindByPrefixo(prefixo string) (*model.Quiosque, error) {
+	var quiosque model.Quiosque
+	err := tenant.Plataforma(r.Db).Preload("LocalTrabalho").Where("prefixo = ?", prefixo).First(&quiosque).Error
+	return &quiosque, err
+}
+
+func (r *quiosqueRepository) FindByID(id uint, empresaID uint) (*model.Quiosque, error) {
+	var quiosque model.Quiosque
+	err := tenant.Escopo(r.Db, empresaID).Preload("LocalTrabalho").Where("id = ?", id).First(&quiosque).Error
+	return &quiosque, err
+}
+
+func (r *quiosqueRepository) GetAllByEmpresaID(empresaID uint) ([]model.Quiosque, error) {
+	var quiosques []model.Quiosque
+	err := tenant.Escopo(r.Db, empresaID).Order("id asc").Find(&quiosques).Error
+	return quiosques, err
+}
+
+func (r *quiosqueRepository) Revogar(id uint, empresaID uint, momento time.Time) error {
+	resultado := tenant.Escopo(r.Db, empresaID).Model(&model.Quiosque{}).
+		Where("id = ? AND revogado_em IS NULL", id).
+		Update("revogado_em", momento)
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *quiosqueRepository) RegistrarUso(id uint, empresaID uint, momento time.Time) error {
+	return tenant.Escopo(r.Db, empresaID).Model(&model.Quiosque{}).Where("id = ?", id).Update("ultimo_uso_em", momento).Error
+}
@@ -0,0 +1,294 @@
+// Package quiosque cuida dos terminais de ponto compartilhados, para quem não tem celular. O
+// funcionário bate o ponto no próprio quiosque com matrícula e PIN, ou lê com o celular o código
+// que o quiosque exibe, provando que está diante dele. Os dois caminhos terminam em
+// PontoService.BaterPonto, com o ponto gravado no local do quiosque.
+package quiosque
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/localtrabalho"
+	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/credencial"
+	"github.com/Loviiin/ponto-api-go/pkg/geofence"
+	"github.com/Loviiin/ponto-api-go/pkg/password"
+	"github.com/Loviiin/ponto-api-go/pkg/totp"
+	"gorm.io/gorm"
+)
+
+// As credenciais têm o formato "quiosque_<prefixo>_<segredo>", como as chaves de API. O segredo só
+// é mostrado uma vez, na criação, para ser configurado no terminal.
+const tipoCredencial = "quiosque"
+
+// prefixoQR identifica o conteúdo do QR code exibido: "PONTO-QUIOSQUE:<id>:<código>".
+const prefixoQR = "PONTO-QUIOSQUE:"
+
+var (
+	ErrCredencialInvalida = errors.New("credencial de quiosque inválida")
+	ErrQuiosqueRevogado   = errors.New("quiosque revogado")
+	ErrLocalInvalido      = errors.New("o local de trabalho informado não existe nesta empresa ou está desativado")
+	ErrForaDoLocal        = errors.New("a posição do quiosque deve ficar dentro do local de trabalho")
+	ErrPinIncorreto       = errors.New("matrícula ou PIN incorretos")
+	ErrCodigoInvalido     = errors.New("código do quiosque inválido ou expirado; leia o código exibido agora")
+)
+
+var agora = time.Now
+
+// Codigo é o que o quiosque exibe: o código numérico e o conteúdo do QR code.
+type Codigo struct {
+	Codigo   string    `json:"codigo"`
+	QRCode   string    `json:"qr_code"`
+	ExpiraEm time.Time `json:"expira_em"`
+}
+
+type QuiosqueService interface {
+	// Criar cadastra o terminal e devolve a credencial dele, que não é mostrada de novo.
+	Criar(quiosque *model.Quiosque) (string, error)
+	Listar(empresaID uint) ([]model.Quiosque, error)
+	FindByID(id uint, empresaID uint) (*model.Quiosque, error)
+	Revogar(id uint, empresaID uint) error
+	Validar(credencial string) (*model.Quiosque, error)
+	CodigoAtual(quiosque *model.Quiosque) Codigo
+	// BaterComPin bate o ponto do funcionário identificado por matrícula e PIN no quiosque.
+	BaterComPin(quiosque *model.Quiosque, matricula string, pin string) (*model.RegistroPonto, error)
+	// BaterComCodigo bate o ponto do funcionário que leu com o celular o QR code de um quiosque.
+	BaterComCodigo(usuarioID uint, empresaID uint, qrCode string) (*model.RegistroPonto, error)
+}
+
+type quiosqueService struct {
+	repo        QuiosqueRepository
+	locais      localtrabalho.LocalTrabalhoService
+	usuarioRepo usuario.UsuarioRepository
+	pontos      ponto.PontoService
+	limitador   *auth.Limitador
+	politica    auth.PoliticaBloqueio
+}
+
+// NewQuiosqueService usa o mesmo armazenamento e os mesmos limites do login para bloquear
+// tentativas repetidas de PIN e de código. Sem a espera progressiva, porém: várias pessoas usam o
+// terminal em sequência, e a espera causada por uma atrasaria a fila inteira.
+func NewQuiosqueService(
+	repo QuiosqueRepository,
+	locais localtrabalho.LocalTrabalhoService,
+	usuarioRepo usuario.UsuarioRepository,
+	pontos ponto.PontoService,
+	tentativas auth.TentativaLoginStore,
+	politica auth.PoliticaBloqueio,
+) QuiosqueService {
+	return &quiosqueService{
+		repo:        repo,
+		locais:      locais,
+		usuarioRepo: usuarioRepo,
+		pontos:      pontos,
+		limitador:   auth.NewLimitador(tentativas, semAtraso(politica)),
+		politica:    politica,
+	}
+}
+
+func (s *quiosqueService) Criar(quiosque *model.Quiosque) (string, error) {
+	local, err := s.locais.FindByID(quiosque.LocalTrabalhoID, quiosque.EmpresaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrLocalInvalido
+		}
+		return "", err
+	}
+	if !local.Ativo {
+		return "", ErrLocalInvalido
+	}
+	cerca, err := geofence.Parse(local.Geometria, local.RaioMetros)
+	if err != nil {
+		return "", err
+	}
+	if !cerca.Contem(geofence.Ponto{Lat: quiosque.Latitude, Lon: quiosque.Longitude}) {
+		return "", ErrForaDoLocal
+	}
+
+	texto, prefixo, hash, err := credencial.Gerar(tipoCredencial)
+	if err != nil {
+		return "", err
+	}
+	segredoCodigo := make([]byte, 20)
+	if _, err := rand.Read(segredoCodigo); err != nil {
+		return "", err
+	}
+
+	quiosque.Prefixo = prefixo
+	quiosque.Hash = hash
+	quiosque.SegredoCodigo = segredoCodigo
+	if err := s.repo.Create(quiosque); err != nil {
+		return "", err
+	}
+	return texto, nil
+}
+
+func (s *quiosqueService) Listar(empresaID uint) ([]model.Quiosque, error) {
+	return s.repo.GetAllByEmpresaID(empresaID)
+}
+
+func (s *quiosqueService) FindByID(id uint, empresaID uint) (*model.Quiosque, error) {
+	return s.repo.FindByID(id, empresaID)
+}
+
+func (s *quiosqueService) Revogar(id uint, empresaID uint) error {
+	return s.repo.Revogar(id, empresaID, agora())
+}
+
+// Validar confere a credencial recebida e devolve o quiosque com o seu local carregado.
+func (s *quiosqueService) Validar(texto string) (*model.Quiosque, error) {
+	prefixo, ok := credencial.Prefixo(tipoCredencial, texto)
+	if !ok {
+		return nil, ErrCredencialInvalida
+	}
+
+	quiosque, err := s.repo.FindByPrefixo(prefixo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCredencialInvalida
+		}
+		return nil, err
+	}
+	if !credencial.Confere(texto, quiosque.Hash) {
+		return nil, ErrCredencialInvalida
+	}
+	if quiosque.RevogadoEm != nil {
+		return nil, ErrQuiosqueRevogado
+	}
+
+	momento := agora()
+	if credencial.DeveRegistrarUso(quiosque.UltimoUsoEm, momento) {
+		if err := s.repo.RegistrarUso(quiosque.ID, quiosque.EmpresaID, momento); err != nil {
+			return nil, err
+		}
+		quiosque.UltimoUsoEm = &momento
+	}
+	return quiosque, nil
+}
+
+func (s *quiosqueService) CodigoAtual(quiosque *model.Quiosque) Codigo {
+	momento := agora()
+	codigo := totp.Codigo(quiosque.SegredoCodigo, momento, totp.Padrao)
+	return Codigo{
+		Codigo:   codigo,
+		QRCode:   fmt.Sprintf("%s%d:%s", prefixoQR, quiosque.ID, codigo),
+		ExpiraEm: totp.Expiracao(momento, totp.Padrao),
+	}
+}
+
+func (s *quiosqueService) BaterComPin(quiosque *model.Quiosque, matricula string, pin string) (*model.RegistroPonto, error) {
+	if err := s.pronto(quiosque); err != nil {
+		return nil, err
+	}
+	matricula = strings.TrimSpace(matricula)
+	// Além da matrícula, o terminal conta as falhas de todas as matrículas: quem tenta o mesmo PIN
+	// em matrícula após matrícula esbarra no limite do terminal, que fica travado para todos.
+	terminal := fmt.Sprintf("quiosque:%d", quiosque.ID)
+	chave := fmt.Sprintf("pin:%d:%s", quiosque.EmpresaID, matricula)
+	for _, c := range []string{terminal, chave} {
+		if err := s.verificarBloqueio(c); err != nil {
+			return nil, err
+		}
+	}
+
+	funcionario, err := s.usuarioRepo.FindByMatricula(matricula, quiosque.EmpresaID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	// Matrícula inexistente, sem PIN ou PIN errado dão a mesma resposta.
+	if err != nil || funcionario.PinHash == "" || !password.VerificaHashSenha(pin, funcionario.PinHash) {
+		if _, err := s.limitador.Falhou(terminal, "", s.politica.MaxTentativasIP, agora()); err != nil {
+			return nil, err
+		}
+		return nil, s.registrarFalha(chave, ErrPinIncorreto)
+	}
+	// Só a matrícula é liberada: um acerto com o próprio PIN não zera as falhas do terminal.
+	if err := s.limitador.Limpar(chave); err != nil {
+		return nil, err
+	}
+
+	return s.pontos.BaterPonto(funcionario.ID, quiosque.EmpresaID, ponto.Batida{Quiosque: quiosque})
+}
+
+func (s *quiosqueService) BaterComCodigo(usuarioID uint, empresaID uint, qrCode string) (*model.RegistroPonto, error) {
+	chave := fmt.Sprintf("codigo:%d:%d", empresaID, usuarioID)
+	if err := s.verificarBloqueio(chave); err != nil {
+		return nil, err
+	}
+
+	quiosqueID, codigo, ok := lerQRCode(qrCode)
+	if !ok {
+		return nil, s.registrarFalha(chave, ErrCodigoInvalido)
+	}
+	quiosque, err := s.repo.FindByID(quiosqueID, empresaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, s.registrarFalha(chave, ErrCodigoInvalido)
+		}
+		return nil, err
+	}
+	if err := s.pronto(quiosque); err != nil {
+		return nil, err
+	}
+	if !totp.Validar(quiosque.SegredoCodigo, codigo, agora(), totp.Padrao) {
+		return nil, s.registrarFalha(chave, ErrCodigoInvalido)
+	}
+	if err := s.limitador.Limpar(chave); err != nil {
+		return nil, err
+	}
+
+	return s.pontos.BaterPonto(usuarioID, empresaID, ponto.Batida{Quiosque: quiosque})
+}
+
+// pronto confere se o quiosque ainda pode registrar pontos.
+func (s *quiosqueService) pronto(quiosque *model.Quiosque) error {
+	if quiosque.RevogadoEm != nil {
+		return ErrQuiosqueRevogado
+	}
+	if !quiosque.LocalTrabalho.Ativo {
+		return ErrLocalInvalido
+	}
+	return nil
+}
+
+func lerQRCode(qrCode string) (uint, string, bool) {
+	restante, ok := strings.CutPrefix(strings.TrimSpace(qrCode), prefixoQR)
+	if !ok {
+		return 0, "", false
+	}
+	textoID, codigo, ok := strings.Cut(restante, ":")
+	if !ok || codigo == "" {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(textoID, 10, 64)
+	if err != nil || id == 0 {
+		return 0, "", false
+	}
+	return uint(id), codigo, true
+}
+
+// semAtraso devolve a política do login sem a espera entre tentativas.
+func semAtraso(politica auth.PoliticaBloqueio) auth.PoliticaBloqueio {
+	politica.AtrasoBase = 0
+	return politica
+}
+
+func (s *quiosqueService) verificarBloqueio(chave string) error {
+	return s.limitador.Verificar(chave, agora())
+}
+
+// registrarFalha contabiliza a falha e devolve o erro a ser mostrado; ao atingir o limite de
+// tentativas do login, a chave fica bloqueada pelo mesmo período.
+func (s *quiosqueService) registrarFalha(chave string, erro error) error {
+	if _, err := s.limitador.Falhou(chave, "", s.politica.MaxTentativasConta, agora()); err != nil {
+		return err
+	}
+	return erro
+}
@@ -0,0 +1,218 @@
+package quiosque
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
+	"github.com/Loviiin/ponto-api-go/internal/domain/localtrabalho"
+	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/totp"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type memoriaQuiosqueRepository struct {
+	QuiosqueRepository
+	quiosques map[uint]*model.Quiosque
+}
+
+func (m *memoriaQuiosqueRepository) Create(quiosque *model.Quiosque) error {
+	quiosque.ID = uint(len(m.quiosques) + 1)
+	m.quiosques[quiosque.ID] = quiosque
+	return nil
+}
+
+func (m *memoriaQuiosqueRepository) FindByPrefixo(prefixo string) (*model.Quiosque, error) {
+	for _, q := range m.quiosques {
+		if q.Prefixo == prefixo {
+			copia := *q
+			return &copia, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaQuiosqueRepository) FindByID(id uint, empresaID uint) (*model.Quiosque, error) {
+	if q, ok := m.quiosques[id]; ok && q.EmpresaID == empresaID {
+		copia := *q
+		return &copia, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaQuiosqueRepository) RegistrarUso(id uint, empresaID uint, momento time.Time) error {
+	return nil
+}
+
+type mockLocais struct {
+	localtrabalho.LocalTrabalhoService
+	local model.LocalTrabalho
+}
+
+func (m *mockLocais) FindByID(id uint, empresaID uint) (*model.LocalTrabalho, error) {
+	if id != m.local.ID || empresaID != m.local.EmpresaID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copia := m.local
+	return &copia, nil
+}
+
+type mockUsuarioRepository struct {
+	usuario.UsuarioRepository
+	usuarios []model.Usuario
+}
+
+func (m *mockUsuarioRepository) FindByMatricula(matricula string, empresaID uint) (*model.Usuario, error) {
+	for _, u := range m.usuarios {
+		if u.Matricula != nil && *u.Matricula == matricula && u.EmpresaID == empresaID {
+			copia := u
+			return &copia, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// mockPontoService guarda as batidas recebidas.
+type mockPontoService struct {
+	ponto.PontoService
+	batidas []ponto.Batida
+}
+
+func (m *mockPontoService) BaterPonto(usuarioID uint, empresaID uint, batida ponto.Batida) (*model.RegistroPonto, error) {
+	m.batidas = append(m.batidas, batida)
+	return &model.RegistroPonto{UsuarioID: usuarioID, EmpresaID: empresaID, QuiosqueID: &batida.Quiosque.ID}, nil
+}
+
+// Portaria da fábrica: um círculo de 100 metros.
+var portaria = model.LocalTrabalho{ID: 3, EmpresaID: 1, Ativo: true, RaioMetros: 100,
+	Geometria: model.JSONB(`{"type":"Point","coordinates":[-46.6565,-23.5613]}`)}
+
+func novoCenario(t *testing.T) (*mockPontoService, QuiosqueService, *model.Quiosque, string) {
+	t.Helper()
+	// Custo mínimo do bcrypt: a verificação segue o custo gravado no hash e o teste fica rápido.
+	pinHash, err := bcrypt.GenerateFromPassword([]byte("4321"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	matricula := "F-102"
+	usuarios := &mockUsuarioRepository{usuarios: []model.Usuario{{ID: 7, EmpresaID: 1, Matricula: &matricula, PinHash: string(pinHash)}}}
+	pontos := &mockPontoService{}
+	repo := &memoriaQuiosqueRepository{quiosques: map[uint]*model.Quiosque{}}
+	politica := auth.PoliticaBloqueio{MaxTentativasConta: 3, MaxTentativasIP: 5, DuracaoBloqueio: 15 * time.Minute}
+	service := NewQuiosqueService(repo, &mockLocais{local: portaria}, usuarios, pontos, auth.NewMemoriaTentativaLoginStore(), politica)
+
+	quiosque := &model.Quiosque{EmpresaID: 1, Nome: "Entrada", LocalTrabalhoID: portaria.ID, Latitude: -23.5614, Longitude: -46.6566}
+	credencial, err := service.Criar(quiosque)
+	if err != nil {
+		t.Fatalf("Erro inesperado ao criar o quiosque: %v", err)
+	}
+	quiosque.LocalTrabalho = portaria
+	return pontos, service, quiosque, credencial
+}
+
+func TestCriar_ValidaLocalEPosicao(t *testing.T) {
+	_, service, quiosque, credencial := novoCenario(t)
+	if !strings.HasPrefix(credencial, tipoCredencial+"_"+quiosque.Prefixo+"_") || quiosque.Hash == "" || len(quiosque.SegredoCodigo) == 0 {
+		t.Errorf("Quiosque criado incorretamente: %+v, credencial %q", quiosque, credencial)
+	}
+
+	if _, err := service.Criar(&model.Quiosque{EmpresaID: 1, LocalTrabalhoID: 99}); !errors.Is(err, ErrLocalInvalido) {
+		t.Errorf("Esperava ErrLocalInvalido, recebeu %v", err)
+	}
+	longe := &model.Quiosque{EmpresaID: 1, LocalTrabalhoID: portaria.ID, Latitude: -23.60, Longitude: -46.70}
+	if _, err := service.Criar(longe); !errors.Is(err, ErrForaDoLocal) {
+		t.Errorf("Esperava ErrForaDoLocal, recebeu %v", err)
+	}
+}
+
+func TestValidar_Credencial(t *testing.T) {
+	_, service, quiosque, credencial := novoCenario(t)
+	validado, err := service.Validar(credencial)
+	if err != nil || validado.ID != quiosque.ID {
+		t.Fatalf("A credencial deveria ser aceita, recebeu %v", err)
+	}
+	if _, err := service.Validar(credencial + "x"); !errors.Is(err, ErrCredencialInvalida) {
+		t.Errorf("Esperava ErrCredencialInvalida, recebeu %v", err)
+	}
+}
+
+func TestBaterComPin(t *testing.T) {
+	pontos, service, quiosque, _ := novoCenario(t)
+
+	if _, err := service.BaterComPin(quiosque, " F-102 ", "4321"); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(pontos.batidas) != 1 || pontos.batidas[0].Quiosque.ID != quiosque.ID {
+		t.Errorf("Esperava uma batida atestada pelo quiosque, recebeu %+v", pontos.batidas)
+	}
+
+	if _, err := service.BaterComPin(quiosque, "F-999", "4321"); !errors.Is(err, ErrPinIncorreto) {
+		t.Errorf("Matrícula inexistente deveria dar ErrPinIncorreto, recebeu %v", err)
+	}
+}
+
+func TestBaterComPin_BloqueiaDepoisDeFalhasSeguidas(t *testing.T) {
+	pontos, service, quiosque, _ := novoCenario(t)
+	for i := 0; i < 3; i++ {
+		if _, err := service.BaterComPin(quiosque, "F-102", "0000"); !errors.Is(err, ErrPinIncorreto) {
+			t.Fatalf("Tentativa %d: esperava ErrPinIncorreto, recebeu %v", i+1, err)
+		}
+	}
+	var bloqueio *auth.BloqueioError
+	if _, err := service.BaterComPin(quiosque, "F-102", "4321"); !errors.As(err, &bloqueio) {
+		t.Errorf("Mesmo com o PIN certo a matrícula deveria estar bloqueada, recebeu %v", err)
+	}
+	if len(pontos.batidas) != 0 {
+		t.Errorf("Nenhuma batida deveria ter sido registrada, recebeu %+v", pontos.batidas)
+	}
+}
+
+func TestBaterComPin_TravaOTerminalDepoisDeFalhasEmVariasMatriculas(t *testing.T) {
+	pontos, service, quiosque, _ := novoCenario(t)
+	for i, matricula := range []string{"F-001", "F-002", "F-003", "F-004", "F-102"} {
+		if _, err := service.BaterComPin(quiosque, matricula, "0000"); !errors.Is(err, ErrPinIncorreto) {
+			t.Fatalf("Tentativa %d: esperava ErrPinIncorreto, recebeu %v", i+1, err)
+		}
+	}
+	var bloqueio *auth.BloqueioError
+	if _, err := service.BaterComPin(quiosque, "F-102", "4321"); !errors.As(err, &bloqueio) || !bloqueio.Bloqueio {
+		t.Errorf("O terminal deveria estar travado mesmo para uma matrícula com uma única falha, recebeu %v", err)
+	}
+	if len(pontos.batidas) != 0 {
+		t.Errorf("Nenhuma batida deveria ter sido registrada, recebeu %+v", pontos.batidas)
+	}
+}
+
+func TestBaterComCodigo(t *testing.T) {
+	pontos, service, quiosque, _ := novoCenario(t)
+	momento := time.Date(2026, 3, 2, 8, 0, 10, 0, time.UTC)
+	agora = func() time.Time { return momento }
+	defer func() { agora = time.Now }()
+
+	codigo := service.CodigoAtual(quiosque)
+	if codigo.Codigo != totp.Codigo(quiosque.SegredoCodigo, momento, totp.Padrao) || !codigo.ExpiraEm.After(momento) {
+		t.Fatalf("Código exibido incorreto: %+v", codigo)
+	}
+
+	// Lido 40 segundos depois, já no passo seguinte: ainda dentro da tolerância.
+	momento = momento.Add(40 * time.Second)
+	if _, err := service.BaterComCodigo(7, 1, codigo.QRCode); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(pontos.batidas) != 1 || pontos.batidas[0].Quiosque.ID != quiosque.ID {
+		t.Errorf("Esperava uma batida atestada pelo quiosque, recebeu %+v", pontos.batidas)
+	}
+
+	momento = momento.Add(2 * time.Minute)
+	if _, err := service.BaterComCodigo(7, 1, codigo.QRCode); !errors.Is(err, ErrCodigoInvalido) {
+		t.Errorf("Um código expirado deveria ser recusado, recebeu %v", err)
+	}
+	if _, err := service.BaterComCodigo(7, 2, codigo.QRCode); !errors.Is(err, ErrCodigoInvalido) {
+		t.Errorf("O quiosque de outra empresa não deveria ser encontrado, recebeu %v", err)
+	}
+}
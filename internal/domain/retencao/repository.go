@@ -0,0 +1,78 @@
+package retencao
+
+import (
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/lgpd"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type RetencaoRepository interface {
+	// Vencidos lista os usuários ainda não anonimizados que foram excluídos, ou cuja empresa foi
+	// excluída, antes do limite. É uma consulta da plataforma, sobre todas as empresas.
+	Vencidos(limite time.Time) ([]model.Usuario, error)
+	// Anonimizar apaga os registros de ponto do usuário e anonimiza os seus dados pessoais
+	// (ver lgpd.AnonimizarDadosPessoais), tudo numa transação. Devolve as chaves das selfies dos
+	// pontos apagados, para que os arquivos sejam apagados depois.
+	Anonimizar(usuario model.Usuario, momento time.Time) ([]string, error)
+	// FotosVencidas lista até limite pontos cuja selfie passou da retenção de fotos da empresa. É
+	// uma consulta da plataforma, sobre todas as empresas.
+	FotosVencidas(agora time.Time, limite int) ([]model.RegistroPonto, error)
+	// MarcarFotoExpurgada tira a selfie do ponto, depois que o arquivo foi apagado.
+	MarcarFotoExpurgada(ponto model.RegistroPonto, momento time.Time) error
+}
+
+type retencaoRepository struct {
+	Db *gorm.DB
+}
+
+func NewRetencaoRepository(db *gorm.DB) RetencaoRepository {
+	return &retencaoRepository{Db: db}
+}
+
+func (r *retencaoRepository) Vencidos(limite time.Time) ([]model.Usuario, error) {
+	var usuarios []model.Usuario
+	err := tenant.Plataforma(r.Db).Unscoped().
+		Where("data_anonimizacao IS NULL").
+		Where("data_exclusao < ? OR empresa_id IN (SELECT id FROM empresas WHERE data_exclusao < ?)", limite, limite).
+		Order("id asc").Find(&usuarios).Error
+	return usuarios, err
+}
+
+func (r *retencaoRepository) Anonimizar(usuario model.Usuario, momento time.Time) ([]string, error) {
+	var fotos []string
+	err := tenant.Escopo(r.Db, usuario.EmpresaID).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if fotos, err = lgpd.ChavesDasFotos(tx, usuario.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("usuario_id = ?", usuario.ID).Delete(&model.RegistroPonto{}).Error; err != nil {
+			return err
+		}
+		// Os fechamentos do banco de horas são calculados a partir desses pontos e vão junto.
+		if err := tx.Where("usuario_id = ?", usuario.ID).Delete(&model.FechamentoDia{}).Error; err != nil {
+			return err
+		}
+		_, err = lgpd.AnonimizarDadosPessoais(tx, usuario, momento)
+		return err
+	})
+	return fotos, err
+}
+
+func (r *retencaoRepository) FotosVencidas(agora time.Time, limite int) ([]model.RegistroPonto, error) {
+	var pontos []model.RegistroPonto
+	err := tenant.Plataforma(r.Db).
+		Joins("JOIN empresas ON empresas.id = registro_pontos.empresa_id").
+		Where("registro_pontos.foto_chave IS NOT NULL AND empresas.retencao_fotos_dias > 0").
+		Where("registro_pontos.timestamp < ?::timestamptz - make_interval(days => empresas.retencao_fotos_dias)", agora).
+		Order("registro_pontos.id asc").Limit(limite).Find(&pontos).Error
+	return pontos, err
+}
+
+func (r *retencaoRepository) MarcarFotoExpurgada(ponto model.RegistroPonto, momento time.Time) error {
+	return tenant.Escopo(r.Db, ponto.EmpresaID).Model(&model.RegistroPonto{}).
+		Where("id = ? AND foto_chave = ?", ponto.ID, *ponto.FotoChave).
+		Updates(map[string]interface{}{"foto_chave": nil, "foto_expurgada_em": momento}).Error
+}
@@ -0,0 +1,98 @@
+// Package retencao expurga os dados de funcionários excluídos depois do prazo de retenção legal.
+//
+// Excluir um usuário, um cargo ou uma empresa é só uma exclusão lógica: os registros de ponto
+// precisam ser guardados por anos. Vencido o prazo, contado da exclusão do usuário ou da empresa,
+// os pontos do usuário são apagados de vez e os seus dados pessoais são anonimizados. A linha do
+// usuário continua existindo, para não quebrar referências como o log de auditoria.
+//
+// As selfies das batidas têm um prazo próprio, mais curto, configurado em cada empresa
+// (retencao_fotos_dias): vencido, o arquivo é apagado e o ponto continua, sem a foto.
+package retencao
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/pkg/armazenamento"
+)
+
+type RetencaoService interface {
+	// Expurgar anonimiza os usuários cuja exclusão passou do prazo e devolve quantos foram tratados.
+	// Uma falha num usuário não impede os demais; os erros são devolvidos juntos.
+	Expurgar(agora time.Time) (int, error)
+	// ExpurgarFotos apaga as selfies que passaram da retenção de fotos da empresa e devolve quantas.
+	ExpurgarFotos(agora time.Time) (int, error)
+}
+
+// loteFotos é quantas fotos vencidas são lidas de cada vez.
+const loteFotos = 500
+
+type retencaoService struct {
+	repo     RetencaoRepository
+	anos     int
+	arquivos armazenamento.Armazenamento
+}
+
+// NewRetencaoService recebe o prazo de retenção em anos. Zero ou negativo desliga o expurgo, para
+// que um erro de configuração nunca apague dados antes da hora.
+func NewRetencaoService(repo RetencaoRepository, anos int, arquivos armazenamento.Armazenamento) RetencaoService {
+	return &retencaoService{repo: repo, anos: anos, arquivos: arquivos}
+}
+
+func (s *retencaoService) Expurgar(agora time.Time) (int, error) {
+	if s.anos <= 0 {
+		return 0, nil
+	}
+	limite := agora.AddDate(-s.anos, 0, 0)
+	vencidos, err := s.repo.Vencidos(limite)
+	if err != nil {
+		return 0, err
+	}
+
+	var erros []error
+	tratados := 0
+	for _, usuario := range vencidos {
+		fotos, err := s.repo.Anonimizar(usuario, agora)
+		if err != nil {
+			log.Printf("RETENCAO: falha ao anonimizar o usuário ID %d: %v", usuario.ID, err)
+			erros = append(erros, err)
+			continue
+		}
+		armazenamento.ApagarTodos(s.arquivos, fotos)
+		tratados++
+	}
+	return tratados, errors.Join(erros...)
+}
+
+// ExpurgarFotos apaga primeiro o arquivo e só então tira a foto do ponto: se a segunda etapa
+// falhar, a próxima execução repete as duas sem prejuízo.
+func (s *retencaoService) ExpurgarFotos(agora time.Time) (int, error) {
+	tratadas := 0
+	for {
+		pontos, err := s.repo.FotosVencidas(agora, loteFotos)
+		if err != nil {
+			return tratadas, err
+		}
+		var erros []error
+		for _, ponto := range pontos {
+			if err := s.arquivos.Apagar(*ponto.FotoChave); err != nil {
+				erros = append(erros, err)
+				continue
+			}
+			if err := s.repo.MarcarFotoExpurgada(ponto, agora); err != nil {
+				erros = append(erros, err)
+				continue
+			}
+			tratadas++
+		}
+		// Com falhas, as mesmas fotos voltariam no próximo lote; ficam para a próxima execução.
+		if len(erros) > 0 {
+			log.Printf("RETENCAO: %d fotos não puderam ser expurgadas", len(erros))
+			return tratadas, errors.Join(erros...)
+		}
+		if len(pontos) < loteFotos {
+			return tratadas, nil
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package retencao
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/armazenamento"
+)
+
+type memoriaRetencaoRepository struct {
+	RetencaoRepository
+	excluidos   map[uint]time.Time
+	limite      time.Time
+	anonimizado []uint
+	falhaEm     uint
+	// fotos são as selfies de cada usuário; vencidas, as fotos que passaram da retenção.
+	fotos     map[uint][]string
+	vencidas  []model.RegistroPonto
+	expurgada []uint
+}
+
+func (m *memoriaRetencaoRepository) Vencidos(limite time.Time) ([]model.Usuario, error) {
+	m.limite = limite
+	var vencidos []model.Usuario
+	for id, excluidoEm := range m.excluidos {
+		if excluidoEm.Before(limite) {
+			vencidos = append(vencidos, model.Usuario{ID: id})
+		}
+	}
+	return vencidos, nil
+}
+
+func (m *memoriaRetencaoRepository) Anonimizar(usuario model.Usuario, momento time.Time) ([]string, error) {
+	if usuario.ID == m.falhaEm {
+		return nil, errors.New("falha simulada")
+	}
+	m.anonimizado = append(m.anonimizado, usuario.ID)
+	return m.fotos[usuario.ID], nil
+}
+
+func (m *memoriaRetencaoRepository) FotosVencidas(agora time.Time, limite int) ([]model.RegistroPonto, error) {
+	if len(m.vencidas) > limite {
+		return m.vencidas[:limite], nil
+	}
+	return m.vencidas, nil
+}
+
+func (m *memoriaRetencaoRepository) MarcarFotoExpurgada(ponto model.RegistroPonto, momento time.Time) error {
+	m.expurgada = append(m.expurgada, ponto.ID)
+	restantes := m.vencidas[:0]
+	for _, p := range m.vencidas {
+		if p.ID != ponto.ID {
+			restantes = append(restantes, p)
+		}
+	}
+	m.vencidas = restantes
+	return nil
+}
+
+func novoArmazenamento(t *testing.T, chaves ...string) armazenamento.Armazenamento {
+	t.Helper()
+	arquivos, err := armazenamento.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Erro ao criar o armazenamento: %v", err)
+	}
+	for _, chave := range chaves {
+		if err := arquivos.Gravar(chave, []byte("selfie"), "image/jpeg"); err != nil {
+			t.Fatalf("Erro ao gravar %s: %v", chave, err)
+		}
+	}
+	return arquivos
+}
+
+var agora = time.Date(2030, 6, 1, 3, 0, 0, 0, time.UTC)
+
+func TestExpurgar_SoDepoisDoPrazo(t *testing.T) {
+	repo := &memoriaRetencaoRepository{excluidos: map[uint]time.Time{
+		1: agora.AddDate(-6, 0, 0),
+		2: agora.AddDate(-4, -11, 0),
+	}}
+	tratados, err := NewRetencaoService(repo, 5, novoArmazenamento(t)).Expurgar(agora)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if !repo.limite.Equal(agora.AddDate(-5, 0, 0)) {
+		t.Errorf("Limite calculado errado: %v", repo.limite)
+	}
+	if tratados != 1 || len(repo.anonimizado) != 1 || repo.anonimizado[0] != 1 {
+		t.Errorf("Só o usuário 1 passou do prazo; anonimizados: %v", repo.anonimizado)
+	}
+}
+
+func TestExpurgar_PrazoZeroNaoApagaNada(t *testing.T) {
+	repo := &memoriaRetencaoRepository{excluidos: map[uint]time.Time{1: agora.AddDate(-10, 0, 0)}}
+	tratados, err := NewRetencaoService(repo, 0, novoArmazenamento(t)).Expurgar(agora)
+	if err != nil || tratados != 0 || len(repo.anonimizado) != 0 {
+		t.Errorf("Prazo zero deveria desligar o expurgo (tratados=%d, err=%v)", tratados, err)
+	}
+}
+
+func TestExpurgar_FalhaNumUsuarioNaoParaOsOutros(t *testing.T) {
+	repo := &memoriaRetencaoRepository{
+		excluidos: map[uint]time.Time{1: agora.AddDate(-6, 0, 0), 2: agora.AddDate(-7, 0, 0)},
+		falhaEm:   1,
+	}
+	tratados, err := NewRetencaoService(repo, 5, novoArmazenamento(t)).Expurgar(agora)
+	if err == nil {
+		t.Error("A falha do usuário 1 deveria ser devolvida")
+	}
+	if tratados != 1 || len(repo.anonimizado) != 1 || repo.anonimizado[0] != 2 {
+		t.Errorf("O usuário 2 deveria ter sido anonimizado mesmo com a falha; anonimizados: %v", repo.anonimizado)
+	}
+}
+
+func TestExpurgar_ApagaAsFotosDoUsuario(t *testing.T) {
+	chave := "fotos/empresa-1/usuario-1/2024/01/abc.jpg"
+	arquivos := novoArmazenamento(t, chave)
+	repo := &memoriaRetencaoRepository{
+		excluidos: map[uint]time.Time{1: agora.AddDate(-6, 0, 0)},
+		fotos:     map[uint][]string{1: {chave}},
+	}
+	if _, err := NewRetencaoService(repo, 5, arquivos).Expurgar(agora); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if _, err := arquivos.Ler(chave); !errors.Is(err, armazenamento.ErrNaoEncontrado) {
+		t.Errorf("A selfie do usuário expurgado deveria ter sido apagada, recebeu %v", err)
+	}
+}
+
+func TestExpurgarFotos(t *testing.T) {
+	chaves := []string{"fotos/empresa-1/usuario-1/2030/01/a.jpg", "fotos/empresa-1/usuario-2/2030/01/b.jpg"}
+	arquivos := novoArmazenamento(t, chaves...)
+	repo := &memoriaRetencaoRepository{vencidas: []model.RegistroPonto{
+		{ID: 10, EmpresaID: 1, FotoChave: &chaves[0]},
+		{ID: 11, EmpresaID: 1, FotoChave: &chaves[1]},
+	}}
+
+	tratadas, err := NewRetencaoService(repo, 5, arquivos).ExpurgarFotos(agora)
+	if err != nil || tratadas != 2 {
+		t.Fatalf("Esperava 2 fotos expurgadas, recebeu %d (%v)", tratadas, err)
+	}
+	if len(repo.expurgada) != 2 {
+		t.Errorf("Os dois pontos deveriam ter sido marcados: %v", repo.expurgada)
+	}
+	for _, chave := range chaves {
+		if _, err := arquivos.Ler(chave); !errors.Is(err, armazenamento.ErrNaoEncontrado) {
+			t.Errorf("A foto %s deveria ter sido apagada, recebeu %v", chave, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package revisao
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service        RevisaoService
+	usuarioService usuario.UsuarioService
+	converter      funcoes.FuncoesInterface
+}
+
+func NewHandler(s RevisaoService, u usuario.UsuarioService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:        s,
+		usuarioService: u,
+		converter:      f,
+	}
+}
+
+// requisitante carrega o gestor que faz a requisição. Chaves de API não revisam pontos: a decisão
+// fica registrada em nome de uma pessoa.
+func (h *Handler) requisitante(c *gin.Context) (*model.Usuario, bool) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	requisitanteID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem revisar pontos."})
+		return nil, false
+	}
+	requisitante, err := usuario.Requisitante(c, h.usuarioService, requisitanteID, empresaID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+		return nil, false
+	}
+	return requisitante, true
+}
+
+func (h *Handler) GetPendentes(c *gin.Context) {
+	requisitante, ok := h.requisitante(c)
+	if !ok {
+		return
+	}
+	pendentes, err := h.service.Pendentes(requisitante)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar os pontos aguardando revisão."})
+		return
+	}
+	c.JSON(http.StatusOK, pendentes)
+}
+
+func (h *Handler) Aprovar(c *gin.Context) {
+	h.decidir(c, true)
+}
+
+func (h *Handler) Rejeitar(c *gin.Context) {
+	h.decidir(c, false)
+}
+
+func (h *Handler) decidir(c *gin.Context, aprovar bool) {
+	requisitante, ok := h.requisitante(c)
+	if !ok {
+		return
+	}
+	pontoID, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do ponto inválido."})
+		return
+	}
+	// A observação é opcional; um corpo vazio é aceito.
+	var req struct {
+		Observacao string `json:"observacao"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Corpo da requisição (JSON) inválido."})
+			return
+		}
+	}
+
+	ponto, err := h.service.Decidir(requisitante, pontoID, aprovar, req.Observacao)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ponto não encontrado nesta empresa."})
+		case errors.Is(err, ErrPontoNaoPendente):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrAutoRevisao), errors.Is(err, ErrForaDoEscopo), errors.Is(err, politica.ErrNegadoPorPolitica):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao registrar a revisão do ponto."})
+		}
+		return
+	}
+	antes := *ponto
+	antes.StatusRevisao, antes.RevisadoPorID, antes.RevisadoEm, antes.ObservacaoRevisao = model.RevisaoPendente, nil, nil, ""
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoPontoRevisado, Entidade: "registro_pontos", EntidadeID: ponto.ID, Antes: antes, Depois: ponto})
+
+	c.JSON(http.StatusOK, ponto)
+}
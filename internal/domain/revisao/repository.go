@@ -0,0 +1,56 @@
+package revisao
+
+import (
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type RevisaoRepository interface {
+	FindPendentes(empresaID uint) ([]model.RegistroPonto, error)
+	FindPonto(id uint, empresaID uint) (*model.RegistroPonto, error)
+	// Decidir grava a decisão só se o ponto ainda estiver pendente; caso contrário devolve
+	// gorm.ErrRecordNotFound.
+	Decidir(id uint, empresaID uint, status string, revisorID uint, momento time.Time, observacao string) error
+}
+
+type revisaoRepository struct {
+	Db *gorm.DB
+}
+
+func NewRevisaoRepository(db *gorm.DB) RevisaoRepository {
+	return &revisaoRepository{Db: db}
+}
+
+func (r *revisaoRepository) FindPendentes(empresaID uint) ([]model.RegistroPonto, error) {
+	var pontos []model.RegistroPonto
+	err := tenant.Escopo(r.Db, empresaID).Where("status_revisao = ?", model.RevisaoPendente).
+		Preload("Usuario").Order("timestamp asc").Find(&pontos).Error
+	return pontos, err
+}
+
+func (r *revisaoRepository) FindPonto(id uint, empresaID uint) (*model.RegistroPonto, error) {
+	var ponto model.RegistroPonto
+	err := tenant.Escopo(r.Db, empresaID).Where("id = ?", id).First(&ponto).Error
+	return &ponto, err
+}
+
+func (r *revisaoRepository) Decidir(id uint, empresaID uint, status string, revisorID uint, momento time.Time, observacao string) error {
+	resultado := tenant.Escopo(r.Db, empresaID).Model(&model.RegistroPonto{}).
+		Where("id = ? AND status_revisao = ?", id, model.RevisaoPendente).
+		Updates(map[string]interface{}{
+			"status_revisao":     status,
+			"revisado_por_id":    revisorID,
+			"revisado_em":        momento,
+			"observacao_revisao": observacao,
+		})
+	if resultado.Error != nil {
+		return resultado.Error
+	}
+	if resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Package revisao é a caixa de entrada dos gestores para os pontos que aguardam revisão: os remotos
+// que a regra do funcionário manda revisar e as batidas offline de horário duvidoso (ver
+// model.RegistroPonto.MotivoRevisao). Até a decisão, esses pontos ficam fora do banco de horas.
+package revisao
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/bancohoras"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPontoNaoPendente = errors.New("este ponto não está aguardando revisão")
+	ErrAutoRevisao      = errors.New("ninguém revisa os próprios pontos")
+	ErrForaDoEscopo     = errors.New("você não tem permissão para revisar os pontos deste funcionário")
+)
+
+var agora = time.Now
+
+// PontoEmRevisao é um ponto pendente com o nome de quem o bateu, para a listagem do gestor.
+type PontoEmRevisao struct {
+	model.RegistroPonto
+	UsuarioNome string `json:"usuario_nome"`
+}
+
+type RevisaoService interface {
+	// Pendentes lista os pontos aguardando revisão dos funcionários no escopo de REVISAR_PONTOS.
+	Pendentes(requisitante *model.Usuario) ([]PontoEmRevisao, error)
+	Decidir(requisitante *model.Usuario, pontoID uint, aprovar bool, observacao string) (*model.RegistroPonto, error)
+}
+
+type revisaoService struct {
+	repo           RevisaoRepository
+	usuarioService usuario.UsuarioService
+	bancoHoras     bancohoras.BancoHorasService
+	politicas      politica.Verificador
+}
+
+func NewRevisaoService(repo RevisaoRepository, usuarioService usuario.UsuarioService, bancoHoras bancohoras.BancoHorasService, politicas politica.Verificador) RevisaoService {
+	return &revisaoService{
+		repo:           repo,
+		usuarioService: usuarioService,
+		bancoHoras:     bancoHoras,
+		politicas:      politicas,
+	}
+}
+
+func (s *revisaoService) Pendentes(requisitante *model.Usuario) ([]PontoEmRevisao, error) {
+	pontos, err := s.repo.FindPendentes(requisitante.EmpresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	usuarios := make([]model.Usuario, 0)
+	vistos := make(map[uint]bool)
+	for _, p := range pontos {
+		// Usuários excluídos não são carregados; seus pontos saem da caixa de entrada.
+		if p.Usuario.ID != 0 && !vistos[p.Usuario.ID] {
+			vistos[p.Usuario.ID] = true
+			usuarios = append(usuarios, p.Usuario)
+		}
+	}
+	visiveis, err := s.usuarioService.FiltrarPorEscopo(requisitante, permissions.REVISAR_PONTOS, usuarios)
+	if err != nil {
+		return nil, err
+	}
+	permitidos := make(map[uint]bool, len(visiveis))
+	for _, u := range visiveis {
+		if u.ID != requisitante.ID {
+			permitidos[u.ID] = true
+		}
+	}
+
+	pendentes := make([]PontoEmRevisao, 0)
+	for _, p := range pontos {
+		if permitidos[p.UsuarioID] {
+			pendentes = append(pendentes, PontoEmRevisao{RegistroPonto: p, UsuarioNome: p.Usuario.Nome})
+		}
+	}
+	return pendentes, nil
+}
+
+// Decidir aprova ou rejeita um ponto pendente, depois de consultar as políticas da empresa com o
+// revisor como sujeito. Se o dia do ponto já foi fechado, o banco de horas recebe a diferença que a
+// decisão causou no saldo do dia.
+func (s *revisaoService) Decidir(requisitante *model.Usuario, pontoID uint, aprovar bool, observacao string) (*model.RegistroPonto, error) {
+	ponto, err := s.repo.FindPonto(pontoID, requisitante.EmpresaID)
+	if err != nil {
+		return nil, err
+	}
+	if ponto.StatusRevisao != model.RevisaoPendente {
+		return nil, ErrPontoNaoPendente
+	}
+	if ponto.UsuarioID == requisitante.ID {
+		return nil, ErrAutoRevisao
+	}
+	permitido, err := s.usuarioService.AlvoNoEscopo(requisitante, permissions.REVISAR_PONTOS, ponto.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+	if !permitido {
+		return nil, ErrForaDoEscopo
+	}
+
+	saldoAnterior, err := s.bancoHoras.CalcularSaldoParaUsuario(ponto.UsuarioID, ponto.EmpresaID, ponto.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	status := model.RevisaoRejeitada
+	if aprovar {
+		status = model.RevisaoAprovada
+	}
+	saldoDepois, err := s.bancoHoras.SaldoComDecisao(ponto, status)
+	if err != nil {
+		return nil, err
+	}
+	minutos := saldoDepois - saldoAnterior
+	absoluto := minutos
+	if absoluto < 0 {
+		absoluto = -absoluto
+	}
+	momento := agora()
+	err = s.politicas.Exigir(requisitante.EmpresaID, politica.Requisicao{
+		Acao:    politica.AcaoPontoRevisar,
+		Sujeito: politica.AtributosUsuario(requisitante),
+		Recurso: politica.Atributos{
+			"ponto_id":                  ponto.ID,
+			"usuario_id":                ponto.UsuarioID,
+			"decisao":                   status,
+			"tipo":                      ponto.Tipo,
+			"motivo":                    ponto.MotivoRevisao,
+			"risco":                     ponto.RiscoPontuacao,
+			"minutos_afetados":          minutos,
+			"minutos_afetados_absoluto": absoluto,
+		},
+		Momento: momento,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	observacao = strings.TrimSpace(observacao)
+	if err := s.repo.Decidir(ponto.ID, ponto.EmpresaID, status, requisitante.ID, momento, observacao); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Outro gestor decidiu entre a leitura e a gravação.
+			return nil, ErrPontoNaoPendente
+		}
+		return nil, err
+	}
+
+	if err := s.bancoHoras.AjustarDiaFechado(ponto.UsuarioID, ponto.EmpresaID, ponto.Timestamp, saldoAnterior); err != nil {
+		return nil, err
+	}
+
+	revisorID := requisitante.ID
+	ponto.StatusRevisao = status
+	ponto.RevisadoPorID = &revisorID
+	ponto.RevisadoEm = &momento
+	ponto.ObservacaoRevisao = observacao
+	return ponto, nil
+}
@@ -0,0 +1,197 @@
+package revisao
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/bancohoras"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+type memoriaRevisaoRepository struct {
+	pontos map[uint]*model.RegistroPonto
+}
+
+func (m *memoriaRevisaoRepository) FindPendentes(empresaID uint) ([]model.RegistroPonto, error) {
+	var pendentes []model.RegistroPonto
+	for _, p := range m.pontos {
+		if p.StatusRevisao == model.RevisaoPendente {
+			pendentes = append(pendentes, *p)
+		}
+	}
+	return pendentes, nil
+}
+
+func (m *memoriaRevisaoRepository) FindPonto(id uint, empresaID uint) (*model.RegistroPonto, error) {
+	if p, ok := m.pontos[id]; ok && p.EmpresaID == empresaID {
+		copia := *p
+		return &copia, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaRevisaoRepository) Decidir(id uint, empresaID uint, status string, revisorID uint, momento time.Time, observacao string) error {
+	p, ok := m.pontos[id]
+	if !ok || p.StatusRevisao != model.RevisaoPendente {
+		return gorm.ErrRecordNotFound
+	}
+	p.StatusRevisao = status
+	return nil
+}
+
+// mockUsuarioService põe no escopo do gestor só os funcionários da sua equipe.
+type mockUsuarioService struct {
+	usuario.UsuarioService
+	equipe map[uint]bool
+}
+
+func (m *mockUsuarioService) AlvoNoEscopo(requisitante *model.Usuario, permissao string, alvoID uint) (bool, error) {
+	return m.equipe[alvoID], nil
+}
+
+func (m *mockUsuarioService) FiltrarPorEscopo(requisitante *model.Usuario, permissao string, usuarios []model.Usuario) ([]model.Usuario, error) {
+	var visiveis []model.Usuario
+	for _, u := range usuarios {
+		if m.equipe[u.ID] {
+			visiveis = append(visiveis, u)
+		}
+	}
+	return visiveis, nil
+}
+
+// mockBancoHoras calcula o saldo do dia a partir dos pontos do repositório em memória.
+type mockBancoHoras struct {
+	bancohoras.BancoHorasService
+	repo    *memoriaRevisaoRepository
+	ajustes []int
+}
+
+func (m *mockBancoHoras) CalcularSaldoParaUsuario(usuarioID uint, empresaID uint, dia time.Time) (int, error) {
+	var pontos []model.RegistroPonto
+	for _, p := range m.repo.pontos {
+		if p.UsuarioID == usuarioID {
+			pontos = append(pontos, *p)
+		}
+	}
+	return bancohoras.CalcularSaldoDoDia(pontos, model.Cargo{CargaHorariaDiariaMinutos: 480})
+}
+
+func (m *mockBancoHoras) SaldoComDecisao(ponto *model.RegistroPonto, status string) (int, error) {
+	var pontos []model.RegistroPonto
+	for _, p := range m.repo.pontos {
+		if p.UsuarioID == ponto.UsuarioID {
+			if p.ID == ponto.ID {
+				copia := *p
+				copia.StatusRevisao = status
+				pontos = append(pontos, copia)
+				continue
+			}
+			pontos = append(pontos, *p)
+		}
+	}
+	return bancohoras.CalcularSaldoDoDia(pontos, model.Cargo{CargaHorariaDiariaMinutos: 480})
+}
+
+// verificadorFixo responde sempre com o mesmo erro; nil permite tudo.
+type verificadorFixo struct {
+	err     error
+	pedidos []politica.Requisicao
+}
+
+func (v *verificadorFixo) Exigir(empresaID uint, req politica.Requisicao) error {
+	v.pedidos = append(v.pedidos, req)
+	return v.err
+}
+
+func (m *mockBancoHoras) AjustarDiaFechado(usuarioID uint, empresaID uint, dia time.Time, saldoAnterior int) error {
+	saldoAtual, _ := m.CalcularSaldoParaUsuario(usuarioID, empresaID, dia)
+	m.ajustes = append(m.ajustes, saldoAtual-saldoAnterior)
+	return nil
+}
+
+func novoCenario() (*memoriaRevisaoRepository, *mockBancoHoras, RevisaoService) {
+	return novoCenarioComPoliticas(&verificadorFixo{})
+}
+
+func novoCenarioComPoliticas(politicas politica.Verificador) (*memoriaRevisaoRepository, *mockBancoHoras, RevisaoService) {
+	dia := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	repo := &memoriaRevisaoRepository{pontos: map[uint]*model.RegistroPonto{
+		1: {ID: 1, EmpresaID: 1, UsuarioID: 7, Timestamp: dia.Add(8 * time.Hour), Usuario: model.Usuario{ID: 7, Nome: "Ana"}},
+		2: {ID: 2, EmpresaID: 1, UsuarioID: 7, Timestamp: dia.Add(17 * time.Hour), StatusRevisao: model.RevisaoPendente,
+			Justificativa: "Visita ao cliente", Usuario: model.Usuario{ID: 7, Nome: "Ana"}},
+		3: {ID: 3, EmpresaID: 1, UsuarioID: 9, Timestamp: dia.Add(9 * time.Hour), StatusRevisao: model.RevisaoPendente,
+			Justificativa: "Home office", Usuario: model.Usuario{ID: 9, Nome: "Bruno"}},
+	}}
+	bancoHoras := &mockBancoHoras{repo: repo}
+	service := NewRevisaoService(repo, &mockUsuarioService{equipe: map[uint]bool{7: true}}, bancoHoras, politicas)
+	return repo, bancoHoras, service
+}
+
+var gestor = &model.Usuario{ID: 2, EmpresaID: 1}
+
+func TestPendentes_SoDaEquipeDoGestor(t *testing.T) {
+	_, _, service := novoCenario()
+	pendentes, err := service.Pendentes(gestor)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(pendentes) != 1 || pendentes[0].ID != 2 || pendentes[0].UsuarioNome != "Ana" {
+		t.Errorf("Esperava só o ponto pendente da Ana, recebeu %+v", pendentes)
+	}
+}
+
+func TestDecidir_AprovacaoEntraNoSaldo(t *testing.T) {
+	repo, bancoHoras, service := novoCenario()
+	ponto, err := service.Decidir(gestor, 2, true, " ok ")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if ponto.StatusRevisao != model.RevisaoAprovada || ponto.ObservacaoRevisao != "ok" || *ponto.RevisadoPorID != gestor.ID {
+		t.Errorf("Decisão gravada incorretamente: %+v", ponto)
+	}
+	if repo.pontos[2].StatusRevisao != model.RevisaoAprovada {
+		t.Error("O repositório deveria ter recebido a aprovação")
+	}
+	// Sem a saída aprovada o dia não tinha pares (-480); com ela, 9h de trabalho (+60).
+	if len(bancoHoras.ajustes) != 1 || bancoHoras.ajustes[0] != 540 {
+		t.Errorf("Esperava um ajuste de 540 minutos, recebeu %v", bancoHoras.ajustes)
+	}
+
+	if _, err := service.Decidir(gestor, 2, false, ""); !errors.Is(err, ErrPontoNaoPendente) {
+		t.Errorf("Um ponto já revisado não deveria ser revisado de novo, recebeu %v", err)
+	}
+}
+
+func TestDecidir_RecusaForaDoEscopoEAutoRevisao(t *testing.T) {
+	_, _, service := novoCenario()
+	if _, err := service.Decidir(gestor, 3, true, ""); !errors.Is(err, ErrForaDoEscopo) {
+		t.Errorf("Esperava ErrForaDoEscopo, recebeu %v", err)
+	}
+	if _, err := service.Decidir(&model.Usuario{ID: 7, EmpresaID: 1}, 2, true, ""); !errors.Is(err, ErrAutoRevisao) {
+		t.Errorf("Esperava ErrAutoRevisao, recebeu %v", err)
+	}
+}
+
+func TestDecidir_ConsultaAsPoliticas(t *testing.T) {
+	politicas := &verificadorFixo{err: politica.ErrNegadoPorPolitica}
+	repo, bancoHoras, service := novoCenarioComPoliticas(politicas)
+
+	if _, err := service.Decidir(gestor, 2, true, ""); !errors.Is(err, politica.ErrNegadoPorPolitica) {
+		t.Fatalf("Esperava ErrNegadoPorPolitica, recebeu %v", err)
+	}
+	if repo.pontos[2].StatusRevisao != model.RevisaoPendente || len(bancoHoras.ajustes) != 0 {
+		t.Error("Uma revisão negada não deveria decidir o ponto nem ajustar o banco de horas")
+	}
+	pedido := politicas.pedidos[0]
+	if pedido.Acao != politica.AcaoPontoRevisar || pedido.Sujeito["id"] != gestor.ID || pedido.Recurso["decisao"] != model.RevisaoAprovada {
+		t.Errorf("Requisição enviada ao motor inesperada: %+v", pedido)
+	}
+	// A saída aprovada forma o par das 8h às 17h: o saldo do dia vai de -480 a +60.
+	if pedido.Recurso["minutos_afetados"] != 540 || pedido.Recurso["minutos_afetados_absoluto"] != 540 {
+		t.Errorf("Esperava 540 minutos afetados, recebeu %v", pedido.Recurso)
+	}
+}
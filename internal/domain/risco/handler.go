@@ -0,0 +1,155 @@
+package risco
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/Loviiin/ponto-api-go/pkg/fuso"
+	"github.com/gin-gonic/gin"
+)
+
+// periodoPadraoDias é o período do relatório quando 'de' e 'ate' não são informados.
+const periodoPadraoDias = 30
+
+type Handler struct {
+	service        RiscoService
+	usuarioService usuario.UsuarioService
+	empresas       empresa.EmpresaService
+	converter      funcoes.FuncoesInterface
+}
+
+func NewHandler(s RiscoService, u usuario.UsuarioService, e empresa.EmpresaService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:        s,
+		usuarioService: u,
+		empresas:       e,
+		converter:      f,
+	}
+}
+
+func (h *Handler) GetConfiguracao(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	configuracao, err := h.service.BuscarConfiguracao(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar a configuração."})
+		return
+	}
+	c.JSON(http.StatusOK, configuracao)
+}
+
+func (h *Handler) SalvarConfiguracao(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	type configuracaoRequest struct {
+		PrecisaoMaximaMetros float64     `json:"precisao_maxima_metros" binding:"required"`
+		VelocidadeMaximaKmh  float64     `json:"velocidade_maxima_kmh" binding:"required"`
+		AreaPermitida        model.JSONB `json:"area_permitida"`
+		LimiteRelatorio      int         `json:"limite_relatorio" binding:"required"`
+		LimiteBloqueio       int         `json:"limite_bloqueio"`
+	}
+	var req configuracaoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	configuracao := model.ConfiguracaoRisco{
+		EmpresaID:            empresaID,
+		PrecisaoMaximaMetros: req.PrecisaoMaximaMetros,
+		VelocidadeMaximaKmh:  req.VelocidadeMaximaKmh,
+		LimiteRelatorio:      req.LimiteRelatorio,
+		LimiteBloqueio:       req.LimiteBloqueio,
+	}
+	// Um "area_permitida": null explícito volta ao padrão (território brasileiro).
+	if string(req.AreaPermitida) != "null" {
+		configuracao.AreaPermitida = req.AreaPermitida
+	}
+
+	antes, _ := h.service.BuscarConfiguracao(empresaID)
+	if err := h.service.SalvarConfiguracao(&configuracao); err != nil {
+		if errors.Is(err, ErrConfiguracaoInvalida) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao salvar a configuração."})
+		return
+	}
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoRiscoConfigurado, Entidade: "empresas", EntidadeID: empresaID, Antes: antes, Depois: configuracao})
+	c.JSON(http.StatusOK, configuracao)
+}
+
+// GetRelatorio lista as batidas suspeitas. Aceita 'de' e 'ate' (AAAA-MM-DD, ambos inclusivos;
+// padrão: últimos 30 dias) e 'minimo', a pontuação mínima (padrão: o limite da empresa).
+func (h *Handler) GetRelatorio(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	requisitanteID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem consultar o relatório de suspeitas."})
+		return
+	}
+	requisitante, err := usuario.Requisitante(c, h.usuarioService, requisitanteID, empresaID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+		return
+	}
+
+	// As datas são dias de calendário no fuso da empresa.
+	fusoEmpresa, err := h.empresas.Fuso(empresaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar o fuso horário da empresa."})
+		return
+	}
+	hoje := fuso.InicioDoDia(time.Now(), fusoEmpresa)
+	de, ate := hoje.AddDate(0, 0, -periodoPadraoDias), hoje
+	if valor := c.Query("de"); valor != "" {
+		if de, err = fuso.ParseDia(valor, fusoEmpresa); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de 'de' inválido. Use AAAA-MM-DD."})
+			return
+		}
+	}
+	if valor := c.Query("ate"); valor != "" {
+		if ate, err = fuso.ParseDia(valor, fusoEmpresa); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de 'ate' inválido. Use AAAA-MM-DD."})
+			return
+		}
+	}
+	minimo := 0
+	if valor := c.Query("minimo"); valor != "" {
+		if minimo, err = strconv.Atoi(valor); err != nil || minimo < 1 || minimo > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "'minimo' deve ser um número de 1 a 100."})
+			return
+		}
+	}
+
+	// 'ate' é inclusivo: vai até o fim do dia.
+	suspeitos, err := h.service.Relatorio(requisitante, de, ate.AddDate(0, 0, 1).Add(-time.Nanosecond), minimo)
+	if err != nil {
+		if errors.Is(err, ErrPeriodoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao gerar o relatório de suspeitas."})
+		return
+	}
+	c.JSON(http.StatusOK, suspeitos)
+}
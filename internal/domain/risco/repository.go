@@ -0,0 +1,50 @@
+package risco
+
+import (
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type RiscoRepository interface {
+	FindConfiguracao(empresaID uint) (*model.ConfiguracaoRisco, error)
+	SaveConfiguracao(configuracao *model.ConfiguracaoRisco) error
+	// UltimosPontos devolve os pontos mais recentes do usuário, do mais novo ao mais antigo.
+	UltimosPontos(usuarioID uint, empresaID uint, limite int) ([]model.RegistroPonto, error)
+	Suspeitos(empresaID uint, de time.Time, ate time.Time, pontuacaoMinima int) ([]model.RegistroPonto, error)
+}
+
+type riscoRepository struct {
+	Db *gorm.DB
+}
+
+func NewRiscoRepository(db *gorm.DB) RiscoRepository {
+	return &riscoRepository{Db: db}
+}
+
+func (r *riscoRepository) FindConfiguracao(empresaID uint) (*model.ConfiguracaoRisco, error) {
+	var configuracao model.ConfiguracaoRisco
+	err := tenant.Escopo(r.Db, empresaID).First(&configuracao).Error
+	return &configuracao, err
+}
+
+func (r *riscoRepository) SaveConfiguracao(configuracao *model.ConfiguracaoRisco) error {
+	return tenant.Escopo(r.Db, configuracao.EmpresaID).Save(configuracao).Error
+}
+
+func (r *riscoRepository) UltimosPontos(usuarioID uint, empresaID uint, limite int) ([]model.RegistroPonto, error) {
+	var pontos []model.RegistroPonto
+	err := tenant.Escopo(r.Db, empresaID).Where("usuario_id = ?", usuarioID).
+		Order("timestamp desc").Limit(limite).Find(&pontos).Error
+	return pontos, err
+}
+
+func (r *riscoRepository) Suspeitos(empresaID uint, de time.Time, ate time.Time, pontuacaoMinima int) ([]model.RegistroPonto, error) {
+	var pontos []model.RegistroPonto
+	err := tenant.Escopo(r.Db, empresaID).
+		Where("risco_pontuacao >= ? AND timestamp BETWEEN ? AND ?", pontuacaoMinima, de, ate).
+		Preload("Usuario").Order("risco_pontuacao desc, timestamp desc").Find(&pontos).Error
+	return pontos, err
+}
@@ -0,0 +1,266 @@
+// Package risco avalia se a localização enviada numa batida de ponto é plausível. As coordenadas
+// vêm do aparelho e podem ser falsificadas; cada indício de falsificação soma pontos a uma
+// pontuação de 0 a 100, gravada com o ponto e exibida aos gestores no relatório de suspeitas.
+package risco
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/geofence"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
+	"github.com/umahmood/haversine"
+	"gorm.io/gorm"
+)
+
+// Indícios de localização falsificada.
+const (
+	SinalSemPrecisao          = "SEM_PRECISAO"
+	SinalPrecisaoBaixa        = "PRECISAO_BAIXA"
+	SinalVelocidadeImpossivel = "VELOCIDADE_IMPOSSIVEL"
+	SinalCoordenadaRepetida   = "COORDENADA_REPETIDA"
+	SinalCoordenadaNula       = "COORDENADA_NULA"
+	SinalForaDaArea           = "FORA_DA_AREA_PERMITIDA"
+	// SinalRelogioDivergente marca batidas offline enviadas por um aparelho com o relógio fora da
+	// hora do servidor: o horário informado da batida não é confiável.
+	SinalRelogioDivergente = "RELOGIO_DIVERGENTE"
+)
+
+// pesos é quanto cada indício soma à pontuação.
+var pesos = map[string]int{
+	SinalSemPrecisao:          10,
+	SinalPrecisaoBaixa:        25,
+	SinalVelocidadeImpossivel: 40,
+	SinalCoordenadaRepetida:   30,
+	SinalCoordenadaNula:       60,
+	SinalForaDaArea:           40,
+	SinalRelogioDivergente:    30,
+}
+
+const (
+	// pontosComparados é quantas batidas anteriores são procuradas com coordenadas idênticas.
+	pontosComparados = 5
+	// deslocamentoMinimoKm evita acusar velocidade impossível por causa do ruído do GPS entre
+	// batidas muito próximas no tempo.
+	deslocamentoMinimoKm = 1.0
+	// DesvioRelogioTolerado é a diferença entre o relógio do aparelho e o do servidor aceita sem
+	// indício de manipulação.
+	DesvioRelogioTolerado = 5 * time.Minute
+)
+
+// areaBrasil é o retângulo que envolve o território brasileiro, ilhas oceânicas incluídas. É
+// aproximado de propósito: pega pontos obviamente fora do país, não fronteiras.
+const areaBrasil = `{"type":"Polygon","coordinates":[[[-74.0,-34.0],[-28.5,-34.0],[-28.5,5.5],[-74.0,5.5],[-74.0,-34.0]]]}`
+
+var (
+	ErrRiscoAlto            = errors.New("batida recusada: a localização informada não parece confiável")
+	ErrConfiguracaoInvalida = errors.New("configuração de risco inválida")
+	ErrPeriodoInvalido      = errors.New("período inválido: 'de' deve ser anterior a 'ate'")
+)
+
+// ConfiguracaoPadrao são os limites usados enquanto a empresa não grava os seus.
+func ConfiguracaoPadrao(empresaID uint) model.ConfiguracaoRisco {
+	return model.ConfiguracaoRisco{
+		EmpresaID:            empresaID,
+		PrecisaoMaximaMetros: 100,
+		VelocidadeMaximaKmh:  300,
+		LimiteRelatorio:      40,
+	}
+}
+
+// Avaliacao é o resultado da análise de uma batida.
+type Avaliacao struct {
+	Pontuacao int
+	Sinais    []string
+	// Bloquear indica que a pontuação atingiu o limite de bloqueio da empresa.
+	Bloquear bool
+}
+
+// SinaisJSON serializa os sinais para a coluna do ponto.
+func (a Avaliacao) SinaisJSON() model.JSONB {
+	if len(a.Sinais) == 0 {
+		return nil
+	}
+	dados, _ := json.Marshal(a.Sinais)
+	return dados
+}
+
+// Avaliador analisa a localização de uma batida antes de ela ser gravada.
+type Avaliador interface {
+	// desvioRelogio é a diferença medida entre o relógio do aparelho e o do servidor; só as batidas
+	// offline a informam.
+	Avaliar(usuario *model.Usuario, latitude, longitude float64, precisaoMetros *float64, momento time.Time, desvioRelogio time.Duration) (Avaliacao, error)
+}
+
+// PontoSuspeito é uma linha do relatório de suspeitas.
+type PontoSuspeito struct {
+	model.RegistroPonto
+	UsuarioNome string `json:"usuario_nome"`
+}
+
+type RiscoService interface {
+	Avaliador
+	BuscarConfiguracao(empresaID uint) (*model.ConfiguracaoRisco, error)
+	SalvarConfiguracao(configuracao *model.ConfiguracaoRisco) error
+	// Relatorio lista as batidas do período com pontuação a partir do mínimo (zero usa o limite de
+	// relatório da empresa), só dos funcionários no escopo de REVISAR_PONTOS do requisitante.
+	Relatorio(requisitante *model.Usuario, de time.Time, ate time.Time, pontuacaoMinima int) ([]PontoSuspeito, error)
+}
+
+type riscoService struct {
+	repo           RiscoRepository
+	usuarioService usuario.UsuarioService
+}
+
+func NewRiscoService(repo RiscoRepository, usuarioService usuario.UsuarioService) RiscoService {
+	return &riscoService{repo: repo, usuarioService: usuarioService}
+}
+
+func (s *riscoService) BuscarConfiguracao(empresaID uint) (*model.ConfiguracaoRisco, error) {
+	configuracao, err := s.repo.FindConfiguracao(empresaID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		padrao := ConfiguracaoPadrao(empresaID)
+		return &padrao, nil
+	}
+	return configuracao, err
+}
+
+// SalvarConfiguracao cria ou substitui a configuração da empresa.
+func (s *riscoService) SalvarConfiguracao(configuracao *model.ConfiguracaoRisco) error {
+	if configuracao.PrecisaoMaximaMetros <= 0 || configuracao.VelocidadeMaximaKmh <= 0 {
+		return fmt.Errorf("%w: precisão e velocidade máximas devem ser maiores que zero", ErrConfiguracaoInvalida)
+	}
+	if configuracao.LimiteRelatorio < 1 || configuracao.LimiteRelatorio > 100 || configuracao.LimiteBloqueio < 0 || configuracao.LimiteBloqueio > 100 {
+		return fmt.Errorf("%w: o limite do relatório vai de 1 a 100 e o de bloqueio de 0 (desligado) a 100", ErrConfiguracaoInvalida)
+	}
+	if len(configuracao.AreaPermitida) > 0 {
+		area, err := geofence.Parse(configuracao.AreaPermitida, 0)
+		if err != nil {
+			return fmt.Errorf("%w: área permitida: %v", ErrConfiguracaoInvalida, err)
+		}
+		if area.Tipo != geofence.TipoPoligono {
+			return fmt.Errorf("%w: a área permitida deve ser um Polygon", ErrConfiguracaoInvalida)
+		}
+	}
+
+	existente, err := s.repo.FindConfiguracao(configuracao.EmpresaID)
+	switch {
+	case err == nil:
+		configuracao.ID = existente.ID
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	return s.repo.SaveConfiguracao(configuracao)
+}
+
+func (s *riscoService) Avaliar(usuario *model.Usuario, latitude, longitude float64, precisaoMetros *float64, momento time.Time, desvioRelogio time.Duration) (Avaliacao, error) {
+	configuracao, err := s.BuscarConfiguracao(usuario.EmpresaID)
+	if err != nil {
+		return Avaliacao{}, err
+	}
+	anteriores, err := s.repo.UltimosPontos(usuario.ID, usuario.EmpresaID, pontosComparados)
+	if err != nil {
+		return Avaliacao{}, err
+	}
+
+	var sinais []string
+	switch {
+	case precisaoMetros == nil:
+		sinais = append(sinais, SinalSemPrecisao)
+	case *precisaoMetros > configuracao.PrecisaoMaximaMetros:
+		sinais = append(sinais, SinalPrecisaoBaixa)
+	}
+
+	nula := latitude == 0 && longitude == 0
+	if nula {
+		sinais = append(sinais, SinalCoordenadaNula)
+	} else {
+		geojson := []byte(areaBrasil)
+		if len(configuracao.AreaPermitida) > 0 {
+			geojson = configuracao.AreaPermitida
+		}
+		// A área foi validada ao salvar; se ainda assim estiver ilegível, o indício é ignorado.
+		if area, err := geofence.Parse(geojson, 0); err == nil && !area.Contem(geofence.Ponto{Lat: latitude, Lon: longitude}) {
+			sinais = append(sinais, SinalForaDaArea)
+		}
+
+		for _, anterior := range anteriores {
+			if anterior.Latitude == latitude && anterior.Longitude == longitude {
+				sinais = append(sinais, SinalCoordenadaRepetida)
+				break
+			}
+		}
+	}
+
+	// Pontos anonimizados ficam com (0,0) e não servem de referência de deslocamento.
+	if len(anteriores) > 0 && !nula && (anteriores[0].Latitude != 0 || anteriores[0].Longitude != 0) {
+		anterior := anteriores[0]
+		_, km := haversine.Distance(haversine.Coord{Lat: anterior.Latitude, Lon: anterior.Longitude}, haversine.Coord{Lat: latitude, Lon: longitude})
+		// Batidas offline chegam depois de outras mais novas: vale o intervalo em qualquer sentido.
+		horas := math.Abs(momento.Sub(anterior.Timestamp).Hours())
+		if km > deslocamentoMinimoKm && (horas <= 0 || km/horas > configuracao.VelocidadeMaximaKmh) {
+			sinais = append(sinais, SinalVelocidadeImpossivel)
+		}
+	}
+
+	if desvioRelogio > DesvioRelogioTolerado || desvioRelogio < -DesvioRelogioTolerado {
+		sinais = append(sinais, SinalRelogioDivergente)
+	}
+
+	avaliacao := Avaliacao{Sinais: sinais}
+	for _, sinal := range sinais {
+		avaliacao.Pontuacao += pesos[sinal]
+	}
+	if avaliacao.Pontuacao > 100 {
+		avaliacao.Pontuacao = 100
+	}
+	avaliacao.Bloquear = configuracao.LimiteBloqueio > 0 && avaliacao.Pontuacao >= configuracao.LimiteBloqueio
+	return avaliacao, nil
+}
+
+func (s *riscoService) Relatorio(requisitante *model.Usuario, de time.Time, ate time.Time, pontuacaoMinima int) ([]PontoSuspeito, error) {
+	if !de.Before(ate) {
+		return nil, ErrPeriodoInvalido
+	}
+	if pontuacaoMinima < 1 {
+		configuracao, err := s.BuscarConfiguracao(requisitante.EmpresaID)
+		if err != nil {
+			return nil, err
+		}
+		pontuacaoMinima = configuracao.LimiteRelatorio
+	}
+	pontos, err := s.repo.Suspeitos(requisitante.EmpresaID, de, ate, pontuacaoMinima)
+	if err != nil {
+		return nil, err
+	}
+
+	usuarios := make([]model.Usuario, 0)
+	vistos := make(map[uint]bool)
+	for _, p := range pontos {
+		if p.Usuario.ID != 0 && !vistos[p.Usuario.ID] {
+			vistos[p.Usuario.ID] = true
+			usuarios = append(usuarios, p.Usuario)
+		}
+	}
+	visiveis, err := s.usuarioService.FiltrarPorEscopo(requisitante, permissions.REVISAR_PONTOS, usuarios)
+	if err != nil {
+		return nil, err
+	}
+	permitidos := make(map[uint]bool, len(visiveis))
+	for _, u := range visiveis {
+		permitidos[u.ID] = true
+	}
+
+	suspeitos := make([]PontoSuspeito, 0)
+	for _, p := range pontos {
+		if permitidos[p.UsuarioID] {
+			suspeitos = append(suspeitos, PontoSuspeito{RegistroPonto: p, UsuarioNome: p.Usuario.Nome})
+		}
+	}
+	return suspeitos, nil
+}
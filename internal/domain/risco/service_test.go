@@ -0,0 +1,236 @@
+package risco
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+type memoriaRiscoRepository struct {
+	configuracao *model.ConfiguracaoRisco
+	// pontos ficam do mais novo ao mais antigo, como devolve UltimosPontos.
+	pontos []model.RegistroPonto
+}
+
+func (m *memoriaRiscoRepository) FindConfiguracao(empresaID uint) (*model.ConfiguracaoRisco, error) {
+	if m.configuracao == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copia := *m.configuracao
+	return &copia, nil
+}
+
+func (m *memoriaRiscoRepository) SaveConfiguracao(configuracao *model.ConfiguracaoRisco) error {
+	if configuracao.ID == 0 {
+		configuracao.ID = 1
+	}
+	copia := *configuracao
+	m.configuracao = &copia
+	return nil
+}
+
+func (m *memoriaRiscoRepository) UltimosPontos(usuarioID uint, empresaID uint, limite int) ([]model.RegistroPonto, error) {
+	if len(m.pontos) > limite {
+		return m.pontos[:limite], nil
+	}
+	return m.pontos, nil
+}
+
+func (m *memoriaRiscoRepository) Suspeitos(empresaID uint, de time.Time, ate time.Time, pontuacaoMinima int) ([]model.RegistroPonto, error) {
+	var suspeitos []model.RegistroPonto
+	for _, p := range m.pontos {
+		if p.RiscoPontuacao >= pontuacaoMinima && !p.Timestamp.Before(de) && !p.Timestamp.After(ate) {
+			suspeitos = append(suspeitos, p)
+		}
+	}
+	return suspeitos, nil
+}
+
+// mockUsuarioService põe no escopo do gestor só os funcionários da sua equipe.
+type mockUsuarioService struct {
+	usuario.UsuarioService
+	equipe map[uint]bool
+}
+
+func (m *mockUsuarioService) FiltrarPorEscopo(requisitante *model.Usuario, permissao string, usuarios []model.Usuario) ([]model.Usuario, error) {
+	var visiveis []model.Usuario
+	for _, u := range usuarios {
+		if m.equipe[u.ID] {
+			visiveis = append(visiveis, u)
+		}
+	}
+	return visiveis, nil
+}
+
+var (
+	funcionario = &model.Usuario{ID: 7, EmpresaID: 1}
+	// Avenida Paulista, São Paulo.
+	paulistaLat, paulistaLon = -23.5613, -46.6565
+	momento                  = time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+)
+
+func precisao(metros float64) *float64 {
+	return &metros
+}
+
+func temSinal(avaliacao Avaliacao, sinal string) bool {
+	for _, s := range avaliacao.Sinais {
+		if s == sinal {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAvaliar_BatidaPlausivelNaoPontua(t *testing.T) {
+	repo := &memoriaRiscoRepository{pontos: []model.RegistroPonto{
+		{Latitude: -23.5600, Longitude: -46.6500, Timestamp: momento.Add(-4 * time.Hour)},
+	}}
+	avaliacao, err := NewRiscoService(repo, nil).Avaliar(funcionario, paulistaLat, paulistaLon, precisao(15), momento, 0)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if avaliacao.Pontuacao != 0 || len(avaliacao.Sinais) != 0 {
+		t.Errorf("Esperava pontuação zero, recebeu %+v", avaliacao)
+	}
+}
+
+func TestAvaliar_Sinais(t *testing.T) {
+	casos := []struct {
+		nome       string
+		lat, lon   float64
+		precisao   *float64
+		anteriores []model.RegistroPonto
+		sinal      string
+	}{
+		{"sem precisão", paulistaLat, paulistaLon, nil, nil, SinalSemPrecisao},
+		{"precisão baixa", paulistaLat, paulistaLon, precisao(1500), nil, SinalPrecisaoBaixa},
+		{"coordenada nula", 0, 0, precisao(5), nil, SinalCoordenadaNula},
+		{"fora do Brasil (Lisboa)", 38.7223, -9.1393, precisao(5), nil, SinalForaDaArea},
+		{"coordenada idêntica a uma batida anterior", paulistaLat, paulistaLon, precisao(5), []model.RegistroPonto{
+			{Latitude: -23.5600, Longitude: -46.6500, Timestamp: momento.Add(-1 * time.Hour)},
+			{Latitude: paulistaLat, Longitude: paulistaLon, Timestamp: momento.Add(-24 * time.Hour)},
+		}, SinalCoordenadaRepetida},
+		// Rio de Janeiro fica a ~360 km: em meia hora seriam ~720 km/h.
+		{"velocidade impossível", paulistaLat, paulistaLon, precisao(5), []model.RegistroPonto{
+			{Latitude: -22.9068, Longitude: -43.1729, Timestamp: momento.Add(-30 * time.Minute)},
+		}, SinalVelocidadeImpossivel},
+	}
+	for _, caso := range casos {
+		repo := &memoriaRiscoRepository{pontos: caso.anteriores}
+		avaliacao, err := NewRiscoService(repo, nil).Avaliar(funcionario, caso.lat, caso.lon, caso.precisao, momento, 0)
+		if err != nil {
+			t.Fatalf("%s: erro inesperado: %v", caso.nome, err)
+		}
+		if !temSinal(avaliacao, caso.sinal) || avaliacao.Pontuacao != pesos[caso.sinal] {
+			t.Errorf("%s: esperava só o sinal %s, recebeu %+v", caso.nome, caso.sinal, avaliacao)
+		}
+	}
+}
+
+func TestAvaliar_ViagemLongaComTempoSuficienteNaoPontua(t *testing.T) {
+	repo := &memoriaRiscoRepository{pontos: []model.RegistroPonto{
+		{Latitude: -22.9068, Longitude: -43.1729, Timestamp: momento.Add(-6 * time.Hour)},
+	}}
+	avaliacao, _ := NewRiscoService(repo, nil).Avaliar(funcionario, paulistaLat, paulistaLon, precisao(5), momento, 0)
+	if temSinal(avaliacao, SinalVelocidadeImpossivel) {
+		t.Errorf("360 km em 6 horas é plausível, recebeu %+v", avaliacao)
+	}
+}
+
+func TestAvaliar_RelogioDivergente(t *testing.T) {
+	service := NewRiscoService(&memoriaRiscoRepository{}, nil)
+	if avaliacao, _ := service.Avaliar(funcionario, paulistaLat, paulistaLon, precisao(5), momento, -2*time.Minute); temSinal(avaliacao, SinalRelogioDivergente) {
+		t.Errorf("Dois minutos de diferença estão dentro da tolerância, recebeu %+v", avaliacao)
+	}
+	if avaliacao, _ := service.Avaliar(funcionario, paulistaLat, paulistaLon, precisao(5), momento, -time.Hour); !temSinal(avaliacao, SinalRelogioDivergente) {
+		t.Errorf("Um relógio uma hora adiantado deveria pontuar, recebeu %+v", avaliacao)
+	}
+}
+
+func TestAvaliar_LimiteDeBloqueio(t *testing.T) {
+	configuracao := ConfiguracaoPadrao(1)
+	configuracao.LimiteBloqueio = 60
+	repo := &memoriaRiscoRepository{configuracao: &configuracao}
+	service := NewRiscoService(repo, nil)
+
+	avaliacao, _ := service.Avaliar(funcionario, 0, 0, nil, momento, 0)
+	if avaliacao.Pontuacao != 70 || !avaliacao.Bloquear {
+		t.Errorf("Coordenada nula sem precisão deveria ser bloqueada com 70 pontos, recebeu %+v", avaliacao)
+	}
+	avaliacao, _ = service.Avaliar(funcionario, paulistaLat, paulistaLon, nil, momento, 0)
+	if avaliacao.Bloquear {
+		t.Errorf("Uma batida abaixo do limite não deveria ser bloqueada, recebeu %+v", avaliacao)
+	}
+}
+
+func TestAvaliar_AreaPermitidaDaEmpresa(t *testing.T) {
+	configuracao := ConfiguracaoPadrao(1)
+	// Uma área em Portugal: Lisboa passa a ser permitida e São Paulo não.
+	configuracao.AreaPermitida = model.JSONB(`{"type":"Polygon","coordinates":[[[-9.6,36.9],[-6.1,36.9],[-6.1,42.2],[-9.6,42.2],[-9.6,36.9]]]}`)
+	service := NewRiscoService(&memoriaRiscoRepository{configuracao: &configuracao}, nil)
+
+	if avaliacao, _ := service.Avaliar(funcionario, 38.7223, -9.1393, precisao(5), momento, 0); temSinal(avaliacao, SinalForaDaArea) {
+		t.Errorf("Lisboa está dentro da área da empresa, recebeu %+v", avaliacao)
+	}
+	if avaliacao, _ := service.Avaliar(funcionario, paulistaLat, paulistaLon, precisao(5), momento, 0); !temSinal(avaliacao, SinalForaDaArea) {
+		t.Errorf("São Paulo está fora da área da empresa, recebeu %+v", avaliacao)
+	}
+}
+
+func TestSalvarConfiguracao_ValidaESubstitui(t *testing.T) {
+	repo := &memoriaRiscoRepository{}
+	service := NewRiscoService(repo, nil)
+
+	invalidas := []model.ConfiguracaoRisco{
+		{EmpresaID: 1, PrecisaoMaximaMetros: 0, VelocidadeMaximaKmh: 300, LimiteRelatorio: 40},
+		{EmpresaID: 1, PrecisaoMaximaMetros: 100, VelocidadeMaximaKmh: 300, LimiteRelatorio: 101},
+		{EmpresaID: 1, PrecisaoMaximaMetros: 100, VelocidadeMaximaKmh: 300, LimiteRelatorio: 40, LimiteBloqueio: -1},
+		{EmpresaID: 1, PrecisaoMaximaMetros: 100, VelocidadeMaximaKmh: 300, LimiteRelatorio: 40,
+			AreaPermitida: model.JSONB(`{"type":"Point","coordinates":[-46.6,-23.5]}`)},
+	}
+	for _, configuracao := range invalidas {
+		if err := service.SalvarConfiguracao(&configuracao); !errors.Is(err, ErrConfiguracaoInvalida) {
+			t.Errorf("Esperava ErrConfiguracaoInvalida para %+v, recebeu %v", configuracao, err)
+		}
+	}
+
+	primeira := ConfiguracaoPadrao(1)
+	if err := service.SalvarConfiguracao(&primeira); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	segunda := ConfiguracaoPadrao(1)
+	segunda.LimiteBloqueio = 80
+	if err := service.SalvarConfiguracao(&segunda); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if segunda.ID != primeira.ID || repo.configuracao.LimiteBloqueio != 80 {
+		t.Errorf("A segunda gravação deveria substituir a primeira, recebeu %+v", repo.configuracao)
+	}
+}
+
+func TestRelatorio_SoDaEquipeEAcimaDoLimite(t *testing.T) {
+	repo := &memoriaRiscoRepository{pontos: []model.RegistroPonto{
+		{ID: 1, UsuarioID: 7, RiscoPontuacao: 70, Timestamp: momento, Usuario: model.Usuario{ID: 7, Nome: "Ana"}},
+		{ID: 2, UsuarioID: 7, RiscoPontuacao: 10, Timestamp: momento, Usuario: model.Usuario{ID: 7, Nome: "Ana"}},
+		{ID: 3, UsuarioID: 9, RiscoPontuacao: 90, Timestamp: momento, Usuario: model.Usuario{ID: 9, Nome: "Bruno"}},
+	}}
+	service := NewRiscoService(repo, &mockUsuarioService{equipe: map[uint]bool{7: true}})
+	gestor := &model.Usuario{ID: 2, EmpresaID: 1}
+
+	suspeitos, err := service.Relatorio(gestor, momento.Add(-time.Hour), momento.Add(time.Hour), 0)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(suspeitos) != 1 || suspeitos[0].ID != 1 || suspeitos[0].UsuarioNome != "Ana" {
+		t.Errorf("Esperava só o ponto de 70 pontos da Ana, recebeu %+v", suspeitos)
+	}
+
+	if _, err := service.Relatorio(gestor, momento, momento.Add(-time.Hour), 0); !errors.Is(err, ErrPeriodoInvalido) {
+		t.Errorf("Esperava ErrPeriodoInvalido, recebeu %v", err)
+	}
+}
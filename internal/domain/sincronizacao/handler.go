@@ -0,0 +1,84 @@
+package sincronizacao
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service   SincronizacaoService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s SincronizacaoService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+type batidaOfflineRequest struct {
+	ID             string    `json:"id" binding:"required"`
+	Timestamp      time.Time `json:"timestamp" binding:"required"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	PrecisaoMetros *float64  `json:"precisao_metros" binding:"omitempty,gte=0"`
+	Justificativa  string    `json:"justificativa"`
+}
+
+// Sincronizar recebe o lote de batidas que o aplicativo guardou sem conexão. Responde 200 mesmo
+// quando algumas batidas são recusadas: a situação de cada uma vem nos resultados.
+func (h *Handler) Sincronizar(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	usuarioID, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários podem bater ponto."})
+		return
+	}
+
+	var req struct {
+		EnviadoEm time.Time              `json:"enviado_em" binding:"required"`
+		Batidas   []batidaOfflineRequest `json:"batidas" binding:"required,dive"`
+		// Dispositivo é o aparelho que guardou as batidas, o mesmo para todo o lote.
+		Dispositivo     string `json:"dispositivo"`
+		DispositivoNome string `json:"dispositivo_nome"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O corpo da requisição é inválido. Envie 'enviado_em' e 'batidas', cada uma com 'id' e 'timestamp'."})
+		return
+	}
+
+	itens := make([]Item, len(req.Batidas))
+	for i, b := range req.Batidas {
+		itens[i] = Item{
+			Chave:           b.ID,
+			Momento:         b.Timestamp,
+			Latitude:        b.Latitude,
+			Longitude:       b.Longitude,
+			PrecisaoMetros:  b.PrecisaoMetros,
+			Justificativa:   b.Justificativa,
+			Dispositivo:     req.Dispositivo,
+			DispositivoNome: req.DispositivoNome,
+		}
+	}
+
+	resultados, err := h.service.Sincronizar(usuarioID, empresaID, req.EnviadoEm, itens)
+	if err != nil {
+		if errors.Is(err, ErrLoteInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ponto.ResponderErroBatida(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"resultados": resultados})
+}
@@ -0,0 +1,28 @@
+package sincronizacao
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type SincronizacaoRepository interface {
+	// FindByChave busca o ponto já gravado com a chave de idempotência do usuário.
+	FindByChave(usuarioID uint, empresaID uint, chave string) (*model.RegistroPonto, error)
+}
+
+type sincronizacaoRepository struct {
+	Db *gorm.DB
+}
+
+func NewSincronizacaoRepository(db *gorm.DB) SincronizacaoRepository {
+	return &sincronizacaoRepository{Db: db}
+}
+
+func (r *sincronizacaoRepository) FindByChave(usuarioID uint, empresaID uint, chave string) (*model.RegistroPonto, error) {
+	var ponto model.RegistroPonto
+	err := tenant.Escopo(r.Db, empresaID).
+		Where("usuario_id = ? AND chave_idempotencia = ?", usuarioID, chave).
+		First(&ponto).Error
+	return &ponto, err
+}
@@ -0,0 +1,191 @@
+// Package sincronizacao recebe as batidas que o aplicativo guardou enquanto estava sem conexão.
+// Cada batida traz um UUID gerado no aparelho: reenviar o lote depois de uma falha não duplica
+// pontos. As batidas passam pelas mesmas validações de uma batida ao vivo, com o horário do
+// aparelho no lugar da hora do servidor. Como esse horário não pode ser conferido, as batidas de dias
+// já fechados, as sincronizadas tarde e as de relógios fora da hora aguardam a revisão de um gestor
+// antes de contar no banco de horas.
+package sincronizacao
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/bancohoras"
+	"github.com/Loviiin/ponto-api-go/internal/domain/dispositivo"
+	"github.com/Loviiin/ponto-api-go/internal/domain/politica"
+	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
+	"github.com/Loviiin/ponto-api-go/internal/domain/risco"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+// MaximoPorLote é quantas batidas um envio pode trazer.
+const MaximoPorLote = 100
+
+// Situação de cada batida do lote.
+const (
+	SituacaoRegistrada = "REGISTRADA"
+	// SituacaoDuplicada indica que a chave já tinha sido sincronizada; o ponto devolvido é o original.
+	SituacaoDuplicada = "DUPLICADA"
+	// SituacaoRecusada indica que a batida não passou nas validações; reenviá-la não muda o resultado.
+	SituacaoRecusada = "RECUSADA"
+)
+
+var (
+	ErrLoteInvalido  = fmt.Errorf("o lote deve ter de 1 a %d batidas", MaximoPorLote)
+	ErrChaveInvalida = errors.New("o 'id' da batida deve ser um UUID")
+)
+
+var formatoUUID = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+var agora = time.Now
+
+// Item é uma batida feita sem conexão, como o aplicativo a guardou.
+type Item struct {
+	Chave          string
+	Momento        time.Time
+	Latitude       float64
+	Longitude      float64
+	PrecisaoMetros *float64
+	Justificativa  string
+	// Dispositivo e DispositivoNome identificam o aparelho que guardou a batida.
+	Dispositivo     string
+	DispositivoNome string
+}
+
+// Resultado informa o que aconteceu com uma batida do lote.
+type Resultado struct {
+	Chave    string               `json:"id"`
+	Situacao string               `json:"situacao"`
+	Ponto    *model.RegistroPonto `json:"ponto,omitempty"`
+	Erro     string               `json:"erro,omitempty"`
+}
+
+type SincronizacaoService interface {
+	// Sincronizar grava as batidas do lote e devolve um resultado para cada uma, na ordem recebida.
+	// enviadoEm é a hora do aparelho no envio, usada para estimar o desvio do seu relógio. Recusas de
+	// batidas individuais vêm nos resultados; um erro devolvido significa que o lote pode ser
+	// reenviado por inteiro.
+	Sincronizar(usuarioID uint, empresaID uint, enviadoEm time.Time, itens []Item) ([]Resultado, error)
+}
+
+type sincronizacaoService struct {
+	repo       SincronizacaoRepository
+	pontos     ponto.PontoService
+	bancoHoras bancohoras.BancoHorasService
+}
+
+func NewSincronizacaoService(repo SincronizacaoRepository, pontos ponto.PontoService, bancoHoras bancohoras.BancoHorasService) SincronizacaoService {
+	return &sincronizacaoService{
+		repo:       repo,
+		pontos:     pontos,
+		bancoHoras: bancoHoras,
+	}
+}
+
+func (s *sincronizacaoService) Sincronizar(usuarioID uint, empresaID uint, enviadoEm time.Time, itens []Item) ([]Resultado, error) {
+	if len(itens) == 0 || len(itens) > MaximoPorLote {
+		return nil, ErrLoteInvalido
+	}
+	recebidoEm := agora()
+	desvio := recebidoEm.Sub(enviadoEm)
+
+	// As batidas são gravadas em ordem cronológica, como teriam sido ao vivo: a análise de risco
+	// compara cada uma com a anterior.
+	ordem := make([]int, len(itens))
+	for i := range itens {
+		ordem[i] = i
+	}
+	sort.SliceStable(ordem, func(a, b int) bool { return itens[ordem[a]].Momento.Before(itens[ordem[b]].Momento) })
+
+	resultados := make([]Resultado, len(itens))
+	for _, i := range ordem {
+		resultado, err := s.sincronizarItem(usuarioID, empresaID, itens[i], recebidoEm, desvio)
+		if err != nil {
+			return nil, err
+		}
+		resultados[i] = resultado
+	}
+	return resultados, nil
+}
+
+func (s *sincronizacaoService) sincronizarItem(usuarioID uint, empresaID uint, item Item, recebidoEm time.Time, desvio time.Duration) (Resultado, error) {
+	chave := strings.ToLower(strings.TrimSpace(item.Chave))
+	resultado := Resultado{Chave: item.Chave}
+	if !formatoUUID.MatchString(chave) {
+		resultado.Situacao = SituacaoRecusada
+		resultado.Erro = ErrChaveInvalida.Error()
+		return resultado, nil
+	}
+
+	existente, err := s.repo.FindByChave(usuarioID, empresaID, chave)
+	if err == nil {
+		resultado.Situacao = SituacaoDuplicada
+		resultado.Ponto = existente
+		return resultado, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Resultado{}, err
+	}
+
+	// Uma batida de um dia já fechado mudaria o saldo que o fechamento somou ao banco de horas: ela
+	// vai para a revisão, e o ajuste do dia só acontece se um gestor a aprovar.
+	fechado, err := s.bancoHoras.DiaEstaFechado(empresaID, item.Momento)
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	registrado, err := s.pontos.BaterPonto(usuarioID, empresaID, ponto.Batida{
+		Latitude:        item.Latitude,
+		Longitude:       item.Longitude,
+		PrecisaoMetros:  item.PrecisaoMetros,
+		Justificativa:   item.Justificativa,
+		Dispositivo:     item.Dispositivo,
+		DispositivoNome: item.DispositivoNome,
+		Offline: &ponto.Offline{
+			Chave:         chave,
+			Momento:       item.Momento,
+			RecebidoEm:    recebidoEm,
+			DesvioRelogio: desvio,
+			DiaFechado:    fechado,
+		},
+	})
+	if err != nil {
+		if recusada(err) {
+			resultado.Situacao = SituacaoRecusada
+			resultado.Erro = err.Error()
+			return resultado, nil
+		}
+		// Dois envios simultâneos do mesmo lote: o outro gravou a chave primeiro.
+		if existente, errBusca := s.repo.FindByChave(usuarioID, empresaID, chave); errBusca == nil {
+			resultado.Situacao = SituacaoDuplicada
+			resultado.Ponto = existente
+			return resultado, nil
+		}
+		return Resultado{}, err
+	}
+
+	resultado.Situacao = SituacaoRegistrada
+	resultado.Ponto = registrado
+	return resultado, nil
+}
+
+// recusada separa as batidas que as regras recusam das falhas que justificam reenviar o lote.
+func recusada(err error) bool {
+	var intervalo *ponto.IntervaloMinimoError
+	var naoAutorizado *dispositivo.NaoAutorizadoError
+	return errors.As(err, &intervalo) ||
+		errors.As(err, &naoAutorizado) ||
+		errors.Is(err, dispositivo.ErrDispositivoObrigatorio) ||
+		errors.Is(err, dispositivo.ErrIdentificadorInvalido) ||
+		errors.Is(err, ponto.ErrBatidaRemotaBloqueada) ||
+		errors.Is(err, ponto.ErrJustificativaObrigatoria) ||
+		errors.Is(err, ponto.ErrBatidaNoFuturo) ||
+		errors.Is(err, ponto.ErrBatidaOfflineAntiga) ||
+		errors.Is(err, risco.ErrRiscoAlto) ||
+		errors.Is(err, politica.ErrNegadoPorPolitica)
+}
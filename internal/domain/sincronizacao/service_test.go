@@ -0,0 +1,207 @@
+package sincronizacao
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/bancohoras"
+	"github.com/Loviiin/ponto-api-go/internal/domain/dispositivo"
+	"github.com/Loviiin/ponto-api-go/internal/domain/ponto"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"gorm.io/gorm"
+)
+
+type memoriaSincronizacaoRepository struct {
+	pontos []model.RegistroPonto
+}
+
+func (m *memoriaSincronizacaoRepository) FindByChave(usuarioID uint, empresaID uint, chave string) (*model.RegistroPonto, error) {
+	for _, p := range m.pontos {
+		if p.UsuarioID == usuarioID && p.EmpresaID == empresaID && p.ChaveIdempotencia != nil && *p.ChaveIdempotencia == chave {
+			copia := p
+			return &copia, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// mockPontoService grava as batidas no repositório em memória; remotas sem justificativa são
+// recusadas, como pela regra REVISAR, e as do aparelho "nao-autorizado" também.
+type mockPontoService struct {
+	ponto.PontoService
+	repo    *memoriaSincronizacaoRepository
+	batidas []ponto.Batida
+	// concorrente simula outro envio que grava a mesma chave antes deste.
+	concorrente bool
+}
+
+func (m *mockPontoService) BaterPonto(usuarioID uint, empresaID uint, batida ponto.Batida) (*model.RegistroPonto, error) {
+	m.batidas = append(m.batidas, batida)
+	if batida.Dispositivo == "nao-autorizado" {
+		return nil, &dispositivo.NaoAutorizadoError{Dispositivo: &model.Dispositivo{Status: model.DispositivoPendente}}
+	}
+	if batida.Latitude == 0 && batida.Justificativa == "" {
+		return nil, ponto.ErrJustificativaObrigatoria
+	}
+	for _, p := range m.repo.pontos {
+		if distancia := p.Timestamp.Sub(batida.Offline.Momento); distancia > -time.Minute && distancia < time.Minute {
+			return nil, &ponto.IntervaloMinimoError{Anterior: p.Timestamp, IntervaloMinimo: time.Minute}
+		}
+	}
+	chave := batida.Offline.Chave
+	registro := model.RegistroPonto{ID: uint(len(m.repo.pontos) + 1), UsuarioID: usuarioID, EmpresaID: empresaID, Timestamp: batida.Offline.Momento, ChaveIdempotencia: &chave}
+	m.repo.pontos = append(m.repo.pontos, registro)
+	if m.concorrente {
+		return nil, errors.New("duplicate key value violates unique constraint")
+	}
+	return &registro, nil
+}
+
+type mockBancoHoras struct {
+	bancohoras.BancoHorasService
+	ajustes []time.Time
+}
+
+func (m *mockBancoHoras) DiaEstaFechado(empresaID uint, dia time.Time) (bool, error) {
+	return bancohoras.DiaFechado(dia, recebidoEm), nil
+}
+
+func (m *mockBancoHoras) AjustarDiaFechado(usuarioID uint, empresaID uint, dia time.Time, saldoAnterior int) error {
+	m.ajustes = append(m.ajustes, dia)
+	return nil
+}
+
+var recebidoEm = time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+
+func novoServico(t *testing.T) (*memoriaSincronizacaoRepository, *mockPontoService, *mockBancoHoras, SincronizacaoService) {
+	t.Helper()
+	agora = func() time.Time { return recebidoEm }
+	t.Cleanup(func() { agora = time.Now })
+	repo := &memoriaSincronizacaoRepository{}
+	pontos := &mockPontoService{repo: repo}
+	banco := &mockBancoHoras{}
+	return repo, pontos, banco, NewSincronizacaoService(repo, pontos, banco)
+}
+
+func TestSincronizar_ReenvioNaoDuplica(t *testing.T) {
+	repo, _, _, service := novoServico(t)
+	itens := []Item{
+		{Chave: "0f8fad5b-d9cb-469f-a165-70867728950e", Momento: recebidoEm.Add(-2 * time.Hour), Latitude: -23.56, Longitude: -46.65},
+		{Chave: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Momento: recebidoEm.Add(-3 * time.Hour), Latitude: -23.56, Longitude: -46.65},
+	}
+
+	primeiro, err := service.Sincronizar(7, 1, recebidoEm, itens)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	segundo, err := service.Sincronizar(7, 1, recebidoEm, itens)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	if len(repo.pontos) != 2 {
+		t.Fatalf("Esperava 2 pontos gravados, recebeu %d", len(repo.pontos))
+	}
+	for i := range itens {
+		if primeiro[i].Situacao != SituacaoRegistrada || segundo[i].Situacao != SituacaoDuplicada || segundo[i].Ponto.ID != primeiro[i].Ponto.ID {
+			t.Errorf("Batida %d: esperava registrada e depois duplicada do mesmo ponto, recebeu %+v e %+v", i, primeiro[i], segundo[i])
+		}
+	}
+	// A batida mais antiga é gravada primeiro, mas os resultados seguem a ordem do envio.
+	if repo.pontos[0].Timestamp != itens[1].Momento || primeiro[0].Chave != itens[0].Chave {
+		t.Errorf("Esperava gravação em ordem cronológica e resultados na ordem do envio, recebeu %+v", primeiro)
+	}
+}
+
+func TestSincronizar_MedeODesvioDoRelogio(t *testing.T) {
+	_, pontos, _, service := novoServico(t)
+	// O aparelho acha que são 11h quando o servidor recebe às 10h: está uma hora adiantado.
+	_, err := service.Sincronizar(7, 1, recebidoEm.Add(time.Hour), []Item{
+		{Chave: "0F8FAD5B-D9CB-469F-A165-70867728950E", Momento: recebidoEm.Add(-time.Hour), Latitude: -23.56, Longitude: -46.65},
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	offline := pontos.batidas[0].Offline
+	if offline.DesvioRelogio != -time.Hour || !offline.RecebidoEm.Equal(recebidoEm) || offline.Chave != "0f8fad5b-d9cb-469f-a165-70867728950e" {
+		t.Errorf("Batida offline montada incorretamente: %+v", offline)
+	}
+}
+
+func TestSincronizar_RecusasNaoInterrompemOLote(t *testing.T) {
+	repo, _, _, service := novoServico(t)
+	resultados, err := service.Sincronizar(7, 1, recebidoEm, []Item{
+		{Chave: "nao-e-uuid", Momento: recebidoEm.Add(-time.Hour), Latitude: -23.56},
+		{Chave: "0f8fad5b-d9cb-469f-a165-70867728950e", Momento: recebidoEm.Add(-time.Hour)},
+		{Chave: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Momento: recebidoEm.Add(-time.Hour), Latitude: -23.56},
+		// Toque duplo gravado offline: outra chave, segundos depois da batida anterior.
+		{Chave: "16fd2706-8baf-433b-82eb-8c7fada847da", Momento: recebidoEm.Add(-time.Hour + 5*time.Second), Latitude: -23.56},
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if resultados[3].Situacao != SituacaoRecusada || !strings.HasPrefix(resultados[3].Erro, "batida recusada: já existe uma batida") {
+		t.Errorf("A segunda batida dentro do intervalo mínimo deveria ser recusada, recebeu %+v", resultados[3])
+	}
+	if resultados[0].Situacao != SituacaoRecusada || resultados[0].Erro != ErrChaveInvalida.Error() {
+		t.Errorf("Uma chave que não é UUID deveria ser recusada, recebeu %+v", resultados[0])
+	}
+	if resultados[1].Situacao != SituacaoRecusada || resultados[1].Erro != ponto.ErrJustificativaObrigatoria.Error() {
+		t.Errorf("A batida sem justificativa deveria ser recusada, recebeu %+v", resultados[1])
+	}
+	if resultados[2].Situacao != SituacaoRegistrada || len(repo.pontos) != 1 {
+		t.Errorf("A batida válida deveria ser gravada, recebeu %+v", resultados[2])
+	}
+
+	if _, err := service.Sincronizar(7, 1, recebidoEm, nil); !errors.Is(err, ErrLoteInvalido) {
+		t.Errorf("Esperava ErrLoteInvalido para um lote vazio, recebeu %v", err)
+	}
+}
+
+func TestSincronizar_DispositivoNaoAutorizadoERecusado(t *testing.T) {
+	repo, pontos, _, service := novoServico(t)
+	resultados, err := service.Sincronizar(7, 1, recebidoEm, []Item{
+		{Chave: "0f8fad5b-d9cb-469f-a165-70867728950e", Momento: recebidoEm.Add(-time.Hour), Latitude: -23.56, Dispositivo: "nao-autorizado"},
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if resultados[0].Situacao != SituacaoRecusada || len(repo.pontos) != 0 {
+		t.Errorf("A batida de um aparelho não autorizado deveria ser recusada, recebeu %+v", resultados[0])
+	}
+	if pontos.batidas[0].Dispositivo != "nao-autorizado" {
+		t.Errorf("O aparelho do lote deveria chegar à batida, recebeu %q", pontos.batidas[0].Dispositivo)
+	}
+}
+
+func TestSincronizar_EnvioConcorrenteViraDuplicada(t *testing.T) {
+	_, pontos, _, service := novoServico(t)
+	pontos.concorrente = true
+	resultados, err := service.Sincronizar(7, 1, recebidoEm, []Item{
+		{Chave: "0f8fad5b-d9cb-469f-a165-70867728950e", Momento: recebidoEm.Add(-time.Hour), Latitude: -23.56},
+	})
+	if err != nil || resultados[0].Situacao != SituacaoDuplicada {
+		t.Errorf("Esperava a batida como duplicada, recebeu %+v, %v", resultados, err)
+	}
+}
+
+func TestSincronizar_DiaFechadoNaoAjustaOBanco(t *testing.T) {
+	_, pontos, banco, service := novoServico(t)
+	ontem := recebidoEm.AddDate(0, 0, -1)
+	_, err := service.Sincronizar(7, 1, recebidoEm, []Item{
+		{Chave: "0f8fad5b-d9cb-469f-a165-70867728950e", Momento: ontem, Latitude: -23.56},
+		{Chave: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Momento: recebidoEm.Add(-time.Hour), Latitude: -23.56},
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	// A batida do dia fechado vai para a revisão; o banco só muda se um gestor a aprovar.
+	if !pontos.batidas[0].Offline.DiaFechado || pontos.batidas[1].Offline.DiaFechado {
+		t.Errorf("Só a batida de ontem deveria chegar como de dia fechado, recebeu %+v e %+v", pontos.batidas[0].Offline, pontos.batidas[1].Offline)
+	}
+	if len(banco.ajustes) != 0 {
+		t.Errorf("A sincronização não deveria ajustar o banco de horas, ajustou %v", banco.ajustes)
+	}
+}
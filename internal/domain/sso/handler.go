@@ -0,0 +1,152 @@
+package sso
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service   SSOService
+	converter funcoes.FuncoesInterface
+}
+
+func NewHandler(s SSOService, f funcoes.FuncoesInterface) *Handler {
+	return &Handler{
+		service:   s,
+		converter: f,
+	}
+}
+
+// Login redireciona o navegador para o provedor de identidade da empresa.
+func (h *Handler) Login(c *gin.Context) {
+	empresaID, err := h.converter.StrParaUint(c.Param("empresaId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O ID da empresa deve ser um número válido"})
+		return
+	}
+
+	urlAutorizacao, err := h.service.IniciarLogin(empresaID)
+	if err != nil {
+		if errors.Is(err, ErrSSONaoConfigurado) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Falha ao contactar o provedor de identidade."})
+		return
+	}
+	c.Redirect(http.StatusFound, urlAutorizacao)
+}
+
+// Callback recebe o retorno do provedor e emite o token da API.
+func (h *Handler) Callback(c *gin.Context) {
+	if erro := c.Query("error"); erro != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "O provedor recusou o login: " + erro})
+		return
+	}
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Os parâmetros 'state' e 'code' são obrigatórios."})
+		return
+	}
+
+	token, err := h.service.Callback(state, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrSessaoInvalida), errors.Is(err, ErrSSONaoConfigurado):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrDominioNaoPermitido), errors.Is(err, ErrEmailNaoVerificado),
+			errors.Is(err, ErrEmailOutraEmpresa), errors.Is(err, ErrUsuarioNaoCadastrado),
+			errors.Is(err, ErrCargoNaoMapeado):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrEmailIndisponivel):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Falha ao validar o login no provedor de identidade."})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+func (h *Handler) GetConfiguracao(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	configuracao, err := h.service.BuscarConfiguracao(empresaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Login único não configurado."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar a configuração."})
+		return
+	}
+	c.JSON(http.StatusOK, configuracao)
+}
+
+func (h *Handler) SalvarConfiguracao(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	type mapeamentoRequest struct {
+		ValorClaim string `json:"valor_claim" binding:"required"`
+		CargoID    uint   `json:"cargo_id" binding:"required"`
+	}
+	type configuracaoRequest struct {
+		Issuer                    string              `json:"issuer" binding:"required,url"`
+		ClientID                  string              `json:"client_id" binding:"required"`
+		ClientSecret              string              `json:"client_secret"`
+		DominiosPermitidos        string              `json:"dominios_permitidos" binding:"required"`
+		ClaimCargo                string              `json:"claim_cargo"`
+		Mapeamentos               []mapeamentoRequest `json:"mapeamentos" binding:"dive"`
+		CargoPadraoID             *uint               `json:"cargo_padrao_id"`
+		ProvisionamentoAutomatico bool                `json:"provisionamento_automatico"`
+		Ativo                     bool                `json:"ativo"`
+	}
+	var req configuracaoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	configuracao := model.ConfiguracaoOIDC{
+		EmpresaID:                 empresaID,
+		Issuer:                    req.Issuer,
+		ClientID:                  req.ClientID,
+		ClientSecret:              req.ClientSecret,
+		DominiosPermitidos:        req.DominiosPermitidos,
+		ClaimCargo:                req.ClaimCargo,
+		CargoPadraoID:             req.CargoPadraoID,
+		ProvisionamentoAutomatico: req.ProvisionamentoAutomatico,
+		Ativo:                     req.Ativo,
+	}
+	for _, m := range req.Mapeamentos {
+		configuracao.Mapeamentos = append(configuracao.Mapeamentos, model.MapeamentoCargoOIDC{ValorClaim: m.ValorClaim, CargoID: m.CargoID})
+	}
+
+	if err := h.service.SalvarConfiguracao(&configuracao); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Um dos cargos informados não existe nesta empresa."})
+			return
+		}
+		if errors.Is(err, ErrClientSecretVazio) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao salvar a configuração."})
+		return
+	}
+	c.JSON(http.StatusOK, configuracao)
+}
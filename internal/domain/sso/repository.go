@@ -0,0 +1,85 @@
+package sso
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
+	"gorm.io/gorm"
+)
+
+type SSORepository interface {
+	FindConfiguracao(empresaID uint) (*model.ConfiguracaoOIDC, error)
+	SaveConfiguracao(configuracao *model.ConfiguracaoOIDC) error
+	CriarSessao(sessao *model.SessaoOIDC) error
+	// ConsumirSessao busca e apaga a sessão, garantindo que cada state só seja usado uma vez.
+	// Procura em todas as empresas, pois o retorno do provedor traz apenas o state.
+	ConsumirSessao(state string) (*model.SessaoOIDC, error)
+	// FindIdentidade procura em todas as empresas: é ela que descobre o usuário de um login externo.
+	FindIdentidade(issuer string, subject string) (*model.IdentidadeExterna, error)
+	CriarIdentidade(identidade *model.IdentidadeExterna) error
+}
+
+type ssoRepository struct {
+	Db *gorm.DB
+}
+
+func NewSSORepository(db *gorm.DB) SSORepository {
+	return &ssoRepository{Db: db}
+}
+
+func (r *ssoRepository) FindConfiguracao(empresaID uint) (*model.ConfiguracaoOIDC, error) {
+	var configuracao model.ConfiguracaoOIDC
+	err := tenant.Escopo(r.Db, empresaID).Preload("Mapeamentos").First(&configuracao).Error
+	return &configuracao, err
+}
+
+func (r *ssoRepository) SaveConfiguracao(configuracao *model.ConfiguracaoOIDC) error {
+	return tenant.Escopo(r.Db, configuracao.EmpresaID).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Mapeamentos").Save(configuracao).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("configuracao_oidc_id = ?", configuracao.ID).Delete(&model.MapeamentoCargoOIDC{}).Error; err != nil {
+			return err
+		}
+		for i := range configuracao.Mapeamentos {
+			configuracao.Mapeamentos[i].ID = 0
+			configuracao.Mapeamentos[i].ConfiguracaoOIDCID = configuracao.ID
+		}
+		if len(configuracao.Mapeamentos) == 0 {
+			return nil
+		}
+		return tx.Create(&configuracao.Mapeamentos).Error
+	})
+}
+
+func (r *ssoRepository) CriarSessao(sessao *model.SessaoOIDC) error {
+	return tenant.Escopo(r.Db, sessao.EmpresaID).Create(sessao).Error
+}
+
+func (r *ssoRepository) ConsumirSessao(state string) (*model.SessaoOIDC, error) {
+	var sessao model.SessaoOIDC
+	err := tenant.Plataforma(r.Db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state = ?", state).First(&sessao).Error; err != nil {
+			return err
+		}
+		resultado := tx.Delete(&model.SessaoOIDC{}, sessao.ID)
+		if resultado.Error != nil {
+			return resultado.Error
+		}
+		// Outra requisição consumiu o mesmo state entre a leitura e a exclusão.
+		if resultado.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	return &sessao, err
+}
+
+func (r *ssoRepository) FindIdentidade(issuer string, subject string) (*model.IdentidadeExterna, error) {
+	var identidade model.IdentidadeExterna
+	err := tenant.Plataforma(r.Db).Where("issuer = ? AND subject = ?", issuer, subject).First(&identidade).Error
+	return &identidade, err
+}
+
+func (r *ssoRepository) CriarIdentidade(identidade *model.IdentidadeExterna) error {
+	return tenant.Escopo(r.Db, identidade.EmpresaID).Create(identidade).Error
+}
@@ -0,0 +1,321 @@
+package sso
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/cargo"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"github.com/Loviiin/ponto-api-go/pkg/oidc"
+	"github.com/Loviiin/ponto-api-go/pkg/password"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSSONaoConfigurado    = errors.New("login único não está configurado para esta empresa")
+	ErrSessaoInvalida       = errors.New("sessão de login inválida ou expirada")
+	ErrDominioNaoPermitido  = errors.New("o domínio do e-mail não é permitido para esta empresa")
+	ErrEmailNaoVerificado   = errors.New("o provedor não confirmou o e-mail do usuário")
+	ErrEmailOutraEmpresa    = errors.New("este e-mail já está vinculado a outra empresa")
+	ErrEmailIndisponivel    = errors.New("este e-mail pertence a uma conta excluída; peça ao administrador para restaurá-la")
+	ErrUsuarioNaoCadastrado = errors.New("usuário não cadastrado e o provisionamento automático está desativado")
+	ErrCargoNaoMapeado      = errors.New("não foi possível determinar o cargo do usuário a partir das claims")
+	ErrClientSecretVazio    = errors.New("client_secret é obrigatório")
+)
+
+var criptografaSenha = password.CriptografaSenha
+
+// duracaoSessao é o tempo máximo entre o início do login e o retorno do provedor.
+const duracaoSessao = 10 * time.Minute
+
+type SSOService interface {
+	IniciarLogin(empresaID uint) (string, error)
+	Callback(state string, code string) (string, error)
+	BuscarConfiguracao(empresaID uint) (*model.ConfiguracaoOIDC, error)
+	SalvarConfiguracao(configuracao *model.ConfiguracaoOIDC) error
+}
+
+type ssoService struct {
+	repo        SSORepository
+	usuarioRepo usuario.UsuarioRepository
+	cargoRepo   cargo.CargoRepository
+	jwtService  *jwt.JWTService
+	oidcClient  *oidc.Client
+	redirectURL string
+}
+
+func NewSSOService(
+	repo SSORepository,
+	usuarioRepo usuario.UsuarioRepository,
+	cargoRepo cargo.CargoRepository,
+	jwtService *jwt.JWTService,
+	oidcClient *oidc.Client,
+	redirectURL string,
+) SSOService {
+	return &ssoService{
+		repo:        repo,
+		usuarioRepo: usuarioRepo,
+		cargoRepo:   cargoRepo,
+		jwtService:  jwtService,
+		oidcClient:  oidcClient,
+		redirectURL: redirectURL,
+	}
+}
+
+func (s *ssoService) configuracaoAtiva(empresaID uint) (*model.ConfiguracaoOIDC, error) {
+	configuracao, err := s.repo.FindConfiguracao(empresaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSSONaoConfigurado
+		}
+		return nil, err
+	}
+	if !configuracao.Ativo {
+		return nil, ErrSSONaoConfigurado
+	}
+	return configuracao, nil
+}
+
+func (s *ssoService) IniciarLogin(empresaID uint) (string, error) {
+	configuracao, err := s.configuracaoAtiva(empresaID)
+	if err != nil {
+		return "", err
+	}
+	provedor, err := s.oidcClient.Descobrir(configuracao.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := oidc.ValorAleatorio(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.ValorAleatorio(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.GerarPKCE()
+	if err != nil {
+		return "", err
+	}
+
+	sessao := &model.SessaoOIDC{
+		State:        state,
+		EmpresaID:    empresaID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiraEm:     time.Now().Add(duracaoSessao),
+	}
+	if err := s.repo.CriarSessao(sessao); err != nil {
+		return "", err
+	}
+
+	return provedor.URLAutorizacao(configuracao.ClientID, s.redirectURL, state, nonce, challenge), nil
+}
+
+func (s *ssoService) Callback(state string, code string) (string, error) {
+	sessao, err := s.repo.ConsumirSessao(state)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrSessaoInvalida
+		}
+		return "", err
+	}
+	if time.Now().After(sessao.ExpiraEm) {
+		return "", ErrSessaoInvalida
+	}
+
+	configuracao, err := s.configuracaoAtiva(sessao.EmpresaID)
+	if err != nil {
+		return "", err
+	}
+	provedor, err := s.oidcClient.Descobrir(configuracao.Issuer)
+	if err != nil {
+		return "", err
+	}
+
+	tokens, err := s.oidcClient.TrocarCodigo(provedor, configuracao.ClientID, configuracao.ClientSecret, s.redirectURL, code, sessao.CodeVerifier)
+	if err != nil {
+		return "", err
+	}
+	claims, err := s.oidcClient.VerificarIDToken(provedor, tokens.IDToken, configuracao.ClientID, sessao.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	usuari, err := s.resolverUsuario(configuracao, provedor.Issuer, claims)
+	if err != nil {
+		return "", err
+	}
+	return s.jwtService.GenerateToken(usuari.ID, usuari.EmpresaID)
+}
+
+// resolverUsuario encontra o usuário pela identidade já vinculada, depois pelo e-mail (só se o
+// provedor o declarou verificado) e, se permitido, cria o usuário na hora (provisionamento
+// just-in-time).
+func (s *ssoService) resolverUsuario(configuracao *model.ConfiguracaoOIDC, issuer string, claims *oidc.Claims) (*model.Usuario, error) {
+	identidade, err := s.repo.FindIdentidade(issuer, claims.Subject)
+	if err == nil {
+		if identidade.EmpresaID != configuracao.EmpresaID {
+			return nil, ErrEmailOutraEmpresa
+		}
+		return s.usuarioRepo.FindByID(identidade.UsuarioID, identidade.EmpresaID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if claims.EmailVerified != nil && !*claims.EmailVerified {
+		return nil, ErrEmailNaoVerificado
+	}
+	if !dominioPermitido(claims.Email, configuracao.DominiosPermitidos) {
+		return nil, ErrDominioNaoPermitido
+	}
+
+	usuari, err := s.usuarioRepo.FindByEmail(claims.Email)
+	switch {
+	case err == nil:
+		if usuari.EmpresaID != configuracao.EmpresaID {
+			return nil, ErrEmailOutraEmpresa
+		}
+		// Vincular pelo e-mail entrega a conta existente a quem o provedor autenticou: só vale se
+		// ele afirmou que o e-mail é do usuário. Provedores que omitem email_verified não bastam.
+		if claims.EmailVerified == nil || !*claims.EmailVerified {
+			return nil, ErrEmailNaoVerificado
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// FindByEmail não enxerga contas excluídas, mas o índice único de e-mail sim.
+		emUso, err := s.usuarioRepo.EmailEmUso(claims.Email)
+		if err != nil {
+			return nil, err
+		}
+		if emUso {
+			return nil, ErrEmailIndisponivel
+		}
+		usuari, err = s.provisionar(configuracao, claims)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	err = s.repo.CriarIdentidade(&model.IdentidadeExterna{
+		UsuarioID: usuari.ID,
+		EmpresaID: usuari.EmpresaID,
+		Issuer:    issuer,
+		Subject:   claims.Subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usuari, nil
+}
+
+func (s *ssoService) provisionar(configuracao *model.ConfiguracaoOIDC, claims *oidc.Claims) (*model.Usuario, error) {
+	if !configuracao.ProvisionamentoAutomatico {
+		return nil, ErrUsuarioNaoCadastrado
+	}
+	cargoID, ok := cargoParaClaims(configuracao, claims)
+	if !ok {
+		return nil, ErrCargoNaoMapeado
+	}
+
+	// O usuário provisionado só entra pelo provedor; a senha local é aleatória e desconhecida.
+	senhaAleatoria, err := oidc.ValorAleatorio(32)
+	if err != nil {
+		return nil, err
+	}
+	senhaHash, err := criptografaSenha(senhaAleatoria)
+	if err != nil {
+		return nil, err
+	}
+
+	nome := claims.Nome
+	if nome == "" {
+		nome = claims.Email
+	}
+	usuari := &model.Usuario{
+		Nome:      nome,
+		Email:     claims.Email,
+		Senha:     senhaHash,
+		EmpresaID: configuracao.EmpresaID,
+		CargoID:   cargoID,
+	}
+	if err := s.usuarioRepo.Save(usuari); err != nil {
+		return nil, err
+	}
+	return usuari, nil
+}
+
+// cargoParaClaims aplica os mapeamentos na ordem cadastrada; o primeiro valor presente na claim vence.
+func cargoParaClaims(configuracao *model.ConfiguracaoOIDC, claims *oidc.Claims) (uint, bool) {
+	if configuracao.ClaimCargo != "" {
+		valores := claims.ValoresClaim(configuracao.ClaimCargo)
+		for _, mapeamento := range configuracao.Mapeamentos {
+			for _, valor := range valores {
+				if valor == mapeamento.ValorClaim {
+					return mapeamento.CargoID, true
+				}
+			}
+		}
+	}
+	if configuracao.CargoPadraoID != nil {
+		return *configuracao.CargoPadraoID, true
+	}
+	return 0, false
+}
+
+func dominioPermitido(email string, dominios string) bool {
+	arroba := strings.LastIndex(email, "@")
+	if arroba < 0 {
+		return false
+	}
+	dominioEmail := strings.ToLower(email[arroba+1:])
+	for _, dominio := range strings.Split(dominios, ",") {
+		if strings.ToLower(strings.TrimSpace(dominio)) == dominioEmail {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ssoService) BuscarConfiguracao(empresaID uint) (*model.ConfiguracaoOIDC, error) {
+	return s.repo.FindConfiguracao(empresaID)
+}
+
+// SalvarConfiguracao cria ou substitui a configuração da empresa, validando que os cargos
+// referenciados pertencem a ela.
+func (s *ssoService) SalvarConfiguracao(configuracao *model.ConfiguracaoOIDC) error {
+	cargos := make([]uint, 0, len(configuracao.Mapeamentos)+1)
+	for _, mapeamento := range configuracao.Mapeamentos {
+		cargos = append(cargos, mapeamento.CargoID)
+	}
+	if configuracao.CargoPadraoID != nil {
+		cargos = append(cargos, *configuracao.CargoPadraoID)
+	}
+	for _, cargoID := range cargos {
+		if _, err := s.cargoRepo.FindByID(cargoID, configuracao.EmpresaID); err != nil {
+			return err
+		}
+	}
+
+	existente, err := s.repo.FindConfiguracao(configuracao.EmpresaID)
+	switch {
+	case err == nil:
+		configuracao.ID = existente.ID
+		// Um segredo vazio mantém o atual, para que o admin não precise reenviá-lo.
+		if configuracao.ClientSecret == "" {
+			configuracao.ClientSecret = existente.ClientSecret
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	if configuracao.ClientSecret == "" {
+		return ErrClientSecretVazio
+	}
+	return s.repo.SaveConfiguracao(configuracao)
+}
@@ -0,0 +1,276 @@
+package sso
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/cargo"
+	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/jwt"
+	"github.com/Loviiin/ponto-api-go/pkg/oidc"
+	"github.com/Loviiin/ponto-api-go/pkg/oidc/oidctest"
+	"gorm.io/gorm"
+)
+
+type memoriaSSORepository struct {
+	configuracoes map[uint]*model.ConfiguracaoOIDC
+	sessoes       map[string]*model.SessaoOIDC
+	identidades   []model.IdentidadeExterna
+}
+
+func (m *memoriaSSORepository) FindConfiguracao(empresaID uint) (*model.ConfiguracaoOIDC, error) {
+	if c, ok := m.configuracoes[empresaID]; ok {
+		return c, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaSSORepository) SaveConfiguracao(c *model.ConfiguracaoOIDC) error {
+	m.configuracoes[c.EmpresaID] = c
+	return nil
+}
+
+func (m *memoriaSSORepository) CriarSessao(sessao *model.SessaoOIDC) error {
+	m.sessoes[sessao.State] = sessao
+	return nil
+}
+
+func (m *memoriaSSORepository) ConsumirSessao(state string) (*model.SessaoOIDC, error) {
+	sessao, ok := m.sessoes[state]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(m.sessoes, state)
+	return sessao, nil
+}
+
+func (m *memoriaSSORepository) FindIdentidade(issuer string, subject string) (*model.IdentidadeExterna, error) {
+	for i := range m.identidades {
+		if m.identidades[i].Issuer == issuer && m.identidades[i].Subject == subject {
+			return &m.identidades[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoriaSSORepository) CriarIdentidade(identidade *model.IdentidadeExterna) error {
+	m.identidades = append(m.identidades, *identidade)
+	return nil
+}
+
+type mockUsuarioRepository struct {
+	usuario.UsuarioRepository
+	usuarios []*model.Usuario
+}
+
+func (m *mockUsuarioRepository) Save(u *model.Usuario) error {
+	u.ID = uint(len(m.usuarios) + 1)
+	m.usuarios = append(m.usuarios, u)
+	return nil
+}
+
+// FindByEmail esconde os usuários excluídos, como o repositório real; EmailEmUso os enxerga.
+func (m *mockUsuarioRepository) FindByEmail(email string) (*model.Usuario, error) {
+	for _, u := range m.usuarios {
+		if u.Email == email && !u.ExcluidoEm.Valid {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *mockUsuarioRepository) EmailEmUso(email string) (bool, error) {
+	for _, u := range m.usuarios {
+		if u.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockUsuarioRepository) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
+	for _, u := range m.usuarios {
+		if u.ID == id && u.EmpresaID == empresaID {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type mockCargoRepository struct {
+	cargo.CargoRepository
+}
+
+func (m *mockCargoRepository) FindByID(id uint, empresaID uint) (*model.Cargo, error) {
+	return &model.Cargo{ID: id, EmpresaID: empresaID}, nil
+}
+
+type cenario struct {
+	service  SSOService
+	provedor *oidctest.Provedor
+	usuarios *mockUsuarioRepository
+	repo     *memoriaSSORepository
+}
+
+func novoCenario(t *testing.T, provisionar bool) *cenario {
+	t.Helper()
+	provedor, err := oidctest.NovoProvedor("ponto", "segredo")
+	if err != nil {
+		t.Fatalf("falha ao iniciar provedor: %v", err)
+	}
+	t.Cleanup(provedor.Close)
+
+	original := criptografaSenha
+	t.Cleanup(func() { criptografaSenha = original })
+	criptografaSenha = func(senha string) (string, error) { return "hash", nil }
+
+	cargoPadrao := uint(20)
+	repo := &memoriaSSORepository{
+		configuracoes: map[uint]*model.ConfiguracaoOIDC{
+			7: {
+				EmpresaID:                 7,
+				Issuer:                    provedor.URL(),
+				ClientID:                  "ponto",
+				ClientSecret:              "segredo",
+				DominiosPermitidos:        "empresa.com, empresa.com.br",
+				ClaimCargo:                "groups",
+				Mapeamentos:               []model.MapeamentoCargoOIDC{{ValorClaim: "gestores", CargoID: 21}},
+				CargoPadraoID:             &cargoPadrao,
+				ProvisionamentoAutomatico: provisionar,
+				Ativo:                     true,
+			},
+		},
+		sessoes: map[string]*model.SessaoOIDC{},
+	}
+	usuarios := &mockUsuarioRepository{}
+	service := NewSSOService(repo, usuarios, &mockCargoRepository{}, jwt.NewJWTService("chave", "teste"), oidc.NewClient(nil), "http://localhost/callback")
+	return &cenario{service: service, provedor: provedor, usuarios: usuarios, repo: repo}
+}
+
+func (c *cenario) login(t *testing.T, claims map[string]interface{}) (string, error) {
+	t.Helper()
+	urlAutorizacao, err := c.service.IniciarLogin(7)
+	if err != nil {
+		t.Fatalf("Erro inesperado ao iniciar login: %v", err)
+	}
+	code, state, err := c.provedor.Autorizar(urlAutorizacao, claims)
+	if err != nil {
+		t.Fatalf("Erro inesperado na autorização: %v", err)
+	}
+	return c.service.Callback(state, code)
+}
+
+func TestCallback_ProvisionaUsuarioComCargoMapeado(t *testing.T) {
+	c := novoCenario(t, true)
+
+	token, err := c.login(t, map[string]interface{}{
+		"sub": "abc", "email": "bia@empresa.com", "name": "Bia", "groups": []string{"gestores"},
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado no callback: %v", err)
+	}
+	if token == "" {
+		t.Fatal("Esperava um token da API")
+	}
+
+	if len(c.usuarios.usuarios) != 1 {
+		t.Fatalf("Esperava 1 usuário provisionado, encontrou %d", len(c.usuarios.usuarios))
+	}
+	criado := c.usuarios.usuarios[0]
+	if criado.EmpresaID != 7 || criado.CargoID != 21 || criado.Nome != "Bia" {
+		t.Errorf("Usuário provisionado incorreto: %+v", criado)
+	}
+
+	// Um segundo login usa a identidade vinculada e não cria outro usuário.
+	if _, err := c.login(t, map[string]interface{}{"sub": "abc", "email": "bia@empresa.com"}); err != nil {
+		t.Fatalf("Erro inesperado no segundo login: %v", err)
+	}
+	if len(c.usuarios.usuarios) != 1 {
+		t.Errorf("Segundo login não deveria provisionar de novo")
+	}
+}
+
+func TestCallback_NaoProvisionaEmailDeContaExcluida(t *testing.T) {
+	c := novoCenario(t, true)
+	c.usuarios.usuarios = append(c.usuarios.usuarios, &model.Usuario{ID: 5, Email: "ex@empresa.com", EmpresaID: 7,
+		ExcluidoEm: gorm.DeletedAt{Time: time.Now(), Valid: true}})
+
+	_, err := c.login(t, map[string]interface{}{"sub": "z", "email": "ex@empresa.com", "email_verified": true})
+	if !errors.Is(err, ErrEmailIndisponivel) {
+		t.Fatalf("Esperava ErrEmailIndisponivel, recebeu: %v", err)
+	}
+	if len(c.usuarios.usuarios) != 1 || len(c.repo.identidades) != 0 {
+		t.Errorf("Nada deveria ser criado: %+v %+v", c.usuarios.usuarios, c.repo.identidades)
+	}
+}
+
+func TestCallback_RecusaDominioNaoPermitido(t *testing.T) {
+	c := novoCenario(t, true)
+
+	_, err := c.login(t, map[string]interface{}{"sub": "x", "email": "intruso@gmail.com"})
+	if !errors.Is(err, ErrDominioNaoPermitido) {
+		t.Fatalf("Esperava domínio não permitido, recebeu: %v", err)
+	}
+}
+
+func TestCallback_SemProvisionamentoExigeCadastro(t *testing.T) {
+	c := novoCenario(t, false)
+
+	_, err := c.login(t, map[string]interface{}{"sub": "y", "email": "novo@empresa.com"})
+	if !errors.Is(err, ErrUsuarioNaoCadastrado) {
+		t.Fatalf("Esperava usuário não cadastrado, recebeu: %v", err)
+	}
+
+	c.usuarios.usuarios = append(c.usuarios.usuarios, &model.Usuario{ID: 5, Email: "novo@empresa.com", EmpresaID: 7})
+	if _, err := c.login(t, map[string]interface{}{"sub": "y", "email": "novo@empresa.com", "email_verified": true}); err != nil {
+		t.Fatalf("Esperava vincular o usuário existente, recebeu: %v", err)
+	}
+	if len(c.repo.identidades) != 1 || c.repo.identidades[0].UsuarioID != 5 {
+		t.Errorf("Identidade não vinculada ao usuário existente: %+v", c.repo.identidades)
+	}
+}
+
+func TestCallback_SoVinculaEmailVerificado(t *testing.T) {
+	c := novoCenario(t, true)
+	c.usuarios.usuarios = append(c.usuarios.usuarios, &model.Usuario{ID: 5, Email: "ana@empresa.com", EmpresaID: 7})
+
+	casos := []struct {
+		nome   string
+		claims map[string]interface{}
+	}{
+		{"sem email_verified", map[string]interface{}{"sub": "w", "email": "ana@empresa.com"}},
+		{"email_verified falso", map[string]interface{}{"sub": "w", "email": "ana@empresa.com", "email_verified": false}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if _, err := c.login(t, caso.claims); !errors.Is(err, ErrEmailNaoVerificado) {
+				t.Fatalf("Esperava e-mail não verificado, recebeu: %v", err)
+			}
+		})
+	}
+	if len(c.repo.identidades) != 0 {
+		t.Fatalf("Nenhuma identidade deveria ser vinculada: %+v", c.repo.identidades)
+	}
+
+	if _, err := c.login(t, map[string]interface{}{"sub": "w", "email": "ana@empresa.com", "email_verified": true}); err != nil {
+		t.Fatalf("Esperava vincular o e-mail verificado, recebeu: %v", err)
+	}
+	if len(c.repo.identidades) != 1 || c.repo.identidades[0].UsuarioID != 5 {
+		t.Errorf("Identidade não vinculada ao usuário existente: %+v", c.repo.identidades)
+	}
+}
+
+func TestCallback_StateReutilizado(t *testing.T) {
+	c := novoCenario(t, true)
+
+	urlAutorizacao, _ := c.service.IniciarLogin(7)
+	code, state, _ := c.provedor.Autorizar(urlAutorizacao, map[string]interface{}{"sub": "z", "email": "z@empresa.com"})
+	if _, err := c.service.Callback(state, code); err != nil {
+		t.Fatalf("Erro inesperado no callback: %v", err)
+	}
+	if _, err := c.service.Callback(state, code); !errors.Is(err, ErrSessaoInvalida) {
+		t.Fatalf("Esperava sessão inválida ao reutilizar o state, recebeu: %v", err)
+	}
+}
@@ -2,29 +2,27 @@ package usuario
 
 import (
 	"errors"
-	"github.com/Loviiin/ponto-api-go/internal/domain/cargo"
-	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"fmt"
+	"github.com/Loviiin/ponto-api-go/internal/domain/auditoria"
 	"github.com/Loviiin/ponto-api-go/internal/model"
 	"github.com/Loviiin/ponto-api-go/pkg/funcoes"
 	"github.com/Loviiin/ponto-api-go/pkg/permissions"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
+	"sort"
+	"strings"
 )
 
 type UsuarioHandler struct {
-	service        UsuarioService
-	empresaService empresa.EmpresaService
-	cargoService   cargo.CargoService
-	converter      funcoes.FuncoesInterface
+	service   UsuarioService
+	converter funcoes.FuncoesInterface
 }
 
-func NewUsuarioHandler(s UsuarioService, e empresa.EmpresaService, ca cargo.CargoService, f funcoes.FuncoesInterface) *UsuarioHandler {
+func NewUsuarioHandler(s UsuarioService, f funcoes.FuncoesInterface) *UsuarioHandler {
 	return &UsuarioHandler{
-		service:        s,
-		empresaService: e,
-		cargoService:   ca,
-		converter:      f,
+		service:   s,
+		converter: f,
 	}
 }
 
@@ -54,7 +52,12 @@ func (h *UsuarioHandler) GetAllUsuariosHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	usuarios, err := h.service.GetAll(empresaID)
+	filtro, err := FiltroDaQuery(c, h.converter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	usuarios, err := h.service.Buscar(empresaID, filtro)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar usuários"})
 		return
@@ -62,13 +65,38 @@ func (h *UsuarioHandler) GetAllUsuariosHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, usuarios)
 }
 
+// FiltroDaQuery lê departamento_id, centro_custo_id e gestor_id da query string.
+func FiltroDaQuery(c *gin.Context, f funcoes.FuncoesInterface) (FiltroUsuarios, error) {
+	var filtro FiltroUsuarios
+	campos := []struct {
+		nome    string
+		destino **uint
+	}{
+		{"departamento_id", &filtro.DepartamentoID},
+		{"centro_custo_id", &filtro.CentroCustoID},
+		{"gestor_id", &filtro.GestorID},
+	}
+	for _, campo := range campos {
+		valor := c.Query(campo.nome)
+		if valor == "" {
+			continue
+		}
+		id, err := f.StrParaUint(valor)
+		if err != nil {
+			return filtro, fmt.Errorf("o parâmetro '%s' deve ser um ID válido", campo.nome)
+		}
+		*campo.destino = &id
+	}
+	return filtro, nil
+}
+
 func (h *UsuarioHandler) DeleteHandler(c *gin.Context) {
 
 	empresaID, _ := h.converter.GetUintIDFromContext(c, "empresaID")
 	idToken, _ := h.converter.GetUintIDFromContext(c, "userID")
 	idUrl, _ := h.converter.StrParaUint(c.Param("id"))
 
-	requester, err := h.service.FindByID(idToken, empresaID)
+	requester, err := Requisitante(c, h.service, idToken, empresaID)
 	if err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
 		return
@@ -76,18 +104,13 @@ func (h *UsuarioHandler) DeleteHandler(c *gin.Context) {
 
 	podeDeletar := false
 	if idUrl == idToken {
-		for _, p := range requester.Cargo.Permissoes {
-			if p.Nome == permissions.DELETAR_PROPRIA_CONTA {
-				podeDeletar = true
-				break
-			}
-		}
+		_, podeDeletar = requester.Cargo.EscopoPermissao(permissions.DELETAR_PROPRIA_CONTA)
 	} else {
-		for _, p := range requester.Cargo.Permissoes {
-			if p.Nome == permissions.DELETAR_USUARIO {
-				podeDeletar = true
-				break
-			}
+		// A permissão só vale para usuários dentro do escopo concedido ao cargo (equipe, departamento...).
+		podeDeletar, err = h.service.AlvoNoEscopo(requester, permissions.DELETAR_USUARIO, idUrl)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao verificar permissões."})
+			return
 		}
 	}
 
@@ -109,37 +132,60 @@ func (h *UsuarioHandler) DeleteHandler(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-func (h *UsuarioHandler) UpdateUsuarioHandler(c *gin.Context) {
-
+// RestaurarHandler desfaz a exclusão de um usuário. Exige a mesma permissão, no mesmo escopo, que a exclusão.
+func (h *UsuarioHandler) RestaurarHandler(c *gin.Context) {
 	empresaID, _ := h.converter.GetUintIDFromContext(c, "empresaID")
 	idToken, _ := h.converter.GetUintIDFromContext(c, "userID")
-	idUrl, _ := h.converter.StrParaUint(c.Param("id"))
+	idUrl, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID do usuário inválido."})
+		return
+	}
 
-	requester, err := h.service.FindByID(idToken, empresaID)
+	requester, err := Requisitante(c, h.service, idToken, empresaID)
 	if err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
 		return
 	}
+	podeRestaurar, err := h.service.AlvoNoEscopo(requester, permissions.DELETAR_USUARIO, idUrl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao verificar permissões."})
+		return
+	}
+	if !podeRestaurar {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado. Você não tem permissão para realizar esta ação."})
+		return
+	}
 
-	podeEditar := false
-	if idUrl == idToken {
-		for _, p := range requester.Cargo.Permissoes {
-			if p.Nome == permissions.EDITAR_PROPRIA_CONTA {
-				podeEditar = true
-				break
-			}
+	if err := h.service.Restaurar(idUrl, empresaID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Nenhum usuário excluído com este ID pode ser restaurado."})
+			return
 		}
-	} else {
-		for _, p := range requester.Cargo.Permissoes {
-			if p.Nome == permissions.EDITAR_USUARIO {
-				podeEditar = true
-				break
-			}
+		if errors.Is(err, ErrCargoExcluido) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao restaurar o usuário."})
+		return
 	}
 
-	if !podeEditar {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Você não tem permissão para editar este usuário."})
+	c.Status(http.StatusNoContent)
+}
+
+func (h *UsuarioHandler) UpdateUsuarioHandler(c *gin.Context) {
+
+	empresaID, _ := h.converter.GetUintIDFromContext(c, "empresaID")
+	idToken, _ := h.converter.GetUintIDFromContext(c, "userID")
+	idUrl, _ := h.converter.StrParaUint(c.Param("id"))
+
+	requester, err := Requisitante(c, h.service, idToken, empresaID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+		return
+	}
+
+	if !h.podeEditar(c, requester, idUrl) {
 		return
 	}
 
@@ -149,62 +195,138 @@ func (h *UsuarioHandler) UpdateUsuarioHandler(c *gin.Context) {
 		return
 	}
 
-	if idUrl == idToken {
-		delete(dadosParaAtualizar, "cargo_id")
+	if proibidos := camposNaoEditaveis(dadosParaAtualizar, idUrl == idToken); len(proibidos) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Campos que não podem ser alterados nesta rota: %s.", strings.Join(proibidos, ", "))})
+		return
+	}
+	if _, trocaCargo := dadosParaAtualizar["cargo_id"]; trocaCargo && !podeTrocarCargo(requester) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Trocar o cargo de um usuário exige EDITAR_USUARIO com escopo EMPRESA e GERENCIAR_CARGOS."})
+		return
 	}
-	delete(dadosParaAtualizar, "empresa_id")
 
+	antes, _ := h.service.FindByID(idUrl, empresaID)
 	err = h.service.Update(idUrl, empresaID, dadosParaAtualizar)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado."})
 			return
 		}
+		if errors.Is(err, ErrGestorInvalido) || errors.Is(err, ErrDepartamentoInvalido) || errors.Is(err, ErrCentroCustoInvalido) || errors.Is(err, ErrCargoInvalido) ||
+			errors.Is(err, ErrBatidaRemotaInvalida) || errors.Is(err, ErrMatriculaInvalida) || errors.Is(err, ErrSenhaInvalida) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrMatriculaEmUso) || errors.Is(err, ErrEmailEmUso) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao atualizar o usuário."})
 		return
 	}
+	depois, _ := h.service.FindByID(idUrl, empresaID)
+	auditoria.Anotar(c, auditoria.Alvo{Acao: auditoria.AcaoUsuarioAtualizado, Entidade: "usuarios", EntidadeID: idUrl, Antes: antes, Depois: depois})
 
 	c.Status(http.StatusNoContent)
 }
 
-func (h *UsuarioHandler) CriarUsuarioHandler(c *gin.Context) {
-	type criarUsuarioRequest struct {
-		Nome      string `json:"nome" binding:"required"`
-		Email     string `json:"email" binding:"required,email"`
-		Senha     string `json:"senha" binding:"required,min=6"`
-		EmpresaID uint   `json:"empresa_id" binding:"required"`
-		CargoID   uint   `json:"cargo_id" binding:"required"`
+// podeEditar confere se o requisitante edita o usuário alvo: a si mesmo com EDITAR_PROPRIA_CONTA,
+// os demais com EDITAR_USUARIO no escopo. Quando não pode, já responde a requisição.
+func (h *UsuarioHandler) podeEditar(c *gin.Context, requester *model.Usuario, alvoID uint) bool {
+	podeEditar := false
+	if alvoID == requester.ID {
+		_, podeEditar = requester.Cargo.EscopoPermissao(permissions.EDITAR_PROPRIA_CONTA)
+	} else {
+		var err error
+		podeEditar, err = h.service.AlvoNoEscopo(requester, permissions.EDITAR_USUARIO, alvoID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao verificar permissões."})
+			return false
+		}
 	}
-	var request criarUsuarioRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+
+	if !podeEditar {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Você não tem permissão para editar este usuário."})
+	}
+	return podeEditar
+}
+
+// Colunas que PUT /usuarios/:id altera. O próprio usuário muda só os seus dados de acesso; quem o
+// edita no escopo de EDITAR_USUARIO muda também a posição dele na empresa. Saldo, exclusão,
+// anonimização, empresa e PIN só mudam pelos fluxos próprios, que os validam e auditam.
+var (
+	camposPropriaConta = map[string]bool{"nome": true, "email": true, "senha": true}
+	camposNoEscopo     = map[string]bool{
+		"nome": true, "email": true, "matricula": true, "cargo_id": true, "gestor_id": true,
+		"departamento_id": true, "centro_custo_id": true, "batida_remota": true,
+	}
+)
+
+// camposNaoEditaveis lista, em ordem alfabética, os campos enviados fora da lista permitida.
+func camposNaoEditaveis(dados map[string]interface{}, propriaConta bool) []string {
+	permitidos := camposNoEscopo
+	if propriaConta {
+		permitidos = camposPropriaConta
+	}
+	proibidos := []string{}
+	for campo := range dados {
+		if !permitidos[campo] {
+			proibidos = append(proibidos, campo)
+		}
 	}
-	_, err := h.empresaService.GetEmpresaByIDSer(request.EmpresaID)
+	sort.Strings(proibidos)
+	return proibidos
+}
+
+// podeTrocarCargo diz se o requisitante pode mudar o cargo de alguém. Escolher o cargo equivale a
+// conceder as permissões dele, então não basta editar a equipe: é preciso alcançar a empresa toda
+// e poder gerenciar cargos, senão um gestor daria a um subordinado um cargo acima do próprio.
+func podeTrocarCargo(requester *model.Usuario) bool {
+	escopo, podeEditar := requester.Cargo.EscopoPermissao(permissions.EDITAR_USUARIO)
+	_, podeGerenciarCargos := requester.Cargo.EscopoPermissao(permissions.GERENCIAR_CARGOS)
+	return podeEditar && escopo == model.EscopoEmpresa && podeGerenciarCargos
+}
+
+// DefinirPinHandler grava o PIN do quiosque. O próprio funcionário ou quem pode editá-lo define o
+// PIN; quem não tem celular normalmente o recebe do RH.
+func (h *UsuarioHandler) DefinirPinHandler(c *gin.Context) {
+	empresaID, _ := h.converter.GetUintIDFromContext(c, "empresaID")
+	idToken, _ := h.converter.GetUintIDFromContext(c, "userID")
+	idUrl, err := h.converter.StrParaUint(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "A empresa especificada não existe."})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuário inválido."})
 		return
 	}
 
-	_, err = h.cargoService.FindByID(request.CargoID, request.EmpresaID)
+	requester, err := Requisitante(c, h.service, idToken, empresaID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "O cargo especificado não existe ou não pertence a esta empresa."})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
 		return
 	}
-	usuario := model.Usuario{
-		Nome:      request.Nome,
-		Email:     request.Email,
-		Senha:     request.Senha,
-		EmpresaID: request.EmpresaID,
-		CargoID:   request.CargoID,
+	if !h.podeEditar(c, requester, idUrl) {
+		return
 	}
 
-	err = h.service.CriarUsuario(&usuario) // Este método agora será mais simples!
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req struct {
+		Pin string `json:"pin" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Corpo da requisição (JSON) inválido: 'pin' é obrigatório."})
+		return
+	}
+
+	if err := h.service.DefinirPin(idUrl, empresaID, req.Pin); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado."})
+			return
+		}
+		if errors.Is(err, ErrPinInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao definir o PIN."})
 		return
 	}
-	c.JSON(http.StatusCreated, usuario)
+	c.Status(http.StatusNoContent)
 }
 
 func (h *UsuarioHandler) GetMeuPerfil(c *gin.Context) {
@@ -216,7 +338,7 @@ func (h *UsuarioHandler) GetMeuPerfil(c *gin.Context) {
 
 	id, err := h.converter.GetUintIDFromContext(c, "userID")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Apenas usuários têm perfil."})
 		return
 	}
 
@@ -228,3 +350,54 @@ func (h *UsuarioHandler) GetMeuPerfil(c *gin.Context) {
 
 	c.JSON(http.StatusOK, usuario)
 }
+
+// GetPermissoesEfetivas lista o que o usuário pode fazer: as permissões do cargo somadas às
+// herdadas, com o escopo e o cargo de origem de cada uma. Ver as de outro usuário exige GERENCIAR_CARGOS.
+func (h *UsuarioHandler) GetPermissoesEfetivas(c *gin.Context) {
+	empresaID, err := h.converter.GetUintIDFromContext(c, "empresaID")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	idToken, err := h.converter.GetUintIDFromContext(c, "userID")
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+		return
+	}
+	id, err := h.converter.StrParaUint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O ID do usuário deve ser um número"})
+		return
+	}
+
+	requester, err := Requisitante(c, h.service, idToken, empresaID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Acesso negado."})
+		return
+	}
+	if id != idToken {
+		if _, podeVer := requester.Cargo.EscopoPermissao(permissions.GERENCIAR_CARGOS); !podeVer {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Você não tem permissão para ver as permissões deste usuário."})
+			return
+		}
+	}
+
+	alvo := requester
+	if id != idToken {
+		alvo, err = h.service.FindByID(id, empresaID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Usuário não encontrado"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Falha ao buscar o usuário."})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"usuario_id": alvo.ID,
+		"cargo_id":   alvo.CargoID,
+		"permissoes": alvo.Cargo.PermissoesEfetivas(),
+	})
+}
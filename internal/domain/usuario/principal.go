@@ -0,0 +1,16 @@
+package usuario
+
+import (
+	"github.com/Loviiin/ponto-api-go/internal/domain/autorizacao"
+	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Requisitante devolve quem faz a requisição, já com as permissões resolvidas. Se a rota passou
+// pelo middleware de permissões, reaproveita o principal que ele deixou no contexto.
+func Requisitante(c *gin.Context, s UsuarioService, id uint, empresaID uint) (*model.Usuario, error) {
+	if principal, ok := autorizacao.Principal(c); ok && principal.ID == id && principal.EmpresaID == empresaID {
+		return principal, nil
+	}
+	return s.Resolver(id, empresaID)
+}
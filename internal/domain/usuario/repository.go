@@ -2,17 +2,47 @@ package usuario
 
 import (
 	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/tenant"
 	"gorm.io/gorm"
 )
 
+// FiltroUsuarios restringe a listagem. O filtro de departamento inclui os subdepartamentos.
+type FiltroUsuarios struct {
+	DepartamentoID *uint
+	CentroCustoID  *uint
+	GestorID       *uint
+}
+
 type UsuarioRepository interface {
 	Save(usuario *model.Usuario) error
+	// FindByEmail procura em todas as empresas: o e-mail é único na plataforma e é por ele que o
+	// login descobre a empresa do usuário.
 	FindByEmail(email string) (*model.Usuario, error)
+	// EmailEmUso informa se algum usuário usa o e-mail, inclusive os excluídos e os de empresas
+	// excluídas: FindByEmail os esconde, mas o índice único de e-mail continua valendo para eles.
+	EmailEmUso(email string) (bool, error)
 	FindByID(id uint, empresaID uint) (*model.Usuario, error)
+	FindByMatricula(matricula string, empresaID uint) (*model.Usuario, error)
 	GetAll(empresaID uint) ([]model.Usuario, error)
 	Update(id uint, empresaID uint, dados map[string]interface{}) error
+	// Delete faz a exclusão lógica; os registros do usuário ficam guardados pelo prazo de retenção.
 	Delete(id uint, empresaID uint) error
+	// FindExcluido busca um usuário excluído que ainda não foi anonimizado, com o seu cargo (mesmo
+	// que o cargo também tenha sido excluído).
+	FindExcluido(id uint, empresaID uint) (*model.Usuario, error)
+	Restaurar(id uint, empresaID uint) error
+	// FindAll lista os usuários de todas as empresas. É reservado a tarefas da plataforma, como o agendador.
 	FindAll() ([]model.Usuario, error)
+	// EhSubordinado informa se alvoID está abaixo de gestorID na cadeia de gestores.
+	EhSubordinado(gestorID uint, alvoID uint, empresaID uint) (bool, error)
+	// EstaNoDepartamento informa se alvoID pertence ao departamento ou a um dos seus subdepartamentos.
+	EstaNoDepartamento(departamentoID uint, alvoID uint, empresaID uint) (bool, error)
+	DepartamentoExiste(departamentoID uint, empresaID uint) (bool, error)
+	CargoExiste(cargoID uint, empresaID uint) (bool, error)
+	CentroCustoAtivo(centroCustoID uint, empresaID uint) (bool, error)
+	Buscar(empresaID uint, filtro FiltroUsuarios) ([]model.Usuario, error)
+	SubordinadosIDs(gestorID uint, empresaID uint) ([]uint, error)
+	DepartamentoEDescendentesIDs(departamentoID uint, empresaID uint) ([]uint, error)
 }
 
 type usuarioRepository struct {
@@ -23,40 +53,196 @@ func NewUsuarioRepository(db *gorm.DB) UsuarioRepository {
 	return &usuarioRepository{Db: db}
 }
 
+// empresaAtiva descarta usuários de empresas excluídas: eles não entram nem passam pelas permissões.
+func empresaAtiva(db *gorm.DB) *gorm.DB {
+	return db.Where("EXISTS (SELECT 1 FROM empresas WHERE empresas.id = usuarios.empresa_id AND empresas.data_exclusao IS NULL)")
+}
+
 func (r *usuarioRepository) Save(usuario *model.Usuario) error {
-	return r.Db.Create(usuario).Error
+	return tenant.Escopo(r.Db, usuario.EmpresaID).Create(usuario).Error
 }
 
 func (r *usuarioRepository) FindByEmail(email string) (*model.Usuario, error) {
 	var usuario model.Usuario
-	err := r.Db.Where("email = ?", email).First(&usuario).Error
+	err := tenant.Plataforma(r.Db).Scopes(empresaAtiva).Where("email = ?", email).First(&usuario).Error
 	return &usuario, err
 }
 
+func (r *usuarioRepository) EmailEmUso(email string) (bool, error) {
+	var total int64
+	err := tenant.Plataforma(r.Db).Unscoped().Model(&model.Usuario{}).Where("email = ?", email).Count(&total).Error
+	return total > 0, err
+}
+
 func (r *usuarioRepository) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
 	var usuario model.Usuario
-	err := r.Db.Where("id = ? AND empresa_id = ?", id, empresaID).Preload("Cargo.Permissoes").First(&usuario).Error
+	db := tenant.Escopo(r.Db, empresaID)
+	err := db.Scopes(empresaAtiva).Where("id = ?", id).Preload("Cargo.Permissoes").Preload("Cargo.Escopos").First(&usuario).Error
+	if err != nil {
+		return &usuario, err
+	}
+	return &usuario, carregarHeranca(db, &usuario.Cargo)
+}
+
+func (r *usuarioRepository) FindByMatricula(matricula string, empresaID uint) (*model.Usuario, error) {
+	var usuario model.Usuario
+	err := tenant.Escopo(r.Db, empresaID).Scopes(empresaAtiva).Where("matricula = ?", matricula).First(&usuario).Error
 	return &usuario, err
 }
 
+// maxNiveisHeranca limita a subida na cadeia de cargos; o serviço de cargos já impede ciclos.
+const maxNiveisHeranca = 10
+
+// carregarHeranca preenche cargo.Herdados com os cargos acima dele, do mais próximo ao mais distante.
+func carregarHeranca(db *gorm.DB, cargo *model.Cargo) error {
+	if cargo.HerdaDeID == nil {
+		return nil
+	}
+	var ids []uint
+	err := db.Raw(`
+WITH RECURSIVE cadeia AS (
+	SELECT id, herda_de_id, 1 AS nivel FROM cargos WHERE id = ? AND empresa_id = ?
+	UNION
+	SELECT c.id, c.herda_de_id, cd.nivel + 1 FROM cargos c JOIN cadeia cd ON c.id = cd.herda_de_id
+	WHERE c.empresa_id = ? AND cd.nivel < ?
+)
+SELECT id FROM cadeia ORDER BY nivel`, *cargo.HerdaDeID, cargo.EmpresaID, cargo.EmpresaID, maxNiveisHeranca).Scan(&ids).Error
+	if err != nil {
+		return err
+	}
+
+	var herdados []model.Cargo
+	if err := db.Preload("Permissoes").Preload("Escopos").Where("id IN ?", ids).Find(&herdados).Error; err != nil {
+		return err
+	}
+	porID := make(map[uint]model.Cargo, len(herdados))
+	for _, h := range herdados {
+		porID[h.ID] = h
+	}
+	for _, id := range ids {
+		if h, ok := porID[id]; ok && h.ID != cargo.ID {
+			cargo.Herdados = append(cargo.Herdados, h)
+		}
+	}
+	return nil
+}
+
 func (r *usuarioRepository) GetAll(empresaID uint) ([]model.Usuario, error) {
 	var usuarios []model.Usuario
-	err := r.Db.Where("empresa_id = ?", empresaID).Order("id asc").Find(&usuarios).Error
+	err := tenant.Escopo(r.Db, empresaID).Order("id asc").Find(&usuarios).Error
 	return usuarios, err
 }
 
 func (r *usuarioRepository) Update(id uint, empresaID uint, dados map[string]interface{}) error {
-	err := r.Db.Model(&model.Usuario{}).Where("id = ? AND empresa_id = ?", id, empresaID).Updates(dados).Error
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.Usuario{}).Where("id = ?", id).Updates(dados).Error
 	return err
 }
 
 func (r *usuarioRepository) Delete(id uint, empresaID uint) error {
-	err := r.Db.Delete(&model.Usuario{}, "id = ? AND empresa_id = ?", id, empresaID).Error
+	err := tenant.Escopo(r.Db, empresaID).Delete(&model.Usuario{}, "id = ?", id).Error
 	return err
 }
 
 func (r *usuarioRepository) FindAll() ([]model.Usuario, error) {
 	var usuarios []model.Usuario
-	err := r.Db.Find(&usuarios).Error
+	err := tenant.Plataforma(r.Db).Scopes(empresaAtiva).Find(&usuarios).Error
+	return usuarios, err
+}
+
+func (r *usuarioRepository) FindExcluido(id uint, empresaID uint) (*model.Usuario, error) {
+	var usuario model.Usuario
+	err := tenant.Escopo(r.Db, empresaID).Unscoped().Preload("Cargo").
+		Where("id = ? AND data_exclusao IS NOT NULL AND data_anonimizacao IS NULL", id).
+		First(&usuario).Error
+	return &usuario, err
+}
+
+func (r *usuarioRepository) Restaurar(id uint, empresaID uint) error {
+	resultado := tenant.Escopo(r.Db, empresaID).Unscoped().Model(&model.Usuario{}).
+		Where("id = ? AND data_exclusao IS NOT NULL AND data_anonimizacao IS NULL", id).
+		Update("data_exclusao", nil)
+	if resultado.Error == nil && resultado.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return resultado.Error
+}
+
+// cteEquipe lista todos abaixo de um gestor (parâmetros: gestor, empresa, empresa).
+// UNION (e não UNION ALL) descarta linhas repetidas e encerra a recursão mesmo se houver ciclo.
+const cteEquipe = `WITH RECURSIVE equipe AS (
+	SELECT id FROM usuarios WHERE gestor_id = ? AND empresa_id = ?
+	UNION
+	SELECT u.id FROM usuarios u JOIN equipe e ON u.gestor_id = e.id WHERE u.empresa_id = ?
+)`
+
+// cteArvore lista um departamento e seus descendentes (parâmetros: departamento, empresa, empresa).
+const cteArvore = `WITH RECURSIVE arvore AS (
+	SELECT id FROM departamentos WHERE id = ? AND empresa_id = ?
+	UNION
+	SELECT d.id FROM departamentos d JOIN arvore a ON d.departamento_pai_id = a.id WHERE d.empresa_id = ?
+)`
+
+func (r *usuarioRepository) EhSubordinado(gestorID uint, alvoID uint, empresaID uint) (bool, error) {
+	var existe bool
+	err := tenant.Escopo(r.Db, empresaID).Raw(cteEquipe+` SELECT EXISTS (SELECT 1 FROM equipe WHERE id = ?)`,
+		gestorID, empresaID, empresaID, alvoID).Scan(&existe).Error
+	return existe, err
+}
+
+func (r *usuarioRepository) EstaNoDepartamento(departamentoID uint, alvoID uint, empresaID uint) (bool, error) {
+	var existe bool
+	err := tenant.Escopo(r.Db, empresaID).Raw(cteArvore+` SELECT EXISTS (
+	SELECT 1 FROM usuarios WHERE id = ? AND empresa_id = ? AND departamento_id IN (SELECT id FROM arvore)
+)`, departamentoID, empresaID, empresaID, alvoID, empresaID).Scan(&existe).Error
+	return existe, err
+}
+
+func (r *usuarioRepository) SubordinadosIDs(gestorID uint, empresaID uint) ([]uint, error) {
+	var ids []uint
+	err := tenant.Escopo(r.Db, empresaID).Raw(cteEquipe+` SELECT id FROM equipe`, gestorID, empresaID, empresaID).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *usuarioRepository) DepartamentoEDescendentesIDs(departamentoID uint, empresaID uint) ([]uint, error) {
+	var ids []uint
+	err := tenant.Escopo(r.Db, empresaID).Raw(cteArvore+` SELECT id FROM arvore`, departamentoID, empresaID, empresaID).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *usuarioRepository) Buscar(empresaID uint, filtro FiltroUsuarios) ([]model.Usuario, error) {
+	consulta := tenant.Escopo(r.Db, empresaID)
+	if filtro.DepartamentoID != nil {
+		departamentos, err := r.DepartamentoEDescendentesIDs(*filtro.DepartamentoID, empresaID)
+		if err != nil {
+			return nil, err
+		}
+		consulta = consulta.Where("departamento_id IN ?", departamentos)
+	}
+	if filtro.CentroCustoID != nil {
+		consulta = consulta.Where("centro_custo_id = ?", *filtro.CentroCustoID)
+	}
+	if filtro.GestorID != nil {
+		consulta = consulta.Where("gestor_id = ?", *filtro.GestorID)
+	}
+	var usuarios []model.Usuario
+	err := consulta.Order("id asc").Find(&usuarios).Error
 	return usuarios, err
 }
+
+func (r *usuarioRepository) CentroCustoAtivo(centroCustoID uint, empresaID uint) (bool, error) {
+	var total int64
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.CentroCusto{}).Where("id = ? AND ativo", centroCustoID).Count(&total).Error
+	return total > 0, err
+}
+
+func (r *usuarioRepository) DepartamentoExiste(departamentoID uint, empresaID uint) (bool, error) {
+	var total int64
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.Departamento{}).Where("id = ?", departamentoID).Count(&total).Error
+	return total > 0, err
+}
+
+func (r *usuarioRepository) CargoExiste(cargoID uint, empresaID uint) (bool, error) {
+	var total int64
+	err := tenant.Escopo(r.Db, empresaID).Model(&model.Cargo{}).Where("id = ?", cargoID).Count(&total).Error
+	return total > 0, err
+}
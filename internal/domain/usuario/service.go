@@ -2,6 +2,9 @@ package usuario
 
 import (
 	"errors"
+	"strings"
+
+	"github.com/Loviiin/ponto-api-go/internal/domain/autorizacao"
 	"github.com/Loviiin/ponto-api-go/internal/model"
 	"github.com/Loviiin/ponto-api-go/pkg/password"
 	"gorm.io/gorm"
@@ -11,20 +14,51 @@ type UsuarioService interface {
 	CriarUsuario(usuario *model.Usuario) error
 	GetAll(empresaID uint) ([]model.Usuario, error)
 	FindByID(id uint, empresaID uint) (*model.Usuario, error)
+	// Resolver é o FindByID usado nas verificações de permissão: passa pelo cache de permissões
+	// efetivas e devolve um valor compartilhado, que não deve ser alterado.
+	Resolver(id uint, empresaID uint) (*model.Usuario, error)
 	Update(id uint, empresaID uint, dados map[string]interface{}) error
+	// DefinirPin grava o PIN que o funcionário usa, com a matrícula, para bater ponto nos quiosques.
+	DefinirPin(id uint, empresaID uint, pin string) error
 	Delete(id uint, empresaID uint) error
+	// Restaurar desfaz a exclusão lógica, enquanto os dados ainda não foram anonimizados.
+	Restaurar(id uint, empresaID uint) error
 	FindAll() ([]model.Usuario, error)
+	// AlvoNoEscopo informa se o cargo do requisitante concede a permissão sobre o usuário alvo,
+	// considerando o escopo da concessão (próprio, equipe, departamento ou empresa).
+	AlvoNoEscopo(requisitante *model.Usuario, permissao string, alvoID uint) (bool, error)
+	Buscar(empresaID uint, filtro FiltroUsuarios) ([]model.Usuario, error)
+	// FiltrarPorEscopo mantém apenas os usuários sobre os quais o requisitante tem a permissão.
+	FiltrarPorEscopo(requisitante *model.Usuario, permissao string, usuarios []model.Usuario) ([]model.Usuario, error)
 }
 
+var (
+	// ErrEmailEmUso vale também para o e-mail de um usuário excluído, que ainda pode ser restaurado.
+	ErrEmailEmUso           = errors.New("e-mail já cadastrado")
+	ErrGestorInvalido       = errors.New("gestor inválido: deve ser outro usuário da empresa que não esteja na equipe do próprio funcionário")
+	ErrDepartamentoInvalido = errors.New("o departamento especificado não existe nesta empresa")
+	ErrCentroCustoInvalido  = errors.New("o centro de custo especificado não existe nesta empresa ou está inativo")
+	ErrCargoInvalido        = errors.New("o cargo especificado não existe nesta empresa")
+	ErrBatidaRemotaInvalida = errors.New("regra de batida remota inválida: use PERMITIR, BLOQUEAR, REVISAR ou null para seguir o cargo")
+	ErrCargoExcluido        = errors.New("o cargo do usuário foi excluído; restaure o cargo antes de restaurar o usuário")
+	ErrMatriculaInvalida    = errors.New("matrícula inválida: informe um texto não vazio ou null para removê-la")
+	ErrMatriculaEmUso       = errors.New("a matrícula já pertence a outro usuário desta empresa")
+	ErrPinInvalido          = errors.New("o PIN deve ter de 4 a 8 dígitos")
+	ErrSenhaInvalida        = errors.New("senha inválida: informe um texto não vazio")
+)
+
 var criptografaSenha = password.CriptografaSenha
 
 type usuarioService struct {
 	usuarioRepo UsuarioRepository
+	cache       *autorizacao.Cache
 }
 
-func NewUsuarioService(repo UsuarioRepository) UsuarioService {
+// NewUsuarioService recebe o cache de permissões efetivas; com cache nil, Resolver sempre consulta o banco.
+func NewUsuarioService(repo UsuarioRepository, cache *autorizacao.Cache) UsuarioService {
 	return &usuarioService{
 		usuarioRepo: repo,
+		cache:       cache,
 	}
 }
 
@@ -36,14 +70,27 @@ func (s *usuarioService) FindByID(id uint, empresaID uint) (*model.Usuario, erro
 	return s.usuarioRepo.FindByID(id, empresaID)
 }
 
-func (s *usuarioService) CriarUsuario(usuario *model.Usuario) error {
-	_, err := s.usuarioRepo.FindByEmail(usuario.Email)
-	if err == nil {
-		return errors.New("e-mail já cadastrado")
+func (s *usuarioService) Resolver(id uint, empresaID uint) (*model.Usuario, error) {
+	usuario, versao, ok := s.cache.Buscar(id, empresaID)
+	if ok {
+		return usuario, nil
 	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
+	usuario, err := s.usuarioRepo.FindByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Guardar(usuario, versao)
+	return usuario, nil
+}
+
+func (s *usuarioService) CriarUsuario(usuario *model.Usuario) error {
+	emUso, err := s.usuarioRepo.EmailEmUso(usuario.Email)
+	if err != nil {
 		return err
 	}
+	if emUso {
+		return ErrEmailEmUso
+	}
 	senhaHash, err := criptografaSenha(usuario.Senha)
 	if err != nil {
 		return err
@@ -53,11 +100,247 @@ func (s *usuarioService) CriarUsuario(usuario *model.Usuario) error {
 }
 
 func (s *usuarioService) Update(id uint, empresaID uint, dados map[string]interface{}) error {
-	_, err := s.usuarioRepo.FindByID(id, empresaID)
+	atual, err := s.usuarioRepo.FindByID(id, empresaID)
+	if err != nil {
+		return err
+	}
+	if email, ok := dados["email"].(string); ok && email != atual.Email {
+		emUso, err := s.usuarioRepo.EmailEmUso(email)
+		if err != nil {
+			return err
+		}
+		if emUso {
+			return ErrEmailEmUso
+		}
+	}
+	if err := s.validarEstrutura(id, empresaID, dados); err != nil {
+		return err
+	}
+	if err := s.usuarioRepo.Update(id, empresaID, dados); err != nil {
+		return err
+	}
+	s.cache.InvalidarUsuario(id, empresaID)
+	return nil
+}
+
+func (s *usuarioService) DefinirPin(id uint, empresaID uint, pin string) error {
+	if len(pin) < 4 || len(pin) > 8 || strings.Trim(pin, "0123456789") != "" {
+		return ErrPinInvalido
+	}
+	if _, err := s.usuarioRepo.FindByID(id, empresaID); err != nil {
+		return err
+	}
+	pinHash, err := criptografaSenha(pin)
 	if err != nil {
 		return err
 	}
-	return s.usuarioRepo.Update(id, empresaID, dados)
+	return s.usuarioRepo.Update(id, empresaID, map[string]interface{}{"pin_hash": pinHash})
+}
+
+// validarEstrutura confere cargo, gestor, departamento, centro de custo, matrícula e regra de batida remota antes de gravar (e troca a senha pelo hash): todos precisam ser da mesma
+// empresa, e o gestor não pode ser o próprio usuário nem alguém da sua equipe (o que criaria um ciclo).
+func (s *usuarioService) validarEstrutura(id uint, empresaID uint, dados map[string]interface{}) error {
+	if valor, informado := dados["cargo_id"]; informado {
+		cargoID, ok := idOpcional(valor)
+		if !ok || cargoID == nil {
+			return ErrCargoInvalido
+		}
+		existe, err := s.usuarioRepo.CargoExiste(*cargoID, empresaID)
+		if err != nil {
+			return err
+		}
+		if !existe {
+			return ErrCargoInvalido
+		}
+		dados["cargo_id"] = *cargoID
+	}
+
+	if valor, informado := dados["gestor_id"]; informado {
+		gestorID, ok := idOpcional(valor)
+		if !ok {
+			return ErrGestorInvalido
+		}
+		if gestorID != nil {
+			if *gestorID == id {
+				return ErrGestorInvalido
+			}
+			if _, err := s.usuarioRepo.FindByID(*gestorID, empresaID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrGestorInvalido
+				}
+				return err
+			}
+			ciclo, err := s.usuarioRepo.EhSubordinado(id, *gestorID, empresaID)
+			if err != nil {
+				return err
+			}
+			if ciclo {
+				return ErrGestorInvalido
+			}
+		}
+		dados["gestor_id"] = gestorID
+	}
+
+	if valor, informado := dados["departamento_id"]; informado {
+		departamentoID, ok := idOpcional(valor)
+		if !ok {
+			return ErrDepartamentoInvalido
+		}
+		if departamentoID != nil {
+			existe, err := s.usuarioRepo.DepartamentoExiste(*departamentoID, empresaID)
+			if err != nil {
+				return err
+			}
+			if !existe {
+				return ErrDepartamentoInvalido
+			}
+		}
+		dados["departamento_id"] = departamentoID
+	}
+
+	if valor, informado := dados["centro_custo_id"]; informado {
+		centroCustoID, ok := idOpcional(valor)
+		if !ok {
+			return ErrCentroCustoInvalido
+		}
+		if centroCustoID != nil {
+			ativo, err := s.usuarioRepo.CentroCustoAtivo(*centroCustoID, empresaID)
+			if err != nil {
+				return err
+			}
+			if !ativo {
+				return ErrCentroCustoInvalido
+			}
+		}
+		dados["centro_custo_id"] = centroCustoID
+	}
+
+	if valor, informado := dados["matricula"]; informado && valor != nil {
+		texto, ok := valor.(string)
+		matricula := strings.TrimSpace(texto)
+		if !ok || matricula == "" {
+			return ErrMatriculaInvalida
+		}
+		dono, err := s.usuarioRepo.FindByMatricula(matricula, empresaID)
+		switch {
+		case err == nil && dono.ID != id:
+			return ErrMatriculaEmUso
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		dados["matricula"] = matricula
+	}
+
+	if valor, informado := dados["batida_remota"]; informado && valor != nil {
+		if regra, ok := valor.(string); !ok || !model.BatidaRemotaValida(regra) {
+			return ErrBatidaRemotaInvalida
+		}
+	}
+
+	// A senha nunca é gravada como veio: só o hash.
+	if valor, informado := dados["senha"]; informado {
+		senha, ok := valor.(string)
+		if !ok || senha == "" {
+			return ErrSenhaInvalida
+		}
+		senhaHash, err := criptografaSenha(senha)
+		if err != nil {
+			return err
+		}
+		dados["senha"] = senhaHash
+	}
+	return nil
+}
+
+// idOpcional interpreta um ID vindo de um JSON genérico: null remove o vínculo.
+func idOpcional(valor interface{}) (*uint, bool) {
+	switch v := valor.(type) {
+	case nil:
+		return nil, true
+	case float64:
+		if v < 1 || v != float64(uint(v)) {
+			return nil, false
+		}
+		id := uint(v)
+		return &id, true
+	case uint:
+		if v == 0 {
+			return nil, false
+		}
+		return &v, true
+	}
+	return nil, false
+}
+
+func (s *usuarioService) AlvoNoEscopo(requisitante *model.Usuario, permissao string, alvoID uint) (bool, error) {
+	escopo, concedida := requisitante.Cargo.EscopoPermissao(permissao)
+	if !concedida {
+		return false, nil
+	}
+	if alvoID == requisitante.ID {
+		return true, nil
+	}
+
+	switch escopo {
+	case model.EscopoEmpresa:
+		return true, nil
+	case model.EscopoEquipe:
+		return s.usuarioRepo.EhSubordinado(requisitante.ID, alvoID, requisitante.EmpresaID)
+	case model.EscopoDepartamento:
+		if requisitante.DepartamentoID == nil {
+			return false, nil
+		}
+		return s.usuarioRepo.EstaNoDepartamento(*requisitante.DepartamentoID, alvoID, requisitante.EmpresaID)
+	}
+	return false, nil
+}
+
+func (s *usuarioService) Buscar(empresaID uint, filtro FiltroUsuarios) ([]model.Usuario, error) {
+	return s.usuarioRepo.Buscar(empresaID, filtro)
+}
+
+func (s *usuarioService) FiltrarPorEscopo(requisitante *model.Usuario, permissao string, usuarios []model.Usuario) ([]model.Usuario, error) {
+	escopo, concedida := requisitante.Cargo.EscopoPermissao(permissao)
+	if !concedida {
+		return []model.Usuario{}, nil
+	}
+	if escopo == model.EscopoEmpresa {
+		return usuarios, nil
+	}
+
+	// Em EQUIPE o conjunto guarda IDs de usuários; em DEPARTAMENTO, IDs de departamentos.
+	var ids []uint
+	var err error
+	switch escopo {
+	case model.EscopoEquipe:
+		ids, err = s.usuarioRepo.SubordinadosIDs(requisitante.ID, requisitante.EmpresaID)
+	case model.EscopoDepartamento:
+		if requisitante.DepartamentoID != nil {
+			ids, err = s.usuarioRepo.DepartamentoEDescendentesIDs(*requisitante.DepartamentoID, requisitante.EmpresaID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	permitidos := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		permitidos[id] = true
+	}
+
+	filtrados := make([]model.Usuario, 0, len(usuarios))
+	for _, u := range usuarios {
+		visivel := u.ID == requisitante.ID
+		switch escopo {
+		case model.EscopoEquipe:
+			visivel = visivel || permitidos[u.ID]
+		case model.EscopoDepartamento:
+			visivel = visivel || (u.DepartamentoID != nil && permitidos[*u.DepartamentoID])
+		}
+		if visivel {
+			filtrados = append(filtrados, u)
+		}
+	}
+	return filtrados, nil
 }
 
 func (s *usuarioService) Delete(id uint, empresaID uint) error {
@@ -65,7 +348,26 @@ func (s *usuarioService) Delete(id uint, empresaID uint) error {
 	if err != nil {
 		return err
 	}
-	return s.usuarioRepo.Delete(id, empresaID)
+	if err := s.usuarioRepo.Delete(id, empresaID); err != nil {
+		return err
+	}
+	s.cache.InvalidarUsuario(id, empresaID)
+	return nil
+}
+
+func (s *usuarioService) Restaurar(id uint, empresaID uint) error {
+	usuario, err := s.usuarioRepo.FindExcluido(id, empresaID)
+	if err != nil {
+		return err
+	}
+	if usuario.Cargo.ExcluidoEm.Valid {
+		return ErrCargoExcluido
+	}
+	if err := s.usuarioRepo.Restaurar(id, empresaID); err != nil {
+		return err
+	}
+	s.cache.InvalidarUsuario(id, empresaID)
+	return nil
 }
 
 func (s *usuarioService) FindAll() ([]model.Usuario, error) {
@@ -2,19 +2,38 @@ package usuario
 
 import (
 	"errors"
+	"github.com/Loviiin/ponto-api-go/internal/domain/autorizacao"
 	"github.com/Loviiin/ponto-api-go/internal/model"
+	"github.com/Loviiin/ponto-api-go/pkg/permissions"
 	"gorm.io/gorm"
+	"strings"
 	"testing"
+	"time"
 )
 
 type mockUsuarioRepository struct {
-	SaveFunc        func(usuario *model.Usuario) error
-	FindByEmailFunc func(email string) (*model.Usuario, error)
-	FindByIDFunc    func(id uint) (*model.Usuario, error)
-	GetAllFunc      func() ([]model.Usuario, error)
-	UpdateFunc      func(id uint, dados map[string]interface{}) error
-	DeleteFunc      func(id uint) error
-	CriarFunc       func(usuario *model.Usuario) error
+	SaveFunc            func(usuario *model.Usuario) error
+	FindByEmailFunc     func(email string) (*model.Usuario, error)
+	EmailEmUsoFunc      func(email string) (bool, error)
+	FindByIDFunc        func(id uint, empresaID uint) (*model.Usuario, error)
+	FindByMatriculaFunc func(matricula string, empresaID uint) (*model.Usuario, error)
+	GetAllFunc          func(empresaID uint) ([]model.Usuario, error)
+	UpdateFunc          func(id uint, empresaID uint, dados map[string]interface{}) error
+	DeleteFunc          func(id uint, empresaID uint) error
+	FindExcluidoFunc    func(id uint, empresaID uint) (*model.Usuario, error)
+	RestaurarFunc       func(id uint, empresaID uint) error
+	FindAllFunc         func() ([]model.Usuario, error)
+	CriarFunc           func(usuario *model.Usuario) error
+
+	EhSubordinadoFunc      func(gestorID uint, alvoID uint, empresaID uint) (bool, error)
+	EstaNoDepartamentoFunc func(departamentoID uint, alvoID uint, empresaID uint) (bool, error)
+	DepartamentoExisteFunc func(departamentoID uint, empresaID uint) (bool, error)
+	CargoExisteFunc        func(cargoID uint, empresaID uint) (bool, error)
+
+	CentroCustoAtivoFunc             func(centroCustoID uint, empresaID uint) (bool, error)
+	BuscarFunc                       func(empresaID uint, filtro FiltroUsuarios) ([]model.Usuario, error)
+	SubordinadosIDsFunc              func(gestorID uint, empresaID uint) ([]uint, error)
+	DepartamentoEDescendentesIDsFunc func(departamentoID uint, empresaID uint) ([]uint, error)
 }
 
 func (m *mockUsuarioRepository) Save(usuario *model.Usuario) error {
@@ -25,36 +44,87 @@ func (m *mockUsuarioRepository) FindByEmail(email string) (*model.Usuario, error
 	return m.FindByEmailFunc(email)
 }
 
-func (m *mockUsuarioRepository) FindByID(id uint) (*model.Usuario, error) {
-	return m.FindByIDFunc(id)
+func (m *mockUsuarioRepository) EmailEmUso(email string) (bool, error) {
+	return m.EmailEmUsoFunc(email)
 }
 
-func (m *mockUsuarioRepository) GetAll() ([]model.Usuario, error) {
-	return m.GetAllFunc()
+func (m *mockUsuarioRepository) FindByID(id uint, empresaID uint) (*model.Usuario, error) {
+	return m.FindByIDFunc(id, empresaID)
 }
 
-func (m *mockUsuarioRepository) Update(id uint, dados map[string]interface{}) error {
-	return m.UpdateFunc(id, dados)
+func (m *mockUsuarioRepository) FindByMatricula(matricula string, empresaID uint) (*model.Usuario, error) {
+	return m.FindByMatriculaFunc(matricula, empresaID)
 }
 
-func (m *mockUsuarioRepository) Delete(id uint) error {
-	return m.DeleteFunc(id)
+func (m *mockUsuarioRepository) GetAll(empresaID uint) ([]model.Usuario, error) {
+	return m.GetAllFunc(empresaID)
+}
+
+func (m *mockUsuarioRepository) Update(id uint, empresaID uint, dados map[string]interface{}) error {
+	return m.UpdateFunc(id, empresaID, dados)
+}
+
+func (m *mockUsuarioRepository) Delete(id uint, empresaID uint) error {
+	return m.DeleteFunc(id, empresaID)
+}
+
+func (m *mockUsuarioRepository) FindExcluido(id uint, empresaID uint) (*model.Usuario, error) {
+	return m.FindExcluidoFunc(id, empresaID)
+}
+
+func (m *mockUsuarioRepository) Restaurar(id uint, empresaID uint) error {
+	return m.RestaurarFunc(id, empresaID)
+}
+
+func (m *mockUsuarioRepository) FindAll() ([]model.Usuario, error) {
+	return m.FindAllFunc()
+}
+
+func (m *mockUsuarioRepository) EhSubordinado(gestorID uint, alvoID uint, empresaID uint) (bool, error) {
+	return m.EhSubordinadoFunc(gestorID, alvoID, empresaID)
+}
+
+func (m *mockUsuarioRepository) EstaNoDepartamento(departamentoID uint, alvoID uint, empresaID uint) (bool, error) {
+	return m.EstaNoDepartamentoFunc(departamentoID, alvoID, empresaID)
+}
+
+func (m *mockUsuarioRepository) DepartamentoExiste(departamentoID uint, empresaID uint) (bool, error) {
+	return m.DepartamentoExisteFunc(departamentoID, empresaID)
+}
+
+func (m *mockUsuarioRepository) CargoExiste(cargoID uint, empresaID uint) (bool, error) {
+	return m.CargoExisteFunc(cargoID, empresaID)
+}
+
+func (m *mockUsuarioRepository) CentroCustoAtivo(centroCustoID uint, empresaID uint) (bool, error) {
+	return m.CentroCustoAtivoFunc(centroCustoID, empresaID)
+}
+
+func (m *mockUsuarioRepository) Buscar(empresaID uint, filtro FiltroUsuarios) ([]model.Usuario, error) {
+	return m.BuscarFunc(empresaID, filtro)
+}
+
+func (m *mockUsuarioRepository) SubordinadosIDs(gestorID uint, empresaID uint) ([]uint, error) {
+	return m.SubordinadosIDsFunc(gestorID, empresaID)
+}
+
+func (m *mockUsuarioRepository) DepartamentoEDescendentesIDs(departamentoID uint, empresaID uint) ([]uint, error) {
+	return m.DepartamentoEDescendentesIDsFunc(departamentoID, empresaID)
 }
 
 func TestCriarUsuario_ComSucesso(t *testing.T) {
 	mockRepo := &mockUsuarioRepository{}
 
-	service := NewUsuarioService(mockRepo)
+	service := NewUsuarioService(mockRepo, nil)
 
 	usuarioParaCriar := &model.Usuario{
 		Nome:  "Usuário de Teste",
 		Email: "sucesso@email.com",
 		Senha: "senha123",
-		Cargo: "Testador",
 	}
 
-	mockRepo.FindByEmailFunc = func(email string) (*model.Usuario, error) {
-		return nil, gorm.ErrRecordNotFound
+	mockRepo.EmailEmUsoFunc = func(email string) (bool, error) {
+		return false, nil
 	}
 	mockRepo.SaveFunc = func(usuario *model.Usuario) error {
 		return nil
@@ -68,7 +138,7 @@ func TestCriarUsuario_ComSucesso(t *testing.T) {
 
 func TestFindByIDComSucesso(t *testing.T) {
 	mockRepo := &mockUsuarioRepository{}
-	service := NewUsuarioService(mockRepo)
+	service := NewUsuarioService(mockRepo, nil)
 
 	usuarioID := uint(1)
 	usuarioEsperado := &model.Usuario{
@@ -76,17 +146,16 @@ func TestFindByIDComSucesso(t *testing.T) {
 		Nome:  "Usuário de Teste",
 		Email: "sucesso@email.com",
 		Senha: "senha123",
-		Cargo: "Testador",
 	}
 
-	mockRepo.FindByIDFunc = func(id uint) (*model.Usuario, error) {
+	mockRepo.FindByIDFunc = func(id uint, empresaID uint) (*model.Usuario, error) {
 		if id == usuarioID {
 			return usuarioEsperado, nil
 		}
 		return nil, gorm.ErrRecordNotFound
 	}
 
-	usuario, err := service.FindByID(usuarioID)
+	usuario, err := service.FindByID(usuarioID, 1)
 
 	if err != nil {
 		t.Fatalf("Esperava não ter erro, mas recebeu: %v", err)
@@ -107,13 +176,13 @@ func TestFindByIDComSucesso(t *testing.T) {
 
 func TestCriarUsuario_EmailJaExiste(t *testing.T) {
 	mockRepo := &mockUsuarioRepository{}
-	service := NewUsuarioService(mockRepo)
+	service := NewUsuarioService(mockRepo, nil)
 	usuarioParaCriar := &model.Usuario{
 		Email: "existente@email.com",
 	}
 
-	mockRepo.FindByEmailFunc = func(email string) (*model.Usuario, error) {
-		return &model.Usuario{ID: 1, Email: "existente@email.com"}, nil
+	mockRepo.EmailEmUsoFunc = func(email string) (bool, error) {
+		return email == "existente@email.com", nil
 	}
 
 	err := service.CriarUsuario(usuarioParaCriar)
@@ -128,19 +197,39 @@ func TestCriarUsuario_EmailJaExiste(t *testing.T) {
 	}
 }
 
+func TestUpdate_EmailDeUsuarioExcluidoDaConflito(t *testing.T) {
+	mockRepo := &mockUsuarioRepository{}
+	service := NewUsuarioService(mockRepo, nil)
+
+	mockRepo.FindByIDFunc = func(id uint, empresaID uint) (*model.Usuario, error) {
+		return &model.Usuario{ID: id, EmpresaID: empresaID, Email: "atual@email.com"}, nil
+	}
+	// O dono do e-mail foi excluído: FindByEmail não o acharia, mas o índice único sim.
+	mockRepo.EmailEmUsoFunc = func(email string) (bool, error) {
+		return email == "excluido@email.com", nil
+	}
+	mockRepo.UpdateFunc = func(id uint, empresaID uint, dados map[string]interface{}) error {
+		t.Fatal("O update não deveria chegar ao banco")
+		return nil
+	}
+
+	if err := service.Update(1, 1, map[string]interface{}{"email": "excluido@email.com"}); !errors.Is(err, ErrEmailEmUso) {
+		t.Errorf("Esperava ErrEmailEmUso, recebeu %v", err)
+	}
+}
+
 func TestCriarUsuario_ErroNaCriptografia(t *testing.T) {
 	mockRepo := &mockUsuarioRepository{}
-	service := NewUsuarioService(mockRepo)
+	service := NewUsuarioService(mockRepo, nil)
 
 	usuarioParaCriar := &model.Usuario{
 		Nome:  "Usuário de Teste",
 		Email: "cripto@email.com",
 		Senha: "senha123",
-		Cargo: "Testador",
 	}
 
-	mockRepo.FindByEmailFunc = func(email string) (*model.Usuario, error) {
-		return nil, gorm.ErrRecordNotFound
+	mockRepo.EmailEmUsoFunc = func(email string) (bool, error) {
+		return false, nil
 	}
 
 	originalCriptografaSenha := criptografaSenha
@@ -165,7 +254,7 @@ func TestCriarUsuario_ErroNaCriptografia(t *testing.T) {
 func TestGetAll_ComSucesso(t *testing.T) {
 	// 1. Configurar o mock e o serviço
 	mockRepo := &mockUsuarioRepository{}
-	service := NewUsuarioService(mockRepo)
+	service := NewUsuarioService(mockRepo, nil)
 
 	// Lista de usuários simulada
 	usuariosEsperados := []model.Usuario{
@@ -175,12 +264,12 @@ func TestGetAll_ComSucesso(t *testing.T) {
 
 	// 2. Definir o comportamento esperado do mock
 	// Esperamos que GetAllFunc retorne a lista de usuários e nenhum erro.
-	mockRepo.GetAllFunc = func() ([]model.Usuario, error) {
+	mockRepo.GetAllFunc = func(empresaID uint) ([]model.Usuario, error) {
 		return usuariosEsperados, nil
 	}
 
 	// 3. Executar o método do serviço
-	usuarios, err := service.GetAll()
+	usuarios, err := service.GetAll(1)
 
 	// 4. Fazer as verificações (assertions)
 	if err != nil {
@@ -205,17 +294,17 @@ func TestGetAll_ComSucesso(t *testing.T) {
 func TestGetAll_ComErro(t *testing.T) {
 	// 1. Configurar o mock e o serviço
 	mockRepo := &mockUsuarioRepository{}
-	service := NewUsuarioService(mockRepo)
+	service := NewUsuarioService(mockRepo, nil)
 
 	// 2. Definir o comportamento esperado do mock
 	// Esperamos que GetAllFunc retorne um erro simulado.
 	expectedError := errors.New("erro de banco de dados simulado")
-	mockRepo.GetAllFunc = func() ([]model.Usuario, error) {
+	mockRepo.GetAllFunc = func(empresaID uint) ([]model.Usuario, error) {
 		return nil, expectedError
 	}
 
 	// 3. Executar o método do serviço
-	_, err := service.GetAll()
+	_, err := service.GetAll(1)
 
 	// 4. Fazer as verificações (assertions)
 	if err == nil {
@@ -230,7 +319,7 @@ func TestGetAll_ComErro(t *testing.T) {
 func TestUpdate_UsuarioNaoEncontrado(t *testing.T) {
 	// 1. Configurar o mock e o serviço
 	mockRepo := &mockUsuarioRepository{}
-	service := NewUsuarioService(mockRepo)
+	service := NewUsuarioService(mockRepo, nil)
 
 	usuarioID := uint(999) // Um ID que sabemos que não existe
 	dadosParaAtualizar := map[string]interface{}{"nome": "Usuário Atualizado"}
@@ -238,12 +327,12 @@ func TestUpdate_UsuarioNaoEncontrado(t *testing.T) {
 	// 2. Definir o comportamento esperado do mock
 	// Esperamos que FindByIDFunc retorne gorm.ErrRecordNotFound,
 	// simulando que o usuário não foi encontrado no banco de dados.
-	mockRepo.FindByIDFunc = func(id uint) (*model.Usuario, error) {
+	mockRepo.FindByIDFunc = func(id uint, empresaID uint) (*model.Usuario, error) {
 		return nil, gorm.ErrRecordNotFound
 	}
 
 	// 3. Executar o método do serviço
-	err := service.Update(usuarioID, dadosParaAtualizar)
+	err := service.Update(usuarioID, 1, dadosParaAtualizar)
 
 	// 4. Fazer as verificações (assertions)
 	if err == nil {
@@ -265,19 +354,19 @@ func TestUpdate_UsuarioNaoEncontrado(t *testing.T) {
 func TestDelete_UsuarioNaoEncontrado(t *testing.T) {
 	// 1. Configurar o mock e o serviço
 	mockRepo := &mockUsuarioRepository{}
-	service := NewUsuarioService(mockRepo)
+	service := NewUsuarioService(mockRepo, nil)
 
 	usuarioID := uint(999) // Um ID que sabemos que não existe
 
 	// 2. Definir o comportamento esperado do mock
 	// Esperamos que FindByIDFunc retorne gorm.ErrRecordNotFound,
 	// simulando que o usuário não foi encontrado.
-	mockRepo.FindByIDFunc = func(id uint) (*model.Usuario, error) {
+	mockRepo.FindByIDFunc = func(id uint, empresaID uint) (*model.Usuario, error) {
 		return nil, gorm.ErrRecordNotFound
 	}
 
 	// 3. Executar o método do serviço
-	err := service.Delete(usuarioID)
+	err := service.Delete(usuarioID, 1)
 
 	// 4. Fazer as verificações (assertions)
 	if err == nil {
@@ -295,3 +384,395 @@ func TestDelete_UsuarioNaoEncontrado(t *testing.T) {
 		t.Errorf("Método Delete do repositório foi chamado, mas não deveria")
 	}
 }
+
+// gestorComEscopo monta um requisitante cujo cargo concede EDITAR_USUARIO no escopo dado.
+func gestorComEscopo(escopo string) *model.Usuario {
+	departamento := uint(4)
+	return &model.Usuario{
+		ID:             10,
+		EmpresaID:      1,
+		DepartamentoID: &departamento,
+		Cargo: model.Cargo{
+			Permissoes: []model.Permissao{{ID: 5, Nome: "EDITAR_USUARIO"}},
+			Escopos:    []model.CargoPermissao{{PermissaoID: 5, Escopo: escopo}},
+		},
+	}
+}
+
+func TestAlvoNoEscopo(t *testing.T) {
+	mockRepo := &mockUsuarioRepository{
+		// O usuário 20 é da equipe do gestor 10; o 30 é do departamento 4; o 40 de nenhum dos dois.
+		EhSubordinadoFunc: func(gestorID uint, alvoID uint, empresaID uint) (bool, error) {
+			return gestorID == 10 && alvoID == 20, nil
+		},
+		EstaNoDepartamentoFunc: func(departamentoID uint, alvoID uint, empresaID uint) (bool, error) {
+			return departamentoID == 4 && alvoID == 30, nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	casos := []struct {
+		escopo   string
+		alvo     uint
+		esperado bool
+	}{
+		{model.EscopoProprio, 10, true},
+		{model.EscopoProprio, 20, false},
+		{model.EscopoEquipe, 20, true},
+		{model.EscopoEquipe, 30, false},
+		{model.EscopoDepartamento, 30, true},
+		{model.EscopoDepartamento, 40, false},
+		{model.EscopoEmpresa, 40, true},
+	}
+	for _, caso := range casos {
+		noEscopo, err := service.AlvoNoEscopo(gestorComEscopo(caso.escopo), "EDITAR_USUARIO", caso.alvo)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if noEscopo != caso.esperado {
+			t.Errorf("Escopo %s, alvo %d: esperava %v, recebeu %v", caso.escopo, caso.alvo, caso.esperado, noEscopo)
+		}
+	}
+
+	semPermissao, _ := service.AlvoNoEscopo(gestorComEscopo(model.EscopoEmpresa), "DELETAR_USUARIO", 40)
+	if semPermissao {
+		t.Error("Uma permissão que o cargo não tem nunca deveria ser concedida")
+	}
+}
+
+func TestUpdate_ValidaGestor(t *testing.T) {
+	mockRepo := &mockUsuarioRepository{
+		FindByIDFunc: func(id uint, empresaID uint) (*model.Usuario, error) {
+			return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+		},
+		// O usuário 2 já é subordinado do usuário 1.
+		EhSubordinadoFunc: func(gestorID uint, alvoID uint, empresaID uint) (bool, error) {
+			return gestorID == 1 && alvoID == 2, nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	// Ele mesmo, alguém da própria equipe, e valores que não são IDs.
+	for _, gestor := range []interface{}{float64(1), float64(2), "3", float64(1.5)} {
+		err := service.Update(1, 1, map[string]interface{}{"gestor_id": gestor})
+		if !errors.Is(err, ErrGestorInvalido) {
+			t.Errorf("Gestor %v: esperava ErrGestorInvalido, recebeu %v", gestor, err)
+		}
+	}
+
+	var gravado map[string]interface{}
+	mockRepo.UpdateFunc = func(id uint, empresaID uint, dados map[string]interface{}) error {
+		gravado = dados
+		return nil
+	}
+	if err := service.Update(1, 1, map[string]interface{}{"gestor_id": float64(3)}); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if gestorID, ok := gravado["gestor_id"].(*uint); !ok || *gestorID != 3 {
+		t.Errorf("O gestor deveria ser gravado como ID, recebeu %#v", gravado["gestor_id"])
+	}
+}
+
+func TestFiltrarPorEscopo(t *testing.T) {
+	departamento5 := uint(5)
+	mockRepo := &mockUsuarioRepository{
+		SubordinadosIDsFunc: func(gestorID uint, empresaID uint) ([]uint, error) {
+			return []uint{20}, nil
+		},
+		// O departamento 5 é filho do 4.
+		DepartamentoEDescendentesIDsFunc: func(departamentoID uint, empresaID uint) ([]uint, error) {
+			return []uint{4, 5}, nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	usuarios := []model.Usuario{
+		{ID: 10},
+		{ID: 20},
+		{ID: 30, DepartamentoID: &departamento5},
+		{ID: 40},
+	}
+
+	casos := []struct {
+		escopo   string
+		esperado []uint
+	}{
+		{model.EscopoProprio, []uint{10}},
+		{model.EscopoEquipe, []uint{10, 20}},
+		{model.EscopoDepartamento, []uint{10, 30}},
+		{model.EscopoEmpresa, []uint{10, 20, 30, 40}},
+	}
+	for _, caso := range casos {
+		filtrados, err := service.FiltrarPorEscopo(gestorComEscopo(caso.escopo), "EDITAR_USUARIO", usuarios)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		var ids []uint
+		for _, u := range filtrados {
+			ids = append(ids, u.ID)
+		}
+		if len(ids) != len(caso.esperado) {
+			t.Errorf("Escopo %s: esperava %v, recebeu %v", caso.escopo, caso.esperado, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != caso.esperado[i] {
+				t.Errorf("Escopo %s: esperava %v, recebeu %v", caso.escopo, caso.esperado, ids)
+				break
+			}
+		}
+	}
+}
+
+func TestUpdate_CentroCustoInativo(t *testing.T) {
+	mockRepo := &mockUsuarioRepository{
+		FindByIDFunc: func(id uint, empresaID uint) (*model.Usuario, error) {
+			return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+		},
+		CentroCustoAtivoFunc: func(centroCustoID uint, empresaID uint) (bool, error) {
+			return centroCustoID == 7, nil
+		},
+		UpdateFunc: func(id uint, empresaID uint, dados map[string]interface{}) error {
+			return nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	if err := service.Update(1, 1, map[string]interface{}{"centro_custo_id": float64(8)}); !errors.Is(err, ErrCentroCustoInvalido) {
+		t.Errorf("Esperava ErrCentroCustoInvalido, recebeu %v", err)
+	}
+	if err := service.Update(1, 1, map[string]interface{}{"centro_custo_id": float64(7)}); err != nil {
+		t.Errorf("Erro inesperado: %v", err)
+	}
+}
+
+func TestUpdate_CargoDeOutraEmpresa(t *testing.T) {
+	mockRepo := &mockUsuarioRepository{
+		FindByIDFunc: func(id uint, empresaID uint) (*model.Usuario, error) {
+			return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+		},
+		CargoExisteFunc: func(cargoID uint, empresaID uint) (bool, error) {
+			return cargoID == 4 && empresaID == 1, nil
+		},
+		UpdateFunc: func(id uint, empresaID uint, dados map[string]interface{}) error {
+			return nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	for _, valor := range []interface{}{float64(9), nil, "4"} {
+		if err := service.Update(1, 1, map[string]interface{}{"cargo_id": valor}); !errors.Is(err, ErrCargoInvalido) {
+			t.Errorf("cargo_id %v: esperava ErrCargoInvalido, recebeu %v", valor, err)
+		}
+	}
+	if err := service.Update(1, 1, map[string]interface{}{"cargo_id": float64(4)}); err != nil {
+		t.Errorf("Erro inesperado: %v", err)
+	}
+}
+
+func TestPodeTrocarCargo(t *testing.T) {
+	cargo := func(escopoEdicao string, gerenciaCargos bool) *model.Usuario {
+		c := model.Cargo{
+			Permissoes: []model.Permissao{{ID: 1, Nome: permissions.EDITAR_USUARIO}},
+			Escopos:    []model.CargoPermissao{{PermissaoID: 1, Escopo: escopoEdicao}},
+		}
+		if gerenciaCargos {
+			c.Permissoes = append(c.Permissoes, model.Permissao{ID: 2, Nome: permissions.GERENCIAR_CARGOS})
+		}
+		return &model.Usuario{ID: 1, Cargo: c}
+	}
+	if podeTrocarCargo(cargo(model.EscopoEquipe, true)) {
+		t.Error("Um gestor com escopo EQUIPE não deveria trocar o cargo dos subordinados")
+	}
+	if podeTrocarCargo(cargo(model.EscopoEmpresa, false)) {
+		t.Error("Sem GERENCIAR_CARGOS não deveria trocar cargos")
+	}
+	if !podeTrocarCargo(cargo(model.EscopoEmpresa, true)) {
+		t.Error("Com escopo EMPRESA e GERENCIAR_CARGOS deveria trocar cargos")
+	}
+}
+
+func TestCamposNaoEditaveis(t *testing.T) {
+	dados := map[string]interface{}{
+		"nome": "Ana", "cargo_id": float64(2), "saldo_banco_horas_minutos": float64(600),
+		"data_exclusao": "2026-01-01T00:00:00Z", "data_anonimizacao": nil, "senha": "nova",
+	}
+	if proibidos := camposNaoEditaveis(dados, true); strings.Join(proibidos, ",") != "cargo_id,data_anonimizacao,data_exclusao,saldo_banco_horas_minutos" {
+		t.Errorf("Na própria conta, esperava recusar cargo, exclusão, anonimização e saldo, recebeu %v", proibidos)
+	}
+	if proibidos := camposNaoEditaveis(dados, false); strings.Join(proibidos, ",") != "data_anonimizacao,data_exclusao,saldo_banco_horas_minutos,senha" {
+		t.Errorf("Editando outro usuário, esperava recusar exclusão, anonimização, saldo e senha, recebeu %v", proibidos)
+	}
+	if proibidos := camposNaoEditaveis(map[string]interface{}{"nome": "Ana", "gestor_id": nil}, false); len(proibidos) != 0 {
+		t.Errorf("Campos permitidos não deveriam ser recusados, recebeu %v", proibidos)
+	}
+}
+
+func TestUpdate_GravaSoOHashDaSenha(t *testing.T) {
+	original := criptografaSenha
+	defer func() { criptografaSenha = original }()
+	criptografaSenha = func(senha string) (string, error) { return "hash:" + senha, nil }
+
+	var gravado map[string]interface{}
+	mockRepo := &mockUsuarioRepository{
+		FindByIDFunc: func(id uint, empresaID uint) (*model.Usuario, error) {
+			return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+		},
+		UpdateFunc: func(id uint, empresaID uint, dados map[string]interface{}) error {
+			gravado = dados
+			return nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, autorizacao.NovoCache(0))
+
+	if err := service.Update(1, 1, map[string]interface{}{"senha": "segredo"}); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if gravado["senha"] != "hash:segredo" {
+		t.Errorf("Esperava gravar o hash da senha, gravou %v", gravado["senha"])
+	}
+	if err := service.Update(1, 1, map[string]interface{}{"senha": ""}); !errors.Is(err, ErrSenhaInvalida) {
+		t.Errorf("Esperava ErrSenhaInvalida para senha vazia, recebeu %v", err)
+	}
+}
+
+func TestAlvoNoEscopo_PermissaoHerdada(t *testing.T) {
+	mockRepo := &mockUsuarioRepository{
+		EhSubordinadoFunc: func(gestorID uint, alvoID uint, empresaID uint) (bool, error) {
+			return false, nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	// O cargo próprio concede EDITAR_USUARIO só na equipe; o cargo herdado concede na empresa.
+	requisitante := gestorComEscopo(model.EscopoEquipe)
+	requisitante.Cargo.Herdados = []model.Cargo{{
+		ID:         1,
+		Nome:       "Base",
+		Permissoes: []model.Permissao{{ID: 5, Nome: "EDITAR_USUARIO"}, {ID: 6, Nome: "VER_AUDITORIA"}},
+		Escopos:    []model.CargoPermissao{{PermissaoID: 5, Escopo: model.EscopoEmpresa}, {PermissaoID: 6, Escopo: model.EscopoEmpresa}},
+	}}
+
+	noEscopo, err := service.AlvoNoEscopo(requisitante, "EDITAR_USUARIO", 40)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if !noEscopo {
+		t.Error("O escopo mais amplo entre o cargo e os herdados deveria prevalecer")
+	}
+
+	efetivas := requisitante.Cargo.PermissoesEfetivas()
+	if len(efetivas) != 2 {
+		t.Fatalf("Esperava 2 permissões efetivas, recebeu %+v", efetivas)
+	}
+	if !efetivas[1].Herdada || efetivas[1].OrigemNome != "Base" {
+		t.Errorf("VER_AUDITORIA deveria aparecer como herdada de Base: %+v", efetivas[1])
+	}
+}
+
+func TestResolver_UsaCacheAteInvalidar(t *testing.T) {
+	consultas := 0
+	mockRepo := &mockUsuarioRepository{
+		FindByIDFunc: func(id uint, empresaID uint) (*model.Usuario, error) {
+			consultas++
+			return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+		},
+		UpdateFunc: func(id uint, empresaID uint, dados map[string]interface{}) error {
+			return nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, autorizacao.NovoCache(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.Resolver(1, 1); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+	}
+	if consultas != 1 {
+		t.Fatalf("Esperava 1 consulta ao banco, houve %d", consultas)
+	}
+
+	// Update consulta o usuário para validar e, depois de gravar, descarta o cache.
+	if err := service.Update(1, 1, map[string]interface{}{"nome": "Novo"}); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	consultas = 0
+	if _, err := service.Resolver(1, 1); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if consultas != 1 {
+		t.Errorf("Depois de atualizar o usuário, o Resolver deveria consultar o banco de novo")
+	}
+}
+
+func TestRestaurar_CargoExcluido(t *testing.T) {
+	restaurado := false
+	mockRepo := &mockUsuarioRepository{
+		FindExcluidoFunc: func(id uint, empresaID uint) (*model.Usuario, error) {
+			usuario := &model.Usuario{ID: id, EmpresaID: empresaID, CargoID: 3}
+			usuario.Cargo.ExcluidoEm = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			return usuario, nil
+		},
+		RestaurarFunc: func(id uint, empresaID uint) error {
+			restaurado = true
+			return nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	if err := service.Restaurar(7, 1); !errors.Is(err, ErrCargoExcluido) {
+		t.Fatalf("Esperava ErrCargoExcluido, recebeu %v", err)
+	}
+	if restaurado {
+		t.Error("O usuário não deveria ser restaurado com o cargo excluído")
+	}
+}
+
+func TestUpdate_MatriculaUnicaNaEmpresa(t *testing.T) {
+	var gravado map[string]interface{}
+	mockRepo := &mockUsuarioRepository{
+		FindByIDFunc: func(id uint, empresaID uint) (*model.Usuario, error) {
+			return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+		},
+		// A matrícula F-1 já é do usuário 2.
+		FindByMatriculaFunc: func(matricula string, empresaID uint) (*model.Usuario, error) {
+			if matricula == "F-1" {
+				return &model.Usuario{ID: 2, EmpresaID: empresaID}, nil
+			}
+			return nil, gorm.ErrRecordNotFound
+		},
+		UpdateFunc: func(id uint, empresaID uint, dados map[string]interface{}) error {
+			gravado = dados
+			return nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	if err := service.Update(1, 1, map[string]interface{}{"matricula": "F-1"}); !errors.Is(err, ErrMatriculaEmUso) {
+		t.Errorf("Esperava ErrMatriculaEmUso, recebeu %v", err)
+	}
+	if err := service.Update(1, 1, map[string]interface{}{"matricula": "  "}); !errors.Is(err, ErrMatriculaInvalida) {
+		t.Errorf("Esperava ErrMatriculaInvalida, recebeu %v", err)
+	}
+	if err := service.Update(2, 1, map[string]interface{}{"matricula": " F-1 "}); err != nil {
+		t.Errorf("O dono da matrícula deveria poder regravá-la, recebeu %v", err)
+	}
+	if gravado["matricula"] != "F-1" {
+		t.Errorf("A matrícula deveria ser gravada sem espaços, recebeu %v", gravado["matricula"])
+	}
+}
+
+func TestDefinirPin_ExigeDeQuatroAOitoDigitos(t *testing.T) {
+	mockRepo := &mockUsuarioRepository{
+		FindByIDFunc: func(id uint, empresaID uint) (*model.Usuario, error) {
+			return &model.Usuario{ID: id, EmpresaID: empresaID}, nil
+		},
+	}
+	service := NewUsuarioService(mockRepo, nil)
+
+	for _, pin := range []string{"123", "123456789", "12a4", ""} {
+		if err := service.DefinirPin(1, 1, pin); !errors.Is(err, ErrPinInvalido) {
+			t.Errorf("PIN %q: esperava ErrPinInvalido, recebeu %v", pin, err)
+		}
+	}
+}
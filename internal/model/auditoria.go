@@ -0,0 +1,73 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// JSONB guarda um documento JSON já serializado numa coluna jsonb.
+type JSONB []byte
+
+func (j JSONB) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSONB) Scan(valor interface{}) error {
+	switch v := valor.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSONB(v)
+	default:
+		return errors.New("valor incompatível com jsonb")
+	}
+	return nil
+}
+
+func (j JSONB) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j *JSONB) UnmarshalJSON(dados []byte) error {
+	*j = append((*j)[:0], dados...)
+	return nil
+}
+
+const (
+	AtorUsuario  = "usuario"
+	AtorChaveAPI = "chave_api"
+	AtorOperador = "operador"
+	AtorQuiosque = "quiosque"
+	AtorAnonimo  = "anonimo"
+	AtorSistema  = "sistema"
+)
+
+// RegistroAuditoria é uma entrada do log de auditoria. A tabela é somente de inserção:
+// um gatilho no banco recusa UPDATE e DELETE.
+type RegistroAuditoria struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time `gorm:"column:data_criacao;index" json:"data_criacao"`
+	EmpresaID      *uint     `gorm:"index" json:"empresa_id,omitempty"`
+	AtorTipo       string    `gorm:"not null" json:"ator_tipo"`
+	AtorID         *uint     `gorm:"index" json:"ator_id,omitempty"`
+	ImpersonadoPor *uint     `json:"impersonado_por,omitempty"`
+	Acao           string    `gorm:"not null;index" json:"acao"`
+	Entidade       string    `gorm:"index:idx_auditoria_entidade" json:"entidade,omitempty"`
+	EntidadeID     string    `gorm:"index:idx_auditoria_entidade" json:"entidade_id,omitempty"`
+	// Alteracoes tem o formato {"campo": {"antes": ..., "depois": ...}}.
+	Alteracoes JSONB  `gorm:"type:jsonb" json:"alteracoes,omitempty"`
+	Metodo     string `json:"metodo,omitempty"`
+	Rota       string `json:"rota,omitempty"`
+	Status     int    `json:"status,omitempty"`
+	IP         string `json:"ip,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+}
@@ -1,12 +1,86 @@
 package model
 
+import "gorm.io/gorm"
+
 type Cargo struct {
-	ID                        uint        `gorm:"primaryKey" json:"id"`
-	Nome                      string      `gorm:"not null" json:"nome"`
-	EmpresaID                 uint        `gorm:"not null" json:"empresa_id"`
-	Permissoes                []Permissao `gorm:"many2many:cargo_permissoes;" json:"permissoes,omitempty"`
-	CargaHorariaDiariaMinutos uint        `json:"carga_horaria_diaria_minutos"`
-	EntradaEsperadaMinutos    uint        `json:"entrada_esperada_minutos"`
-	SaidaEsperadaMinutos      uint        `json:"saida_esperada_minutos"`
-	MinutosAlmocoEsperado     uint        `json:"minutos_almoco_esperado"`
+	ID                        uint             `gorm:"primaryKey" json:"id"`
+	Nome                      string           `gorm:"not null" json:"nome"`
+	EmpresaID                 uint             `gorm:"not null" json:"empresa_id"`
+	HerdaDeID                 *uint            `gorm:"index" json:"herda_de_id"` // cargo modelo cujas permissões este cargo herda
+	Permissoes                []Permissao      `gorm:"many2many:cargo_permissoes;" json:"permissoes,omitempty"`
+	Escopos                   []CargoPermissao `gorm:"foreignKey:CargoID" json:"escopos,omitempty"`
+	CargaHorariaDiariaMinutos uint             `json:"carga_horaria_diaria_minutos"`
+	EntradaEsperadaMinutos    uint             `json:"entrada_esperada_minutos"`
+	SaidaEsperadaMinutos      uint             `json:"saida_esperada_minutos"`
+	MinutosAlmocoEsperado     uint             `json:"minutos_almoco_esperado"`
+	BatidaRemota              string           `gorm:"not null;default:PERMITIR" json:"batida_remota"` // PERMITIR, BLOQUEAR ou REVISAR
+	ExcluidoEm                gorm.DeletedAt   `gorm:"column:data_exclusao;index" json:"-"`
+
+	// Herdados são os cargos acima deste na cadeia de herança, do mais próximo ao mais distante.
+	// Só é preenchido pelo repositório de usuários, ao carregar o cargo de quem faz a requisição.
+	Herdados []Cargo `gorm:"-" json:"-"`
+}
+
+// PermissaoEfetiva é uma permissão que o cargo concede, diretamente ou por herança.
+type PermissaoEfetiva struct {
+	Nome       string `json:"nome"`
+	Descricao  string `json:"descricao"`
+	Escopo     string `json:"escopo"`
+	OrigemID   uint   `json:"origem_cargo_id"`
+	OrigemNome string `json:"origem_cargo"`
+	Herdada    bool   `json:"herdada"`
+}
+
+// EscopoPermissao devolve o escopo em que o cargo concede a permissão, considerando os cargos
+// herdados; se mais de um concede, vale o escopo mais amplo. Se as linhas de escopo não foram
+// carregadas, a concessão vale só para o próprio usuário: na dúvida, o mínimo.
+func (c *Cargo) EscopoPermissao(nome string) (string, bool) {
+	for _, p := range c.PermissoesEfetivas() {
+		if p.Nome == nome {
+			return p.Escopo, true
+		}
+	}
+	return "", false
+}
+
+// PermissoesEfetivas junta as permissões do cargo com as dos cargos herdados, na ordem em que
+// aparecem. Uma permissão repetida fica com o escopo mais amplo e com o cargo que o concede.
+func (c *Cargo) PermissoesEfetivas() []PermissaoEfetiva {
+	efetivas := make([]PermissaoEfetiva, 0)
+	posicao := make(map[string]int)
+
+	cadeia := append([]Cargo{*c}, c.Herdados...)
+	for i, cargo := range cadeia {
+		for _, p := range cargo.Permissoes {
+			escopo := cargo.escopoDireto(p.ID)
+			if j, repetida := posicao[p.Nome]; repetida {
+				if ordemEscopo[escopo] > ordemEscopo[efetivas[j].Escopo] {
+					efetivas[j].Escopo = escopo
+					efetivas[j].OrigemID = cargo.ID
+					efetivas[j].OrigemNome = cargo.Nome
+					efetivas[j].Herdada = i > 0
+				}
+				continue
+			}
+			posicao[p.Nome] = len(efetivas)
+			efetivas = append(efetivas, PermissaoEfetiva{
+				Nome:       p.Nome,
+				Descricao:  p.Descricao,
+				Escopo:     escopo,
+				OrigemID:   cargo.ID,
+				OrigemNome: cargo.Nome,
+				Herdada:    i > 0,
+			})
+		}
+	}
+	return efetivas
+}
+
+func (c *Cargo) escopoDireto(permissaoID uint) string {
+	for _, e := range c.Escopos {
+		if e.PermissaoID == permissaoID && e.Escopo != "" {
+			return e.Escopo
+		}
+	}
+	return EscopoProprio
 }
@@ -0,0 +1,38 @@
+package model
+
+// Escopos de uma permissão, do mais restrito ao mais amplo. Cada escopo inclui o anterior.
+const (
+	EscopoProprio      = "PROPRIO"
+	EscopoEquipe       = "EQUIPE"
+	EscopoDepartamento = "DEPARTAMENTO"
+	EscopoEmpresa      = "EMPRESA"
+)
+
+// ordemEscopo dá a amplitude de cada escopo, para escolher o maior quando há mais de uma concessão.
+var ordemEscopo = map[string]int{
+	EscopoProprio:      1,
+	EscopoEquipe:       2,
+	EscopoDepartamento: 3,
+	EscopoEmpresa:      4,
+}
+
+// EscopoValido informa se o texto é um dos escopos conhecidos.
+func EscopoValido(escopo string) bool {
+	switch escopo {
+	case EscopoProprio, EscopoEquipe, EscopoDepartamento, EscopoEmpresa:
+		return true
+	}
+	return false
+}
+
+// CargoPermissao é a linha da tabela de junção entre cargos e permissões, com o escopo
+// (próprio usuário, equipe, departamento ou empresa) sobre o qual a permissão vale.
+type CargoPermissao struct {
+	CargoID     uint   `gorm:"primaryKey" json:"cargo_id"`
+	PermissaoID uint   `gorm:"primaryKey" json:"permissao_id"`
+	Escopo      string `gorm:"not null;default:EMPRESA" json:"escopo"`
+}
+
+func (CargoPermissao) TableName() string {
+	return "cargo_permissoes"
+}
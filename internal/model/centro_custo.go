@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// CentroCusto é a unidade contábil à qual as horas de um funcionário são apropriadas.
+type CentroCusto struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	EmpresaID uint      `gorm:"not null;uniqueIndex:idx_centro_custo_codigo" json:"empresa_id"`
+	Codigo    string    `gorm:"not null;uniqueIndex:idx_centro_custo_codigo" json:"codigo"`
+	Nome      string    `gorm:"not null" json:"nome"`
+	Ativo     bool      `gorm:"not null" json:"ativo"`
+	CreatedAt time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ChaveAPI é uma credencial de integração máquina-a-máquina de uma empresa.
+// Só o hash do segredo é guardado; o prefixo permite identificar a chave sem revelá-la.
+type ChaveAPI struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	EmpresaID   uint        `gorm:"not null;index" json:"empresa_id"`
+	Nome        string      `gorm:"not null" json:"nome"`
+	Prefixo     string      `gorm:"uniqueIndex;not null" json:"prefixo"`
+	Hash        string      `gorm:"not null" json:"-"`
+	Escopos     []Permissao `gorm:"many2many:chave_api_escopos;" json:"escopos"`
+	CriadoPorID uint        `gorm:"not null" json:"criado_por_id"`
+	ExpiraEm    *time.Time  `json:"expira_em,omitempty"`
+	UltimoUsoEm *time.Time  `json:"ultimo_uso_em,omitempty"`
+	RevogadaEm  *time.Time  `json:"revogada_em,omitempty"`
+	CreatedAt   time.Time   `gorm:"column:data_criacao" json:"data_criacao"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Convite é o convite enviado por um administrador para alguém entrar na empresa com um cargo definido.
+type Convite struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	EmpresaID      uint       `gorm:"not null;index" json:"empresa_id"`
+	Email          string     `gorm:"not null" json:"email"`
+	Nome           string     `json:"nome"`
+	CargoID        uint       `gorm:"not null" json:"cargo_id"`
+	ConvidadoPorID uint       `gorm:"not null" json:"convidado_por_id"`
+	ExpiraEm       time.Time  `gorm:"not null" json:"expira_em"`
+	AceitoEm       *time.Time `json:"aceito_em,omitempty"`
+	UsuarioID      *uint      `json:"usuario_id,omitempty"`
+	CanceladoEm    *time.Time `json:"cancelado_em,omitempty"`
+	CreatedAt      time.Time  `gorm:"column:data_criacao" json:"data_criacao"`
+}
+
+const (
+	SolicitacaoPendente  = "PENDENTE"
+	SolicitacaoAprovada  = "APROVADA"
+	SolicitacaoRejeitada = "REJEITADA"
+)
+
+// SolicitacaoCadastro é um pedido de cadastro público, que só vira convite após aprovação.
+type SolicitacaoCadastro struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	EmpresaID     uint       `gorm:"not null;index" json:"empresa_id"`
+	Nome          string     `gorm:"not null" json:"nome"`
+	Email         string     `gorm:"not null" json:"email"`
+	Status        string     `gorm:"not null;default:PENDENTE" json:"status"`
+	AvaliadoPorID *uint      `json:"avaliado_por_id,omitempty"`
+	AvaliadoEm    *time.Time `json:"avaliado_em,omitempty"`
+	ConviteID     *uint      `json:"convite_id,omitempty"`
+	CreatedAt     time.Time  `gorm:"column:data_criacao" json:"data_criacao"`
+}
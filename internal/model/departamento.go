@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Departamento agrupa funcionários. Departamentos podem conter subdepartamentos.
+type Departamento struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	EmpresaID         uint      `gorm:"not null;index" json:"empresa_id"`
+	Nome              string    `gorm:"not null" json:"nome"`
+	DepartamentoPaiID *uint     `gorm:"index" json:"departamento_pai_id,omitempty"`
+	CreatedAt         time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Situação de um dispositivo. Um aparelho novo entra como pendente e só bate ponto, nas empresas
+// que exigem dispositivos autorizados, depois de aprovado por um gestor ou ativado com um código.
+const (
+	DispositivoPendente  = "PENDENTE"
+	DispositivoAprovado  = "APROVADO"
+	DispositivoRejeitado = "REJEITADO"
+	DispositivoRevogado  = "REVOGADO"
+)
+
+// Dispositivo é um aparelho de onde um funcionário bate o ponto. O mesmo aparelho usado por dois
+// funcionários vira dois registros com o mesmo Identificador, o que permite apontar o compartilhamento.
+type Dispositivo struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+	// Identificador é o que o aplicativo envia para reconhecer o aparelho (ex: um UUID gerado na
+	// instalação ou a impressão digital do aparelho).
+	Identificador string     `gorm:"not null;index;uniqueIndex:idx_dispositivos_usuario_identificador" json:"identificador"`
+	Nome          string     `json:"nome,omitempty"`
+	Status        string     `gorm:"not null;index" json:"status"`
+	DecididoPorID *uint      `json:"decidido_por_id,omitempty"`
+	DecididoEm    *time.Time `json:"decidido_em,omitempty"`
+	UltimoUsoEm   *time.Time `json:"ultimo_uso_em,omitempty"`
+
+	UsuarioID uint    `gorm:"not null;uniqueIndex:idx_dispositivos_usuario_identificador" json:"usuario_id"`
+	Usuario   Usuario `json:"-"`
+	EmpresaID uint    `gorm:"not null;index" json:"empresa_id"`
+	Empresa   Empresa `json:"-"`
+}
+
+// CodigoDispositivo é o código de uso único, gerado por um gestor, com que o funcionário ativa
+// sozinho um aparelho novo. Há no máximo um por funcionário; só o hash é guardado.
+type CodigoDispositivo struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	CreatedAt   time.Time `gorm:"column:data_criacao" json:"-"`
+	UsuarioID   uint      `gorm:"not null;uniqueIndex" json:"-"`
+	EmpresaID   uint      `gorm:"not null;index" json:"-"`
+	Hash        string    `gorm:"not null" json:"-"`
+	ExpiraEm    time.Time `gorm:"not null" json:"-"`
+	Tentativas  int       `gorm:"not null;default:0" json:"-"`
+	CriadoPorID uint      `gorm:"not null" json:"-"`
+}
@@ -1,9 +1,33 @@
 package model
 
+import "gorm.io/gorm"
+
 type Empresa struct {
 	ID                 uint    `gorm:"primaryKey" json:"id"`
 	Nome               string  `gorm:"not null" json:"nome"`
 	SedeLatitude       float64 `json:"sedeLatitude"`
 	SedeLongitude      float64 `json:"sedeLongitude"`
 	RaioGeofenceMetros float64 `json:"raioGeofenceMetros"`
+	// CadastroPublico permite pedidos de cadastro sem convite, que entram numa fila de aprovação.
+	CadastroPublico bool `gorm:"not null;default:false" json:"cadastro_publico"`
+	// CargoCadastroPublicoID é o cargo sugerido para quem é aprovado pela fila.
+	CargoCadastroPublicoID *uint `json:"cargo_cadastro_publico_id"`
+	// IntervaloMinimoBatidaSegundos recusa uma batida tão próxima de outra do mesmo funcionário (o
+	// toque duplo no botão); zero desativa a regra.
+	IntervaloMinimoBatidaSegundos int `gorm:"not null;default:60" json:"intervalo_minimo_batida_segundos"`
+	// ExigirFotoBatida recusa as batidas feitas pelo aplicativo sem selfie.
+	ExigirFotoBatida bool `gorm:"not null;default:false" json:"exigir_foto_batida"`
+	// RetencaoFotosDias é por quantos dias as selfies são guardadas depois da batida; zero as
+	// guarda enquanto o ponto existir.
+	RetencaoFotosDias int `gorm:"not null;default:90" json:"retencao_fotos_dias"`
+	// ExigirDispositivoAutorizado aceita batidas do aplicativo só de aparelhos aprovados para o
+	// funcionário, que pode ter até MaximoDispositivos deles.
+	ExigirDispositivoAutorizado bool `gorm:"not null;default:false" json:"exigir_dispositivo_autorizado"`
+	MaximoDispositivos          int  `gorm:"not null;default:2" json:"maximo_dispositivos"`
+	// FusoHorario (nome IANA) define onde começa e termina o dia dos funcionários e a hora local
+	// avaliada pelas políticas.
+	FusoHorario string `gorm:"not null;default:America/Sao_Paulo" json:"fuso_horario"`
+	// ExcluidoEm marca a exclusão lógica: os usuários da empresa deixam de entrar, e os dados são
+	// expurgados só depois do prazo de retenção legal.
+	ExcluidoEm gorm.DeletedAt `gorm:"column:data_exclusao;index" json:"-"`
 }
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// FechamentoDia registra que um dia já foi somado ao banco de horas do funcionário. O índice único
+// impede que o mesmo dia seja somado duas vezes, seja pelo agendador, seja pela API.
+type FechamentoDia struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+	// Dia é a data de calendário no fuso da empresa, gravada como meia-noite UTC.
+	Dia          time.Time `gorm:"type:date;not null;uniqueIndex:idx_fechamentos_usuario_dia,priority:2" json:"dia"`
+	SaldoMinutos int       `gorm:"not null" json:"saldo_minutos"`
+
+	UsuarioID uint    `gorm:"not null;uniqueIndex:idx_fechamentos_usuario_dia,priority:1" json:"usuario_id"`
+	Usuario   Usuario `json:"-"`
+	EmpresaID uint    `gorm:"not null;index" json:"empresa_id"`
+	Empresa   Empresa `json:"-"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// LocalTrabalho é uma cerca onde a empresa aceita pontos presenciais: uma filial, um cliente, um
+// campus. A Geometria é GeoJSON: um Point com RaioMetros (círculo) ou um Polygon.
+type LocalTrabalho struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	EmpresaID  uint    `gorm:"not null;index" json:"empresa_id"`
+	Nome       string  `gorm:"not null" json:"nome"`
+	Geometria  JSONB   `gorm:"type:jsonb;not null" json:"geometria"`
+	RaioMetros float64 `json:"raio_metros,omitempty"`
+	Ativo      bool    `gorm:"not null" json:"ativo"`
+	// FusoHorario substitui o da empresa na hora local das batidas feitas neste local; nulo segue a
+	// empresa.
+	FusoHorario *string   `json:"fuso_horario"`
+	CreatedAt   time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+}
+
+// LocalTrabalhoAtribuicao libera um local para um funcionário ou para todos os de um cargo.
+// Exatamente um entre UsuarioID e CargoID é preenchido.
+type LocalTrabalhoAtribuicao struct {
+	ID              uint  `gorm:"primaryKey" json:"id"`
+	EmpresaID       uint  `gorm:"not null;index" json:"empresa_id"`
+	LocalTrabalhoID uint  `gorm:"not null;index" json:"local_trabalho_id"`
+	UsuarioID       *uint `gorm:"index" json:"usuario_id,omitempty"`
+	CargoID         *uint `gorm:"index" json:"cargo_id,omitempty"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// ConfiguracaoOIDC guarda os dados do provedor de identidade corporativo de uma empresa.
+type ConfiguracaoOIDC struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	EmpresaID    uint   `gorm:"uniqueIndex;not null" json:"empresa_id"`
+	Issuer       string `gorm:"not null" json:"issuer"`
+	ClientID     string `gorm:"not null" json:"client_id"`
+	ClientSecret string `gorm:"not null" json:"-"`
+	// DominiosPermitidos é uma lista separada por vírgulas (ex: "empresa.com,empresa.com.br").
+	DominiosPermitidos string `gorm:"not null" json:"dominios_permitidos"`
+	// ClaimCargo é a claim do ID token usada no mapeamento para cargos (ex: "groups", "roles").
+	ClaimCargo                string                `json:"claim_cargo"`
+	Mapeamentos               []MapeamentoCargoOIDC `gorm:"foreignKey:ConfiguracaoOIDCID;constraint:OnDelete:CASCADE" json:"mapeamentos"`
+	CargoPadraoID             *uint                 `json:"cargo_padrao_id"`
+	ProvisionamentoAutomatico bool                  `json:"provisionamento_automatico"`
+	Ativo                     bool                  `json:"ativo"`
+}
+
+// MapeamentoCargoOIDC associa um valor da claim configurada a um cargo da empresa.
+type MapeamentoCargoOIDC struct {
+	ID                 uint   `gorm:"primaryKey" json:"id"`
+	ConfiguracaoOIDCID uint   `gorm:"not null;index" json:"-"`
+	ValorClaim         string `gorm:"not null" json:"valor_claim"`
+	CargoID            uint   `gorm:"not null" json:"cargo_id"`
+}
+
+// SessaoOIDC guarda o state, o nonce e o code_verifier de um login em andamento.
+type SessaoOIDC struct {
+	ID           uint      `gorm:"primaryKey"`
+	State        string    `gorm:"uniqueIndex;not null"`
+	EmpresaID    uint      `gorm:"not null"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	ExpiraEm     time.Time `gorm:"not null"`
+}
+
+// IdentidadeExterna vincula um usuário ao sujeito (sub) de um provedor OIDC.
+type IdentidadeExterna struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UsuarioID uint      `gorm:"not null;index" json:"usuario_id"`
+	EmpresaID uint      `gorm:"not null" json:"empresa_id"`
+	Issuer    string    `gorm:"not null;uniqueIndex:idx_identidade_issuer_subject" json:"issuer"`
+	Subject   string    `gorm:"not null;uniqueIndex:idx_identidade_issuer_subject" json:"subject"`
+	CreatedAt time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// OperadorPlataforma é quem administra a plataforma como um todo (super-admin).
+// Não pertence a nenhuma empresa e não tem cargo: é uma identidade separada dos usuários dos tenants.
+type OperadorPlataforma struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Nome      string    `gorm:"not null" json:"nome"`
+	Email     string    `gorm:"unique;not null" json:"email"`
+	Senha     string    `gorm:"not null" json:"-"`
+	Ativo     bool      `gorm:"not null;default:true" json:"ativo"`
+	CreatedAt time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+}
+
+// Impersonacao registra cada vez que um operador passou a agir como um usuário de uma empresa.
+type Impersonacao struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	OperadorID uint      `gorm:"not null;index" json:"operador_id"`
+	EmpresaID  uint      `gorm:"not null;index" json:"empresa_id"`
+	UsuarioID  uint      `gorm:"not null" json:"usuario_id"`
+	Motivo     string    `gorm:"not null" json:"motivo"`
+	IP         string    `json:"ip"`
+	ExpiraEm   time.Time `gorm:"not null" json:"expira_em"`
+	CreatedAt  time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+}
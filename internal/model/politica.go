@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+const (
+	EfeitoPermitir = "PERMITIR"
+	EfeitoNegar    = "NEGAR"
+)
+
+// CondicaoPolitica compara um atributo da requisição (ex: "sujeito.cargo_id", "recurso.tipo",
+// "ambiente.dia_semana") com um valor fixo.
+type CondicaoPolitica struct {
+	Atributo string      `json:"atributo"`
+	Operador string      `json:"operador"`
+	Valor    interface{} `json:"valor"`
+}
+
+// Politica é uma regra de autorização configurada pela empresa. Para cada ação, as políticas ativas
+// são avaliadas da maior prioridade para a menor, e a primeira cujas condições são todas
+// verdadeiras decide. Sem nenhuma aplicável, a ação é permitida.
+type Politica struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EmpresaID  uint      `gorm:"not null;index" json:"empresa_id"`
+	Nome       string    `gorm:"not null" json:"nome"`
+	Descricao  string    `json:"descricao"`
+	Acao       string    `gorm:"not null;index" json:"acao"`
+	Efeito     string    `gorm:"not null" json:"efeito"`
+	Prioridade int       `gorm:"not null;default:0" json:"prioridade"`
+	Condicoes  JSONB     `gorm:"type:jsonb" json:"condicoes"`
+	Ativa      bool      `gorm:"not null" json:"ativa"`
+	CreatedAt  time.Time `gorm:"column:data_criacao" json:"data_criacao"`
+}
@@ -2,18 +2,112 @@ package model
 
 import "time"
 
+// Regras para a batida fora de todas as cercas (remota), definidas no cargo e, opcionalmente,
+// sobrepostas no funcionário.
+const (
+	BatidaRemotaPermitir = "PERMITIR"
+	BatidaRemotaBloquear = "BLOQUEAR"
+	// BatidaRemotaRevisar aceita a batida com justificativa, mas ela só conta no saldo depois que
+	// um gestor a aprova.
+	BatidaRemotaRevisar = "REVISAR"
+)
+
+// BatidaRemotaValida informa se o texto é uma das regras conhecidas.
+func BatidaRemotaValida(regra string) bool {
+	switch regra {
+	case BatidaRemotaPermitir, BatidaRemotaBloquear, BatidaRemotaRevisar:
+		return true
+	}
+	return false
+}
+
+// Situação da revisão de um ponto. Pontos que não passam por revisão ficam sem situação.
+const (
+	RevisaoPendente  = "PENDENTE"
+	RevisaoAprovada  = "APROVADA"
+	RevisaoRejeitada = "REJEITADA"
+)
+
+// Por que um ponto foi mandado para revisão.
+const (
+	// MotivoRevisaoRemota: batida fora dos locais de trabalho de um funcionário com a regra REVISAR.
+	MotivoRevisaoRemota = "BATIDA_REMOTA"
+	// MotivoRevisaoDiaFechado: batida offline de um dia cujo saldo já foi somado ao banco de horas.
+	MotivoRevisaoDiaFechado = "DIA_FECHADO"
+	// MotivoRevisaoSincronizacaoTardia: batida offline sincronizada muito depois do horário informado.
+	MotivoRevisaoSincronizacaoTardia = "SINCRONIZACAO_TARDIA"
+	// MotivoRevisaoRelogioDivergente: batida offline de um aparelho com o relógio fora da hora.
+	MotivoRevisaoRelogioDivergente = "RELOGIO_DIVERGENTE"
+	// MotivoRevisaoSemFoto: batida offline sem selfie numa empresa que exige a foto.
+	MotivoRevisaoSemFoto = "SEM_FOTO"
+)
+
 type RegistroPonto struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	CreatedAt time.Time `gorm:"column:data_criacao" json:"data_criacao"`
 
-	Timestamp time.Time `gorm:"not null" json:"timestamp"`
+	// Os índices compostos atendem à consulta de batidas por período, de um funcionário ou da
+	// empresa toda, em ordem de Timestamp.
+	Timestamp time.Time `gorm:"not null;index:idx_pontos_empresa_usuario_timestamp,priority:3;index:idx_pontos_empresa_timestamp,priority:2" json:"timestamp"`
+	// Batidas feitas sem conexão chegam depois: Timestamp é o horário do aparelho, SincronizadoEm é
+	// quando o servidor recebeu a batida e DesvioRelogioSegundos a diferença entre os dois relógios
+	// no envio. O desvio é calculado com a hora de envio que o próprio aparelho informa, então não é
+	// verificável: serve de indício contra a batida, nunca a favor. ChaveIdempotencia é o UUID gerado pelo aplicativo, único por usuário, que
+	// impede que um reenvio crie um segundo ponto.
+	ChaveIdempotencia     *string    `gorm:"uniqueIndex:idx_pontos_usuario_chave" json:"chave_idempotencia,omitempty"`
+	SincronizadoEm        *time.Time `gorm:"index" json:"sincronizado_em,omitempty"`
+	DesvioRelogioSegundos *int       `json:"desvio_relogio_segundos,omitempty"`
 
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
+	// PrecisaoMetros é o raio de precisão informado pelo aparelho, quando ele informa.
+	PrecisaoMetros *float64 `json:"precisao_metros,omitempty"`
+	// RiscoPontuacao (0 a 100) mede o quanto a localização parece falsificada; RiscoSinais lista
+	// os indícios que a compõem.
+	RiscoPontuacao int   `gorm:"not null;default:0;index" json:"risco_pontuacao"`
+	RiscoSinais    JSONB `gorm:"type:jsonb" json:"risco_sinais,omitempty"`
+
+	// FusoHorario é o fuso do local onde a batida foi feita (o do local de trabalho ou o da
+	// empresa); Timestamp fica sempre em UTC.
+	FusoHorario string `gorm:"not null;default:America/Sao_Paulo" json:"fuso_horario"`
+
+	Tipo string `json:"tipo"`
+	// LocalTrabalhoID é o local em cuja cerca o ponto foi batido; nulo para pontos remotos ou
+	// validados pela sede da empresa.
+	LocalTrabalhoID *uint `gorm:"index" json:"local_trabalho_id,omitempty"`
+	// QuiosqueID é o terminal que atestou a presença, para pontos batidos no quiosque (matrícula e
+	// PIN) ou lendo o código exibido por ele.
+	QuiosqueID *uint `gorm:"index" json:"quiosque_id,omitempty"`
+	// DispositivoID é o aparelho de onde a batida foi feita, quando o aplicativo o identifica.
+	DispositivoID *uint `gorm:"index" json:"dispositivo_id,omitempty"`
 
-	Tipo      string  `json:"tipo"`
-	UsuarioID uint    `gorm:"not null" json:"usuario_id"`
+	// A selfie da batida fica no armazenamento de arquivos (pkg/armazenamento): FotoChave localiza o
+	// arquivo e FotoTipo é o seu Content-Type. Vencida a retenção de fotos da empresa, o arquivo é
+	// apagado, FotoChave volta a nulo e FotoExpurgadaEm marca quando.
+	FotoChave       *string    `gorm:"index" json:"-"`
+	FotoTipo        string     `json:"foto_tipo,omitempty"`
+	FotoExpurgadaEm *time.Time `json:"foto_expurgada_em,omitempty"`
+
+	Justificativa     string     `json:"justificativa,omitempty"`
+	StatusRevisao     string     `gorm:"index" json:"status_revisao,omitempty"`
+	MotivoRevisao     string     `json:"motivo_revisao,omitempty"`
+	RevisadoPorID     *uint      `json:"revisado_por_id,omitempty"`
+	RevisadoEm        *time.Time `json:"revisado_em,omitempty"`
+	ObservacaoRevisao string     `json:"observacao_revisao,omitempty"`
+
+	UsuarioID uint    `gorm:"not null;uniqueIndex:idx_pontos_usuario_chave;index:idx_pontos_empresa_usuario_timestamp,priority:2" json:"usuario_id"`
 	Usuario   Usuario `json:"-"`
-	EmpresaID uint    `gorm:"not null" json:"empresa_id"`
+	EmpresaID uint    `gorm:"not null;index:idx_pontos_empresa_usuario_timestamp,priority:1;index:idx_pontos_empresa_timestamp,priority:1" json:"empresa_id"`
 	Empresa   Empresa `json:"-"`
 }
+
+// TemFoto informa se a selfie da batida ainda está guardada.
+func (p RegistroPonto) TemFoto() bool {
+	return p.FotoChave != nil
+}
+
+// ContaNoSaldo informa se o ponto entra no cálculo do banco de horas: pontos aguardando revisão ou
+// rejeitados ficam de fora.
+func (p RegistroPonto) ContaNoSaldo() bool {
+	return p.StatusRevisao != RevisaoPendente && p.StatusRevisao != RevisaoRejeitada
+}
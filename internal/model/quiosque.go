@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Quiosque é um terminal compartilhado (um tablet na entrada, por exemplo) instalado num local de
+// trabalho. Ele se autentica com a própria credencial, da qual só o hash é guardado, e exibe um
+// código que muda a cada 30 segundos, gerado a partir de SegredoCodigo.
+type Quiosque struct {
+	ID              uint          `gorm:"primaryKey" json:"id"`
+	EmpresaID       uint          `gorm:"not null;index" json:"empresa_id"`
+	Nome            string        `gorm:"not null" json:"nome"`
+	LocalTrabalhoID uint          `gorm:"not null;index" json:"local_trabalho_id"`
+	LocalTrabalho   LocalTrabalho `json:"-"`
+	// Latitude e Longitude são a posição do terminal, dentro do local; é ela que fica gravada nos
+	// pontos batidos por ele.
+	Latitude      float64    `gorm:"not null" json:"latitude"`
+	Longitude     float64    `gorm:"not null" json:"longitude"`
+	Prefixo       string     `gorm:"uniqueIndex;not null" json:"prefixo"`
+	Hash          string     `gorm:"not null" json:"-"`
+	SegredoCodigo []byte     `gorm:"not null" json:"-"`
+	CriadoPorID   uint       `gorm:"not null" json:"criado_por_id"`
+	UltimoUsoEm   *time.Time `json:"ultimo_uso_em,omitempty"`
+	RevogadoEm    *time.Time `json:"revogado_em,omitempty"`
+	CreatedAt     time.Time  `gorm:"column:data_criacao" json:"data_criacao"`
+}
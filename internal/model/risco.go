@@ -0,0 +1,19 @@
+package model
+
+// ConfiguracaoRisco são os limites que a empresa usa para avaliar se a localização de uma batida é
+// plausível. Sem configuração gravada valem os padrões do pacote de risco.
+type ConfiguracaoRisco struct {
+	ID        uint `gorm:"primaryKey" json:"id"`
+	EmpresaID uint `gorm:"uniqueIndex;not null" json:"empresa_id"`
+	// PrecisaoMaximaMetros é o maior raio de precisão informado pelo aparelho aceito sem suspeita.
+	PrecisaoMaximaMetros float64 `gorm:"not null" json:"precisao_maxima_metros"`
+	// VelocidadeMaximaKmh é a maior velocidade plausível entre duas batidas consecutivas.
+	VelocidadeMaximaKmh float64 `gorm:"not null" json:"velocidade_maxima_kmh"`
+	// AreaPermitida é um Polygon GeoJSON fora do qual a batida é suspeita (ex: o país da empresa).
+	// Nula, vale o território brasileiro.
+	AreaPermitida JSONB `gorm:"type:jsonb" json:"area_permitida"`
+	// LimiteRelatorio é a pontuação a partir da qual a batida aparece no relatório de suspeitas.
+	LimiteRelatorio int `gorm:"not null" json:"limite_relatorio"`
+	// LimiteBloqueio é a pontuação a partir da qual a batida é recusada; zero nunca recusa.
+	LimiteBloqueio int `gorm:"not null" json:"limite_bloqueio"`
+}
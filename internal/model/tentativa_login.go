@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// TentativaLogin guarda o contador de falhas de login de uma chave,
+// que pode ser uma conta ("email:...") ou um IP de origem ("ip:..."). UltimoIP é de onde veio a
+// falha mais recente, quando se sabe.
+type TentativaLogin struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	Chave              string     `gorm:"uniqueIndex;not null" json:"chave"`
+	Falhas             int        `gorm:"not null;default:0" json:"falhas"`
+	UltimaFalha        time.Time  `gorm:"index" json:"ultima_falha"`
+	UltimoIP           string     `json:"ultimo_ip,omitempty"`
+	ProximaTentativaEm *time.Time `json:"proxima_tentativa_em,omitempty"`
+	BloqueadoAte       *time.Time `json:"bloqueado_ate,omitempty"`
+}
@@ -1,17 +1,47 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Usuario struct {
 	ID                     uint      `gorm:"primaryKey" json:"id"`
 	Nome                   string    `gorm:"not null"   json:"nome"`
 	Email                  string    `gorm:"unique;not null" json:"email"`
 	Senha                  string    `gorm:"not null" json:"-"`
-	EmpresaID              uint      `gorm:"not null" json:"empresa_id"`
+	EmpresaID              uint      `gorm:"not null;uniqueIndex:idx_usuarios_empresa_matricula" json:"empresa_id"`
 	Empresa                Empresa   `json:"-"`
 	CargoID                uint      `gorm:"not null" json:"cargo_id"`
 	Cargo                  Cargo     `json:"-"`
+	GestorID               *uint     `gorm:"index" json:"gestor_id"` // gestor direto; a cadeia de gestores define as equipes
+	DepartamentoID         *uint     `gorm:"index" json:"departamento_id"`
+	CentroCustoID          *uint     `gorm:"index" json:"centro_custo_id"`
 	CreatedAt              time.Time `gorm:"column:data_criacao" json:"data_criacao"`
 	UpdatedAt              time.Time `gorm:"column:data_atualizacao" json:"data_atualizacao"`
 	SaldoBancoHorasMinutos int       `json:"saldo_banco_horas_minutos"`
+	// Matricula identifica o funcionário nos quiosques de ponto, junto com o PIN. É única na empresa.
+	Matricula *string `gorm:"uniqueIndex:idx_usuarios_empresa_matricula" json:"matricula"`
+	PinHash   string  `json:"-"`
+	// BatidaRemota sobrepõe a regra de batida remota do cargo; nulo segue o cargo.
+	BatidaRemota *string `json:"batida_remota"`
+
+	// ExcluidoEm marca a exclusão lógica: o usuário some das consultas, não entra nem bate ponto,
+	// mas seus registros ficam guardados pelo prazo de retenção legal.
+	ExcluidoEm gorm.DeletedAt `gorm:"column:data_exclusao;index" json:"-"`
+	// AnonimizadoEm é preenchido quando, vencida a retenção, os dados pessoais são apagados.
+	AnonimizadoEm *time.Time `gorm:"column:data_anonimizacao" json:"-"`
+}
+
+// RegraBatidaRemota é a regra que vale para as batidas remotas do usuário: a dele, se houver, senão
+// a do cargo. Sem nenhuma, a batida remota é permitida.
+func (u *Usuario) RegraBatidaRemota() string {
+	if u.BatidaRemota != nil && *u.BatidaRemota != "" {
+		return *u.BatidaRemota
+	}
+	if u.Cargo.BatidaRemota != "" {
+		return u.Cargo.BatidaRemota
+	}
+	return BatidaRemotaPermitir
 }
@@ -0,0 +1,122 @@
+// Package armazenamento guarda arquivos fora do banco de dados, como as fotos das batidas de ponto.
+// A implementação (disco local ou um serviço compatível com S3) é escolhida na inicialização da
+// aplicação; o banco guarda apenas a chave de cada arquivo.
+package armazenamento
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNaoEncontrado = errors.New("arquivo não encontrado no armazenamento")
+
+// Armazenamento grava, lê e apaga arquivos pela chave, um caminho relativo como
+// "fotos/empresa-1/abc.jpg". O tipo é o Content-Type do arquivo.
+type Armazenamento interface {
+	Gravar(chave string, conteudo []byte, tipo string) error
+	// Ler devolve ErrNaoEncontrado se não há arquivo com a chave.
+	Ler(chave string) ([]byte, error)
+	// Apagar não falha se o arquivo já não existe.
+	Apagar(chave string) error
+}
+
+// ApagarTodos apaga os arquivos de registros que já não os referenciam. Uma falha não interrompe
+// as demais e fica só no log: o registro já foi alterado, e o arquivo vira um órfão.
+func ApagarTodos(a Armazenamento, chaves []string) {
+	for _, chave := range chaves {
+		if err := a.Apagar(chave); err != nil {
+			log.Printf("ARMAZENAMENTO: falha ao apagar %s: %v", chave, err)
+		}
+	}
+}
+
+// validarChave aceita só letras minúsculas, dígitos, '-', '_', '.' e '/' entre segmentos não
+// vazios, o que impede que uma chave escape do diretório ou do bucket.
+func validarChave(chave string) error {
+	if chave == "" || strings.HasPrefix(chave, "/") || strings.HasSuffix(chave, "/") {
+		return fmt.Errorf("chave de armazenamento inválida: %q", chave)
+	}
+	for _, segmento := range strings.Split(chave, "/") {
+		if segmento == "" || segmento == "." || segmento == ".." {
+			return fmt.Errorf("chave de armazenamento inválida: %q", chave)
+		}
+	}
+	for _, r := range chave {
+		valido := (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || strings.ContainsRune("-_./", r)
+		if !valido {
+			return fmt.Errorf("chave de armazenamento inválida: %q", chave)
+		}
+	}
+	return nil
+}
+
+type localArmazenamento struct {
+	diretorio string
+}
+
+// NewLocal guarda os arquivos num diretório do disco, criado se ainda não existir. Serve para
+// desenvolvimento e para instalações com uma única instância da API.
+func NewLocal(diretorio string) (Armazenamento, error) {
+	if err := os.MkdirAll(diretorio, 0o750); err != nil {
+		return nil, fmt.Errorf("não foi possível criar o diretório de armazenamento: %w", err)
+	}
+	return &localArmazenamento{diretorio: diretorio}, nil
+}
+
+func (a *localArmazenamento) caminho(chave string) (string, error) {
+	if err := validarChave(chave); err != nil {
+		return "", err
+	}
+	return filepath.Join(a.diretorio, filepath.FromSlash(chave)), nil
+}
+
+func (a *localArmazenamento) Gravar(chave string, conteudo []byte, tipo string) error {
+	caminho, err := a.caminho(chave)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(caminho), 0o750); err != nil {
+		return err
+	}
+	// O arquivo é escrito ao lado e renomeado, para que uma leitura nunca veja um arquivo pela metade.
+	temporario, err := os.CreateTemp(filepath.Dir(caminho), ".gravando-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporario.Name())
+	if _, err := temporario.Write(conteudo); err != nil {
+		temporario.Close()
+		return err
+	}
+	if err := temporario.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporario.Name(), caminho)
+}
+
+func (a *localArmazenamento) Ler(chave string) ([]byte, error) {
+	caminho, err := a.caminho(chave)
+	if err != nil {
+		return nil, err
+	}
+	conteudo, err := os.ReadFile(caminho)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNaoEncontrado
+	}
+	return conteudo, err
+}
+
+func (a *localArmazenamento) Apagar(chave string) error {
+	caminho, err := a.caminho(chave)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(caminho); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
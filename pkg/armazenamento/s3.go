@@ -0,0 +1,183 @@
+package armazenamento
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigS3 aponta para um bucket num serviço compatível com S3 (AWS S3, MinIO, etc).
+type ConfigS3 struct {
+	// Endpoint é o endereço do serviço com o esquema, ex: "https://s3.sa-east-1.amazonaws.com" ou
+	// "http://localhost:9000".
+	Endpoint     string
+	Regiao       string
+	Bucket       string
+	ChaveAcesso  string
+	ChaveSecreta string
+	// EstiloCaminho endereça o bucket no caminho (endpoint/bucket/chave) em vez de no nome do host
+	// (bucket.endpoint/chave); é o que o MinIO espera.
+	EstiloCaminho bool
+}
+
+type s3Armazenamento struct {
+	config ConfigS3
+	base   *url.URL
+	http   *http.Client
+	agora  func() time.Time
+}
+
+// NewS3 guarda os arquivos num bucket compatível com S3. As requisições são assinadas com AWS
+// Signature Version 4.
+func NewS3(config ConfigS3) (Armazenamento, error) {
+	base, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("endpoint S3 inválido: %q", config.Endpoint)
+	}
+	if config.Bucket == "" || config.Regiao == "" || config.ChaveAcesso == "" || config.ChaveSecreta == "" {
+		return nil, fmt.Errorf("a configuração do S3 exige bucket, região e credenciais")
+	}
+	return &s3Armazenamento{
+		config: config,
+		base:   base,
+		http:   &http.Client{Timeout: 30 * time.Second},
+		agora:  time.Now,
+	}, nil
+}
+
+func (a *s3Armazenamento) Gravar(chave string, conteudo []byte, tipo string) error {
+	cabecalhos := http.Header{}
+	if tipo != "" {
+		cabecalhos.Set("Content-Type", tipo)
+	}
+	resposta, err := a.executar(http.MethodPut, chave, conteudo, cabecalhos)
+	if err != nil {
+		return err
+	}
+	defer resposta.Body.Close()
+	return a.verificar(http.MethodPut, chave, resposta)
+}
+
+func (a *s3Armazenamento) Ler(chave string) ([]byte, error) {
+	resposta, err := a.executar(http.MethodGet, chave, nil, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+	defer resposta.Body.Close()
+	if resposta.StatusCode == http.StatusNotFound {
+		return nil, ErrNaoEncontrado
+	}
+	if err := a.verificar(http.MethodGet, chave, resposta); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resposta.Body)
+}
+
+func (a *s3Armazenamento) Apagar(chave string) error {
+	resposta, err := a.executar(http.MethodDelete, chave, nil, http.Header{})
+	if err != nil {
+		return err
+	}
+	defer resposta.Body.Close()
+	if resposta.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return a.verificar(http.MethodDelete, chave, resposta)
+}
+
+func (a *s3Armazenamento) executar(metodo string, chave string, corpo []byte, cabecalhos http.Header) (*http.Response, error) {
+	if err := validarChave(chave); err != nil {
+		return nil, err
+	}
+	endereco := *a.base
+	if a.config.EstiloCaminho {
+		endereco.Path = strings.TrimSuffix(endereco.Path, "/") + "/" + a.config.Bucket + "/" + chave
+	} else {
+		endereco.Host = a.config.Bucket + "." + endereco.Host
+		endereco.Path = strings.TrimSuffix(endereco.Path, "/") + "/" + chave
+	}
+	requisicao, err := http.NewRequest(metodo, endereco.String(), bytes.NewReader(corpo))
+	if err != nil {
+		return nil, err
+	}
+	for nome, valores := range cabecalhos {
+		requisicao.Header[nome] = valores
+	}
+	assinar(requisicao, corpo, a.config, a.agora())
+	return a.http.Do(requisicao)
+}
+
+// verificar transforma uma resposta fora da faixa 2xx em erro, com o início da mensagem do serviço.
+func (a *s3Armazenamento) verificar(metodo string, chave string, resposta *http.Response) error {
+	if resposta.StatusCode >= 200 && resposta.StatusCode < 300 {
+		return nil
+	}
+	mensagem, _ := io.ReadAll(io.LimitReader(resposta.Body, 1024))
+	return fmt.Errorf("armazenamento S3: %s %s respondeu %d: %s", metodo, chave, resposta.StatusCode, strings.TrimSpace(string(mensagem)))
+}
+
+// assinar acrescenta à requisição os cabeçalhos x-amz-date, x-amz-content-sha256 e Authorization
+// da AWS Signature Version 4. São assinados o host e todos os cabeçalhos já presentes.
+func assinar(requisicao *http.Request, corpo []byte, config ConfigS3, momento time.Time) {
+	momento = momento.UTC()
+	dataHora := momento.Format("20060102T150405Z")
+	data := momento.Format("20060102")
+	hashCorpo := sha256.Sum256(corpo)
+	requisicao.Header.Set("X-Amz-Date", dataHora)
+	requisicao.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(hashCorpo[:]))
+
+	valores := map[string]string{"host": requisicao.URL.Host}
+	for nome, lista := range requisicao.Header {
+		valores[strings.ToLower(nome)] = strings.TrimSpace(strings.Join(lista, ","))
+	}
+	nomes := make([]string, 0, len(valores))
+	for nome := range valores {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	var canonicos strings.Builder
+	for _, nome := range nomes {
+		canonicos.WriteString(nome + ":" + valores[nome] + "\n")
+	}
+	assinados := strings.Join(nomes, ";")
+
+	caminho := requisicao.URL.EscapedPath()
+	if caminho == "" {
+		caminho = "/"
+	}
+	requisicaoCanonica := strings.Join([]string{
+		requisicao.Method,
+		caminho,
+		requisicao.URL.RawQuery,
+		canonicos.String(),
+		assinados,
+		hex.EncodeToString(hashCorpo[:]),
+	}, "\n")
+
+	escopo := data + "/" + config.Regiao + "/s3/aws4_request"
+	hashCanonica := sha256.Sum256([]byte(requisicaoCanonica))
+	textoAssinado := "AWS4-HMAC-SHA256\n" + dataHora + "\n" + escopo + "\n" + hex.EncodeToString(hashCanonica[:])
+
+	chave := hmacSHA256([]byte("AWS4"+config.ChaveSecreta), data)
+	chave = hmacSHA256(chave, config.Regiao)
+	chave = hmacSHA256(chave, "s3")
+	chave = hmacSHA256(chave, "aws4_request")
+	assinatura := hex.EncodeToString(hmacSHA256(chave, textoAssinado))
+
+	requisicao.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		config.ChaveAcesso, escopo, assinados, assinatura))
+}
+
+func hmacSHA256(chave []byte, texto string) []byte {
+	h := hmac.New(sha256.New, chave)
+	h.Write([]byte(texto))
+	return h.Sum(nil)
+}
@@ -0,0 +1,31 @@
+//go:build integracao
+
+package armazenamento
+
+import (
+	"os"
+	"testing"
+)
+
+// Rode com o MinIO do docker-compose (o bucket precisa existir):
+//
+//	TESTE_S3_ENDPOINT=http://localhost:9000 TESTE_S3_BUCKET=fotos-ponto \
+//	TESTE_S3_CHAVE_ACESSO=minioadmin TESTE_S3_CHAVE_SECRETA=minioadmin go test -tags integracao ./pkg/armazenamento/
+func TestS3_MinIO(t *testing.T) {
+	endpoint := os.Getenv("TESTE_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TESTE_S3_ENDPOINT não definido")
+	}
+	a, err := NewS3(ConfigS3{
+		Endpoint:      endpoint,
+		Regiao:        "us-east-1",
+		Bucket:        os.Getenv("TESTE_S3_BUCKET"),
+		ChaveAcesso:   os.Getenv("TESTE_S3_CHAVE_ACESSO"),
+		ChaveSecreta:  os.Getenv("TESTE_S3_CHAVE_SECRETA"),
+		EstiloCaminho: true,
+	})
+	if err != nil {
+		t.Fatalf("Erro ao criar o armazenamento: %v", err)
+	}
+	testarIdaEVolta(t, a)
+}
@@ -0,0 +1,70 @@
+// Package credencial gera e confere os segredos de máquina (chaves de API, quiosques) no formato
+// "<tipo>_<prefixo>_<segredo>". O prefixo é público e serve para achar o registro; do texto
+// completo só se guarda o hash.
+package credencial
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// IntervaloRegistroUso evita uma escrita no banco a cada requisição feita com a mesma credencial.
+const IntervaloRegistroUso = time.Minute
+
+// Gerar cria uma credencial do tipo informado e devolve o texto, que só deve ser mostrado uma vez,
+// o prefixo e o hash a serem gravados.
+func Gerar(tipo string) (texto string, prefixo string, hash string, err error) {
+	prefixo, err = aleatorio(6, hex.EncodeToString)
+	if err != nil {
+		return "", "", "", err
+	}
+	segredo, err := aleatorio(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", "", "", err
+	}
+	texto = tipo + "_" + prefixo + "_" + segredo
+	return texto, prefixo, Hash(texto), nil
+}
+
+// Prefixo extrai o prefixo de uma credencial do tipo informado; ok é falso se o formato não bate.
+func Prefixo(tipo string, texto string) (string, bool) {
+	restante, ok := strings.CutPrefix(texto, tipo+"_")
+	if !ok {
+		return "", false
+	}
+	prefixo, _, ok := strings.Cut(restante, "_")
+	if !ok || prefixo == "" {
+		return "", false
+	}
+	return prefixo, true
+}
+
+// Confere compara o texto recebido com o hash gravado em tempo constante.
+func Confere(texto string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(Hash(texto))) == 1
+}
+
+// Hash usa um SHA-256 simples: o segredo tem 256 bits de entropia, e a validação fica barata o
+// suficiente para rodar em toda requisição.
+func Hash(texto string) string {
+	soma := sha256.Sum256([]byte(texto))
+	return hex.EncodeToString(soma[:])
+}
+
+// DeveRegistrarUso diz se o último uso gravado já está velho o bastante para ser atualizado.
+func DeveRegistrarUso(ultimoUso *time.Time, momento time.Time) bool {
+	return ultimoUso == nil || momento.Sub(*ultimoUso) > IntervaloRegistroUso
+}
+
+func aleatorio(bytes int, codificar func([]byte) string) (string, error) {
+	b := make([]byte, bytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return codificar(b), nil
+}
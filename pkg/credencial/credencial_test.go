@@ -0,0 +1,50 @@
+package credencial
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGerar_PrefixoEHashConferem(t *testing.T) {
+	texto, prefixo, hash, err := Gerar("quiosque")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if !strings.HasPrefix(texto, "quiosque_"+prefixo+"_") {
+		t.Fatalf("Formato inesperado: %s", texto)
+	}
+	if lido, ok := Prefixo("quiosque", texto); !ok || lido != prefixo {
+		t.Errorf("Esperava o prefixo %s, recebeu %q (ok=%v)", prefixo, lido, ok)
+	}
+	if !Confere(texto, hash) {
+		t.Error("A credencial gerada deveria conferir com o próprio hash")
+	}
+	if Confere(texto+"x", hash) {
+		t.Error("Uma credencial alterada não deveria conferir")
+	}
+}
+
+func TestPrefixo_RecusaOutroTipoOuFormato(t *testing.T) {
+	for _, texto := range []string{"ponto_abc_segredo", "quiosque_", "quiosque__segredo", "quiosque_abc"} {
+		if _, ok := Prefixo("quiosque", texto); ok {
+			t.Errorf("%q não deveria ser aceito como credencial de quiosque", texto)
+		}
+	}
+}
+
+func TestDeveRegistrarUso(t *testing.T) {
+	agora := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	recente := agora.Add(-30 * time.Second)
+	antigo := agora.Add(-2 * IntervaloRegistroUso)
+
+	if !DeveRegistrarUso(nil, agora) {
+		t.Error("O primeiro uso deveria ser registrado")
+	}
+	if DeveRegistrarUso(&recente, agora) {
+		t.Error("Um uso registrado há pouco não deveria ser gravado de novo")
+	}
+	if !DeveRegistrarUso(&antigo, agora) {
+		t.Error("Um uso antigo deveria ser atualizado")
+	}
+}
@@ -0,0 +1,59 @@
+// Package fuso resolve os fusos horários (nomes IANA) das empresas e dos locais de trabalho. Os
+// horários são gravados em UTC; o fuso só decide onde começa e termina cada dia e qual é a hora
+// local de uma batida.
+package fuso
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Padrao é o fuso das empresas que não configuraram outro.
+const Padrao = "America/Sao_Paulo"
+
+var ErrFusoInvalido = errors.New("fuso horário inválido: use um nome IANA, como America/Manaus")
+
+var carregados sync.Map
+
+// Carregar devolve o fuso pelo nome IANA; vazio é o Padrao.
+func Carregar(nome string) (*time.Location, error) {
+	nome = strings.TrimSpace(nome)
+	if nome == "" {
+		nome = Padrao
+	}
+	if loc, ok := carregados.Load(nome); ok {
+		return loc.(*time.Location), nil
+	}
+	// "Local" e "UTC" são aceitos por LoadLocation, mas só o segundo é um nome IANA.
+	if nome == "Local" {
+		return nil, ErrFusoInvalido
+	}
+	loc, err := time.LoadLocation(nome)
+	if err != nil {
+		return nil, ErrFusoInvalido
+	}
+	carregados.Store(nome, loc)
+	return loc, nil
+}
+
+// Validar confere o nome sem devolver o fuso; vazio não é aceito.
+func Validar(nome string) error {
+	if strings.TrimSpace(nome) == "" {
+		return ErrFusoInvalido
+	}
+	_, err := Carregar(nome)
+	return err
+}
+
+// InicioDoDia é a meia-noite, no fuso, do dia em que o instante cai nesse fuso.
+func InicioDoDia(momento time.Time, loc *time.Location) time.Time {
+	ano, mes, dia := momento.In(loc).Date()
+	return time.Date(ano, mes, dia, 0, 0, 0, 0, loc)
+}
+
+// ParseDia lê uma data AAAA-MM-DD como a meia-noite desse dia no fuso.
+func ParseDia(valor string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", valor, loc)
+}
@@ -0,0 +1,42 @@
+package fuso
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCarregar(t *testing.T) {
+	if loc, err := Carregar(""); err != nil || loc.String() != Padrao {
+		t.Errorf("Vazio deveria carregar o padrão, recebeu %v, %v", loc, err)
+	}
+	if _, err := Carregar("America/Manaus"); err != nil {
+		t.Errorf("Erro inesperado: %v", err)
+	}
+	for _, nome := range []string{"Local", "America/Atlantida", "-03:00"} {
+		if _, err := Carregar(nome); !errors.Is(err, ErrFusoInvalido) {
+			t.Errorf("%q: esperava ErrFusoInvalido, recebeu %v", nome, err)
+		}
+	}
+}
+
+func TestInicioDoDia_UsaODiaNoFuso(t *testing.T) {
+	manaus, _ := Carregar("America/Manaus")
+	// 02:30 UTC do dia 5 ainda é 22:30 do dia 4 em Manaus (UTC-4).
+	momento := time.Date(2026, 3, 5, 2, 30, 0, 0, time.UTC)
+	inicio := InicioDoDia(momento, manaus)
+	if esperado := time.Date(2026, 3, 4, 4, 0, 0, 0, time.UTC); !inicio.Equal(esperado) {
+		t.Errorf("Esperava %v, recebeu %v", esperado, inicio.UTC())
+	}
+}
+
+func TestParseDia(t *testing.T) {
+	noronha, _ := Carregar("America/Noronha")
+	dia, err := ParseDia("2026-03-04", noronha)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if esperado := time.Date(2026, 3, 4, 2, 0, 0, 0, time.UTC); !dia.Equal(esperado) {
+		t.Errorf("Esperava meia-noite de Noronha (02:00 UTC), recebeu %v", dia.UTC())
+	}
+}
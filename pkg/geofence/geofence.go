@@ -0,0 +1,211 @@
+// Package geofence interpreta cercas geográficas escritas em GeoJSON e responde se uma coordenada
+// está dentro delas.
+//
+// São aceitos dois formatos de geometria (também dentro de um Feature):
+//   - Point, com um raio em metros informado à parte: um círculo;
+//   - Polygon, com o anel externo e, opcionalmente, buracos.
+//
+// Como no GeoJSON, as coordenadas vêm na ordem [longitude, latitude]. As contas são feitas numa
+// projeção plana em torno do ponto consultado, o que é preciso o bastante para cercas de alguns
+// quilômetros, que é o caso de locais de trabalho.
+package geofence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/umahmood/haversine"
+)
+
+const (
+	TipoPonto    = "Point"
+	TipoPoligono = "Polygon"
+)
+
+var ErrGeometriaInvalida = errors.New("geometria inválida")
+
+// raioTerraMetros é o raio médio da Terra, o mesmo usado pela fórmula de haversine.
+const raioTerraMetros = 6371008.8
+
+// Ponto é uma coordenada geográfica em graus.
+type Ponto struct {
+	Lat float64
+	Lon float64
+}
+
+// Geometria é uma cerca já validada: um círculo (centro e raio) ou um polígono (anéis).
+type Geometria struct {
+	Tipo       string
+	centro     Ponto
+	raioMetros float64
+	// aneis[0] é o contorno externo; os demais são buracos.
+	aneis [][]Ponto
+}
+
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+}
+
+func invalida(formato string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrGeometriaInvalida, fmt.Sprintf(formato, args...))
+}
+
+// Parse lê a geometria GeoJSON. raioMetros só é usado (e exigido) para Point.
+func Parse(dados []byte, raioMetros float64) (*Geometria, error) {
+	var g geoJSON
+	if err := json.Unmarshal(dados, &g); err != nil {
+		return nil, invalida("JSON malformado")
+	}
+	if g.Type == "Feature" {
+		if g.Geometry == nil {
+			return nil, invalida("Feature sem geometry")
+		}
+		g = *g.Geometry
+	}
+
+	switch g.Type {
+	case TipoPonto:
+		var posicao []float64
+		if err := json.Unmarshal(g.Coordinates, &posicao); err != nil {
+			return nil, invalida("coordenadas do Point devem ser [longitude, latitude]")
+		}
+		centro, err := ponto(posicao)
+		if err != nil {
+			return nil, err
+		}
+		if raioMetros <= 0 {
+			return nil, invalida("um Point precisa de raio_metros maior que zero")
+		}
+		return &Geometria{Tipo: TipoPonto, centro: centro, raioMetros: raioMetros}, nil
+
+	case TipoPoligono:
+		var coordenadas [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &coordenadas); err != nil {
+			return nil, invalida("coordenadas do Polygon devem ser uma lista de anéis de [longitude, latitude]")
+		}
+		if len(coordenadas) == 0 {
+			return nil, invalida("Polygon sem anéis")
+		}
+		geometria := &Geometria{Tipo: TipoPoligono}
+		for i, anel := range coordenadas {
+			// Um anel fechado tem ao menos três vértices distintos e repete o primeiro no final.
+			if len(anel) < 4 {
+				return nil, invalida("o anel %d precisa de ao menos 4 posições", i)
+			}
+			pontos := make([]Ponto, 0, len(anel))
+			for _, posicao := range anel {
+				p, err := ponto(posicao)
+				if err != nil {
+					return nil, err
+				}
+				pontos = append(pontos, p)
+			}
+			if pontos[0] != pontos[len(pontos)-1] {
+				return nil, invalida("o anel %d não está fechado: a última posição deve repetir a primeira", i)
+			}
+			geometria.aneis = append(geometria.aneis, pontos[:len(pontos)-1])
+		}
+		return geometria, nil
+	}
+	return nil, invalida("tipo %q não suportado; use Point (com raio) ou Polygon", g.Type)
+}
+
+func ponto(posicao []float64) (Ponto, error) {
+	if len(posicao) < 2 {
+		return Ponto{}, invalida("posição deve ter longitude e latitude")
+	}
+	p := Ponto{Lon: posicao[0], Lat: posicao[1]}
+	if p.Lon < -180 || p.Lon > 180 || p.Lat < -90 || p.Lat > 90 {
+		return Ponto{}, invalida("posição [%g, %g] fora do globo", p.Lon, p.Lat)
+	}
+	return p, nil
+}
+
+// plano projeta pontos em metros, com origem em ref.
+type plano struct {
+	ref    Ponto
+	escLon float64
+}
+
+func novoPlano(ref Ponto) plano {
+	return plano{ref: ref, escLon: math.Cos(ref.Lat*math.Pi/180) * raioTerraMetros * math.Pi / 180}
+}
+
+func (pl plano) projetar(p Ponto) (x, y float64) {
+	return (p.Lon - pl.ref.Lon) * pl.escLon, (p.Lat - pl.ref.Lat) * raioTerraMetros * math.Pi / 180
+}
+
+// Contem informa se o ponto está dentro da cerca (a borda conta como dentro).
+func (g *Geometria) Contem(p Ponto) bool {
+	return g.DistanciaMetros(p) == 0
+}
+
+// DistanciaMetros é a distância do ponto até a cerca: zero dentro dela.
+func (g *Geometria) DistanciaMetros(p Ponto) float64 {
+	if g.Tipo == TipoPonto {
+		_, km := haversine.Distance(haversine.Coord{Lat: g.centro.Lat, Lon: g.centro.Lon}, haversine.Coord{Lat: p.Lat, Lon: p.Lon})
+		return math.Max(0, km*1000-g.raioMetros)
+	}
+
+	pl := novoPlano(p)
+	dentro := false
+	minima := math.Inf(1)
+	for i, anel := range g.aneis {
+		dentroDoAnel := false
+		for j := range anel {
+			ax, ay := pl.projetar(anel[j])
+			bx, by := pl.projetar(anel[(j+1)%len(anel)])
+			minima = math.Min(minima, distanciaAoSegmento(ax, ay, bx, by))
+			// Raio saindo da origem (o ponto consultado) para a direita.
+			if (ay > 0) != (by > 0) && ax+(0-ay)*(bx-ax)/(by-ay) > 0 {
+				dentroDoAnel = !dentroDoAnel
+			}
+		}
+		if i == 0 {
+			dentro = dentroDoAnel
+		} else if dentroDoAnel {
+			dentro = false
+		}
+	}
+	if dentro || minima == 0 {
+		return 0
+	}
+	return minima
+}
+
+// distanciaAoSegmento é a distância da origem ao segmento AB.
+func distanciaAoSegmento(ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if comprimento := dx*dx + dy*dy; comprimento > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/comprimento))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// AreaMetros2 é a área da cerca, usada para preferir a mais específica quando cercas se sobrepõem.
+func (g *Geometria) AreaMetros2() float64 {
+	if g.Tipo == TipoPonto {
+		return math.Pi * g.raioMetros * g.raioMetros
+	}
+	pl := novoPlano(g.aneis[0][0])
+	area := 0.0
+	for i, anel := range g.aneis {
+		soma := 0.0
+		for j := range anel {
+			ax, ay := pl.projetar(anel[j])
+			bx, by := pl.projetar(anel[(j+1)%len(anel)])
+			soma += ax*by - bx*ay
+		}
+		if i == 0 {
+			area += math.Abs(soma) / 2
+		} else {
+			area -= math.Abs(soma) / 2
+		}
+	}
+	return area
+}
@@ -0,0 +1,84 @@
+package geofence
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// campus é um quadrado de ~1,1 km de lado com um buraco (um terreno de terceiros) no canto.
+const campus = `{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[
+	[[-46.70,-23.56],[-46.69,-23.56],[-46.69,-23.55],[-46.70,-23.55],[-46.70,-23.56]],
+	[[-46.699,-23.559],[-46.697,-23.559],[-46.697,-23.557],[-46.699,-23.557],[-46.699,-23.559]]
+]}}`
+
+func TestPoligono_ContemRespeitaBuracos(t *testing.T) {
+	g, err := Parse([]byte(campus), 0)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	casos := []struct {
+		nome     string
+		ponto    Ponto
+		esperado bool
+	}{
+		{"centro do campus", Ponto{Lat: -23.555, Lon: -46.695}, true},
+		{"dentro do buraco", Ponto{Lat: -23.558, Lon: -46.698}, false},
+		{"fora do campus", Ponto{Lat: -23.54, Lon: -46.695}, false},
+	}
+	for _, caso := range casos {
+		if g.Contem(caso.ponto) != caso.esperado {
+			t.Errorf("%s: esperava Contem=%v", caso.nome, caso.esperado)
+		}
+	}
+
+	// 0,01° de latitude ao norte da borda são ~1,1 km.
+	distancia := g.DistanciaMetros(Ponto{Lat: -23.54, Lon: -46.695})
+	if math.Abs(distancia-1112) > 10 {
+		t.Errorf("Distância até a borda deveria ser ~1112 m, foi %.0f", distancia)
+	}
+}
+
+func TestCirculo_DistanciaAteABorda(t *testing.T) {
+	g, err := Parse([]byte(`{"type":"Point","coordinates":[-46.6333,-23.5505]}`), 100)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if !g.Contem(Ponto{Lat: -23.5505, Lon: -46.6333}) {
+		t.Error("O centro deveria estar dentro do círculo")
+	}
+	// ~0,0018° de latitude são ~200 m do centro, ou seja, ~100 m além da borda.
+	distancia := g.DistanciaMetros(Ponto{Lat: -23.5487, Lon: -46.6333})
+	if math.Abs(distancia-100) > 5 {
+		t.Errorf("Distância até a borda deveria ser ~100 m, foi %.0f", distancia)
+	}
+}
+
+func TestArea_CirculoMenorQueCampus(t *testing.T) {
+	poligono, _ := Parse([]byte(campus), 0)
+	circulo, _ := Parse([]byte(`{"type":"Point","coordinates":[-46.695,-23.555]}`), 50)
+	if circulo.AreaMetros2() >= poligono.AreaMetros2() {
+		t.Errorf("Círculo de 50 m deveria ser menor que o campus (%.0f >= %.0f)", circulo.AreaMetros2(), poligono.AreaMetros2())
+	}
+}
+
+func TestParse_RecusaGeometriasInvalidas(t *testing.T) {
+	casos := map[string]struct {
+		geojson string
+		raio    float64
+	}{
+		"ponto sem raio":     {`{"type":"Point","coordinates":[-46.6,-23.5]}`, 0},
+		"anel aberto":        {`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`, 0},
+		"poucas posições":    {`{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`, 0},
+		"fora do globo":      {`{"type":"Point","coordinates":[200,10]}`, 10},
+		"tipo não suportado": {`{"type":"LineString","coordinates":[[0,0],[1,1]]}`, 0},
+		"não é JSON":         {`circulo`, 10},
+	}
+	for nome, caso := range casos {
+		t.Run(nome, func(t *testing.T) {
+			if _, err := Parse([]byte(caso.geojson), caso.raio); !errors.Is(err, ErrGeometriaInvalida) {
+				t.Errorf("Esperava ErrGeometriaInvalida, recebeu %v", err)
+			}
+		})
+	}
+}
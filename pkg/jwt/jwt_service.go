@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"fmt"
 	"strconv"
 	"time"
@@ -10,6 +12,10 @@ import (
 
 type PontoClaims struct {
 	EmpresaID uint `json:"empresa_id"`
+	// Operador identifica tokens de operadores da plataforma, que não pertencem a nenhuma empresa.
+	Operador bool `json:"operador,omitempty"`
+	// ImpersonadoPor é o ID do operador que emitiu este token em nome de um usuário.
+	ImpersonadoPor uint `json:"impersonado_por,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -24,14 +30,41 @@ func NewJWTService(secretKey string, issuer string) *JWTService {
 
 func (service *JWTService) GenerateToken(userID uint, empresaID uint) (string, error) {
 	claims := &PontoClaims{
-		EmpresaID: empresaID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    service.issuer,
-			Subject:   strconv.Itoa(int(userID)),
-		},
+		EmpresaID:        empresaID,
+		RegisteredClaims: service.registeredClaims(userID, time.Hour*24),
+	}
+	return service.assinar(claims)
+}
+
+// GenerateOperadorToken emite o token de um operador da plataforma (super-admin).
+func (service *JWTService) GenerateOperadorToken(operadorID uint) (string, error) {
+	claims := &PontoClaims{
+		Operador:         true,
+		RegisteredClaims: service.registeredClaims(operadorID, time.Hour*8),
+	}
+	return service.assinar(claims)
+}
+
+// GenerateImpersonationToken emite um token de curta duração para um operador agir como um usuário.
+func (service *JWTService) GenerateImpersonationToken(userID uint, empresaID uint, operadorID uint, duracao time.Duration) (string, error) {
+	claims := &PontoClaims{
+		EmpresaID:        empresaID,
+		ImpersonadoPor:   operadorID,
+		RegisteredClaims: service.registeredClaims(userID, duracao),
+	}
+	return service.assinar(claims)
+}
+
+func (service *JWTService) registeredClaims(subject uint, duracao time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(duracao)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		Issuer:    service.issuer,
+		Subject:   strconv.Itoa(int(subject)),
 	}
+}
+
+func (service *JWTService) assinar(claims *PontoClaims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(service.secretKey))
 	return tokenString, err
@@ -45,3 +78,45 @@ func (s *JWTService) ValidateToken(tokenString string) (*jwt.Token, error) {
 		return []byte(s.secretKey), nil
 	})
 }
+
+// ConviteClaims são as claims do link de convite de um funcionário.
+type ConviteClaims struct {
+	ConviteID uint `json:"convite_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateConviteToken assina o link de convite. A chave é derivada da chave principal,
+// então um token de convite nunca é aceito como token de login (e vice-versa).
+func (s *JWTService) GenerateConviteToken(conviteID uint, expiraEm time.Time) (string, error) {
+	claims := &ConviteClaims{
+		ConviteID: conviteID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiraEm),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    s.issuer,
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.chaveConvite())
+}
+
+// ValidateConviteToken confere assinatura e validade e devolve o ID do convite.
+func (s *JWTService) ValidateConviteToken(tokenString string) (uint, error) {
+	claims := &ConviteClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+		}
+		return s.chaveConvite(), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return claims.ConviteID, nil
+}
+
+func (s *JWTService) chaveConvite() []byte {
+	mac := hmac.New(sha256.New, []byte(s.secretKey))
+	mac.Write([]byte("convite"))
+	return mac.Sum(nil)
+}
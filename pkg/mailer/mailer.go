@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"regexp"
+	"strings"
+)
+
+// Mensagem é um e-mail em texto simples.
+type Mensagem struct {
+	Para    string
+	Assunto string
+	Corpo   string
+}
+
+// Mailer envia e-mails. A implementação é escolhida na inicialização da aplicação.
+type Mailer interface {
+	Enviar(msg Mensagem) error
+}
+
+type logMailer struct{}
+
+// tokenNoLink encontra o valor do parâmetro "token" dos links.
+var tokenNoLink = regexp.MustCompile(`([?&]token=)[^&\s]+`)
+
+// NewLogMailer apenas escreve os e-mails no log; útil em desenvolvimento e testes. Os tokens dos
+// links são omitidos: quem lê o log não deve poder aceitar o convite de outra pessoa.
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+func (m *logMailer) Enviar(msg Mensagem) error {
+	log.Printf("MAILER: para=%s assunto=%q\n%s", msg.Para, msg.Assunto, omitirTokens(msg.Corpo))
+	return nil
+}
+
+// omitirTokens troca o valor do parâmetro "token" dos links do texto por "[omitido]".
+func omitirTokens(texto string) string {
+	return tokenNoLink.ReplaceAllString(texto, "${1}[omitido]")
+}
+
+type smtpMailer struct {
+	endereco  string
+	auth      smtp.Auth
+	remetente string
+}
+
+// NewSMTPMailer envia os e-mails por um servidor SMTP.
+func NewSMTPMailer(host string, porta string, usuario string, senha string, remetente string) Mailer {
+	var auth smtp.Auth
+	if usuario != "" {
+		auth = smtp.PlainAuth("", usuario, senha, host)
+	}
+	return &smtpMailer{
+		endereco:  host + ":" + porta,
+		auth:      auth,
+		remetente: remetente,
+	}
+}
+
+func (m *smtpMailer) Enviar(msg Mensagem) error {
+	// Quebras de linha no destinatário ou no assunto permitiriam injetar cabeçalhos.
+	if strings.ContainsAny(msg.Para+msg.Assunto, "\r\n") {
+		return fmt.Errorf("destinatário ou assunto inválido")
+	}
+	corpo := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		m.remetente, msg.Para, msg.Assunto, msg.Corpo)
+	return smtp.SendMail(m.endereco, m.auth, m.remetente, []string{msg.Para}, []byte(corpo))
+}
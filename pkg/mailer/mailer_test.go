@@ -0,0 +1,11 @@
+package mailer
+
+import "testing"
+
+func TestOmitirTokens(t *testing.T) {
+	corpo := "Aceite em https://app.exemplo.com/convite?token=abc.def-ghi&origem=email até sexta."
+	esperado := "Aceite em https://app.exemplo.com/convite?token=[omitido]&origem=email até sexta."
+	if obtido := omitirTokens(corpo); obtido != esperado {
+		t.Errorf("Esperava %q, recebeu %q", esperado, obtido)
+	}
+}
@@ -0,0 +1,313 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Provedor reúne os endpoints publicados pelo documento de descoberta de um emissor OIDC.
+type Provedor struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// RespostaToken é a resposta do endpoint de token do provedor.
+type RespostaToken struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// Claims são as informações de identidade extraídas de um ID token validado.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool
+	Nome          string
+	Todas         jwt.MapClaims
+}
+
+// Client implementa o fluxo authorization code com PKCE contra qualquer provedor OIDC.
+type Client struct {
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	provedores map[string]*Provedor
+	chaves     map[string]map[string]interface{}
+}
+
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{
+		httpClient: httpClient,
+		provedores: make(map[string]*Provedor),
+		chaves:     make(map[string]map[string]interface{}),
+	}
+}
+
+// Descobrir lê o documento .well-known/openid-configuration do emissor e guarda o resultado em cache.
+func (c *Client) Descobrir(issuer string) (*Provedor, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	c.mu.Lock()
+	provedor, ok := c.provedores[issuer]
+	c.mu.Unlock()
+	if ok {
+		return provedor, nil
+	}
+
+	var descoberto Provedor
+	if err := c.getJSON(issuer+"/.well-known/openid-configuration", &descoberto); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(descoberto.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("emissor do documento de descoberta (%s) difere do configurado (%s)", descoberto.Issuer, issuer)
+	}
+
+	c.mu.Lock()
+	c.provedores[issuer] = &descoberto
+	c.mu.Unlock()
+	return &descoberto, nil
+}
+
+// URLAutorizacao monta a URL para onde o navegador do usuário deve ser redirecionado.
+func (p *Provedor) URLAutorizacao(clientID, redirectURL, state, nonce, codeChallenge string) string {
+	parametros := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {clientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	separador := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		separador = "&"
+	}
+	return p.AuthorizationEndpoint + separador + parametros.Encode()
+}
+
+// TrocarCodigo troca o código de autorização pelos tokens, enviando o code_verifier do PKCE.
+func (c *Client) TrocarCodigo(p *Provedor, clientID, clientSecret, redirectURL, code, codeVerifier string) (*RespostaToken, error) {
+	formulario := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	resp, err := c.httpClient.PostForm(p.TokenEndpoint, formulario)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("provedor recusou a troca do código: status %d", resp.StatusCode)
+	}
+
+	var token RespostaToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("resposta do provedor não contém id_token")
+	}
+	return &token, nil
+}
+
+// VerificarIDToken valida assinatura, emissor, audiência, expiração e nonce do ID token.
+func (c *Client) VerificarIDToken(p *Provedor, idToken, clientID, nonce string) (*Claims, error) {
+	mapa := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, mapa, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.chavePublica(p, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("id_token inválido: %w", err)
+	}
+
+	if valor, _ := mapa["nonce"].(string); valor != nonce {
+		return nil, errors.New("id_token inválido: nonce não confere")
+	}
+
+	claims := &Claims{Todas: mapa}
+	claims.Subject, _ = mapa["sub"].(string)
+	claims.Email, _ = mapa["email"].(string)
+	claims.Nome, _ = mapa["name"].(string)
+	switch v := mapa["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = &v
+	case string:
+		verificado := v == "true"
+		claims.EmailVerified = &verificado
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id_token inválido: claim 'sub' ausente")
+	}
+	return claims, nil
+}
+
+// ValoresClaim devolve o conteúdo de uma claim como lista de strings, aceitando texto ou lista.
+func (c *Claims) ValoresClaim(nome string) []string {
+	switch v := c.Todas[nome].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		valores := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				valores = append(valores, s)
+			}
+		}
+		return valores
+	}
+	return nil
+}
+
+// chavePublica procura a chave pelo kid e recarrega o JWKS uma vez se ela não estiver em cache,
+// o que cobre a rotação de chaves do provedor.
+func (c *Client) chavePublica(p *Provedor, kid string) (interface{}, error) {
+	c.mu.Lock()
+	chave, ok := c.chaves[p.JWKSURI][kid]
+	c.mu.Unlock()
+	if ok {
+		return chave, nil
+	}
+
+	chaves, err := c.carregarJWKS(p.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.chaves[p.JWKSURI] = chaves
+	c.mu.Unlock()
+
+	if chave, ok := chaves[kid]; ok {
+		return chave, nil
+	}
+	// Provedores com uma única chave às vezes omitem o kid.
+	if kid == "" && len(chaves) == 1 {
+		for _, chave := range chaves {
+			return chave, nil
+		}
+	}
+	return nil, fmt.Errorf("chave de assinatura '%s' não encontrada no JWKS", kid)
+}
+
+func (c *Client) carregarJWKS(uri string) (map[string]interface{}, error) {
+	var conjunto struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := c.getJSON(uri, &conjunto); err != nil {
+		return nil, err
+	}
+
+	chaves := make(map[string]interface{})
+	for _, k := range conjunto.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			chaves[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curva elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curva = elliptic.P256()
+			case "P-384":
+				curva = elliptic.P384()
+			case "P-521":
+				curva = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			chaves[k.Kid] = &ecdsa.PublicKey{Curve: curva, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	return chaves, nil
+}
+
+func (c *Client) getJSON(endereco string, destino interface{}) error {
+	resp, err := c.httpClient.Get(endereco)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("falha ao consultar %s: status %d", endereco, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(destino)
+}
+
+// GerarPKCE cria um code_verifier aleatório e o code_challenge S256 correspondente.
+func GerarPKCE() (verifier string, challenge string, err error) {
+	verifier, err = ValorAleatorio(32)
+	if err != nil {
+		return "", "", err
+	}
+	return verifier, ChallengeS256(verifier), nil
+}
+
+// ChallengeS256 calcula o code_challenge de um code_verifier.
+func ChallengeS256(verifier string) string {
+	soma := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(soma[:])
+}
+
+// ValorAleatorio gera um texto aleatório seguro para uso em state, nonce e verifier.
+func ValorAleatorio(bytes int) (string, error) {
+	b := make([]byte, bytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
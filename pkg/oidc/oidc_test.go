@@ -0,0 +1,127 @@
+package oidc_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Loviiin/ponto-api-go/pkg/oidc"
+	"github.com/Loviiin/ponto-api-go/pkg/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost/api/v1/sso/callback"
+
+func TestFluxoCompletoComPKCE(t *testing.T) {
+	provedorMock, err := oidctest.NovoProvedor("app", "segredo")
+	if err != nil {
+		t.Fatalf("falha ao iniciar provedor: %v", err)
+	}
+	defer provedorMock.Close()
+
+	client := oidc.NewClient(nil)
+	provedor, err := client.Descobrir(provedorMock.URL())
+	if err != nil {
+		t.Fatalf("Erro inesperado na descoberta: %v", err)
+	}
+
+	verifier, challenge, err := oidc.GerarPKCE()
+	if err != nil {
+		t.Fatalf("Erro inesperado ao gerar PKCE: %v", err)
+	}
+	urlAutorizacao := provedor.URLAutorizacao("app", redirectURL, "estado", "numero-unico", challenge)
+
+	code, state, err := provedorMock.Autorizar(urlAutorizacao, map[string]interface{}{
+		"sub":    "123",
+		"email":  "ana@empresa.com",
+		"groups": []string{"rh", "gestores"},
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado na autorização: %v", err)
+	}
+	if state != "estado" {
+		t.Errorf("State incorreto. Esperava 'estado', recebeu '%s'", state)
+	}
+
+	tokens, err := client.TrocarCodigo(provedor, "app", "segredo", redirectURL, code, verifier)
+	if err != nil {
+		t.Fatalf("Erro inesperado na troca do código: %v", err)
+	}
+
+	claims, err := client.VerificarIDToken(provedor, tokens.IDToken, "app", "numero-unico")
+	if err != nil {
+		t.Fatalf("Erro inesperado ao validar o id_token: %v", err)
+	}
+	if claims.Subject != "123" || claims.Email != "ana@empresa.com" {
+		t.Errorf("Claims incorretas: %+v", claims)
+	}
+	if grupos := claims.ValoresClaim("groups"); len(grupos) != 2 || grupos[1] != "gestores" {
+		t.Errorf("Claim 'groups' incorreta: %v", grupos)
+	}
+}
+
+func TestTrocarCodigo_VerifierErrado(t *testing.T) {
+	provedorMock, err := oidctest.NovoProvedor("app", "segredo")
+	if err != nil {
+		t.Fatalf("falha ao iniciar provedor: %v", err)
+	}
+	defer provedorMock.Close()
+
+	client := oidc.NewClient(nil)
+	provedor, err := client.Descobrir(provedorMock.URL())
+	if err != nil {
+		t.Fatalf("Erro inesperado na descoberta: %v", err)
+	}
+
+	_, challenge, _ := oidc.GerarPKCE()
+	code, _, err := provedorMock.Autorizar(provedor.URLAutorizacao("app", redirectURL, "s", "n", challenge), map[string]interface{}{"sub": "1"})
+	if err != nil {
+		t.Fatalf("Erro inesperado na autorização: %v", err)
+	}
+
+	if _, err := client.TrocarCodigo(provedor, "app", "segredo", redirectURL, code, "outro-verifier"); err == nil {
+		t.Fatal("Esperava erro ao trocar o código com um code_verifier diferente")
+	}
+}
+
+func TestVerificarIDToken_NonceOuAudienciaInvalidos(t *testing.T) {
+	provedorMock, err := oidctest.NovoProvedor("app", "segredo")
+	if err != nil {
+		t.Fatalf("falha ao iniciar provedor: %v", err)
+	}
+	defer provedorMock.Close()
+
+	client := oidc.NewClient(nil)
+	provedor, _ := client.Descobrir(provedorMock.URL())
+
+	emitir := func() string {
+		verifier, challenge, _ := oidc.GerarPKCE()
+		code, _, _ := provedorMock.Autorizar(provedor.URLAutorizacao("app", redirectURL, "s", "nonce-certo", challenge), map[string]interface{}{"sub": "1"})
+		tokens, err := client.TrocarCodigo(provedor, "app", "segredo", redirectURL, code, verifier)
+		if err != nil {
+			t.Fatalf("Erro inesperado na troca do código: %v", err)
+		}
+		return tokens.IDToken
+	}
+
+	if _, err := client.VerificarIDToken(provedor, emitir(), "app", "nonce-errado"); err == nil {
+		t.Error("Esperava erro com nonce diferente")
+	}
+	if _, err := client.VerificarIDToken(provedor, emitir(), "outra-app", "nonce-certo"); err == nil {
+		t.Error("Esperava erro com audiência diferente")
+	}
+}
+
+func TestURLAutorizacao_IncluiPKCE(t *testing.T) {
+	p := &oidc.Provedor{AuthorizationEndpoint: "https://idp.exemplo.com/authorize"}
+	u, err := url.Parse(p.URLAutorizacao("app", redirectURL, "s", "n", "desafio"))
+	if err != nil {
+		t.Fatalf("URL inválida: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge") != "desafio" || q.Get("code_challenge_method") != "S256" {
+		t.Errorf("Parâmetros PKCE ausentes: %s", u.RawQuery)
+	}
+	if !strings.Contains(q.Get("scope"), "openid") {
+		t.Errorf("Escopo openid ausente: %s", q.Get("scope"))
+	}
+}
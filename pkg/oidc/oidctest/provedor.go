@@ -0,0 +1,199 @@
+// Package oidctest oferece um provedor OIDC local para testes do fluxo de login único.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Loviiin/ponto-api-go/pkg/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const kid = "chave-teste"
+
+type autorizacao struct {
+	clientID      string
+	redirectURL   string
+	nonce         string
+	codeChallenge string
+	claims        map[string]interface{}
+}
+
+// Provedor é um servidor OIDC mínimo: descoberta, JWKS, autorização e token.
+type Provedor struct {
+	Servidor     *httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	chave    *rsa.PrivateKey
+	mu       sync.Mutex
+	codigos  map[string]autorizacao
+	proximas map[string]interface{}
+}
+
+// NovoProvedor inicia o servidor; chame Close ao final do teste.
+func NovoProvedor(clientID, clientSecret string) (*Provedor, error) {
+	chave, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provedor{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		chave:        chave,
+		codigos:      make(map[string]autorizacao),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.descoberta)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	p.Servidor = httptest.NewServer(mux)
+	return p, nil
+}
+
+func (p *Provedor) URL() string { return p.Servidor.URL }
+
+func (p *Provedor) Close() { p.Servidor.Close() }
+
+// DefinirUsuario escolhe as claims que o próximo acesso ao endpoint /authorize vai emitir.
+func (p *Provedor) DefinirUsuario(claims map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.proximas = claims
+}
+
+// Autorizar simula o usuário autenticando no provedor a partir da URL de autorização
+// gerada pela aplicação. Retorna o código e o state que seriam enviados ao callback.
+func (p *Provedor) Autorizar(urlAutorizacao string, claims map[string]interface{}) (code string, state string, err error) {
+	u, err := url.Parse(urlAutorizacao)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		return "", "", errors.New("requisição de autorização sem PKCE S256")
+	}
+	code, err = oidc.ValorAleatorio(16)
+	if err != nil {
+		return "", "", err
+	}
+	p.mu.Lock()
+	p.codigos[code] = autorizacao{
+		clientID:      q.Get("client_id"),
+		redirectURL:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		claims:        claims,
+	}
+	p.mu.Unlock()
+	return code, q.Get("state"), nil
+}
+
+func (p *Provedor) descoberta(w http.ResponseWriter, r *http.Request) {
+	escreverJSON(w, http.StatusOK, oidc.Provedor{
+		Issuer:                p.URL(),
+		AuthorizationEndpoint: p.URL() + "/authorize",
+		TokenEndpoint:         p.URL() + "/token",
+		JWKSURI:               p.URL() + "/jwks",
+	})
+}
+
+func (p *Provedor) jwks(w http.ResponseWriter, r *http.Request) {
+	publica := p.chave.PublicKey
+	escreverJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": kid,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(publica.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publica.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize permite usar o provedor manualmente pelo navegador: redireciona de volta
+// com um código para as claims definidas em DefinirUsuario.
+func (p *Provedor) authorize(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	claims := p.proximas
+	p.mu.Unlock()
+	if claims == nil {
+		http.Error(w, "nenhum usuário definido", http.StatusBadRequest)
+		return
+	}
+	code, state, err := p.Autorizar(r.URL.String(), claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	destino, _ := url.Parse(r.URL.Query().Get("redirect_uri"))
+	q := destino.Query()
+	q.Set("code", code)
+	q.Set("state", state)
+	destino.RawQuery = q.Encode()
+	http.Redirect(w, r, destino.String(), http.StatusFound)
+}
+
+func (p *Provedor) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		escreverJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	p.mu.Lock()
+	aut, ok := p.codigos[r.PostForm.Get("code")]
+	delete(p.codigos, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	switch {
+	case !ok:
+		escreverJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case r.PostForm.Get("client_id") != p.ClientID || r.PostForm.Get("client_secret") != p.ClientSecret:
+		escreverJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	case r.PostForm.Get("redirect_uri") != aut.redirectURL:
+		escreverJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case oidc.ChallengeS256(r.PostForm.Get("code_verifier")) != aut.codeChallenge:
+		escreverJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE"})
+		return
+	}
+
+	agora := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.URL(),
+		"aud":   aut.clientID,
+		"iat":   agora.Unix(),
+		"exp":   agora.Add(5 * time.Minute).Unix(),
+		"nonce": aut.nonce,
+	}
+	for k, v := range aut.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	idToken, err := token.SignedString(p.chave)
+	if err != nil {
+		escreverJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	escreverJSON(w, http.StatusOK, oidc.RespostaToken{AccessToken: "access-" + idToken[:8], IDToken: idToken, TokenType: "Bearer"})
+}
+
+func escreverJSON(w http.ResponseWriter, status int, corpo interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(corpo)
+}
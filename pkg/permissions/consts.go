@@ -10,4 +10,15 @@ const (
 	DELETAR_PROPRIA_CONTA     = "DELETAR_PROPRIA_CONTA"
 	VER_SALDO_FUNCIONARIOS    = "VER_SALDO_FUNCIONARIOS"
 	EDITAR_SALDO_FUNCIONARIOS = "EDITAR_SALDO_FUNCIONARIOS"
+	DESBLOQUEAR_USUARIO       = "DESBLOQUEAR_USUARIO"
+	GERENCIAR_SSO             = "GERENCIAR_SSO"
+	GERENCIAR_CHAVES_API      = "GERENCIAR_CHAVES_API"
+	CONVIDAR_USUARIO          = "CONVIDAR_USUARIO"
+	VER_AUDITORIA             = "VER_AUDITORIA"
+	GERENCIAR_ESTRUTURA       = "GERENCIAR_ESTRUTURA"
+	GERENCIAR_POLITICAS       = "GERENCIAR_POLITICAS"
+	GERENCIAR_DADOS_PESSOAIS  = "GERENCIAR_DADOS_PESSOAIS"
+	REVISAR_PONTOS            = "REVISAR_PONTOS"
+	VER_FOTOS_PONTO           = "VER_FOTOS_PONTO"
+	GERENCIAR_DISPOSITIVOS    = "GERENCIAR_DISPOSITIVOS"
 )
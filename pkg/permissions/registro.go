@@ -0,0 +1,42 @@
+package permissions
+
+// Definicao descreve uma permissão do catálogo.
+type Definicao struct {
+	Nome      string
+	Descricao string
+}
+
+// Catalogo é a fonte única das permissões do sistema. O seeder grava exatamente esta lista no
+// banco e os middlewares de rota recusam, na inicialização, qualquer nome que não esteja aqui.
+var Catalogo = []Definicao{
+	{EDITAR_EMPRESA, "Permite editar os dados da própria empresa."},
+	{DELETAR_EMPRESA, "Permite deletar a própria empresa."},
+	{GERENCIAR_CARGOS, "Permite criar, editar, apagar e gerenciar permissões de cargos."},
+	{DELETAR_USUARIO, "Permite deletar outros usuários da empresa."},
+	{EDITAR_USUARIO, "Permite editar os dados de outros usuários da empresa."},
+	{DELETAR_PROPRIA_CONTA, "Permite que um usuário delete a sua própria conta."},
+	{EDITAR_PROPRIA_CONTA, "Permite que um usuário edite seus próprios dados."},
+	{VER_SALDO_FUNCIONARIOS, "Permite ver saldo de horas de um funcionário"},
+	{EDITAR_SALDO_FUNCIONARIOS, "Pemite a edição de pontos de um funcionário caso necessário"},
+	{DESBLOQUEAR_USUARIO, "Permite desbloquear o login de um usuário bloqueado por excesso de tentativas."},
+	{GERENCIAR_SSO, "Permite configurar o login único (OIDC) da empresa."},
+	{GERENCIAR_CHAVES_API, "Permite criar, listar e revogar chaves de API de integração."},
+	{CONVIDAR_USUARIO, "Permite convidar funcionários e avaliar pedidos de cadastro."},
+	{VER_AUDITORIA, "Permite consultar e exportar o log de auditoria da empresa."},
+	{GERENCIAR_ESTRUTURA, "Permite criar, editar e apagar departamentos, centros de custo e locais de trabalho."},
+	{GERENCIAR_POLITICAS, "Permite criar, editar, apagar e simular as políticas de autorização da empresa."},
+	{GERENCIAR_DADOS_PESSOAIS, "Permite exportar e anonimizar os dados pessoais de usuários (LGPD), dentro do escopo."},
+	{REVISAR_PONTOS, "Permite aprovar ou rejeitar os pontos remotos que aguardam revisão, dentro do escopo."},
+	{VER_FOTOS_PONTO, "Permite ver as selfies enviadas com as batidas de ponto, dentro do escopo."},
+	{GERENCIAR_DISPOSITIVOS, "Permite aprovar, rejeitar e revogar os dispositivos de ponto dos funcionários e gerar códigos de ativação, dentro do escopo."},
+}
+
+// Existe informa se o nome pertence ao catálogo.
+func Existe(nome string) bool {
+	for _, d := range Catalogo {
+		if d.Nome == nome {
+			return true
+		}
+	}
+	return false
+}
@@ -1,59 +1,138 @@
 package scheduler
 
 import (
+	"github.com/Loviiin/ponto-api-go/internal/domain/auth"
 	"github.com/Loviiin/ponto-api-go/internal/domain/bancohoras"
+	"github.com/Loviiin/ponto-api-go/internal/domain/empresa"
+	"github.com/Loviiin/ponto-api-go/internal/domain/retencao"
 	"github.com/Loviiin/ponto-api-go/internal/domain/usuario"
 	"github.com/robfig/cron/v3"
 	"log"
+	"sync"
 	"time"
 )
 
 type Scheduler struct {
 	bancoHorasService bancohoras.BancoHorasService
 	usuarioService    usuario.UsuarioService
+	retencaoService   retencao.RetencaoService
+	empresaService    empresa.EmpresaService
+	authService       auth.AuthService
+
+	// fechadoAte guarda, por empresa, o último dia que o fechamento tratou para todos os usuários,
+	// para não repetir a busca nas horas seguintes. É só um atalho: quem impede somar um dia duas
+	// vezes é o registro de cada fechamento no banco.
+	fechamento sync.Mutex
+	fechadoAte map[uint]time.Time
 }
 
-func NewScheduler(bancohorasService bancohoras.BancoHorasService, usuarioService usuario.UsuarioService) *Scheduler {
+func NewScheduler(bancohorasService bancohoras.BancoHorasService, usuarioService usuario.UsuarioService, retencaoService retencao.RetencaoService, empresaService empresa.EmpresaService, authService auth.AuthService) *Scheduler {
 	return &Scheduler{
 		bancoHorasService: bancohorasService,
 		usuarioService:    usuarioService,
+		retencaoService:   retencaoService,
+		empresaService:    empresaService,
+		authService:       authService,
+		fechadoAte:        make(map[uint]time.Time),
 	}
 }
 
 func (s *Scheduler) Start() {
 	c := cron.New()
 
-	_, err := c.AddFunc("0 1 * * *", s.executarFechamentoDiario)
+	// Cada empresa fecha o dia no próprio fuso: a tarefa roda de hora em hora e fecha os dias que
+	// já passaram da hora do fechamento e ainda não foram fechados.
+	_, err := c.AddFunc("0 * * * *", s.executarFechamentoDiario)
 	if err != nil {
 		log.Fatalf("Erro ao agendar a tarefa de fechamento diário: %v", err)
 	}
+	_, err = c.AddFunc("0 3 * * *", s.executarExpurgoRetencao)
+	if err != nil {
+		log.Fatalf("Erro ao agendar a tarefa de expurgo da retenção: %v", err)
+	}
 
 	c.Start()
 
-	log.Println("Agendador de tarefas iniciado. O fechamento diário será executado à 01:00.")
+	log.Printf("Agendador de tarefas iniciado. O fechamento diário será executado às %02d:00 no fuso de cada empresa e o expurgo da retenção às 03:00.", bancohoras.HoraFechamentoDiario)
 }
 
 func (s *Scheduler) executarFechamentoDiario() {
-	log.Println("Iniciando tarefa agendada: Fechamento diário do banco de horas...")
-
-	diaAnterior := time.Now().AddDate(0, 0, -1)
+	s.fechamento.Lock()
+	defer s.fechamento.Unlock()
+	agora := time.Now()
 
+	// FindAll é uma consulta da plataforma, sobre todas as empresas; cada fechamento volta a ser
+	// feito dentro da empresa do usuário.
 	usuarios, err := s.usuarioService.FindAll()
 	if err != nil {
 		log.Printf("SCHEDULER: Erro ao buscar usuários para o fechamento diário: %v", err)
 		return
 	}
 
-	log.Printf("Encontrados %d usuários para processar.", len(usuarios))
-
+	// diasDevidos guarda, por empresa, o último dia cujo fechamento já é devido; nil para as
+	// empresas já tratadas até esse dia ou cujo fuso não pôde ser lido.
+	diasDevidos := make(map[uint]*time.Time)
+	falhas := make(map[uint]bool)
+	processados, fechados := 0, 0
 	for _, usr := range usuarios {
-		_, err := s.bancoHorasService.FecharDiaParaUsuario(usr.ID, usr.EmpresaID, diaAnterior)
+		diaDevido, visto := diasDevidos[usr.EmpresaID]
+		if !visto {
+			fusoEmpresa, err := s.empresaService.Fuso(usr.EmpresaID)
+			if err != nil {
+				log.Printf("SCHEDULER: Erro ao buscar o fuso da empresa ID %d: %v", usr.EmpresaID, err)
+			} else if dia := bancohoras.UltimoDiaAFechar(agora.In(fusoEmpresa)); !s.fechadoAte[usr.EmpresaID].Equal(dia) {
+				diaDevido = &dia
+			}
+			diasDevidos[usr.EmpresaID] = diaDevido
+		}
+		if diaDevido == nil {
+			continue
+		}
+		if processados == 0 {
+			log.Println("Iniciando tarefa agendada: Fechamento diário do banco de horas...")
+		}
+		processados++
+
+		dias, err := s.bancoHorasService.FecharDiasPendentes(usr.ID, usr.EmpresaID, agora)
+		fechados += dias
 		if err != nil {
-			log.Printf("SCHEDULER: Erro ao fechar o dia para o usuário ID %d: %v", usr.ID, err)
-		} else {
-			log.Printf("SCHEDULER: Fechamento do dia %s concluído para o usuário ID %d.", diaAnterior.Format("2006-01-02"), usr.ID)
+			falhas[usr.EmpresaID] = true
+			log.Printf("SCHEDULER: Erro ao fechar os dias do usuário ID %d: %v", usr.ID, err)
+		} else if dias > 0 {
+			log.Printf("SCHEDULER: %d dia(s) fechado(s) até %s para o usuário ID %d.", dias, diaDevido.Format("2006-01-02"), usr.ID)
+		}
+	}
+
+	// Empresas com falha voltam a ser tentadas na próxima hora.
+	for empresaID, diaDevido := range diasDevidos {
+		if diaDevido != nil && !falhas[empresaID] {
+			s.fechadoAte[empresaID] = *diaDevido
 		}
 	}
+	if processados > 0 {
+		log.Printf("Tarefa agendada: Fechamento diário concluído para %d usuários, %d dias fechados.", processados, fechados)
+	}
+}
+
+func (s *Scheduler) executarExpurgoRetencao() {
+	log.Println("Iniciando tarefa agendada: Expurgo de dados com retenção vencida...")
+
+	tratados, err := s.retencaoService.Expurgar(time.Now())
+	if err != nil {
+		log.Printf("SCHEDULER: Erros no expurgo da retenção: %v", err)
+	}
 
-	log.Println("Tarefa agendada: Fechamento diário concluído.")
+	log.Printf("Tarefa agendada: Expurgo da retenção concluído, %d usuários anonimizados.", tratados)
+
+	fotos, err := s.retencaoService.ExpurgarFotos(time.Now())
+	if err != nil {
+		log.Printf("SCHEDULER: Erros no expurgo das fotos de batida: %v", err)
+	}
+	log.Printf("Tarefa agendada: Expurgo das fotos de batida concluído, %d fotos apagadas.", fotos)
+
+	tentativas, err := s.authService.ExpurgarTentativas(time.Now())
+	if err != nil {
+		log.Printf("SCHEDULER: Erros no expurgo das tentativas de login: %v", err)
+	}
+	log.Printf("Tarefa agendada: Expurgo das tentativas de login concluído, %d contadores apagados.", tentativas)
 }
@@ -0,0 +1,170 @@
+package tenant
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// Variáveis de sessão do Postgres lidas pelas políticas de Row-Level Security.
+const (
+	VariavelEmpresa    = "app.empresa_id"
+	VariavelPlataforma = "app.plataforma"
+)
+
+// politicaRLS é o nome da política criada em cada tabela por AtivarRLS.
+const politicaRLS = "isolamento_empresa"
+
+const sqlSessao = `SELECT set_config('` + VariavelEmpresa + `', $1, $3), set_config('` + VariavelPlataforma + `', $2, $3)`
+
+// condicaoRLS libera a linha para a plataforma ou para a empresa da sessão. Sem as variáveis
+// definidas, nenhuma linha passa.
+const condicaoRLS = `current_setting('` + VariavelPlataforma + `', true) = 'on' OR ` +
+	coluna + ` = NULLIF(current_setting('` + VariavelEmpresa + `', true), '')::bigint`
+
+// chaveConexao guarda, na instância da operação, a conexão reservada por prepararSessao.
+const chaveConexao = "tenant:conexao"
+
+// conexaoReservada é a conexão dedicada a uma operação e o pool que ela substituiu.
+type conexaoReservada struct {
+	conn     *sql.Conn
+	original gorm.ConnPool
+}
+
+// SessaoPostgres leva o acesso do contexto para as variáveis de sessão do Postgres, antes de cada
+// comando, para que as políticas de AtivarRLS funcionem como segunda barreira: mesmo um SQL sem
+// filtro de empresa_id só enxerga as linhas da empresa. Instale com db.Use(tenant.SessaoPostgres{}).
+//
+// Dentro de uma transação as variáveis são definidas com escopo local (SET LOCAL). Fora dela, o
+// comando é executado numa conexão reservada do pool, com as variáveis definidas logo antes.
+type SessaoPostgres struct{}
+
+func (SessaoPostgres) Name() string {
+	return "tenant:sessao"
+}
+
+func (SessaoPostgres) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	registros := []error{
+		callbacks.Query().Before("gorm:query").Register("tenant:sessao", prepararSessao),
+		callbacks.Query().After("gorm:after_query").Register("tenant:liberar", liberarSessao),
+		callbacks.Row().Before("gorm:row").Register("tenant:sessao", prepararSessao),
+		callbacks.Row().After("gorm:row").Register("tenant:liberar", liberarSessaoAoFecharLinhas),
+		callbacks.Raw().Before("gorm:raw").Register("tenant:sessao", prepararSessao),
+		callbacks.Raw().After("gorm:raw").Register("tenant:liberar", liberarSessao),
+		callbacks.Create().After("gorm:begin_transaction").Before("gorm:create").Register("tenant:sessao", prepararSessao),
+		callbacks.Create().After("gorm:after_create").Register("tenant:liberar", liberarSessao),
+		callbacks.Update().After("gorm:begin_transaction").Before("gorm:update").Register("tenant:sessao", prepararSessao),
+		callbacks.Update().After("gorm:after_update").Register("tenant:liberar", liberarSessao),
+		callbacks.Delete().After("gorm:begin_transaction").Before("gorm:delete").Register("tenant:sessao", prepararSessao),
+		callbacks.Delete().After("gorm:after_delete").Register("tenant:liberar", liberarSessao),
+	}
+	return errors.Join(registros...)
+}
+
+// valoresSessao traduz o acesso do contexto para os valores das variáveis de sessão.
+func valoresSessao(db *gorm.DB) (empresa, plataforma string) {
+	plataforma = "off"
+	if ehPlataforma(db.Statement.Context) {
+		plataforma = "on"
+	}
+	if empresaID, ok := EmpresaDe(db.Statement.Context); ok {
+		empresa = strconv.FormatUint(uint64(empresaID), 10)
+	}
+	return empresa, plataforma
+}
+
+func prepararSessao(db *gorm.DB) {
+	if db.Error != nil || db.DryRun {
+		return
+	}
+	ctx := db.Statement.Context
+	empresa, plataforma := valoresSessao(db)
+
+	switch pool := db.Statement.ConnPool.(type) {
+	case *sql.DB:
+		conn, err := pool.Conn(ctx)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		if _, err := conn.ExecContext(ctx, sqlSessao, empresa, plataforma, false); err != nil {
+			conn.Close()
+			db.AddError(err)
+			return
+		}
+		db.InstanceSet(chaveConexao, conexaoReservada{conn: conn, original: pool})
+		db.Statement.ConnPool = conn
+	case *sql.Conn:
+		// Comando aninhado (preload, associações) numa conexão já preparada pela operação principal.
+	default:
+		if _, ok := pool.(gorm.TxCommitter); ok {
+			if _, err := pool.ExecContext(ctx, sqlSessao, empresa, plataforma, true); err != nil {
+				db.AddError(err)
+			}
+		}
+	}
+}
+
+// devolverPool desfaz a troca de pool feita por prepararSessao e devolve a conexão reservada.
+func devolverPool(db *gorm.DB) (*sql.Conn, bool) {
+	valor, ok := db.InstanceGet(chaveConexao)
+	if !ok {
+		return nil, false
+	}
+	reservada := valor.(conexaoReservada)
+	db.Statement.ConnPool = reservada.original
+	return reservada.conn, true
+}
+
+func liberarSessao(db *gorm.DB) {
+	if conn, ok := devolverPool(db); ok {
+		conn.Close()
+	}
+}
+
+// liberarSessaoAoFecharLinhas devolve a conexão de um Row/Rows. As linhas ainda estão abertas
+// para quem chamou, e Conn.Close espera que sejam fechadas, por isso a devolução é em segundo plano.
+func liberarSessaoAoFecharLinhas(db *gorm.DB) {
+	if conn, ok := devolverPool(db); ok {
+		go conn.Close()
+	}
+}
+
+// AtivarRLS liga o Row-Level Security nas tabelas dos modelos que têm empresa_id, com uma política
+// que só libera as linhas da empresa definida por SessaoPostgres (ou todas, para a plataforma).
+// O FORCE faz a política valer também para o dono das tabelas; superusuários e papéis com
+// BYPASSRLS continuam ignorando as políticas.
+func AtivarRLS(db *gorm.DB, modelos ...interface{}) error {
+	for _, modelo := range modelos {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(modelo); err != nil {
+			return err
+		}
+		if stmt.Schema.LookUpField(coluna) == nil {
+			continue
+		}
+		tabela := stmt.Quote(stmt.Schema.Table)
+		err := db.Exec(fmt.Sprintf(`
+ALTER TABLE %[1]s ENABLE ROW LEVEL SECURITY;
+ALTER TABLE %[1]s FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS %[2]s ON %[1]s;
+CREATE POLICY %[2]s ON %[1]s USING (%[3]s) WITH CHECK (%[3]s);
+`, tabela, politicaRLS, condicaoRLS)).Error
+		if err != nil {
+			return fmt.Errorf("tabela %s: %w", stmt.Schema.Table, err)
+		}
+	}
+	return nil
+}
+
+// RLSEfetivo informa se o papel conectado está sujeito às políticas de RLS. Superusuários e papéis
+// com BYPASSRLS não estão, e para eles só o filtro do Plugin protege os dados.
+func RLSEfetivo(db *gorm.DB) (bool, error) {
+	var ignora bool
+	err := db.Raw(`SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`).Scan(&ignora).Error
+	return !ignora, err
+}
@@ -0,0 +1,184 @@
+//go:build integracao
+
+package tenant
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Rode com: TESTE_POSTGRES_DSN="host=localhost user=pontouser password=... dbname=ponto_api_db" go test -tags integracao ./pkg/tenant/
+//
+// O DSN deve ser de um superusuário (como o do docker-compose): o teste cria um esquema e um papel
+// comum próprios, porque superusuários ignoram o RLS.
+const (
+	esquemaTeste = "tenant_rls_teste"
+	papelTeste   = "tenant_rls_teste"
+)
+
+// registroPonto é um recorte de model.RegistroPonto, sem as chaves estrangeiras.
+type registroPonto struct {
+	ID        uint
+	EmpresaID uint
+	UsuarioID uint
+	Tipo      string
+}
+
+func (registroPonto) TableName() string {
+	return "registro_pontos"
+}
+
+func abrir(t *testing.T, config *pgx.ConnConfig, opcoes ...stdlib.OptionOpenDB) *gorm.DB {
+	t.Helper()
+	sqlDB := stdlib.OpenDB(*config, opcoes...)
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("Falha ao abrir o banco: %v", err)
+	}
+	return db
+}
+
+// prepararBanco cria o esquema de teste com registro_pontos protegida por RLS e devolve uma conexão
+// da aplicação, sujeita às políticas, com os dois plugins de isolamento instalados.
+func prepararBanco(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TESTE_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TESTE_POSTGRES_DSN não definido")
+	}
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("DSN inválido: %v", err)
+	}
+	config.RuntimeParams["search_path"] = esquemaTeste
+
+	admin := abrir(t, config)
+	err = admin.Exec(`
+DROP SCHEMA IF EXISTS ` + esquemaTeste + ` CASCADE;
+CREATE SCHEMA ` + esquemaTeste + `;
+DO $$ BEGIN
+	IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = '` + papelTeste + `') THEN
+		CREATE ROLE ` + papelTeste + ` NOLOGIN NOSUPERUSER NOBYPASSRLS;
+	END IF;
+END $$;
+GRANT USAGE ON SCHEMA ` + esquemaTeste + ` TO ` + papelTeste + `;
+`).Error
+	if err != nil {
+		t.Fatalf("Falha ao criar o esquema de teste: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(`DROP SCHEMA IF EXISTS ` + esquemaTeste + ` CASCADE`)
+	})
+	if err := admin.AutoMigrate(&registroPonto{}); err != nil {
+		t.Fatalf("Falha na migração: %v", err)
+	}
+	if err := AtivarRLS(admin, &registroPonto{}); err != nil {
+		t.Fatalf("Falha ao ativar o RLS: %v", err)
+	}
+	err = admin.Exec(`
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA ` + esquemaTeste + ` TO ` + papelTeste + `;
+GRANT USAGE ON ALL SEQUENCES IN SCHEMA ` + esquemaTeste + ` TO ` + papelTeste + `;
+`).Error
+	if err != nil {
+		t.Fatalf("Falha ao conceder acesso ao papel de teste: %v", err)
+	}
+
+	app := abrir(t, config, stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "SET ROLE "+papelTeste)
+		return err
+	}))
+	if err := app.Use(Plugin{}); err != nil {
+		t.Fatalf("Falha ao instalar o plugin: %v", err)
+	}
+	if err := app.Use(SessaoPostgres{}); err != nil {
+		t.Fatalf("Falha ao instalar a sessão: %v", err)
+	}
+	if efetivo, err := RLSEfetivo(app); err != nil || !efetivo {
+		t.Fatalf("O papel da aplicação deveria estar sujeito ao RLS (efetivo=%v, err=%v)", efetivo, err)
+	}
+
+	pontos := map[uint][]registroPonto{
+		1: {{UsuarioID: 10, Tipo: "ENTRADA"}, {UsuarioID: 10, Tipo: "SAIDA"}},
+		2: {{UsuarioID: 20, Tipo: "ENTRADA"}},
+	}
+	for empresaID, lote := range pontos {
+		if err := Escopo(app, empresaID).Create(&lote).Error; err != nil {
+			t.Fatalf("Falha ao inserir pontos da empresa %d: %v", empresaID, err)
+		}
+	}
+	return app
+}
+
+func TestRLS_SQLSemFiltroSoEnxergaAPropriaEmpresa(t *testing.T) {
+	db := prepararBanco(t)
+
+	// Uma consulta escrita à mão que esqueceu o WHERE empresa_id: o Plugin não a reescreve.
+	var vistos []registroPonto
+	if err := Escopo(db, 1).Raw(`SELECT * FROM registro_pontos`).Scan(&vistos).Error; err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(vistos) != 2 {
+		t.Fatalf("Esperava os 2 pontos da empresa 1, recebeu %d", len(vistos))
+	}
+	for _, p := range vistos {
+		if p.EmpresaID != 1 {
+			t.Errorf("Empresa 1 enxergou ponto da empresa %d", p.EmpresaID)
+		}
+	}
+
+	// Dentro de uma transação a variável é local e vale do mesmo jeito.
+	err := Escopo(db, 2).Transaction(func(tx *gorm.DB) error {
+		var total int64
+		if err := tx.Raw(`SELECT count(*) FROM registro_pontos`).Scan(&total).Error; err != nil {
+			return err
+		}
+		if total != 1 {
+			t.Errorf("Empresa 2 deveria ver 1 ponto na transação, viu %d", total)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado na transação: %v", err)
+	}
+
+	var semEmpresa int64
+	if err := db.Raw(`SELECT count(*) FROM registro_pontos`).Scan(&semEmpresa).Error; err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if semEmpresa != 0 {
+		t.Errorf("Sem empresa na sessão nenhuma linha deveria aparecer, apareceram %d", semEmpresa)
+	}
+
+	var todos int64
+	if err := Plataforma(db).Raw(`SELECT count(*) FROM registro_pontos`).Scan(&todos).Error; err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if todos != 3 {
+		t.Errorf("A plataforma deveria ver os 3 pontos, viu %d", todos)
+	}
+}
+
+func TestRLS_EscritaSemFiltroNaoAlcancaOutraEmpresa(t *testing.T) {
+	db := prepararBanco(t)
+
+	resultado := Escopo(db, 1).Exec(`UPDATE registro_pontos SET tipo = 'ALTERADO'`)
+	if resultado.Error != nil {
+		t.Fatalf("Erro inesperado: %v", resultado.Error)
+	}
+	if resultado.RowsAffected != 2 {
+		t.Errorf("O UPDATE sem filtro deveria alcançar só os 2 pontos da empresa 1, alcançou %d", resultado.RowsAffected)
+	}
+
+	err := Escopo(db, 1).Exec(`INSERT INTO registro_pontos (empresa_id, usuario_id, tipo) VALUES (2, 20, 'ENTRADA')`).Error
+	if err == nil {
+		t.Error("Inserir ponto para outra empresa deveria ser recusado pela política")
+	}
+}
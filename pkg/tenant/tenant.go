@@ -0,0 +1,145 @@
+// Package tenant garante o isolamento entre empresas na camada de dados.
+//
+// A empresa da requisição viaja no contexto da consulta do GORM. O plugin deste pacote lê esse
+// contexto e, para toda tabela com a coluna empresa_id, filtra consultas, atualizações e remoções
+// pela empresa e carimba a empresa nas inserções. Sem empresa no contexto a operação falha, a não
+// ser que o acesso tenha sido marcado explicitamente como da plataforma (ex: o agendador).
+//
+// SQL escrito à mão (Raw/Exec) não é reescrito: quem escreve a consulta continua responsável pelo
+// filtro de empresa_id.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSemEmpresa   = errors.New("acesso a dados sem empresa definida")
+	ErrOutraEmpresa = errors.New("registro pertence a outra empresa")
+)
+
+// coluna é a coluna que identifica a empresa dona de cada linha.
+const coluna = "empresa_id"
+
+type chaveContexto struct{}
+
+// acesso é o que o contexto diz sobre a empresa. A marcação mais recente prevalece, então
+// restringir um contexto da plataforma a uma empresa volta a filtrar.
+type acesso struct {
+	empresaID  uint
+	plataforma bool
+}
+
+// ComEmpresa devolve um contexto restrito à empresa. O ID zero não conta como empresa.
+func ComEmpresa(ctx context.Context, empresaID uint) context.Context {
+	return context.WithValue(ctx, chaveContexto{}, acesso{empresaID: empresaID})
+}
+
+// EmpresaDe devolve a empresa do contexto, se houver.
+func EmpresaDe(ctx context.Context) (uint, bool) {
+	a, _ := ctx.Value(chaveContexto{}).(acesso)
+	return a.empresaID, a.empresaID != 0
+}
+
+// ComoPlataforma marca o contexto como acesso da plataforma, que enxerga todas as empresas.
+// É a saída explícita para tarefas sem empresa, como o agendador e o login.
+func ComoPlataforma(ctx context.Context) context.Context {
+	return context.WithValue(ctx, chaveContexto{}, acesso{plataforma: true})
+}
+
+func ehPlataforma(ctx context.Context) bool {
+	a, _ := ctx.Value(chaveContexto{}).(acesso)
+	return a.plataforma
+}
+
+// Escopo devolve uma sessão do banco restrita à empresa.
+func Escopo(db *gorm.DB, empresaID uint) *gorm.DB {
+	return db.WithContext(ComEmpresa(db.Statement.Context, empresaID))
+}
+
+// Plataforma devolve uma sessão do banco sem restrição de empresa. Use com parcimônia.
+func Plataforma(db *gorm.DB) *gorm.DB {
+	return db.WithContext(ComoPlataforma(db.Statement.Context))
+}
+
+// Plugin registra os callbacks de isolamento. Instale com db.Use(tenant.Plugin{}).
+type Plugin struct{}
+
+func (Plugin) Name() string {
+	return "tenant"
+}
+
+func (Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	registros := []error{
+		callbacks.Query().Before("gorm:query").Register("tenant:filtrar", filtrar),
+		callbacks.Row().Before("gorm:row").Register("tenant:filtrar", filtrar),
+		callbacks.Update().Before("gorm:update").Register("tenant:filtrar", filtrar),
+		callbacks.Delete().Before("gorm:delete").Register("tenant:filtrar", filtrar),
+		callbacks.Create().Before("gorm:create").Register("tenant:carimbar", carimbar),
+	}
+	return errors.Join(registros...)
+}
+
+// empresaDaOperacao decide se a operação precisa de empresa e qual é. Devolve ok=false quando
+// não há nada a fazer (tabela sem empresa_id, acesso da plataforma ou erro anterior).
+func empresaDaOperacao(db *gorm.DB) (empresaID uint, ok bool) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.LookUpField(coluna) == nil {
+		return 0, false
+	}
+	if ehPlataforma(db.Statement.Context) {
+		return 0, false
+	}
+	empresaID, ok = EmpresaDe(db.Statement.Context)
+	if !ok {
+		db.AddError(fmt.Errorf("%w: tabela %s", ErrSemEmpresa, db.Statement.Schema.Table))
+	}
+	return empresaID, ok
+}
+
+func filtrar(db *gorm.DB) {
+	empresaID, ok := empresaDaOperacao(db)
+	if !ok || db.Statement.SQL.Len() > 0 {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: coluna}, Value: empresaID},
+	}})
+}
+
+// carimbar preenche empresa_id vazio com a empresa do contexto e recusa registros de outra empresa.
+func carimbar(db *gorm.DB) {
+	empresaID, ok := empresaDaOperacao(db)
+	if !ok {
+		return
+	}
+	campo := db.Statement.Schema.LookUpField(coluna)
+	ctx := db.Statement.Context
+	carimbarUm := func(valor reflect.Value) {
+		atual, vazio := campo.ValueOf(ctx, valor)
+		if vazio {
+			if err := campo.Set(ctx, valor, empresaID); err != nil {
+				db.AddError(err)
+			}
+			return
+		}
+		if reflect.Indirect(reflect.ValueOf(atual)).Uint() != uint64(empresaID) {
+			db.AddError(ErrOutraEmpresa)
+		}
+	}
+
+	switch valor := db.Statement.ReflectValue; valor.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < valor.Len(); i++ {
+			carimbarUm(reflect.Indirect(valor.Index(i)))
+		}
+	case reflect.Struct:
+		carimbarUm(valor)
+	}
+}
@@ -0,0 +1,125 @@
+package tenant
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type registro struct {
+	ID        uint
+	EmpresaID uint
+	Nome      string
+}
+
+type global struct {
+	ID   uint
+	Nome string
+}
+
+// novoBanco monta um GORM em modo DryRun: as consultas são geradas, mas nunca enviadas ao Postgres.
+func novoBanco(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("Falha ao abrir o banco: %v", err)
+	}
+	if err := db.Use(Plugin{}); err != nil {
+		t.Fatalf("Falha ao instalar o plugin: %v", err)
+	}
+	return db
+}
+
+func TestConsultaSemEmpresa_Falha(t *testing.T) {
+	db := novoBanco(t)
+	var registros []registro
+	if err := db.Find(&registros).Error; !errors.Is(err, ErrSemEmpresa) {
+		t.Errorf("Esperava ErrSemEmpresa, recebeu %v", err)
+	}
+	if err := db.Model(&registro{}).Where("id = ?", 1).Update("nome", "x").Error; !errors.Is(err, ErrSemEmpresa) {
+		t.Errorf("Atualização sem empresa deveria falhar, recebeu %v", err)
+	}
+	if err := db.Delete(&registro{}, 1).Error; !errors.Is(err, ErrSemEmpresa) {
+		t.Errorf("Remoção sem empresa deveria falhar, recebeu %v", err)
+	}
+}
+
+func TestConsultaComEmpresa_FiltraPelaEmpresa(t *testing.T) {
+	db := novoBanco(t)
+	var r registro
+	sql := Escopo(db, 7).Where("id = ?", 3).First(&r).Statement.SQL.String()
+	if !strings.Contains(sql, `"registros"."empresa_id" = $`) {
+		t.Errorf("Consulta não foi filtrada pela empresa: %s", sql)
+	}
+
+	sql = Escopo(db, 7).Model(&registro{}).Where("id = ?", 3).Update("nome", "x").Statement.SQL.String()
+	if !strings.Contains(sql, `"registros"."empresa_id" = $`) {
+		t.Errorf("Atualização não foi filtrada pela empresa: %s", sql)
+	}
+}
+
+// Os repositórios não repetem empresa_id nas condições; o filtro precisa valer também nas
+// subconsultas e nas remoções com condição.
+func TestSubconsultaERemocao_FiltramPelaEmpresa(t *testing.T) {
+	db := novoBanco(t)
+	escopo := Escopo(db, 7)
+	var registros []registro
+	repetidos := escopo.Model(&registro{}).Select("nome").Group("nome")
+	sql := escopo.Where("nome IN (?)", repetidos).Find(&registros).Statement.SQL.String()
+	if strings.Count(sql, `"registros"."empresa_id" = $`) != 2 {
+		t.Errorf("Consulta e subconsulta deveriam ser filtradas pela empresa: %s", sql)
+	}
+
+	sql = escopo.Delete(&registro{}, "id = ?", 3).Statement.SQL.String()
+	if !strings.Contains(sql, `"registros"."empresa_id" = $`) {
+		t.Errorf("Remoção não foi filtrada pela empresa: %s", sql)
+	}
+}
+
+func TestCreate_CarimbaEVerificaEmpresa(t *testing.T) {
+	db := novoBanco(t)
+	novo := registro{Nome: "novo"}
+	if err := Escopo(db, 7).Create(&novo).Error; err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if novo.EmpresaID != 7 {
+		t.Errorf("Esperava empresa 7 carimbada, recebeu %d", novo.EmpresaID)
+	}
+
+	lote := []registro{{Nome: "a"}, {Nome: "b", EmpresaID: 8}}
+	if err := Escopo(db, 7).Create(&lote).Error; !errors.Is(err, ErrOutraEmpresa) {
+		t.Errorf("Esperava ErrOutraEmpresa, recebeu %v", err)
+	}
+}
+
+func TestPlataformaETabelasGlobais_NaoExigemEmpresa(t *testing.T) {
+	db := novoBanco(t)
+	var registros []registro
+	sql := Plataforma(db).Find(&registros).Statement.SQL.String()
+	if strings.Contains(sql, "empresa_id") {
+		t.Errorf("Acesso da plataforma não deveria ser filtrado: %s", sql)
+	}
+
+	var globais []global
+	if err := db.Find(&globais).Error; err != nil {
+		t.Errorf("Tabela sem empresa_id não deveria exigir empresa, recebeu %v", err)
+	}
+}
+
+func TestEscopoSobrePlataforma_VoltaAFiltrar(t *testing.T) {
+	db := novoBanco(t)
+	var registros []registro
+	sql := Escopo(Plataforma(db), 7).Find(&registros).Statement.SQL.String()
+	if !strings.Contains(sql, `"registros"."empresa_id" = $`) {
+		t.Errorf("Escopo aplicado depois da plataforma deveria filtrar: %s", sql)
+	}
+}
@@ -0,0 +1,67 @@
+// Package totp gera e confere códigos temporários (RFC 6238, HMAC-SHA1): o mesmo segredo e o
+// mesmo instante produzem sempre o mesmo código, que muda a cada passo.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Parametros definem o formato dos códigos.
+type Parametros struct {
+	Passo   time.Duration
+	Digitos int
+	// Tolerancia é quantos passos anteriores ainda são aceitos, para cobrir o atraso entre exibir e
+	// digitar (ou escanear) o código.
+	Tolerancia int
+}
+
+// Padrao são os parâmetros dos aplicativos autenticadores: 30 segundos e 6 dígitos.
+var Padrao = Parametros{Passo: 30 * time.Second, Digitos: 6, Tolerancia: 1}
+
+// Codigo devolve o código válido no momento.
+func Codigo(segredo []byte, momento time.Time, p Parametros) string {
+	return codigoDoPasso(segredo, passo(momento, p), p.Digitos)
+}
+
+// Expiracao devolve o instante em que o código do momento deixa de ser o atual.
+func Expiracao(momento time.Time, p Parametros) time.Time {
+	return time.Unix(int64((passo(momento, p)+1)*uint64(p.Passo/time.Second)), 0)
+}
+
+// Validar confere o código contra o passo atual e os Tolerancia passos anteriores.
+func Validar(segredo []byte, codigo string, momento time.Time, p Parametros) bool {
+	atual := passo(momento, p)
+	for i := 0; i <= p.Tolerancia && uint64(i) <= atual; i++ {
+		esperado := codigoDoPasso(segredo, atual-uint64(i), p.Digitos)
+		if subtle.ConstantTimeCompare([]byte(esperado), []byte(codigo)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func passo(momento time.Time, p Parametros) uint64 {
+	return uint64(momento.Unix()) / uint64(p.Passo/time.Second)
+}
+
+func codigoDoPasso(segredo []byte, contador uint64, digitos int) string {
+	var mensagem [8]byte
+	binary.BigEndian.PutUint64(mensagem[:], contador)
+	mac := hmac.New(sha1.New, segredo)
+	mac.Write(mensagem[:])
+	soma := mac.Sum(nil)
+
+	// Truncamento dinâmico (RFC 4226, seção 5.3).
+	deslocamento := soma[len(soma)-1] & 0x0f
+	valor := binary.BigEndian.Uint32(soma[deslocamento:deslocamento+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < digitos; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digitos, valor%modulo)
+}
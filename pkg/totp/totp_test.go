@@ -0,0 +1,48 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Vetores do apêndice B da RFC 6238 (SHA-1, 8 dígitos).
+func TestCodigo_VetoresDaRFC(t *testing.T) {
+	segredo := []byte("12345678901234567890")
+	p := Parametros{Passo: 30 * time.Second, Digitos: 8}
+	casos := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for segundos, esperado := range casos {
+		if codigo := Codigo(segredo, time.Unix(segundos, 0), p); codigo != esperado {
+			t.Errorf("T=%d: esperava %s, recebeu %s", segundos, esperado, codigo)
+		}
+	}
+}
+
+func TestValidar_ToleraPassoAnterior(t *testing.T) {
+	segredo := []byte("segredo-do-quiosque")
+	momento := time.Unix(1_800_000_000, 0)
+	codigo := Codigo(segredo, momento, Padrao)
+
+	if !Validar(segredo, codigo, momento.Add(Padrao.Passo), Padrao) {
+		t.Error("O código do passo anterior deveria ser aceito")
+	}
+	if Validar(segredo, codigo, momento.Add(2*Padrao.Passo), Padrao) {
+		t.Error("Um código de dois passos atrás não deveria ser aceito")
+	}
+	if Validar([]byte("outro-segredo"), codigo, momento, Padrao) {
+		t.Error("Um código de outro segredo não deveria ser aceito")
+	}
+}
+
+func TestExpiracao(t *testing.T) {
+	momento := time.Unix(65, 0)
+	if expira := Expiracao(momento, Padrao); expira.Unix() != 90 {
+		t.Errorf("Esperava expiração em T=90, recebeu %d", expira.Unix())
+	}
+}